@@ -166,14 +166,17 @@ func (self *ApierV1) SetAccount(attr utils.AttrSetAccount, reply *string) error
 	balanceId := utils.AccountKey(attr.Tenant, attr.Account, attr.Direction)
 	var ub *engine.Account
 	var ats engine.ActionPlan
+	var activated bool
 	_, err := engine.AccLock.Guard(func() (interface{}, error) {
 		if bal, _ := self.AccountDb.GetAccount(balanceId); bal != nil {
 			ub = bal
 		} else { // Not found in db, create it here
 			ub = &engine.Account{
-				Id:            balanceId,
-				AllowNegative: attr.AllowNegative,
+				Id:             balanceId,
+				AllowNegative:  attr.AllowNegative,
+				ActivationDate: time.Now(),
 			}
+			activated = true
 		}
 
 		if len(attr.ActionPlanId) != 0 {
@@ -205,6 +208,16 @@ func (self *ApierV1) SetAccount(attr utils.AttrSetAccount, reply *string) error
 		if err != nil {
 			return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
 		}
+		if activated { // charge the share of the current periods left, the next runs charge the full periods
+			for _, at := range ats {
+				if at.IsASAP() {
+					continue
+				}
+				if err := at.ExecuteProrated(balanceId, time.Now()); err != nil {
+					return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+				}
+			}
+		}
 		if self.Sched != nil {
 			self.Sched.LoadActionTimings(self.AccountDb)
 			self.Sched.Restart()
@@ -214,6 +227,110 @@ func (self *ApierV1) SetAccount(attr utils.AttrSetAccount, reply *string) error
 	return nil
 }
 
+type AttrSetAccountBilling struct {
+	Tenant           string
+	Direction        string
+	Account          string
+	CreditLimit      float64 // how far below zero the postpaid account can go, 0 for none
+	BillingPeriod    string  // *monthly or *weekly, empty for no billing cycle
+	BillingAnchorDay int     // day of the month or of the week (0 for Sunday) the cycles start on
+	BillingActionsId string  // executed when a billing cycle is closed
+}
+
+// Sets the credit limit and the billing cycle of an existing account
+func (self *ApierV1) SetAccountBilling(attrs AttrSetAccountBilling, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Direction", "Account"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if attrs.CreditLimit < 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, "negative CreditLimit")
+	}
+	accId := utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction)
+	_, err := engine.AccLock.Guard(func() (interface{}, error) {
+		acnt, err := self.AccountDb.GetAccount(accId)
+		if err != nil {
+			return 0, err
+		}
+		acnt.CreditLimit = attrs.CreditLimit
+		if err := acnt.SetBillingCycle(attrs.BillingPeriod, attrs.BillingAnchorDay, attrs.BillingActionsId, time.Now()); err != nil {
+			return 0, err
+		}
+		return 0, self.AccountDb.SetAccount(acnt)
+	}, accId)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = OK
+	return nil
+}
+
+// Closes the ended billing cycles of an account, returns the snapshots of the cycles closed
+func (self *ApierV1) CloseBillingCycle(attrs AttrAcntAction, reply *[]*engine.ClosedBillingCycle) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Account", "Direction"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	accId := utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction)
+	closed := make([]*engine.ClosedBillingCycle, 0)
+	_, err := engine.AccLock.Guard(func() (interface{}, error) {
+		acnt, err := self.AccountDb.GetAccount(accId)
+		if err != nil {
+			return 0, err
+		}
+		cycles, err := acnt.CloseBillingCycles(time.Now())
+		if len(cycles) == 0 && err != nil {
+			return 0, err
+		}
+		if err != nil { // the cycles are closed, only announcing them failed
+			engine.Logger.Err(fmt.Sprintf("<CloseBillingCycle> %s", err.Error()))
+		}
+		closed = append(closed, cycles...)
+		return 0, self.AccountDb.SetAccount(acnt)
+	}, accId)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = closed
+	return nil
+}
+
+type AttrCancelAccount struct {
+	Tenant           string
+	Direction        string
+	Account          string
+	CancellationDate string // empty for now
+}
+
+// Ends the service of an account, correcting the prorated charges of its action plans up to the cancellation date
+func (self *ApierV1) CancelAccount(attrs AttrCancelAccount, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Direction", "Account"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	now := time.Now()
+	cancellation := now
+	if len(attrs.CancellationDate) != 0 {
+		var err error
+		if cancellation, err = utils.ParseTimeDetectLayout(attrs.CancellationDate); err != nil {
+			return fmt.Errorf("%s:CancellationDate:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	accId := utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction)
+	_, err := engine.AccLock.Guard(func() (interface{}, error) {
+		acnt, err := self.AccountDb.GetAccount(accId)
+		if err != nil {
+			return 0, err
+		}
+		if err := acnt.Cancel(cancellation, now); err != nil {
+			return 0, err
+		}
+		return 0, self.AccountDb.SetAccount(acnt)
+	}, accId)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = OK
+	return nil
+}
+
 type AttrGetAccounts struct {
 	Tenant     string
 	Direction  string
@@ -263,3 +380,98 @@ func (self *ApierV1) GetAccounts(attr AttrGetAccounts, reply *[]*engine.Account)
 	*reply = retAccounts
 	return nil
 }
+
+type AttrGetAccountLedger struct {
+	Tenant    string
+	Account   string
+	Direction string
+	TimeStart string // Movements written starting with this time, empty for no lower limit
+	TimeEnd   string // Movements written before this time, empty for no upper limit
+}
+
+// Returns the balance movements of an account, oldest first
+func (self *ApierV1) GetAccountLedger(attrs AttrGetAccountLedger, reply *[]*engine.LedgerEntry) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Account", "Direction"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	var timeStart, timeEnd time.Time
+	var err error
+	if len(attrs.TimeStart) != 0 {
+		if timeStart, err = utils.ParseTimeDetectLayout(attrs.TimeStart); err != nil {
+			return fmt.Errorf("%s:TimeStart:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	if len(attrs.TimeEnd) != 0 {
+		if timeEnd, err = utils.ParseTimeDetectLayout(attrs.TimeEnd); err != nil {
+			return fmt.Errorf("%s:TimeEnd:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	entries, err := self.CdrDb.GetLedgerEntries(utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction), timeStart, timeEnd)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	if entries == nil {
+		entries = make([]*engine.LedgerEntry, 0)
+	}
+	*reply = entries
+	return nil
+}
+
+// Returns the reservations of an account still in effect, by session id
+func (self *ApierV1) GetAccountReservations(attrs AttrAcntAction, reply *map[string]*engine.Reservation) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Account", "Direction"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	acnt, err := self.AccountDb.GetAccount(utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction))
+	if err != nil {
+		return errors.New(utils.ERR_NOT_FOUND)
+	}
+	reservations := make(map[string]*engine.Reservation)
+	now := time.Now()
+	for id, r := range acnt.Reservations {
+		if !r.IsExpired(now) {
+			reservations[id] = r
+		}
+	}
+	*reply = reservations
+	return nil
+}
+
+// Reserves the balances needed by the usage of the call for the session identified by CgrId
+func (self *ApierV1) ReserveBalance(req engine.ReservationRequest, reply *engine.Reservation) error {
+	if missing := utils.MissingStructFields(&req.CallDescriptor, []string{"CgrId", "Direction", "Tenant", "Category", "Account", "Destination"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if err := self.Responder.ReserveBalance(req, reply); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	return nil
+}
+
+// Debits the actual usage of the session, giving back the rest of its reservation
+func (self *ApierV1) CommitReservation(cd engine.CallDescriptor, reply *engine.CallCost) error {
+	if missing := utils.MissingStructFields(&cd, []string{"CgrId", "Direction", "Tenant", "Category", "Account", "Destination"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if err := self.Responder.CommitReservation(cd, reply); err != nil {
+		if err.Error() == utils.ERR_NOT_FOUND {
+			return err
+		}
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	return nil
+}
+
+// Drops the reservation of the session without debiting anything
+func (self *ApierV1) ReleaseReservation(cd engine.CallDescriptor, reply *string) error {
+	if missing := utils.MissingStructFields(&cd, []string{"CgrId", "Direction", "Tenant", "Account"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if err := self.Responder.ReleaseReservation(cd, reply); err != nil {
+		if err.Error() == utils.ERR_NOT_FOUND {
+			return err
+		}
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	return nil
+}
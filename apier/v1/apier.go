@@ -108,7 +108,8 @@ type AttrAddBalance struct {
 	DestinationId string
 	Weight        float64
 	SharedGroup   string
-	Overwrite     bool // When true it will reset if the balance is already there
+	Currency      string // Currency of a monetary balance
+	Overwrite     bool   // When true it will reset if the balance is already there
 }
 
 func (self *ApierV1) AddBalance(attr *AttrAddBalance, reply *string) error {
@@ -154,6 +155,7 @@ func (self *ApierV1) AddBalance(attr *AttrAddBalance, reply *string) error {
 				DestinationIds: attr.DestinationId,
 				Weight:         attr.Weight,
 				SharedGroup:    attr.SharedGroup,
+				Currency:       attr.Currency,
 			},
 		},
 	})
@@ -165,6 +167,68 @@ func (self *ApierV1) AddBalance(attr *AttrAddBalance, reply *string) error {
 	return nil
 }
 
+type AttrTransferBalance struct {
+	Tenant        string
+	Direction     string
+	FromAccount   string
+	ToAccount     string
+	BalanceType   string
+	Value         float64
+	BalanceId     string // Filters the balances of both accounts, the received balance gets created out of them when missing
+	DestinationId string
+	Category      string
+	ExpiryTime    string
+	Weight        float64
+}
+
+// Moves value out of the matching balances of one account into the other one, both accounts change or none
+func (self *ApierV1) TransferBalance(attr *AttrTransferBalance, reply *string) error {
+	if missing := utils.MissingStructFields(attr, []string{"Tenant", "FromAccount", "ToAccount", "BalanceType"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	expTime, err := utils.ParseDate(attr.ExpiryTime)
+	if err != nil {
+		return fmt.Errorf("%s:ExpiryTime:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	if attr.Direction == "" {
+		attr.Direction = engine.OUTBOUND
+	}
+	fromId := utils.AccountKey(attr.Tenant, attr.FromAccount, attr.Direction)
+	toId := utils.AccountKey(attr.Tenant, attr.ToAccount, attr.Direction)
+	if fromId == toId {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, "FromAccount same as ToAccount")
+	}
+	_, err = engine.AccLock.Guard(func() (interface{}, error) {
+		from, err := self.AccountDb.GetAccount(fromId)
+		if err != nil {
+			return 0, fmt.Errorf("could not get account %s: %v", fromId, err)
+		}
+		to, err := self.AccountDb.GetAccount(toId)
+		if err != nil {
+			return 0, fmt.Errorf("could not get account %s: %v", toId, err)
+		}
+		return 0, engine.TransferBalance(from, to, &engine.Action{
+			Id:          engine.TRANSFER_BALANCE,
+			ActionType:  engine.TRANSFER_BALANCE,
+			BalanceType: attr.BalanceType,
+			Direction:   attr.Direction,
+			Balance: &engine.Balance{
+				Id:             attr.BalanceId,
+				Value:          attr.Value,
+				DestinationIds: attr.DestinationId,
+				Category:       attr.Category,
+				ExpirationDate: expTime,
+				Weight:         attr.Weight,
+			},
+		})
+	}, fromId, toId)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = OK
+	return nil
+}
+
 func (self *ApierV1) ExecuteAction(attr *utils.AttrExecuteAction, reply *string) error {
 	tag := fmt.Sprintf("%s:%s:%s", attr.Direction, attr.Tenant, attr.Account)
 	at := &engine.ActionTiming{
@@ -326,6 +390,69 @@ func (self *ApierV1) LoadCdrStats(attrs AttrLoadCdrStats, reply *string) error {
 	return nil
 }
 
+type AttrLoadPortedNumbers struct {
+	TPid            string
+	PortedNumbersId string
+}
+
+// Load ported numbers from storDb into dataDb, numbers without routing prefix are removed.
+func (self *ApierV1) LoadPortedNumbers(attrs AttrLoadPortedNumbers, reply *string) error {
+	if len(attrs.TPid) == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "TPid")
+	}
+	dbReader := engine.NewDbReader(self.StorDb, self.RatingDb, self.AccountDb, attrs.TPid)
+	if err := dbReader.LoadPortedNumbersByTag(attrs.PortedNumbersId, true); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = OK
+	return nil
+}
+
+type AttrSetPortedNumbers struct {
+	PortedNumbers []*utils.TPPortedNumber // empty RoutingPrefix ports the number back
+}
+
+// Incrementally updates ported numbers directly in dataDb
+func (self *ApierV1) SetPortedNumbers(attrs AttrSetPortedNumbers, reply *string) error {
+	if len(attrs.PortedNumbers) == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "PortedNumbers")
+	}
+	for _, pn := range attrs.PortedNumbers {
+		if len(pn.Number) == 0 {
+			return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "Number")
+		}
+	}
+	for _, pn := range attrs.PortedNumbers {
+		var err error
+		if len(pn.RoutingPrefix) == 0 {
+			err = self.RatingDb.RemovePortedNumber(pn.Number)
+		} else {
+			err = self.RatingDb.SetPortedNumber(&engine.PortedNumber{Id: pn.Number, RoutingPrefix: pn.RoutingPrefix})
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	*reply = OK
+	return nil
+}
+
+// Returns the porting information of a number
+func (self *ApierV1) GetPortedNumber(number string, reply *engine.PortedNumber) error {
+	if len(number) == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "Number")
+	}
+	pn, err := self.RatingDb.GetPortedNumber(number)
+	if err != nil {
+		if err.Error() == utils.ERR_NOT_FOUND {
+			return err
+		}
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = *pn
+	return nil
+}
+
 type AttrLoadTpFromStorDb struct {
 	TPid    string
 	FlushDb bool // Flush ratingDb before loading
@@ -493,8 +620,11 @@ func (self *ApierV1) SetRatingProfile(attrs AttrSetRatingProfile, reply *string)
 		} else if !exists {
 			return fmt.Errorf(fmt.Sprintf("%s:RatingPlanId:%s", utils.ERR_NOT_FOUND, ra.RatingPlanId))
 		}
+		if _, err := utils.GetLocation(ra.Timezone); err != nil {
+			return fmt.Errorf("%s:Timezone:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
 		rpfl.RatingPlanActivations[idx] = &engine.RatingPlanActivation{ActivationTime: at, RatingPlanId: ra.RatingPlanId,
-			FallbackKeys: utils.FallbackSubjKeys(tpRpf.Direction, tpRpf.Tenant, tpRpf.Category, ra.FallbackSubjects)}
+			FallbackKeys: utils.FallbackSubjKeys(tpRpf.Direction, tpRpf.Tenant, tpRpf.Category, ra.FallbackSubjects), Timezone: ra.Timezone}
 	}
 	if err := self.RatingDb.SetRatingProfile(rpfl); err != nil {
 		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
@@ -605,6 +735,7 @@ type ApiActionTiming struct {
 	WeekDays  string  // semicolon separated list of week day names this timing is valid on *any or empty supported
 	Time      string  // String representing the time this timing starts on, *asap supported
 	Weight    float64 // Binding's weight
+	Timezone  string  // IANA time zone the timing is scheduled in, server local one if empty
 }
 
 func (self *ApierV1) SetActionPlan(attrs AttrSetActionPlan, reply *string) error {
@@ -631,6 +762,9 @@ func (self *ApierV1) SetActionPlan(attrs AttrSetActionPlan, reply *string) error
 		} else if !exists {
 			return fmt.Errorf("%s:%s", utils.ERR_BROKEN_REFERENCE, apiAtm.ActionsId)
 		}
+		if _, err := utils.GetLocation(apiAtm.Timezone); err != nil {
+			return fmt.Errorf("%s:Timezone:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
 		timing := new(engine.RITiming)
 		timing.Years.Parse(apiAtm.Years, ";")
 		timing.Months.Parse(apiAtm.Months, ";")
@@ -643,6 +777,7 @@ func (self *ApierV1) SetActionPlan(attrs AttrSetActionPlan, reply *string) error
 			Weight:    apiAtm.Weight,
 			Timing:    &engine.RateInterval{Timing: timing},
 			ActionsId: apiAtm.ActionsId,
+			Timezone:  apiAtm.Timezone,
 		}
 		storeAtms[idx] = at
 	}
@@ -900,7 +1035,7 @@ func (self *ApierV1) ReloadCache(attrs utils.ApiReloadCache, reply *string) erro
 
 func (self *ApierV1) GetCacheStats(attrs utils.AttrCacheStats, reply *utils.CacheStats) error {
 	cs := new(utils.CacheStats)
-	cs.Destinations = cache2go.CountEntries(engine.DESTINATION_PREFIX)
+	cs.Destinations = engine.CountCachedDestPrefixes()
 	cs.RatingPlans = cache2go.CountEntries(engine.RATING_PLAN_PREFIX)
 	cs.RatingProfiles = cache2go.CountEntries(engine.RATING_PROFILE_PREFIX)
 	cs.Actions = cache2go.CountEntries(engine.ACTION_PREFIX)
@@ -918,24 +1053,26 @@ func (self *ApierV1) GetCachedItemAge(itemId string, reply *utils.CachedItemAge)
 	}
 	cachedItemAge := new(utils.CachedItemAge)
 	var found bool
-	for idx, cacheKey := range []string{engine.DESTINATION_PREFIX + itemId, engine.RATING_PLAN_PREFIX + itemId, engine.RATING_PROFILE_PREFIX + itemId,
+	if age, err := engine.CachedDestPrefixAge(itemId); err == nil {
+		found = true
+		cachedItemAge.Destination = age
+	}
+	for idx, cacheKey := range []string{engine.RATING_PLAN_PREFIX + itemId, engine.RATING_PROFILE_PREFIX + itemId,
 		engine.ACTION_PREFIX + itemId, engine.SHARED_GROUP_PREFIX + itemId, engine.RP_ALIAS_PREFIX + itemId, engine.ACC_ALIAS_PREFIX + itemId} {
 		if age, err := cache2go.GetKeyAge(cacheKey); err == nil {
 			found = true
 			switch idx {
 			case 0:
-				cachedItemAge.Destination = age
-			case 1:
 				cachedItemAge.RatingPlan = age
-			case 2:
+			case 1:
 				cachedItemAge.RatingProfile = age
-			case 3:
+			case 2:
 				cachedItemAge.Action = age
-			case 4:
+			case 3:
 				cachedItemAge.SharedGroup = age
-			case 5:
+			case 4:
 				cachedItemAge.RatingAlias = age
-			case 6:
+			case 5:
 				cachedItemAge.AccountAlias = age
 			}
 		}
@@ -973,7 +1110,11 @@ func (self *ApierV1) LoadTariffPlanFromFolder(attrs utils.AttrLoadTpFromFolder,
 		path.Join(attrs.FolderPath, utils.ACTION_TRIGGERS_CSV),
 		path.Join(attrs.FolderPath, utils.ACCOUNT_ACTIONS_CSV),
 		path.Join(attrs.FolderPath, utils.DERIVED_CHARGERS_CSV),
-		path.Join(attrs.FolderPath, utils.CDR_STATS_CSV))
+		path.Join(attrs.FolderPath, utils.CDR_STATS_CSV),
+		path.Join(attrs.FolderPath, utils.EXCHANGE_RATES_CSV),
+		path.Join(attrs.FolderPath, utils.TAX_RULES_CSV),
+		path.Join(attrs.FolderPath, utils.PORTED_NUMBERS_CSV),
+		path.Join(attrs.FolderPath, utils.CALENDARS_CSV))
 	if err := loader.LoadAll(); err != nil {
 		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
 	}
@@ -62,3 +62,20 @@ func (apier *ApierV1) GetCdrs(attrs utils.AttrGetCdrs, reply *[]*engine.External
 	}
 	return nil
 }
+
+type AttrArchiveCdrs struct {
+	DryRun bool // Only report the CDRs reached by the retention policies
+}
+
+// Applies the configured CDR retention policies once, out of schedule
+func (apier *ApierV1) ArchiveCdrs(attrs AttrArchiveCdrs, reply *[]*engine.CdrArchiveReport) error {
+	if len(apier.Config.CdrArchiverConfig.Policies) == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_NOT_FOUND, "CdrRetentionPolicies")
+	}
+	if reports, err := engine.NewCdrArchiver(apier.CdrDb, apier.Config.CdrArchiverConfig).Run(attrs.DryRun); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else {
+		*reply = reports
+	}
+	return nil
+}
@@ -58,3 +58,30 @@ func (apier *ApierV1) GetDataCost(attrs AttrGetDataCost, reply *engine.DataCost)
 	}
 	return nil
 }
+
+// Rates the call the same way GetCost does, returning each rating decision taken for troubleshooting disputed charges
+func (apier *ApierV1) ExplainCost(cd engine.CallDescriptor, reply *engine.CostExplanation) error {
+	if missing := utils.MissingStructFields(&cd, []string{"Direction", "Tenant", "Category", "Subject", "Destination"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if cd.TimeEnd.Before(cd.TimeStart) {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, "TimeEnd before TimeStart")
+	}
+	*reply = *cd.ExplainCost()
+	return nil
+}
+
+// Rates many calls in one request, for price lookups and invoice previews; failed items carry their error
+func (apier *ApierV1) GetCostBatch(cds []engine.CallDescriptor, reply *[]*engine.BatchCallCost) error {
+	if err := apier.Responder.GetCostBatch(cds, reply); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	return nil
+}
+
+func (apier *ApierV1) GetMaxSessionTimeBatch(cds []engine.CallDescriptor, reply *[]*engine.BatchMaxSessionTime) error {
+	if err := apier.Responder.GetMaxSessionTimeBatch(cds, reply); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	return nil
+}
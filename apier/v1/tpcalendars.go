@@ -0,0 +1,96 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Creates a new holiday calendar within a tariff plan
+func (self *ApierV1) SetTPCalendar(attrs utils.TPCalendar, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "CalendarId", "Days"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	for _, day := range attrs.Days {
+		if missing := utils.MissingStructFields(day, []string{"Date"}); len(missing) != 0 {
+			return fmt.Errorf("%s:CalendarDay:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+		}
+	}
+	if err := self.StorDb.SetTPCalendars(attrs.TPid, map[string][]*utils.TPCalendarDay{attrs.CalendarId: attrs.Days}); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = "OK"
+	return nil
+}
+
+type AttrGetTPCalendar struct {
+	TPid       string // Tariff plan id
+	CalendarId string // Calendar id
+}
+
+// Queries specific holiday calendar on tariff plan
+func (self *ApierV1) GetTPCalendar(attrs AttrGetTPCalendar, reply *utils.TPCalendar) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "CalendarId"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if days, err := self.StorDb.GetTpCalendars(attrs.TPid, attrs.CalendarId); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else if len(days) == 0 {
+		return errors.New(utils.ERR_NOT_FOUND)
+	} else {
+		*reply = utils.TPCalendar{TPid: attrs.TPid, CalendarId: attrs.CalendarId, Days: days[attrs.CalendarId]}
+	}
+	return nil
+}
+
+type AttrGetTPCalendarIds struct {
+	TPid string // Tariff plan id
+	utils.Paginator
+}
+
+// Queries holiday calendar identities on specific tariff plan.
+func (self *ApierV1) GetTPCalendarIds(attrs AttrGetTPCalendarIds, reply *[]string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if ids, err := self.StorDb.GetTPTableIds(attrs.TPid, utils.TBL_TP_CALENDARS, utils.TPDistinctIds{"tag"}, nil, &attrs.Paginator); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else if ids == nil {
+		return errors.New(utils.ERR_NOT_FOUND)
+	} else {
+		*reply = ids
+	}
+	return nil
+}
+
+// Removes specific holiday calendar on Tariff plan
+func (self *ApierV1) RemTPCalendar(attrs AttrGetTPCalendar, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "CalendarId"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if err := self.StorDb.RemTPData(utils.TBL_TP_CALENDARS, attrs.TPid, attrs.CalendarId); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else {
+		*reply = "OK"
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Creates a new PortedNumbers profile within a tariff plan
+func (self *ApierV1) SetTPPortedNumbers(attrs utils.TPPortedNumbers, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "PortedNumbersId", "PortedNumbers"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	for _, pn := range attrs.PortedNumbers {
+		if missing := utils.MissingStructFields(pn, []string{"Number"}); len(missing) != 0 {
+			return fmt.Errorf("%s:PortedNumber:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+		}
+	}
+	if err := self.StorDb.SetTPPortedNumbers(attrs.TPid, map[string][]*utils.TPPortedNumber{attrs.PortedNumbersId: attrs.PortedNumbers}); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = "OK"
+	return nil
+}
+
+type AttrGetTPPortedNumbers struct {
+	TPid            string // Tariff plan id
+	PortedNumbersId string // PortedNumbers id
+}
+
+// Queries specific PortedNumbers on tariff plan
+func (self *ApierV1) GetTPPortedNumbers(attrs AttrGetTPPortedNumbers, reply *utils.TPPortedNumbers) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "PortedNumbersId"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if pns, err := self.StorDb.GetTpPortedNumbers(attrs.TPid, attrs.PortedNumbersId); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else if len(pns) == 0 {
+		return errors.New(utils.ERR_NOT_FOUND)
+	} else {
+		*reply = utils.TPPortedNumbers{TPid: attrs.TPid, PortedNumbersId: attrs.PortedNumbersId, PortedNumbers: pns[attrs.PortedNumbersId]}
+	}
+	return nil
+}
+
+type AttrGetTPPortedNumberIds struct {
+	TPid string // Tariff plan id
+	utils.Paginator
+}
+
+// Queries PortedNumbers identities on specific tariff plan.
+func (self *ApierV1) GetTPPortedNumberIds(attrs AttrGetTPPortedNumberIds, reply *[]string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if ids, err := self.StorDb.GetTPTableIds(attrs.TPid, utils.TBL_TP_PORTED_NUMBERS, utils.TPDistinctIds{"tag"}, nil, &attrs.Paginator); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else if ids == nil {
+		return errors.New(utils.ERR_NOT_FOUND)
+	} else {
+		*reply = ids
+	}
+	return nil
+}
+
+// Removes specific PortedNumbers on Tariff plan
+func (self *ApierV1) RemTPPortedNumbers(attrs AttrGetTPPortedNumbers, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"TPid", "PortedNumbersId"}); len(missing) != 0 { //Params missing
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	if err := self.StorDb.RemTPData(utils.TBL_TP_PORTED_NUMBERS, attrs.TPid, attrs.PortedNumbersId); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else {
+		*reply = "OK"
+	}
+	return nil
+}
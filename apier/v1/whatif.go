@@ -0,0 +1,48 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrWhatIfRating struct {
+	TPid       string              // Candidate tariff plan in storDb
+	CdrsFilter utils.RpcCdrsFilter // Stored CDRs to rate against it
+}
+
+// Rates stored CDRs against a tariff plan not yet loaded, returning the cost deltas per account and destination
+func (self *ApierV1) WhatIfRating(attrs AttrWhatIfRating, reply *engine.WhatIfReport) error {
+	if len(attrs.TPid) == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "TPid")
+	}
+	cdrsFltr, err := attrs.CdrsFilter.AsCdrsFilter()
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	report, err := engine.WhatIfRating(self.StorDb, self.CdrDb, attrs.TPid, cdrsFltr)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = *report
+	return nil
+}
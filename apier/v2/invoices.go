@@ -0,0 +1,156 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+type AttrGenerateInvoice struct {
+	Tenant      string
+	Account     string
+	Direction   string
+	PeriodStart string // Empty together with PeriodEnd for the last closed billing cycle of the account
+	PeriodEnd   string
+}
+
+// Builds the invoice of an account out of its rated CDRs and recurring charges, storing it with the next number
+func (self *ApierV2) GenerateInvoice(attrs AttrGenerateInvoice, reply *engine.Invoice) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Account", "Direction"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	var periodStart, periodEnd time.Time
+	var err error
+	if len(attrs.PeriodStart) == 0 && len(attrs.PeriodEnd) == 0 {
+		acnt, err := self.AccountDb.GetAccount(utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction))
+		if err != nil {
+			return errors.New(utils.ERR_NOT_FOUND)
+		}
+		if acnt.BillingCycle == nil || len(acnt.BillingCycle.Closed) == 0 {
+			return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "PeriodStart, PeriodEnd (no closed billing cycle)")
+		}
+		lastCycle := acnt.BillingCycle.Closed[len(acnt.BillingCycle.Closed)-1]
+		periodStart, periodEnd = lastCycle.Start, lastCycle.End
+	} else {
+		if periodStart, err = utils.ParseTimeDetectLayout(attrs.PeriodStart); err != nil {
+			return fmt.Errorf("%s:PeriodStart:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+		if periodEnd, err = utils.ParseTimeDetectLayout(attrs.PeriodEnd); err != nil {
+			return fmt.Errorf("%s:PeriodEnd:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	if !periodEnd.After(periodStart) {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, "PeriodEnd not after PeriodStart")
+	}
+	inv, err := engine.GenerateInvoice(self.CdrDb, attrs.Tenant, attrs.Account, attrs.Direction, periodStart, periodEnd, 0)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), utils.ERR_EXISTS) {
+			return err
+		}
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = *inv
+	return nil
+}
+
+type AttrInvoice struct {
+	Number int64
+}
+
+// Builds again a stored invoice over the same period, keeping its number
+func (self *ApierV2) RegenerateInvoice(attrs AttrInvoice, reply *engine.Invoice) error {
+	if attrs.Number == 0 {
+		return fmt.Errorf("%s:%s", utils.ERR_MANDATORY_IE_MISSING, "Number")
+	}
+	inv, err := engine.RegenerateInvoice(self.CdrDb, attrs.Number)
+	if err != nil {
+		if err.Error() == utils.ERR_NOT_FOUND {
+			return err
+		}
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = *inv
+	return nil
+}
+
+type AttrGetInvoices struct {
+	Tenant    string
+	Account   string
+	Direction string
+}
+
+// Lists the invoices of an account, oldest first
+func (self *ApierV2) GetInvoices(attrs AttrGetInvoices, reply *[]*engine.Invoice) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Tenant", "Account", "Direction"}); len(missing) != 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, missing)
+	}
+	invs, err := self.CdrDb.GetInvoices(utils.AccountKey(attrs.Tenant, attrs.Account, attrs.Direction), 0)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	if invs == nil {
+		invs = make([]*engine.Invoice, 0)
+	}
+	*reply = invs
+	return nil
+}
+
+type AttrRenderInvoice struct {
+	Number   int64
+	Format   string // *json, *csv or *html
+	Template string // name of a HTML template out of the invoice_templates_dir, replacing the default one
+}
+
+// Returns the invoice rendered in one of the export formats
+func (self *ApierV2) RenderInvoice(attrs AttrRenderInvoice, reply *string) error {
+	if missing := utils.MissingStructFields(&attrs, []string{"Format"}); len(missing) != 0 || attrs.Number == 0 {
+		return fmt.Errorf("%s:%v", utils.ERR_MANDATORY_IE_MISSING, append(missing, "Number"))
+	}
+	invs, err := self.CdrDb.GetInvoices("", attrs.Number)
+	if err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	} else if len(invs) == 0 {
+		return errors.New(utils.ERR_NOT_FOUND)
+	}
+	var tmpl *template.Template
+	if len(attrs.Template) != 0 {
+		// only the files of the configured folder, the name cannot lead out of it
+		if len(self.Config.InvoiceTemplatesDir) == 0 || attrs.Template != filepath.Base(attrs.Template) || strings.HasPrefix(attrs.Template, ".") {
+			return fmt.Errorf("%s:Template:%s", utils.ERR_SERVER_ERROR, "unknown template")
+		}
+		if tmpl, err = template.ParseFiles(filepath.Join(self.Config.InvoiceTemplatesDir, attrs.Template)); err != nil {
+			return fmt.Errorf("%s:Template:%s", utils.ERR_SERVER_ERROR, err.Error())
+		}
+	}
+	var out bytes.Buffer
+	if err := invs[0].Render(&out, attrs.Format, tmpl); err != nil {
+		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
+	}
+	*reply = out.String()
+	return nil
+}
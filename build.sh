@@ -8,7 +8,9 @@ go install github.com/cgrates/cgrates/cmd/cgr-console
 cc=$?
 go install github.com/cgrates/cgrates/cmd/cgr-tester
 ct=$?
+go install github.com/cgrates/cgrates/cmd/cgr-migrator
+cm=$?
 
-exit $cr || $cl || $cc || $ct
+exit $cr || $cl || $cc || $ct || $cm
 
 
@@ -44,6 +44,8 @@ const (
 	META_SMSUSAGE        = "*sms_usage"
 	META_DATAUSAGE       = "*data_usage"
 	META_COSTCDRS        = "*cdrs_cost"
+	META_TAXCDRS         = "*cdrs_tax"
+	META_GROSSCOSTCDRS   = "*cdrs_gross_cost"
 	META_MASKDESTINATION = "*mask_destination"
 	META_FORMATCOST      = "*format_cost"
 )
@@ -99,10 +101,10 @@ type CdrExporter struct {
 	numberOfRecords                              int
 	totalDuration, totalDataUsage, totalSmsUsage time.Duration
 
-	totalCost                       float64
-	firstExpOrderId, lastExpOrderId int64
-	positiveExports                 []string          // CGRIds of successfully exported CDRs
-	negativeExports                 map[string]string // CgrIds of failed exports
+	totalCost, totalTax, totalGrossCost float64
+	firstExpOrderId, lastExpOrderId     int64
+	positiveExports                     []string          // CGRIds of successfully exported CDRs
+	negativeExports                     map[string]string // CgrIds of failed exports
 }
 
 // Return Json marshaled callCost attached to
@@ -190,6 +192,10 @@ func (cdre *CdrExporter) cdrFieldValue(cdr *engine.StoredCdr, cfgCdrFld *config.
 			}
 		case utils.COST:
 			cdrVal = cdr.FormatCost(cdre.costShiftDigits, cdre.roundDecimals)
+		case utils.TAX:
+			cdrVal = cdr.FormatTax(cdre.costShiftDigits, cdre.roundDecimals)
+		case utils.GROSS_COST:
+			cdrVal = cdr.FormatGrossCost(cdre.costShiftDigits, cdre.roundDecimals)
 		case utils.USAGE:
 			cdrVal = cdr.FormatUsage(layout)
 		case utils.SETUP_TIME:
@@ -233,6 +239,10 @@ func (cdre *CdrExporter) metaHandler(tag, arg string) (string, error) {
 		return emulatedCdr.FormatUsage(arg), nil
 	case META_COSTCDRS:
 		return strconv.FormatFloat(utils.Round(cdre.totalCost, cdre.roundDecimals, utils.ROUNDING_MIDDLE), 'f', -1, 64), nil
+	case META_TAXCDRS:
+		return strconv.FormatFloat(utils.Round(cdre.totalTax, cdre.roundDecimals, utils.ROUNDING_MIDDLE), 'f', -1, 64), nil
+	case META_GROSSCOSTCDRS:
+		return strconv.FormatFloat(utils.Round(cdre.totalGrossCost, cdre.roundDecimals, utils.ROUNDING_MIDDLE), 'f', -1, 64), nil
 	case META_MASKDESTINATION:
 		if cdre.maskedDestination(arg) {
 			return "1", nil
@@ -384,6 +394,8 @@ func (cdre *CdrExporter) processCdr(cdr *engine.StoredCdr) error {
 	if cdr.Cost != -1 {
 		cdre.totalCost += cdr.Cost
 		cdre.totalCost = utils.Round(cdre.totalCost, cdre.roundDecimals, utils.ROUNDING_MIDDLE)
+		cdre.totalTax = utils.Round(cdre.totalTax+cdr.Tax, cdre.roundDecimals, utils.ROUNDING_MIDDLE)
+		cdre.totalGrossCost = utils.Round(cdre.totalGrossCost+cdr.GrossCost, cdre.roundDecimals, utils.ROUNDING_MIDDLE)
 	}
 	if cdre.firstExpOrderId > cdr.OrderId || cdre.firstExpOrderId == 0 {
 		cdre.firstExpOrderId = cdr.OrderId
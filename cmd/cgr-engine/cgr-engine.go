@@ -433,23 +433,31 @@ func main() {
 	var cdrDb engine.CdrStorage
 	if cfg.RaterEnabled || cfg.SchedulerEnabled { // Only connect to dataDb if required
 		ratingDb, err = engine.ConfigureRatingStorage(cfg.RatingDBType, cfg.RatingDBHost, cfg.RatingDBPort,
-			cfg.RatingDBName, cfg.RatingDBUser, cfg.RatingDBPass, cfg.DBDataEncoding)
+			cfg.RatingDBName, cfg.RatingDBUser, cfg.RatingDBPass, cfg.DBDataEncoding, cfg.RatingDBSentinels, cfg.RatingDBMasterName)
 		if err != nil { // Cannot configure getter database, show stopper
 			engine.Logger.Crit(fmt.Sprintf("Could not configure dataDb: %s exiting!", err))
 			return
 		}
 		defer ratingDb.Close()
+		if err := engine.CheckVersion(ratingDb, engine.VER_RATING_DB); err != nil {
+			engine.Logger.Crit(fmt.Sprintf("Incompatible ratingDb: %s exiting!", err))
+			return
+		}
 		engine.SetRatingStorage(ratingDb)
 		accountDb, err = engine.ConfigureAccountingStorage(cfg.AccountDBType, cfg.AccountDBHost, cfg.AccountDBPort,
-			cfg.AccountDBName, cfg.AccountDBUser, cfg.AccountDBPass, cfg.DBDataEncoding)
+			cfg.AccountDBName, cfg.AccountDBUser, cfg.AccountDBPass, cfg.DBDataEncoding, cfg.AccountDBSentinels, cfg.AccountDBMasterName)
 		if err != nil { // Cannot configure getter database, show stopper
 			engine.Logger.Crit(fmt.Sprintf("Could not configure dataDb: %s exiting!", err))
 			return
 		}
 		defer accountDb.Close()
+		if err := engine.CheckVersion(accountDb, engine.VER_ACCOUNTING_DB); err != nil {
+			engine.Logger.Crit(fmt.Sprintf("Incompatible accountDb: %s exiting!", err))
+			return
+		}
 		engine.SetAccountingStorage(accountDb)
 	}
-	if cfg.RaterEnabled || cfg.CDRSEnabled || cfg.SchedulerEnabled { // Only connect to storDb if necessary
+	if cfg.RaterEnabled || cfg.CDRSEnabled || cfg.SchedulerEnabled || cfg.CdrArchiverConfig.Enabled { // Only connect to storDb if necessary
 		if cfg.StorDBType == SAME {
 			logDb = ratingDb.(engine.LogStorage)
 		} else {
@@ -459,6 +467,10 @@ func main() {
 				engine.Logger.Crit(fmt.Sprintf("Could not configure logger database: %s exiting!", err))
 				return
 			}
+			if err := engine.CheckVersion(logDb, engine.VER_STOR_DB); err != nil {
+				engine.Logger.Crit(fmt.Sprintf("Incompatible storDb: %s exiting!", err))
+				return
+			}
 		}
 		defer logDb.Close()
 		engine.SetStorageLogger(logDb)
@@ -583,6 +595,13 @@ func main() {
 		go startCDRS(logDb, cdrDb, responder, cacheChan, cdrsChan)
 	}
 
+	if cfg.CdrArchiverConfig.Enabled {
+		engine.Logger.Info("Starting CGRateS CdrArchiver service.")
+		cdrArchiver := engine.NewCdrArchiver(cdrDb, cfg.CdrArchiverConfig)
+		defer cdrArchiver.Stop()
+		go cdrArchiver.Loop()
+	}
+
 	if cfg.SmFsConfig.Enabled {
 		engine.Logger.Info("Starting CGRateS SM-FreeSWITCH service.")
 		go startSmFreeSWITCH(responder, cdrDb, cacheChan)
@@ -19,11 +19,13 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/rpc"
 	"path"
+	"strings"
 
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/engine"
@@ -33,22 +35,26 @@ import (
 
 var (
 	//separator = flag.String("separator", ",", "Default field separator")
-	cgrConfig, _  = config.NewDefaultCGRConfig()
-	ratingdb_type = flag.String("ratingdb_type", cgrConfig.RatingDBType, "The type of the RatingDb database <redis>")
-	ratingdb_host = flag.String("ratingdb_host", cgrConfig.RatingDBHost, "The RatingDb host to connect to.")
-	ratingdb_port = flag.String("ratingdb_port", cgrConfig.RatingDBPort, "The RatingDb port to bind to.")
-	ratingdb_name = flag.String("ratingdb_name", cgrConfig.RatingDBName, "The name/number of the RatingDb to connect to.")
-	ratingdb_user = flag.String("ratingdb_user", cgrConfig.RatingDBUser, "The RatingDb user to sign in as.")
-	ratingdb_pass = flag.String("ratingdb_passwd", cgrConfig.RatingDBPass, "The RatingDb user's password.")
+	cgrConfig, _         = config.NewDefaultCGRConfig()
+	ratingdb_type        = flag.String("ratingdb_type", cgrConfig.RatingDBType, "The type of the RatingDb database <redis|mongo|*internal>")
+	ratingdb_host        = flag.String("ratingdb_host", cgrConfig.RatingDBHost, "The RatingDb host to connect to.")
+	ratingdb_port        = flag.String("ratingdb_port", cgrConfig.RatingDBPort, "The RatingDb port to bind to.")
+	ratingdb_name        = flag.String("ratingdb_name", cgrConfig.RatingDBName, "The name/number of the RatingDb to connect to.")
+	ratingdb_user        = flag.String("ratingdb_user", cgrConfig.RatingDBUser, "The RatingDb user to sign in as.")
+	ratingdb_pass        = flag.String("ratingdb_passwd", cgrConfig.RatingDBPass, "The RatingDb user's password.")
+	ratingdb_sentinels   = flag.String("ratingdb_sentinels", strings.Join(cgrConfig.RatingDBSentinels, ","), "Comma separated redis sentinels used to discover the RatingDb master.")
+	ratingdb_master_name = flag.String("ratingdb_master_name", cgrConfig.RatingDBMasterName, "The name of the RatingDb master monitored by the sentinels.")
 
-	accountdb_type = flag.String("accountdb_type", cgrConfig.AccountDBType, "The type of the AccountingDb database <redis>")
-	accountdb_host = flag.String("accountdb_host", cgrConfig.AccountDBHost, "The AccountingDb host to connect to.")
-	accountdb_port = flag.String("accountdb_port", cgrConfig.AccountDBPort, "The AccountingDb port to bind to.")
-	accountdb_name = flag.String("accountdb_name", cgrConfig.AccountDBName, "The name/number of the AccountingDb to connect to.")
-	accountdb_user = flag.String("accountdb_user", cgrConfig.AccountDBUser, "The AccountingDb user to sign in as.")
-	accountdb_pass = flag.String("accountdb_passwd", cgrConfig.AccountDBPass, "The AccountingDb user's password.")
+	accountdb_type        = flag.String("accountdb_type", cgrConfig.AccountDBType, "The type of the AccountingDb database <redis|mongo|*internal>")
+	accountdb_host        = flag.String("accountdb_host", cgrConfig.AccountDBHost, "The AccountingDb host to connect to.")
+	accountdb_port        = flag.String("accountdb_port", cgrConfig.AccountDBPort, "The AccountingDb port to bind to.")
+	accountdb_name        = flag.String("accountdb_name", cgrConfig.AccountDBName, "The name/number of the AccountingDb to connect to.")
+	accountdb_user        = flag.String("accountdb_user", cgrConfig.AccountDBUser, "The AccountingDb user to sign in as.")
+	accountdb_pass        = flag.String("accountdb_passwd", cgrConfig.AccountDBPass, "The AccountingDb user's password.")
+	accountdb_sentinels   = flag.String("accountdb_sentinels", strings.Join(cgrConfig.AccountDBSentinels, ","), "Comma separated redis sentinels used to discover the AccountingDb master.")
+	accountdb_master_name = flag.String("accountdb_master_name", cgrConfig.AccountDBMasterName, "The name of the AccountingDb master monitored by the sentinels.")
 
-	stor_db_type = flag.String("stordb_type", cgrConfig.StorDBType, "The type of the storDb database <mysql>")
+	stor_db_type = flag.String("stordb_type", cgrConfig.StorDBType, "The type of the storDb database <mysql|postgres|mongo|sqlite>")
 	stor_db_host = flag.String("stordb_host", cgrConfig.StorDBHost, "The storDb host to connect to.")
 	stor_db_port = flag.String("stordb_port", cgrConfig.StorDBPort, "The storDb port to bind to.")
 	stor_db_name = flag.String("stordb_name", cgrConfig.StorDBName, "The name/number of the storDb to connect to.")
@@ -66,12 +72,21 @@ var (
 	stats           = flag.Bool("stats", false, "Generates statsistics about given data.")
 	fromStorDb      = flag.Bool("from_stordb", false, "Load the tariff plan from storDb to dataDb")
 	toStorDb        = flag.Bool("to_stordb", false, "Import the tariff plan from files to storDb")
+	whatIf          = flag.Bool("whatif", false, "Rate the stored CDRs against the tariff plan in storDb and report the cost deltas, nothing is loaded")
+	whatIfFilter    = flag.String("whatif_filter", "{}", "JSON encoded filter selecting the stored CDRs rated on -whatif")
 	historyServer   = flag.String("history_server", cgrConfig.RPCGOBListen, "The history server address:port, empty to disable automaticautomatic  history archiving")
 	raterAddress    = flag.String("rater_address", cgrConfig.RPCGOBListen, "Rater service to contact for cache reloads, empty to disable automatic cache reloads")
 	cdrstatsAddress = flag.String("cdrstats_address", cgrConfig.RPCGOBListen, "CDRStats service to contact for data reloads, empty to disable automatic data reloads")
 	runId           = flag.String("runid", "", "Uniquely identify an import/load, postpended to some automatic fields")
 )
 
+func splitSentinels(sentinels string) []string {
+	if sentinels == "" {
+		return nil
+	}
+	return strings.Split(sentinels, ",")
+}
+
 func main() {
 	flag.Parse()
 	if *version {
@@ -88,17 +103,24 @@ func main() {
 	if !*dryRun { // make sure we do not need db connections on dry run, also not importing into any stordb
 		if *fromStorDb {
 			ratingDb, errRatingDb = engine.ConfigureRatingStorage(*ratingdb_type, *ratingdb_host, *ratingdb_port, *ratingdb_name,
-				*ratingdb_user, *ratingdb_pass, *dbdata_encoding)
-			accountDb, errAccDb = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding)
+				*ratingdb_user, *ratingdb_pass, *dbdata_encoding, splitSentinels(*ratingdb_sentinels), *ratingdb_master_name)
+			accountDb, errAccDb = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding,
+				splitSentinels(*accountdb_sentinels), *accountdb_master_name)
 			storDb, errStorDb = engine.ConfigureLoadStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
 				cgrConfig.StorDBMaxOpenConns, cgrConfig.StorDBMaxIdleConns)
 		} else if *toStorDb { // Import from csv files to storDb
 			storDb, errStorDb = engine.ConfigureLoadStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
 				cgrConfig.StorDBMaxOpenConns, cgrConfig.StorDBMaxIdleConns)
+		} else if *whatIf { // Rate CDRs out of storDb, accountDb only read for the usage of tiered rates
+			accountDb, errAccDb = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding,
+				splitSentinels(*accountdb_sentinels), *accountdb_master_name)
+			storDb, errStorDb = engine.ConfigureLoadStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
+				cgrConfig.StorDBMaxOpenConns, cgrConfig.StorDBMaxIdleConns)
 		} else { // Default load from csv files to dataDb
 			ratingDb, errRatingDb = engine.ConfigureRatingStorage(*ratingdb_type, *ratingdb_host, *ratingdb_port, *ratingdb_name,
-				*ratingdb_user, *ratingdb_pass, *dbdata_encoding)
-			accountDb, errAccDb = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding)
+				*ratingdb_user, *ratingdb_pass, *dbdata_encoding, splitSentinels(*ratingdb_sentinels), *ratingdb_master_name)
+			accountDb, errAccDb = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding,
+				splitSentinels(*accountdb_sentinels), *accountdb_master_name)
 		}
 		// Defer databases opened to be closed when we are done
 		for _, db := range []engine.Storage{ratingDb, accountDb, storDb} {
@@ -129,6 +151,31 @@ func main() {
 			}
 			return
 		}
+		if *whatIf { // Simulate the tariff plan in storDb on stored CDRs
+			if *tpid == "" {
+				log.Fatal("TPid required, please define it via *-tpid* command argument.")
+			}
+			var rpcFltr utils.RpcCdrsFilter
+			if err = json.Unmarshal([]byte(*whatIfFilter), &rpcFltr); err != nil {
+				log.Fatal("Could not parse the CDRs filter: ", err)
+			}
+			cdrsFltr, err := rpcFltr.AsCdrsFilter()
+			if err != nil {
+				log.Fatal("Could not parse the CDRs filter: ", err)
+			}
+			cdrDb, hasCdrs := storDb.(engine.CdrStorage)
+			if !hasCdrs {
+				log.Fatalf("StorDb of type %s does not hold CDRs", *stor_db_type)
+			}
+			engine.SetAccountingStorage(accountDb)
+			report, err := engine.WhatIfRating(storDb, cdrDb, *tpid, cdrsFltr)
+			if err != nil {
+				log.Fatal(err)
+			}
+			out, _ := json.MarshalIndent(report, "", " ")
+			fmt.Println(string(out))
+			return
+		}
 	}
 	if *fromStorDb { // Load Tariff Plan from storDb into dataDb
 		loader = engine.NewDbReader(storDb, ratingDb, accountDb, *tpid)
@@ -153,7 +200,11 @@ func main() {
 			path.Join(*dataPath, utils.ACTION_TRIGGERS_CSV),
 			path.Join(*dataPath, utils.ACCOUNT_ACTIONS_CSV),
 			path.Join(*dataPath, utils.DERIVED_CHARGERS_CSV),
-			path.Join(*dataPath, utils.CDR_STATS_CSV))
+			path.Join(*dataPath, utils.CDR_STATS_CSV),
+			path.Join(*dataPath, utils.EXCHANGE_RATES_CSV),
+			path.Join(*dataPath, utils.TAX_RULES_CSV),
+			path.Join(*dataPath, utils.PORTED_NUMBERS_CSV),
+			path.Join(*dataPath, utils.CALENDARS_CSV))
 	}
 	err = loader.LoadAll()
 	if err != nil {
@@ -0,0 +1,129 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+const ALL = "*all"
+
+var (
+	cgrConfig, _          = config.NewDefaultCGRConfig()
+	ratingdb_type         = flag.String("ratingdb_type", cgrConfig.RatingDBType, "The type of the RatingDb database <redis|mongo|*internal>")
+	ratingdb_host         = flag.String("ratingdb_host", cgrConfig.RatingDBHost, "The RatingDb host to connect to.")
+	ratingdb_port         = flag.String("ratingdb_port", cgrConfig.RatingDBPort, "The RatingDb port to bind to.")
+	ratingdb_name         = flag.String("ratingdb_name", cgrConfig.RatingDBName, "The name/number of the RatingDb to connect to.")
+	ratingdb_user         = flag.String("ratingdb_user", cgrConfig.RatingDBUser, "The RatingDb user to sign in as.")
+	ratingdb_pass         = flag.String("ratingdb_passwd", cgrConfig.RatingDBPass, "The RatingDb user's password.")
+	ratingdb_sentinels    = flag.String("ratingdb_sentinels", strings.Join(cgrConfig.RatingDBSentinels, ","), "Comma separated redis sentinels used to discover the RatingDb master.")
+	ratingdb_master_name  = flag.String("ratingdb_master_name", cgrConfig.RatingDBMasterName, "The name of the RatingDb master monitored by the sentinels.")
+	accountdb_type        = flag.String("accountdb_type", cgrConfig.AccountDBType, "The type of the AccountingDb database <redis|mongo|*internal>")
+	accountdb_host        = flag.String("accountdb_host", cgrConfig.AccountDBHost, "The AccountingDb host to connect to.")
+	accountdb_port        = flag.String("accountdb_port", cgrConfig.AccountDBPort, "The AccountingDb port to bind to.")
+	accountdb_name        = flag.String("accountdb_name", cgrConfig.AccountDBName, "The name/number of the AccountingDb to connect to.")
+	accountdb_user        = flag.String("accountdb_user", cgrConfig.AccountDBUser, "The AccountingDb user to sign in as.")
+	accountdb_pass        = flag.String("accountdb_passwd", cgrConfig.AccountDBPass, "The AccountingDb user's password.")
+	accountdb_sentinels   = flag.String("accountdb_sentinels", strings.Join(cgrConfig.AccountDBSentinels, ","), "Comma separated redis sentinels used to discover the AccountingDb master.")
+	accountdb_master_name = flag.String("accountdb_master_name", cgrConfig.AccountDBMasterName, "The name of the AccountingDb master monitored by the sentinels.")
+	stor_db_type          = flag.String("stordb_type", cgrConfig.StorDBType, "The type of the storDb database <mysql|postgres|mongo|sqlite>")
+	stor_db_host          = flag.String("stordb_host", cgrConfig.StorDBHost, "The storDb host to connect to.")
+	stor_db_port          = flag.String("stordb_port", cgrConfig.StorDBPort, "The storDb port to bind to.")
+	stor_db_name          = flag.String("stordb_name", cgrConfig.StorDBName, "The name/number of the storDb to connect to.")
+	stor_db_user          = flag.String("stordb_user", cgrConfig.StorDBUser, "The storDb user to sign in as.")
+	stor_db_pass          = flag.String("stordb_passwd", cgrConfig.StorDBPass, "The storDb user's password.")
+	dbdata_encoding       = flag.String("dbdata_encoding", cgrConfig.DBDataEncoding, "The encoding used to store object data in strings")
+
+	migrate = flag.String("migrate", ALL, "Comma separated databases to migrate <*all|rating_db|accounting_db|stor_db>")
+	dryRun  = flag.Bool("dry_run", false, "When true will only report the changes needed, without writing them.")
+	version = flag.Bool("version", false, "Prints the application version.")
+)
+
+func splitSentinels(sentinels string) []string {
+	if sentinels == "" {
+		return nil
+	}
+	return strings.Split(sentinels, ",")
+}
+
+func main() {
+	flag.Parse()
+	if *version {
+		fmt.Println("CGRateS " + utils.VERSION)
+		return
+	}
+	engine.Logger = new(utils.StdLogger) // report on console
+	items := []string{engine.VER_RATING_DB, engine.VER_ACCOUNTING_DB, engine.VER_STOR_DB}
+	if *migrate != ALL {
+		items = strings.Split(*migrate, ",")
+	}
+	var ratingDb engine.RatingStorage
+	var accountDb engine.AccountingStorage
+	var storDb engine.LoadStorage
+	var err error
+	for _, item := range items {
+		switch item {
+		case engine.VER_RATING_DB:
+			ratingDb, err = engine.ConfigureRatingStorage(*ratingdb_type, *ratingdb_host, *ratingdb_port, *ratingdb_name,
+				*ratingdb_user, *ratingdb_pass, *dbdata_encoding, splitSentinels(*ratingdb_sentinels), *ratingdb_master_name)
+			if err == nil {
+				defer ratingDb.Close()
+			}
+		case engine.VER_ACCOUNTING_DB:
+			accountDb, err = engine.ConfigureAccountingStorage(*accountdb_type, *accountdb_host, *accountdb_port, *accountdb_name, *accountdb_user, *accountdb_pass, *dbdata_encoding,
+				splitSentinels(*accountdb_sentinels), *accountdb_master_name)
+			if err == nil {
+				defer accountDb.Close()
+			}
+		case engine.VER_STOR_DB:
+			storDb, err = engine.ConfigureLoadStorage(*stor_db_type, *stor_db_host, *stor_db_port, *stor_db_name, *stor_db_user, *stor_db_pass, *dbdata_encoding,
+				cgrConfig.StorDBMaxOpenConns, cgrConfig.StorDBMaxIdleConns)
+			if err == nil {
+				defer storDb.Close()
+			}
+		default:
+			log.Fatalf("Unsupported database to migrate: %s", item)
+		}
+		if err != nil {
+			log.Fatalf("Could not open database connection for %s: %v", item, err)
+		}
+	}
+	var stor engine.Storage
+	if storDb != nil {
+		stor = storDb
+	}
+	migrator := engine.NewMigrator(ratingDb, accountDb, stor, *dryRun)
+	for _, item := range items {
+		if err := migrator.Migrate(item); err != nil {
+			log.Fatalf("Could not migrate %s: %v", item, err)
+		}
+	}
+	if *dryRun {
+		log.Print("Dry run finished, no data was written.")
+	} else {
+		log.Print("Migration finished.")
+	}
+}
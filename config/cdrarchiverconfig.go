@@ -0,0 +1,102 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Keeps the stored CDRs of one tenant and TOR for a number of days
+type CdrRetentionPolicy struct {
+	Tenant        string // <*any> for all tenants without own policy
+	TOR           string // <*any> for all TORs without own policy
+	RetentionDays int
+	Action        string // <*delete|*archive>
+}
+
+func (self *CdrRetentionPolicy) loadFromJsonCfg(jsnCfg *CdrRetentionJsonCfg) error {
+	if jsnCfg == nil {
+		return nil
+	}
+	if jsnCfg.Tenant != nil {
+		self.Tenant = *jsnCfg.Tenant
+	}
+	if jsnCfg.Tor != nil {
+		self.TOR = *jsnCfg.Tor
+	}
+	if jsnCfg.Retention_days != nil {
+		self.RetentionDays = *jsnCfg.Retention_days
+	}
+	if jsnCfg.Action != nil {
+		self.Action = *jsnCfg.Action
+	}
+	if self.RetentionDays <= 0 {
+		return fmt.Errorf("invalid retention_days %d for tenant %s, tor %s", self.RetentionDays, self.Tenant, self.TOR)
+	}
+	if !utils.IsSliceMember([]string{utils.META_DELETE, utils.META_ARCHIVE}, self.Action) {
+		return fmt.Errorf("unsupported retention action: %s", self.Action)
+	}
+	return nil
+}
+
+type CdrArchiverConfig struct {
+	Enabled     bool
+	RunInterval time.Duration
+	DryRun      bool
+	ArchiveDir  string
+	BatchSize   int
+	Policies    []*CdrRetentionPolicy
+}
+
+func (self *CdrArchiverConfig) loadFromJsonCfg(jsnCfg *CdrArchiverJsonCfg) error {
+	if jsnCfg == nil {
+		return nil
+	}
+	var err error
+	if jsnCfg.Enabled != nil {
+		self.Enabled = *jsnCfg.Enabled
+	}
+	if jsnCfg.Run_interval != nil {
+		if self.RunInterval, err = utils.ParseDurationWithSecs(*jsnCfg.Run_interval); err != nil {
+			return err
+		}
+	}
+	if jsnCfg.Dry_run != nil {
+		self.DryRun = *jsnCfg.Dry_run
+	}
+	if jsnCfg.Archive_dir != nil {
+		self.ArchiveDir = *jsnCfg.Archive_dir
+	}
+	if jsnCfg.Batch_size != nil {
+		self.BatchSize = *jsnCfg.Batch_size
+	}
+	if jsnCfg.Policies != nil {
+		self.Policies = make([]*CdrRetentionPolicy, len(*jsnCfg.Policies))
+		for idx, jsnPlcy := range *jsnCfg.Policies {
+			self.Policies[idx] = &CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.ANY, Action: utils.META_ARCHIVE}
+			if err := self.Policies[idx].loadFromJsonCfg(jsnPlcy); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
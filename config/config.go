@@ -60,6 +60,7 @@ func SetCgrConfig(cfg *CGRConfig) {
 func NewDefaultCGRConfig() (*CGRConfig, error) {
 	cfg := new(CGRConfig)
 	cfg.DataFolderPath = "/usr/share/cgrates/"
+	cfg.CdrArchiverConfig = new(CdrArchiverConfig)
 	cfg.SmFsConfig = new(SmFsConfig)
 	cfg.SmKamConfig = new(SmKamConfig)
 	cfg.SmOsipsConfig = new(SmOsipsConfig)
@@ -158,17 +159,21 @@ func NewCGRConfigFromFolder(cfgDir string) (*CGRConfig, error) {
 // Holds system configuration, defaults are overwritten with values from config file if found
 type CGRConfig struct {
 	RatingDBType         string
-	RatingDBHost         string // The host to connect to. Values that start with / are for UNIX domain sockets.
-	RatingDBPort         string // The port to bind to.
-	RatingDBName         string // The name of the database to connect to.
-	RatingDBUser         string // The user to sign in as.
-	RatingDBPass         string // The user's password.
+	RatingDBHost         string   // The host to connect to. Values that start with / are for UNIX domain sockets.
+	RatingDBPort         string   // The port to bind to.
+	RatingDBName         string   // The name of the database to connect to.
+	RatingDBUser         string   // The user to sign in as.
+	RatingDBPass         string   // The user's password.
+	RatingDBSentinels    []string // Redis sentinels used to discover the master, connect directly to host if empty
+	RatingDBMasterName   string   // Name of the redis master monitored by the sentinels
 	AccountDBType        string
 	AccountDBHost        string        // The host to connect to. Values that start with / are for UNIX domain sockets.
 	AccountDBPort        string        // The port to bind to.
 	AccountDBName        string        // The name of the database to connect to.
 	AccountDBUser        string        // The user to sign in as.
 	AccountDBPass        string        // The user's password.
+	AccountDBSentinels   []string      // Redis sentinels used to discover the master, connect directly to host if empty
+	AccountDBMasterName  string        // Name of the redis master monitored by the sentinels
 	StorDBType           string        // Should reflect the database type used to store logs
 	StorDBHost           string        // The host to connect to. Values that start with / are for UNIX domain sockets.
 	StorDBPort           string        // Th e port to bind to.
@@ -188,6 +193,7 @@ type CGRConfig struct {
 	RoundingDecimals     int           // Number of decimals to round end prices at
 	HttpSkipTlsVerify    bool          // If enabled Http Client will accept any TLS certificate
 	TpExportPath         string        // Path towards export folder for offline Tariff Plans
+	InvoiceTemplatesDir  string        // Folder with the HTML invoice templates selectable by name
 	MaxCallDuration      time.Duration // The maximum call duration (used by responder when querying DerivedCharging) // ToDo: export it in configuration file
 	RaterEnabled         bool          // start standalone server (no balancer)
 	RaterBalancer        string        // balancer address host:port
@@ -203,6 +209,7 @@ type CGRConfig struct {
 	CDRSCdrReplication   []*CdrReplicationCfg // Replicate raw CDRs to a number of servers
 	CDRStatsEnabled      bool                 // Enable CDR Stats service
 	CDRStatConfig        *CdrStatsConfig      // Active cdr stats configuration instances, platform level
+	CdrArchiverConfig    *CdrArchiverConfig   // CDR retention and archival
 	CdreProfiles         map[string]*CdreConfig
 	CdrcProfiles         map[string]map[string]*CdrcConfig // Number of CDRC instances running imports, format map[dirPath]map[instanceName]{Configs}
 	SmFsConfig           *SmFsConfig                       // SM-FreeSWITCH configuration
@@ -357,6 +364,11 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		return err
 	}
 
+	jsnCdrArchiverCfg, err := jsnCfg.CdrArchiverJsonCfg()
+	if err != nil {
+		return err
+	}
+
 	jsnCdreCfg, err := jsnCfg.CdreJsonCfgs()
 	if err != nil {
 		return err
@@ -417,6 +429,12 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnRatingDbCfg.Db_passwd != nil {
 			self.RatingDBPass = *jsnRatingDbCfg.Db_passwd
 		}
+		if jsnRatingDbCfg.Db_sentinels != nil {
+			self.RatingDBSentinels = *jsnRatingDbCfg.Db_sentinels
+		}
+		if jsnRatingDbCfg.Db_master_name != nil {
+			self.RatingDBMasterName = *jsnRatingDbCfg.Db_master_name
+		}
 	}
 
 	if jsnAccountingDbCfg != nil {
@@ -438,6 +456,12 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnAccountingDbCfg.Db_passwd != nil {
 			self.AccountDBPass = *jsnAccountingDbCfg.Db_passwd
 		}
+		if jsnAccountingDbCfg.Db_sentinels != nil {
+			self.AccountDBSentinels = *jsnAccountingDbCfg.Db_sentinels
+		}
+		if jsnAccountingDbCfg.Db_master_name != nil {
+			self.AccountDBMasterName = *jsnAccountingDbCfg.Db_master_name
+		}
 	}
 
 	if jsnStorDbCfg != nil {
@@ -492,6 +516,9 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		if jsnGeneralCfg.Tpexport_dir != nil {
 			self.TpExportPath = *jsnGeneralCfg.Tpexport_dir
 		}
+		if jsnGeneralCfg.Invoice_templates_dir != nil {
+			self.InvoiceTemplatesDir = *jsnGeneralCfg.Invoice_templates_dir
+		}
 	}
 
 	if jsnListenCfg != nil {
@@ -583,6 +610,15 @@ func (self *CGRConfig) loadFromJsonCfg(jsnCfg *CgrJsonCfg) error {
 		}
 	}
 
+	if jsnCdrArchiverCfg != nil {
+		if self.CdrArchiverConfig == nil {
+			self.CdrArchiverConfig = new(CdrArchiverConfig)
+		}
+		if err := self.CdrArchiverConfig.loadFromJsonCfg(jsnCdrArchiverCfg); err != nil {
+			return err
+		}
+	}
+
 	if jsnCdreCfg != nil {
 		if self.CdreProfiles == nil {
 			self.CdreProfiles = make(map[string]*CdreConfig)
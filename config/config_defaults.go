@@ -32,6 +32,7 @@ const CGRATES_CFG_JSON = `
 	"rounding_decimals": 10,				// system level precision for floats
 	"dbdata_encoding": "msgpack",			// encoding used to store object data in strings: <msgpack|json>
 	"tpexport_dir": "/var/log/cgrates/tpe",	// path towards export folder for offline Tariff Plans
+	"invoice_templates_dir": "",			// folder with the HTML invoice templates selectable by name, empty for the default template only
 	"default_reqtype": "*rated",			// default request type to consider when missing from requests: <""|*prepaid|*postpaid|*pseudoprepaid|*rated>
 	"default_category": "call",				// default Type of Record to consider when missing from requests
 	"default_tenant": "cgrates.org",		// default Tenant to consider when missing from requests
@@ -47,30 +48,34 @@ const CGRATES_CFG_JSON = `
 
 
 "rating_db": {
-	"db_type": "redis",						// rating subsystem database type: <redis>
+	"db_type": "redis",						// rating subsystem database type: <redis|mongo|*internal>
 	"db_host": "127.0.0.1",					// rating subsystem database host address
 	"db_port": 6379, 						// rating subsystem port to reach the database
-	"db_name": "10", 						// rating subsystem database name to connect to
+	"db_name": "10", 						// rating subsystem database name to connect to, data folder for *internal
 	"db_user": "", 							// rating subsystem username to use when connecting to database
 	"db_passwd": "", 						// rating subsystem password to use when connecting to database
+	"db_sentinels": [],						// redis sentinel addresses used to discover the master, empty to connect directly to db_host
+	"db_master_name": "",					// name of the redis master monitored by the sentinels
 },
 
 
 "accounting_db": {
-	"db_type": "redis",						// accounting subsystem database: <redis>
+	"db_type": "redis",						// accounting subsystem database: <redis|mongo|*internal>
 	"db_host": "127.0.0.1",					// accounting subsystem database host address
 	"db_port": 6379, 						// accounting subsystem port to reach the database
-	"db_name": "11", 						// accounting subsystem database name to connect to
+	"db_name": "11", 						// accounting subsystem database name to connect to, data folder for *internal
 	"db_user": "", 							// accounting subsystem username to use when connecting to database
 	"db_passwd": "", 						// accounting subsystem password to use when connecting to database
+	"db_sentinels": [],						// redis sentinel addresses used to discover the master, empty to connect directly to db_host
+	"db_master_name": "",					// name of the redis master monitored by the sentinels
 },
 
 
 "stor_db": {
-	"db_type": "mysql",						// stor database type to use: <mysql|postgres>
+	"db_type": "mysql",						// stor database type to use: <mysql|postgres|mongo|sqlite>
 	"db_host": "127.0.0.1",					// the host to connect to
 	"db_port": 3306, 						// the port to reach the stordb
-	"db_name": "cgrates", 					// stor database name
+	"db_name": "cgrates", 					// stor database name, path to the database file for sqlite
 	"db_user": "cgrates", 					// username to use when connecting to stordb
 	"db_passwd": "CGRateS.org", 			// password to use when connecting to stordb
 	"max_open_conns": 0,					// maximum database connections opened
@@ -130,6 +135,16 @@ const CGRATES_CFG_JSON = `
 },
 
 
+"cdr_archiver": {
+	"enabled": false,								// starts the CDR retention job: <true|false>
+	"run_interval": "24h",							// interval between consecutive runs of the retention policies
+	"dry_run": false,								// only report the CDRs reached by policies, without touching them
+	"archive_dir": "/var/log/cgrates/cdr_archive",	// location on disk where to write the compressed archives
+	"batch_size": 1000,								// number of CDRs processed at once
+	"policies": [],									// retention policies, most specific applies, eg: {"tenant": "*any", "tor": "*any", "retention_days": 365, "action": "<*delete|*archive>"}
+},
+
+
 "cdre": {
 	"*default": {
 		"cdr_format": "csv",							// exported CDRs format <csv>
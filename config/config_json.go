@@ -37,6 +37,7 @@ const (
 	CDRS_JSN         = "cdrs"
 	MEDIATOR_JSN     = "mediator"
 	CDRSTATS_JSN     = "cdr_stats"
+	CDRARCHIVER_JSN  = "cdr_archiver"
 	CDRE_JSN         = "cdre"
 	CDRC_JSN         = "cdrc"
 	SMFS_JSN         = "sm_freeswitch"
@@ -170,6 +171,18 @@ func (self CgrJsonCfg) CdrStatsJsonCfg() (*CdrStatsJsonCfg, error) {
 	return cfg, nil
 }
 
+func (self CgrJsonCfg) CdrArchiverJsonCfg() (*CdrArchiverJsonCfg, error) {
+	rawCfg, hasKey := self[CDRARCHIVER_JSN]
+	if !hasKey {
+		return nil, nil
+	}
+	cfg := new(CdrArchiverJsonCfg)
+	if err := json.Unmarshal(*rawCfg, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func (self CgrJsonCfg) CdreJsonCfgs() (map[string]*CdreJsonCfg, error) {
 	rawCfg, hasKey := self[CDRE_JSN]
 	if !hasKey {
@@ -37,14 +37,15 @@ func TestDfNewdfCgrJsonCfgFromReader(t *testing.T) {
 
 func TestDfGeneralJsonCfg(t *testing.T) {
 	eCfg := &GeneralJsonCfg{
-		Http_skip_tls_veify: utils.BoolPointer(false),
-		Rounding_decimals:   utils.IntPointer(10),
-		Dbdata_encoding:     utils.StringPointer("msgpack"),
-		Tpexport_dir:        utils.StringPointer("/var/log/cgrates/tpe"),
-		Default_reqtype:     utils.StringPointer(utils.META_RATED),
-		Default_category:    utils.StringPointer("call"),
-		Default_tenant:      utils.StringPointer("cgrates.org"),
-		Default_subject:     utils.StringPointer("cgrates")}
+		Http_skip_tls_veify:   utils.BoolPointer(false),
+		Rounding_decimals:     utils.IntPointer(10),
+		Dbdata_encoding:       utils.StringPointer("msgpack"),
+		Tpexport_dir:          utils.StringPointer("/var/log/cgrates/tpe"),
+		Invoice_templates_dir: utils.StringPointer(""),
+		Default_reqtype:       utils.StringPointer(utils.META_RATED),
+		Default_category:      utils.StringPointer("call"),
+		Default_tenant:        utils.StringPointer("cgrates.org"),
+		Default_subject:       utils.StringPointer("cgrates")}
 	if gCfg, err := dfCgrJsonCfg.GeneralJsonCfg(); err != nil {
 		t.Error(err)
 	} else if !reflect.DeepEqual(eCfg, gCfg) {
@@ -66,12 +67,14 @@ func TestDfListenJsonCfg(t *testing.T) {
 
 func TestDfDbJsonCfg(t *testing.T) {
 	eCfg := &DbJsonCfg{
-		Db_type:   utils.StringPointer("redis"),
-		Db_host:   utils.StringPointer("127.0.0.1"),
-		Db_port:   utils.IntPointer(6379),
-		Db_name:   utils.StringPointer("10"),
-		Db_user:   utils.StringPointer(""),
-		Db_passwd: utils.StringPointer(""),
+		Db_type:        utils.StringPointer("redis"),
+		Db_host:        utils.StringPointer("127.0.0.1"),
+		Db_port:        utils.IntPointer(6379),
+		Db_name:        utils.StringPointer("10"),
+		Db_user:        utils.StringPointer(""),
+		Db_passwd:      utils.StringPointer(""),
+		Db_sentinels:   utils.StringSlicePointer([]string{}),
+		Db_master_name: utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.DbJsonCfg(RATINGDB_JSN); err != nil {
 		t.Error(err)
@@ -79,12 +82,14 @@ func TestDfDbJsonCfg(t *testing.T) {
 		t.Error("Received: ", cfg)
 	}
 	eCfg = &DbJsonCfg{
-		Db_type:   utils.StringPointer("redis"),
-		Db_host:   utils.StringPointer("127.0.0.1"),
-		Db_port:   utils.IntPointer(6379),
-		Db_name:   utils.StringPointer("11"),
-		Db_user:   utils.StringPointer(""),
-		Db_passwd: utils.StringPointer(""),
+		Db_type:        utils.StringPointer("redis"),
+		Db_host:        utils.StringPointer("127.0.0.1"),
+		Db_port:        utils.IntPointer(6379),
+		Db_name:        utils.StringPointer("11"),
+		Db_user:        utils.StringPointer(""),
+		Db_passwd:      utils.StringPointer(""),
+		Db_sentinels:   utils.StringSlicePointer([]string{}),
+		Db_master_name: utils.StringPointer(""),
 	}
 	if cfg, err := dfCgrJsonCfg.DbJsonCfg(ACCOUNTINGDB_JSN); err != nil {
 		t.Error(err)
@@ -182,6 +187,22 @@ func TestDfCdrStatsJsonCfg(t *testing.T) {
 	}
 }
 
+func TestDfCdrArchiverJsonCfg(t *testing.T) {
+	eCfg := &CdrArchiverJsonCfg{
+		Enabled:      utils.BoolPointer(false),
+		Run_interval: utils.StringPointer("24h"),
+		Dry_run:      utils.BoolPointer(false),
+		Archive_dir:  utils.StringPointer("/var/log/cgrates/cdr_archive"),
+		Batch_size:   utils.IntPointer(1000),
+		Policies:     &[]*CdrRetentionJsonCfg{},
+	}
+	if cfg, err := dfCgrJsonCfg.CdrArchiverJsonCfg(); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cfg) {
+		t.Error("Received: ", cfg)
+	}
+}
+
 func TestDfCdreJsonCfgs(t *testing.T) {
 	eFields := []*CdrFieldJsonCfg{}
 	eContentFlds := []*CdrFieldJsonCfg{
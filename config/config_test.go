@@ -21,6 +21,9 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
 )
 
 var cfg *CGRConfig
@@ -58,3 +61,29 @@ func TestLoadCgrCfgWithDefaults(t *testing.T) {
 		t.Errorf("Expected: %+v, received: %+v", eCgrCfg.SmFsConfig, cgrCfg.SmFsConfig)
 	}
 }
+
+func TestLoadCdrArchiverCfg(t *testing.T) {
+	JSN_CFG := `
+{
+"cdr_archiver": {
+	"enabled": true,
+	"policies": [
+		{"tenant": "cgrates.org", "retention_days": 30, "action": "*delete"},
+		{"retention_days": 365},
+	],
+},
+}`
+	eCfg := &CdrArchiverConfig{Enabled: true, RunInterval: 24 * time.Hour, ArchiveDir: "/var/log/cgrates/cdr_archive", BatchSize: 1000,
+		Policies: []*CdrRetentionPolicy{
+			&CdrRetentionPolicy{Tenant: "cgrates.org", TOR: utils.ANY, RetentionDays: 30, Action: utils.META_DELETE},
+			&CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.ANY, RetentionDays: 365, Action: utils.META_ARCHIVE},
+		}}
+	if cgrCfg, err := NewCGRConfigFromJsonStringWithDefaults(JSN_CFG); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(eCfg, cgrCfg.CdrArchiverConfig) {
+		t.Errorf("Expected: %+v, received: %+v", eCfg, cgrCfg.CdrArchiverConfig)
+	}
+	if _, err := NewCGRConfigFromJsonStringWithDefaults(`{"cdr_archiver": {"policies": [{"action": "*move"}]}}`); err == nil {
+		t.Error("Expecting error on policy without retention")
+	}
+}
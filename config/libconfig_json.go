@@ -20,14 +20,15 @@ package config
 
 // General config section
 type GeneralJsonCfg struct {
-	Http_skip_tls_veify *bool
-	Rounding_decimals   *int
-	Dbdata_encoding     *string
-	Tpexport_dir        *string
-	Default_reqtype     *string
-	Default_category    *string
-	Default_tenant      *string
-	Default_subject     *string
+	Http_skip_tls_veify   *bool
+	Rounding_decimals     *int
+	Dbdata_encoding       *string
+	Tpexport_dir          *string
+	Invoice_templates_dir *string
+	Default_reqtype       *string
+	Default_category      *string
+	Default_tenant        *string
+	Default_subject       *string
 }
 
 // Listen config section
@@ -45,6 +46,8 @@ type DbJsonCfg struct {
 	Db_name        *string
 	Db_user        *string
 	Db_passwd      *string
+	Db_sentinels   *[]string // Used only in case of redis
+	Db_master_name *string
 	Max_open_conns *int // Used only in case of storDb
 	Max_idle_conns *int
 }
@@ -110,6 +113,24 @@ type CdrStatsJsonCfg struct {
 	Cost_interval        *[]float64
 }
 
+// Retention policy applied on stored CDRs
+type CdrRetentionJsonCfg struct {
+	Tenant         *string
+	Tor            *string
+	Retention_days *int
+	Action         *string
+}
+
+// CdrArchiver config section
+type CdrArchiverJsonCfg struct {
+	Enabled      *bool
+	Run_interval *string
+	Dry_run      *bool
+	Archive_dir  *string
+	Batch_size   *int
+	Policies     *[]*CdrRetentionJsonCfg
+}
+
 // One cdr field config, used in cdre and cdrc
 type CdrFieldJsonCfg struct {
 	Tag          *string
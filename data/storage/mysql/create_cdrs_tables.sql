@@ -65,6 +65,11 @@ CREATE TABLE cost_details (
   subject varchar(128) NOT NULL,
   destination varchar(128) NOT NULL,
   cost DECIMAL(20,4) NOT NULL,
+  currency varchar(8) NOT NULL,
+  debited_cost DECIMAL(20,4) NOT NULL,
+  debited_currency varchar(8) NOT NULL,
+  tax DECIMAL(20,4) NOT NULL,
+  gross_cost DECIMAL(20,4) NOT NULL,
   timespans text,
   cost_source varchar(64) NOT NULL,
   created_at TIMESTAMP,
@@ -96,6 +101,12 @@ CREATE TABLE `rated_cdrs` (
   supplier varchar(128) NOT NULL,
   disconnect_cause varchar(64) NOT NULL,
   cost DECIMAL(20,4) DEFAULT NULL,
+  currency varchar(8) NOT NULL,
+  debited_cost DECIMAL(20,4) DEFAULT NULL,
+  debited_currency varchar(8) NOT NULL,
+  tax DECIMAL(20,4) DEFAULT NULL,
+  gross_cost DECIMAL(20,4) DEFAULT NULL,
+  resolved_destination varchar(128) NOT NULL,
   extra_info text,
   created_at TIMESTAMP,
   updated_at TIMESTAMP,
@@ -103,4 +114,55 @@ CREATE TABLE `rated_cdrs` (
   PRIMARY KEY (`id`),
   UNIQUE KEY `costid` (`cgrid`,`runid`),
   KEY deleted_at_idx (deleted_at)
-);
\ No newline at end of file
+);
+
+--
+-- Table structure for table `versions`
+--
+DROP TABLE IF EXISTS versions;
+CREATE TABLE versions (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  item varchar(64) NOT NULL,
+  version int(11) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `item` (`item`)
+);
+
+--
+-- Table structure for table `ledger`
+--
+DROP TABLE IF EXISTS ledger;
+CREATE TABLE ledger (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  account_id varchar(192) NOT NULL,
+  balance_type varchar(32) NOT NULL,
+  balance_uuid varchar(64) NOT NULL,
+  balance_id varchar(64) NOT NULL,
+  delta DECIMAL(20,4) NOT NULL,
+  value DECIMAL(20,4) NOT NULL,
+  cgrid varchar(40) NOT NULL,
+  action_id varchar(64) NOT NULL,
+  action_type varchar(24) NOT NULL,
+  created_at TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY account_time (account_id, created_at)
+);
+
+--
+-- Table structure for table `invoices`
+--
+DROP TABLE IF EXISTS invoices;
+CREATE TABLE invoices (
+  id int(11) NOT NULL AUTO_INCREMENT,
+  account_id varchar(192) NOT NULL,
+  period_start TIMESTAMP NULL,
+  period_end TIMESTAMP NULL,
+  net DECIMAL(20,4) NOT NULL,
+  tax DECIMAL(20,4) NOT NULL,
+  gross DECIMAL(20,4) NOT NULL,
+  content text,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL,
+  PRIMARY KEY (`id`),
+  KEY account_period (account_id, period_start)
+);
@@ -71,6 +71,12 @@ CREATE TABLE `tp_destination_rates` (
   `rounding_decimals` tinyint(4) NOT NULL,
   `max_cost` decimal(7,4) NOT NULL,
   `max_cost_strategy` varchar(16) NOT NULL,
+  `tier_period` varchar(16) NOT NULL,
+  `currency` varchar(8) NOT NULL,
+  `min_duration` varchar(16) NOT NULL,
+  `min_cost` decimal(7,4) NOT NULL,
+  `free_under` varchar(16) NOT NULL,
+  `connect_fee_on_answer` BOOLEAN NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `tpid` (`tpid`),
@@ -114,6 +120,7 @@ CREATE TABLE `tp_rating_profiles` (
   `rating_plan_tag` varchar(64) NOT NULL,
   `fallback_subjects` varchar(64),
   `cdr_stat_queue_ids` varchar(64),
+  `timezone` varchar(64) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
    KEY `tpid` (`tpid`),
@@ -139,6 +146,79 @@ CREATE TABLE `tp_shared_groups` (
   UNIQUE KEY `unique_shared_group` (`tpid`,`tag`,`account`,`strategy`,`rating_subject`)
 );
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS `tp_exchange_rates`;
+CREATE TABLE `tp_exchange_rates` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tag` varchar(64) NOT NULL,
+  `from_currency` varchar(8) NOT NULL,
+  `to_currency` varchar(8) NOT NULL,
+  `activation_time` varchar(24) NOT NULL,
+  `rate` DECIMAL(20,8) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_exchange_rate` (`tpid`,`tag`,`from_currency`,`to_currency`,`activation_time`)
+);
+
+--
+-- Table structure for table `tp_tax_rules`
+--
+
+DROP TABLE IF EXISTS `tp_tax_rules`;
+CREATE TABLE `tp_tax_rules` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tag` varchar(64) NOT NULL,
+  `tenant` varchar(64) NOT NULL,
+  `category` varchar(32) NOT NULL,
+  `destination_id` varchar(64) NOT NULL,
+  `activation_time` varchar(24) NOT NULL,
+  `rate` DECIMAL(20,8) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_tax_rule` (`tpid`,`tag`,`tenant`,`category`,`destination_id`,`activation_time`)
+);
+
+--
+-- Table structure for table `tp_ported_numbers`
+--
+
+DROP TABLE IF EXISTS `tp_ported_numbers`;
+CREATE TABLE `tp_ported_numbers` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tag` varchar(64) NOT NULL,
+  `number` varchar(64) NOT NULL,
+  `routing_prefix` varchar(32) NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_ported_number` (`tpid`,`tag`,`number`)
+);
+
+--
+-- Table structure for table `tp_calendars`
+--
+
+DROP TABLE IF EXISTS `tp_calendars`;
+CREATE TABLE `tp_calendars` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `tpid` varchar(64) NOT NULL,
+  `tag` varchar(64) NOT NULL,
+  `date` varchar(10) NOT NULL,
+  `yearly` BOOLEAN NOT NULL,
+  `created_at` TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `tpid` (`tpid`),
+  UNIQUE KEY `unique_calendar_date` (`tpid`,`tag`,`date`)
+);
+
 --
 -- Table structure for table `tp_actions`
 --
@@ -180,6 +260,7 @@ CREATE TABLE `tp_action_plans` (
   `actions_tag` varchar(64) NOT NULL,
   `timing_tag` varchar(64) NOT NULL,
   `weight` DECIMAL(8,2) NOT NULL,
+  `timezone` varchar(64) NOT NULL,
   `created_at` TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `tpid` (`tpid`),
@@ -62,6 +62,11 @@ CREATE TABLE cost_details (
   subject VARCHAR(128) NOT NULL,
   destination VARCHAR(128) NOT NULL,
   cost NUMERIC(20,4) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  debited_cost NUMERIC(20,4) NOT NULL,
+  debited_currency VARCHAR(8) NOT NULL,
+  tax NUMERIC(20,4) NOT NULL,
+  gross_cost NUMERIC(20,4) NOT NULL,
   timespans text,
   cost_source VARCHAR(64) NOT NULL,
   created_at TIMESTAMP,
@@ -92,10 +97,64 @@ CREATE TABLE rated_cdrs (
   supplier VARCHAR(128) NOT NULL,
   disconnect_cause VARCHAR(64) NOT NULL,
   cost NUMERIC(20,4) DEFAULT NULL,
+  currency VARCHAR(8) NOT NULL,
+  debited_cost NUMERIC(20,4) DEFAULT NULL,
+  debited_currency VARCHAR(8) NOT NULL,
+  tax NUMERIC(20,4) DEFAULT NULL,
+  gross_cost NUMERIC(20,4) DEFAULT NULL,
+  resolved_destination VARCHAR(128) NOT NULL,
   extra_info text,
   created_at TIMESTAMP,
   updated_at TIMESTAMP,
   deleted_at TIMESTAMP,
   UNIQUE (cgrid, runid)
 );
-CREATE INDEX deleted_at_rc_idx ON rated_cdrs (deleted_at);
\ No newline at end of file
+CREATE INDEX deleted_at_rc_idx ON rated_cdrs (deleted_at);
+
+--
+-- Table structure for table `versions`
+--
+DROP TABLE IF EXISTS versions;
+CREATE TABLE versions (
+  id SERIAL PRIMARY KEY,
+  item VARCHAR(64) NOT NULL,
+  version INTEGER NOT NULL,
+  UNIQUE (item)
+);
+
+--
+-- Table structure for table `ledger`
+--
+DROP TABLE IF EXISTS ledger;
+CREATE TABLE ledger (
+  id SERIAL PRIMARY KEY,
+  account_id VARCHAR(192) NOT NULL,
+  balance_type VARCHAR(32) NOT NULL,
+  balance_uuid VARCHAR(64) NOT NULL,
+  balance_id VARCHAR(64) NOT NULL,
+  delta NUMERIC(20,4) NOT NULL,
+  value NUMERIC(20,4) NOT NULL,
+  cgrid VARCHAR(40) NOT NULL,
+  action_id VARCHAR(64) NOT NULL,
+  action_type VARCHAR(24) NOT NULL,
+  created_at TIMESTAMP
+);
+CREATE INDEX account_time_idx ON ledger (account_id, created_at);
+
+--
+-- Table structure for table `invoices`
+--
+DROP TABLE IF EXISTS invoices;
+CREATE TABLE invoices (
+  id SERIAL PRIMARY KEY,
+  account_id VARCHAR(192) NOT NULL,
+  period_start TIMESTAMP,
+  period_end TIMESTAMP,
+  net NUMERIC(20,4) NOT NULL,
+  tax NUMERIC(20,4) NOT NULL,
+  gross NUMERIC(20,4) NOT NULL,
+  content TEXT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+CREATE INDEX account_period_idx ON invoices (account_id, period_start);
@@ -68,6 +68,12 @@ CREATE TABLE tp_destination_rates (
   rounding_decimals SMALLINT NOT NULL,
   max_cost NUMERIC(7,4) NOT NULL,
   max_cost_strategy VARCHAR(16) NOT NULL,
+  tier_period VARCHAR(16) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  min_duration VARCHAR(16) NOT NULL,
+  min_cost NUMERIC(7,4) NOT NULL,
+  free_under VARCHAR(16) NOT NULL,
+  connect_fee_on_answer BOOLEAN NOT NULL,
   created_at TIMESTAMP,
   UNIQUE (tpid, tag , destinations_tag)
 );
@@ -110,6 +116,7 @@ CREATE TABLE tp_rating_profiles (
   rating_plan_tag VARCHAR(64) NOT NULL,
   fallback_subjects VARCHAR(64),
   cdr_stat_queue_ids varchar(64),
+  timezone VARCHAR(64) NOT NULL,
   created_at TIMESTAMP,
   UNIQUE (tpid, loadid, tenant, category, direction, subject, activation_time)
 );
@@ -134,6 +141,79 @@ CREATE TABLE tp_shared_groups (
 CREATE INDEX tpsharedgroups_tpid_idx ON tp_shared_groups (tpid);
 CREATE INDEX tpsharedgroups_idx ON tp_shared_groups (tpid,tag);
 
+--
+-- Table structure for table `tp_exchange_rates`
+--
+
+DROP TABLE IF EXISTS tp_exchange_rates;
+CREATE TABLE tp_exchange_rates (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  from_currency VARCHAR(8) NOT NULL,
+  to_currency VARCHAR(8) NOT NULL,
+  activation_time VARCHAR(24) NOT NULL,
+  rate NUMERIC(20,8) NOT NULL,
+  created_at TIMESTAMP,
+  UNIQUE (tpid, tag, from_currency, to_currency, activation_time)
+);
+CREATE INDEX tpexchangerates_tpid_idx ON tp_exchange_rates (tpid);
+CREATE INDEX tpexchangerates_idx ON tp_exchange_rates (tpid,tag);
+
+--
+-- Table structure for table `tp_tax_rules`
+--
+
+DROP TABLE IF EXISTS tp_tax_rules;
+CREATE TABLE tp_tax_rules (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  tenant VARCHAR(64) NOT NULL,
+  category VARCHAR(32) NOT NULL,
+  destination_id VARCHAR(64) NOT NULL,
+  activation_time VARCHAR(24) NOT NULL,
+  rate NUMERIC(20,8) NOT NULL,
+  created_at TIMESTAMP,
+  UNIQUE (tpid, tag, tenant, category, destination_id, activation_time)
+);
+CREATE INDEX tptaxrules_tpid_idx ON tp_tax_rules (tpid);
+CREATE INDEX tptaxrules_idx ON tp_tax_rules (tpid,tag);
+
+--
+-- Table structure for table `tp_ported_numbers`
+--
+
+DROP TABLE IF EXISTS tp_ported_numbers;
+CREATE TABLE tp_ported_numbers (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  number VARCHAR(64) NOT NULL,
+  routing_prefix VARCHAR(32) NOT NULL,
+  created_at TIMESTAMP,
+  UNIQUE (tpid, tag, number)
+);
+CREATE INDEX tpportednumbers_tpid_idx ON tp_ported_numbers (tpid);
+CREATE INDEX tpportednumbers_idx ON tp_ported_numbers (tpid,tag);
+
+--
+-- Table structure for table `tp_calendars`
+--
+
+DROP TABLE IF EXISTS tp_calendars;
+CREATE TABLE tp_calendars (
+  id SERIAL PRIMARY KEY,
+  tpid VARCHAR(64) NOT NULL,
+  tag VARCHAR(64) NOT NULL,
+  date VARCHAR(10) NOT NULL,
+  yearly BOOLEAN NOT NULL,
+  created_at TIMESTAMP,
+  UNIQUE (tpid, tag, date)
+);
+CREATE INDEX tpcalendars_tpid_idx ON tp_calendars (tpid);
+CREATE INDEX tpcalendars_idx ON tp_calendars (tpid,tag);
+
 --
 -- Table structure for table `tp_actions`
 --
@@ -175,6 +255,7 @@ CREATE TABLE tp_action_plans (
   actions_tag VARCHAR(64) NOT NULL,
   timing_tag VARCHAR(64) NOT NULL,
   weight NUMERIC(8,2) NOT NULL,
+  timezone VARCHAR(64) NOT NULL,
   created_at TIMESTAMP,
   UNIQUE  (tpid, tag, actions_tag)
 );
@@ -0,0 +1,160 @@
+
+--
+-- Table structure for table `cdrs_primary`
+--
+
+DROP TABLE IF EXISTS cdrs_primary;
+CREATE TABLE cdrs_primary (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  cgrid CHAR(40) NOT NULL,
+  tor  VARCHAR(16) NOT NULL, 
+  accid VARCHAR(64) NOT NULL,
+  cdrhost VARCHAR(64) NOT NULL,
+  cdrsource VARCHAR(64) NOT NULL,
+  reqtype VARCHAR(24) NOT NULL,
+  direction VARCHAR(8) NOT NULL,
+  tenant VARCHAR(64) NOT NULL,
+  category VARCHAR(32) NOT NULL,
+  account VARCHAR(128) NOT NULL,
+  subject VARCHAR(128) NOT NULL,
+  destination VARCHAR(128) NOT NULL,
+  setup_time TIMESTAMP NOT NULL,
+  answer_time TIMESTAMP NOT NULL,
+  usage NUMERIC(30,9) NOT NULL,
+  supplier VARCHAR(128) NOT NULL,
+  disconnect_cause VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  UNIQUE (cgrid)
+);
+CREATE INDEX answer_time_idx ON cdrs_primary (answer_time);
+CREATE INDEX deleted_at_cp_idx ON cdrs_primary (deleted_at);
+
+--
+-- Table structure for table `cdrs_extra`
+--
+
+DROP TABLE IF EXISTS cdrs_extra;
+CREATE TABLE cdrs_extra (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  cgrid CHAR(40) NOT NULL,
+  extra_fields text NOT NULL,
+  created_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  UNIQUE (cgrid)
+);
+CREATE INDEX deleted_at_ce_idx ON cdrs_extra (deleted_at);
+
+--
+-- Table structure for table `cost_details`
+--
+
+DROP TABLE IF EXISTS cost_details;
+CREATE TABLE cost_details (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  cgrid CHAR(40) NOT NULL,
+  runid  VARCHAR(64) NOT NULL,
+  tor  VARCHAR(16) NOT NULL,
+  direction VARCHAR(8) NOT NULL,
+  tenant VARCHAR(128) NOT NULL,
+  category VARCHAR(32) NOT NULL,
+  account VARCHAR(128) NOT NULL,
+  subject VARCHAR(128) NOT NULL,
+  destination VARCHAR(128) NOT NULL,
+  cost NUMERIC(20,4) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  debited_cost NUMERIC(20,4) NOT NULL,
+  debited_currency VARCHAR(8) NOT NULL,
+  tax NUMERIC(20,4) NOT NULL,
+  gross_cost NUMERIC(20,4) NOT NULL,
+  timespans text,
+  cost_source VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  UNIQUE (cgrid, runid)
+);
+CREATE INDEX deleted_at_cd_idx ON cost_details (deleted_at);
+
+--
+-- Table structure for table `rated_cdrs`
+--
+DROP TABLE IF EXISTS rated_cdrs;
+CREATE TABLE rated_cdrs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  cgrid CHAR(40) NOT NULL,
+  runid  VARCHAR(64) NOT NULL,
+  reqtype VARCHAR(24) NOT NULL,
+  direction VARCHAR(8) NOT NULL,
+  tenant VARCHAR(64) NOT NULL,
+  category VARCHAR(32) NOT NULL,
+  account VARCHAR(128) NOT NULL,
+  subject VARCHAR(128) NOT NULL,
+  destination VARCHAR(128) NOT NULL,
+  setup_time TIMESTAMP NOT NULL,
+  answer_time TIMESTAMP NOT NULL,
+  usage NUMERIC(30,9) NOT NULL,
+  supplier VARCHAR(128) NOT NULL,
+  disconnect_cause VARCHAR(64) NOT NULL,
+  cost NUMERIC(20,4) DEFAULT NULL,
+  currency VARCHAR(8) NOT NULL,
+  debited_cost NUMERIC(20,4) DEFAULT NULL,
+  debited_currency VARCHAR(8) NOT NULL,
+  tax NUMERIC(20,4) DEFAULT NULL,
+  gross_cost NUMERIC(20,4) DEFAULT NULL,
+  resolved_destination VARCHAR(128) NOT NULL,
+  extra_info text,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  UNIQUE (cgrid, runid)
+);
+CREATE INDEX deleted_at_rc_idx ON rated_cdrs (deleted_at);
+
+--
+-- Table structure for table `versions`
+--
+DROP TABLE IF EXISTS versions;
+CREATE TABLE versions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  item VARCHAR(64) NOT NULL,
+  version INTEGER NOT NULL,
+  UNIQUE (item)
+);
+
+--
+-- Table structure for table `ledger`
+--
+DROP TABLE IF EXISTS ledger;
+CREATE TABLE ledger (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  account_id VARCHAR(192) NOT NULL,
+  balance_type VARCHAR(32) NOT NULL,
+  balance_uuid VARCHAR(64) NOT NULL,
+  balance_id VARCHAR(64) NOT NULL,
+  delta NUMERIC(20,4) NOT NULL,
+  value NUMERIC(20,4) NOT NULL,
+  cgrid VARCHAR(40) NOT NULL,
+  action_id VARCHAR(64) NOT NULL,
+  action_type VARCHAR(24) NOT NULL,
+  created_at TIMESTAMP
+);
+CREATE INDEX account_time_idx ON ledger (account_id, created_at);
+
+--
+-- Table structure for table `invoices`
+--
+DROP TABLE IF EXISTS invoices;
+CREATE TABLE invoices (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  account_id VARCHAR(192) NOT NULL,
+  period_start TIMESTAMP,
+  period_end TIMESTAMP,
+  net NUMERIC(20,4) NOT NULL,
+  tax NUMERIC(20,4) NOT NULL,
+  gross NUMERIC(20,4) NOT NULL,
+  content TEXT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+CREATE INDEX account_period_idx ON invoices (account_id, period_start);
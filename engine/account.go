@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/cgrates/cgrates/cache2go"
 	"github.com/cgrates/cgrates/utils"
 
 	"strings"
@@ -45,12 +44,19 @@ Structure containing information about user's credit (minutes, cents, sms...).'
 This can represent a user or a shared group.
 */
 type Account struct {
-	Id             string
-	BalanceMap     map[string]BalanceChain
-	UnitCounters   []*UnitsCounter
-	ActionTriggers ActionTriggerPriotityList
-	AllowNegative  bool
-	Disabled       bool
+	Id               string
+	BalanceMap       map[string]BalanceChain
+	UnitCounters     []*UnitsCounter
+	ActionTriggers   ActionTriggerPriotityList
+	AllowNegative    bool
+	CreditLimit      float64 // postpaid accounts: how far below zero the default money balance can go, not used with AllowNegative
+	BillingCycle     *BillingCycle
+	Disabled         bool
+	PeriodUsages     map[string]*PeriodUsage // usage cumulated for the volume tiered rates
+	Reservations     map[string]*Reservation // balances put aside for the sessions in progress, by session id
+	ActivationDate   time.Time               // start of the service, the prorated recurring charges are computed from it
+	CancellationDate time.Time               // end of the service, zero while active
+	ledger           []*LedgerEntry          // movements waiting for the account to be saved before reaching the ledger
 }
 
 // User's available minutes for the specified destination
@@ -107,6 +113,7 @@ func (ub *Account) debitBalanceAction(a *Action, reset bool) error {
 	found := false
 	id := a.BalanceType + a.Direction
 	ub.CleanExpiredBalances()
+	ledger := newLedgerSnapshot(ub)
 	for _, b := range ub.BalanceMap[id] {
 		if b.IsExpired() {
 			continue // just to be safe (cleaned expired balances above)
@@ -125,24 +132,30 @@ func (ub *Account) debitBalanceAction(a *Action, reset bool) error {
 		a.Balance.dirty = true // Mark the balance as dirty since we have modified and it should be checked by action triggers
 		ub.BalanceMap[id] = append(ub.BalanceMap[id], a.Balance)
 	}
-	if a.Balance.SharedGroup != "" {
-		// add shared group member
-		sg, err := accountingStorage.GetSharedGroup(a.Balance.SharedGroup, false)
-		if err != nil || sg == nil {
-			//than problem
-			Logger.Warning(fmt.Sprintf("Could not get shared group: %v", a.Balance.SharedGroup))
-		} else {
-			if !utils.IsSliceMember(sg.MemberIds, ub.Id) {
-				// add member and save
-				sg.MemberIds = append(sg.MemberIds, ub.Id)
-				accountingStorage.SetSharedGroup(sg)
-			}
-		}
-	}
+	ledger.write("", a)
+	ub.joinSharedGroup(a.Balance.SharedGroup)
 	ub.executeActionTriggers(nil)
 	return nil //ub.BalanceMap[id].GetTotalValue()
 }
 
+// Adds the account to the members of the shared group its balance was topped up into
+func (ub *Account) joinSharedGroup(sharedGroup string) {
+	if sharedGroup == "" {
+		return
+	}
+	sg, err := accountingStorage.GetSharedGroup(sharedGroup, false)
+	if err != nil || sg == nil {
+		//than problem
+		Logger.Warning(fmt.Sprintf("Could not get shared group: %v", sharedGroup))
+	} else {
+		if !utils.IsSliceMember(sg.MemberIds, ub.Id) {
+			// add member and save
+			sg.MemberIds = append(sg.MemberIds, ub.Id)
+			accountingStorage.SetSharedGroup(sg)
+		}
+	}
+}
+
 func (ub *Account) getBalancesForPrefix(prefix, category string, balances BalanceChain, sharedGroup string) BalanceChain {
 	var usefulBalances BalanceChain
 	for _, b := range balances {
@@ -157,22 +170,19 @@ func (ub *Account) getBalancesForPrefix(prefix, category string, balances Balanc
 		}
 		b.account = ub
 		if b.DestinationIds != "" && b.DestinationIds != utils.ANY {
-			for _, p := range utils.SplitPrefix(prefix, MIN_PREFIX_MATCH) {
-				if x, err := cache2go.GetCached(DESTINATION_PREFIX + p); err == nil {
-					destIds := x.(map[interface{}]struct{})
-					for dId, _ := range destIds {
-						balDestIds := strings.Split(b.DestinationIds, utils.INFIELD_SEP)
-						for _, balDestID := range balDestIds {
-							if dId == balDestID {
-								b.precision = len(p)
-								usefulBalances = append(usefulBalances, b)
-								break
-							}
-						}
-						if b.precision > 0 {
+			for _, match := range MatchDestinations(prefix) {
+				for _, dId := range match.Ids {
+					balDestIds := strings.Split(b.DestinationIds, utils.INFIELD_SEP)
+					for _, balDestID := range balDestIds {
+						if dId == balDestID {
+							b.precision = len(match.Prefix)
+							usefulBalances = append(usefulBalances, b)
 							break
 						}
 					}
+					if b.precision > 0 {
+						break
+					}
 				}
 				if b.precision > 0 {
 					break
@@ -214,11 +224,18 @@ func (account *Account) getAlldBalancesForPrefix(destination, category, balanceT
 func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun bool, goNegative bool) (cc *CallCost, err error) {
 	usefulUnitBalances := ub.getAlldBalancesForPrefix(cd.Destination, cd.Category, cd.TOR+cd.Direction)
 	usefulMoneyBalances := ub.getAlldBalancesForPrefix(cd.Destination, cd.Category, utils.MONETARY+cd.Direction)
+	cd.traceBalances(ub, cd.TOR+cd.Direction, usefulUnitBalances)
+	cd.traceBalances(ub, utils.MONETARY+cd.Direction, usefulMoneyBalances)
 	//log.Print(usefulMoneyBalances, usefulUnitBalances)
 	//log.Print("STARTCD: ", cd)
 	var leftCC *CallCost
 	var initialLength int
+	cd.getTaxRate() // the connect fee is taxed out of the call cost
 	cc = cd.CreateCallCost()
+	var ledger *ledgerSnapshot
+	if !dryRun {
+		ledger = newLedgerSnapshot(ub, usefulUnitBalances, usefulMoneyBalances)
+	}
 
 	generalBalanceChecker := true
 	for generalBalanceChecker {
@@ -242,7 +259,9 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					cc.Timespans = append(cc.Timespans, partCC.Timespans...)
 					if initialLength == 0 {
 						// this is the first add, debit the connect fee
-						ub.DebitConnectionFee(cc, usefulMoneyBalances, count)
+						if feeErr := ub.DebitConnectionFee(cc, usefulMoneyBalances, count); feeErr != nil {
+							err = feeErr
+						}
 					}
 					// for i, ts := range cc.Timespans {
 					//  log.Printf("cc.times[an[%d]: %+v\n", i, ts)
@@ -281,7 +300,9 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 					cc.Timespans = append(cc.Timespans, partCC.Timespans...)
 					if initialLength == 0 {
 						// this is the first add, debit the connect fee
-						ub.DebitConnectionFee(cc, usefulMoneyBalances, count)
+						if feeErr := ub.DebitConnectionFee(cc, usefulMoneyBalances, count); feeErr != nil {
+							err = feeErr
+						}
 					}
 					//for i, ts := range cc.Timespans {
 					//log.Printf("cc.times[an[%d]: %+v\n", i, ts)
@@ -314,38 +335,57 @@ func (ub *Account) debitCreditBalance(cd *CallDescriptor, count bool, dryRun boo
 	cc.Timespans = append(cc.Timespans, leftCC.Timespans...)
 	if initialLength == 0 {
 		// this is the first add, debit the connect fee
-		ub.DebitConnectionFee(cc, usefulMoneyBalances, count)
+		if feeErr := ub.DebitConnectionFee(cc, usefulMoneyBalances, count); feeErr != nil {
+			err = feeErr
+		}
 	}
 	if leftCC.Cost == 0 || goNegative {
 		//log.Printf("Left CC: %+v", leftCC)
 		// get the default money balanance
 		// and go negative on it with the amount still unpaid
-		if len(leftCC.Timespans) > 0 && leftCC.Cost > 0 && !ub.AllowNegative {
-			err = errors.New("not enough credit")
-		}
 		for _, ts := range leftCC.Timespans {
 			if ts.Increments == nil {
 				ts.createIncrementsSlice()
 			}
 			for _, increment := range ts.Increments {
-				cost := increment.Cost
 				defaultBalance := ub.GetDefaultMoneyBalance(leftCC.Direction)
+				exRate := 1.0
+				if ts.RateInterval != nil {
+					var rateErr error
+					if exRate, rateErr = GetExchangeRate(ts.RateInterval.Rating.Currency, defaultBalance.Currency, cd.TimeStart); rateErr != nil {
+						err = rateErr // the amount cannot be converted, refuse debiting it
+						continue
+					}
+				}
+				cost := increment.Cost * (1 + cd.taxRate) * exRate
 				defaultBalance.SubstractAmount(cost)
 				increment.BalanceInfo.MoneyBalanceUuid = defaultBalance.Uuid
 				increment.BalanceInfo.AccountId = ub.Id
+				if exRate != 1 {
+					increment.BalanceInfo.ExchangeRate = exRate
+				}
+				increment.BalanceInfo.TaxRate = cd.taxRate
 				increment.paid = true
 				if count {
 					ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: leftCC.Direction, Balance: &Balance{Value: cost, DestinationIds: leftCC.Destination}})
 				}
 			}
 		}
+		if err == nil && len(leftCC.Timespans) > 0 && leftCC.Cost > 0 && !ub.AllowNegative &&
+			ub.GetDefaultMoneyBalance(leftCC.Direction).Value < -ub.CreditLimit {
+			err = errors.New("not enough credit")
+		}
 	}
 
 COMMIT:
+	if minErr := ub.DebitMinCost(cc, usefulMoneyBalances, count); minErr != nil {
+		err = minErr
+	}
 	if !dryRun {
 		// save darty shared balances
 		usefulMoneyBalances.SaveDirtyBalances(ub)
 		usefulUnitBalances.SaveDirtyBalances(ub)
+		ledger.write(cd.CgrId, nil)
 	}
 	//log.Printf("Final CC: %+v", cc)
 	return
@@ -385,9 +425,10 @@ func (ub *Account) refundIncrement(increment *Increment, direction, unitType str
 		if balance = ub.BalanceMap[utils.MONETARY+direction].GetBalance(increment.BalanceInfo.MoneyBalanceUuid); balance == nil {
 			return
 		}
-		balance.Value += increment.Cost
+		amount := increment.BalanceInfo.GetMoneyAmount(increment.Cost)
+		balance.Value += amount
 		if count {
-			ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: direction, Balance: &Balance{Value: -increment.Cost}})
+			ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: direction, Balance: &Balance{Value: -amount}})
 		}
 	}
 }
@@ -533,6 +574,8 @@ func (ub *Account) initCounters() {
 }
 
 func (ub *Account) CleanExpiredBalances() {
+	ledger := newLedgerSnapshot(ub)
+	defer ledger.write("", nil)
 	for key, bm := range ub.BalanceMap {
 		for i := 0; i < len(bm); i++ {
 			if bm[i].IsExpired() {
@@ -605,38 +648,90 @@ func (acc *Account) Clone() *Account {
 		UnitCounters:   nil, // not used when cloned (dryRun)
 		ActionTriggers: nil, // not used when cloned (dryRun)
 		AllowNegative:  acc.AllowNegative,
+		CreditLimit:    acc.CreditLimit,
 		Disabled:       acc.Disabled,
+		Reservations:   acc.Reservations, // read only in the clones
 	}
 	for key, balanceChain := range acc.BalanceMap {
 		newAcc.BalanceMap[key] = balanceChain.Clone()
 	}
+	if acc.PeriodUsages != nil {
+		newAcc.PeriodUsages = make(map[string]*PeriodUsage, len(acc.PeriodUsages))
+		for key, pu := range acc.PeriodUsages {
+			newAcc.PeriodUsages[key] = &PeriodUsage{PeriodStart: pu.PeriodStart, Usage: pu.Usage}
+		}
+	}
 	return newAcc
 }
 
-func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances BalanceChain, count bool) {
+func (acc *Account) DebitConnectionFee(cc *CallCost, usefulMoneyBalances BalanceChain, count bool) (err error) {
 	if cc.deductConnectFee {
-		connectFee := cc.GetConnectFee()
-		//log.Print("CONNECT FEE: %f", connectFee)
-		connectFeePaid := false
-		for _, b := range usefulMoneyBalances {
-			if b.Value >= connectFee {
-				b.SubstractAmount(connectFee)
-				// the conect fee is not refundable!
-				if count {
-					acc.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: connectFee, DestinationIds: cc.Destination}})
-				}
-				connectFeePaid = true
-				break
-			}
+		if connectFee := cc.GetConnectFee(); connectFee > 0 {
+			// kept for refunding the connect fee of the calls ending unanswered
+			cc.ConnectFeeIncrement, err = acc.debitFee(cc, connectFee, usefulMoneyBalances, count)
 		}
-		// debit connect fee
-		if connectFee > 0 && !connectFeePaid {
-			// there are no money for the connect fee; go negative
-			acc.GetDefaultMoneyBalance(cc.Direction).Value -= connectFee
-			// the conect fee is not refundable!
-			if count {
-				acc.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: connectFee, DestinationIds: cc.Destination}})
-			}
+	}
+	return
+}
+
+// Debits the difference up to the minimum cost of the call, once the timespans are paid
+func (acc *Account) DebitMinCost(cc *CallCost, usefulMoneyBalances BalanceChain, count bool) (err error) {
+	if !cc.deductConnectFee {
+		return
+	}
+	minCost := cc.GetMinCost()
+	if minCost == 0 {
+		return
+	}
+	cost := cc.GetConnectFee()
+	for _, ts := range cc.Timespans {
+		cost += ts.getCost()
+	}
+	if cost < minCost {
+		topUp := utils.Round(minCost-cost, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+		cc.MinCostIncrement, err = acc.debitFee(cc, topUp, usefulMoneyBalances, count)
+	}
+	return
+}
+
+// Takes a fee of the call out of the first money balance able to pay it, going negative on the default one otherwise.
+// The returned increment records where the fee was taken from so it can be refunded.
+// The fee is not taken when it cannot be converted into the currency of the default balance.
+func (acc *Account) debitFee(cc *CallCost, fee float64, usefulMoneyBalances BalanceChain, count bool) (*Increment, error) {
+	currency := cc.GetCurrency()
+	increment := &Increment{Cost: fee, BalanceInfo: &BalanceInfo{TaxRate: cc.taxRate}, paid: true}
+	var paidBalance *Balance
+	var exRate float64
+	for _, b := range usefulMoneyBalances {
+		rate, err := GetExchangeRate(currency, b.Currency, cc.GetStartTime())
+		if err != nil {
+			continue
+		}
+		if amount := increment.BalanceInfo.GetMoneyAmount(fee) * rate; b.Value >= amount {
+			paidBalance, exRate = b, rate
+			break
 		}
 	}
+	if paidBalance == nil {
+		// there are no money for the fee; go negative
+		paidBalance = acc.GetDefaultMoneyBalance(cc.Direction)
+		var err error
+		if exRate, err = GetExchangeRate(currency, paidBalance.Currency, cc.GetStartTime()); err != nil {
+			return nil, err
+		}
+	}
+	if exRate != 1 {
+		increment.BalanceInfo.ExchangeRate = exRate
+	}
+	amount := increment.BalanceInfo.GetMoneyAmount(fee)
+	paidBalance.SubstractAmount(amount)
+	increment.BalanceInfo.MoneyBalanceUuid = paidBalance.Uuid
+	increment.BalanceInfo.AccountId = acc.Id
+	if paidBalance.account != nil {
+		increment.BalanceInfo.AccountId = paidBalance.account.Id
+	}
+	if count {
+		acc.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: amount, DestinationIds: cc.Destination}})
+	}
+	return increment, nil
 }
@@ -1413,6 +1413,39 @@ func TestAccountGetDefaultMoneyBalance(t *testing.T) {
 
 /*********************************** Benchmarks *******************************/
 
+func TestAccountCreditLimit(t *testing.T) {
+	acc := &Account{Id: "*out:vdf:postpaid", CreditLimit: 100, BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "postpaid_money"}}}}
+	if err := accountingStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	timeStart := time.Date(2012, 2, 2, 17, 30, 0, 0, time.UTC)
+	cd := &CallDescriptor{Direction: OUTBOUND, Category: "0", Tenant: "vdf", Subject: "rif", Account: "postpaid", Destination: "0256",
+		TimeStart: timeStart, TimeEnd: timeStart.Add(time.Minute)}
+	// 1 connect fee + 1 per second
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil || d != time.Minute {
+		t.Errorf("Credit limit not used: %v, %v", d, err)
+	}
+	over := cd.Clone()
+	over.TimeEnd = timeStart.Add(2 * time.Minute)
+	if d, err := over.GetMaxSessionDuration(); err != nil || d == 2*time.Minute {
+		t.Errorf("Credit limit exceeded: %v, %v", d, err)
+	}
+	if cc, err := cd.Clone().MaxDebit(); err != nil || cc.Cost != 61 {
+		t.Fatalf("Wrong debit on credit: %+v, %v", cc, err)
+	}
+	acc, _ = accountingStorage.GetAccount("*out:vdf:postpaid")
+	if acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != -61 {
+		t.Error("Wrong balance: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	// 39 left on the credit
+	if d, err := cd.Clone().GetMaxSessionDuration(); err != nil || d != 0 {
+		t.Errorf("Credit limit exceeded: %v, %v", d, err)
+	}
+	if cc, _ := cd.Clone().MaxDebit(); cc.Cost != 0 {
+		t.Error("Debited over the credit limit: ", cc.Cost)
+	}
+}
+
 func BenchmarkGetSecondForPrefix(b *testing.B) {
 	b.StopTimer()
 	b1 := &Balance{Value: 10, Weight: 10, DestinationIds: "NAT"}
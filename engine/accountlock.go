@@ -48,3 +48,32 @@ func (cm *AccountLock) Guard(handler func() (interface{}, error), names ...strin
 	}
 	return
 }
+
+// Runs the handler only if none of the names is locked at the moment, returning false without waiting otherwise.
+// Meant for locking one more account while already holding others, where waiting could deadlock.
+func (cm *AccountLock) TryGuard(handler func() (interface{}, error), names ...string) (reply interface{}, locked bool, err error) {
+	cm.mu.Lock()
+	for idx, name := range names {
+		lock, exists := AccLock.queue[name]
+		if !exists {
+			lock = make(chan bool, 1)
+			AccLock.queue[name] = lock
+		}
+		select {
+		case lock <- true:
+		default:
+			for _, taken := range names[:idx] {
+				<-AccLock.queue[taken]
+			}
+			cm.mu.Unlock()
+			return nil, false, nil
+		}
+	}
+	cm.mu.Unlock()
+	reply, err = handler()
+	for _, name := range names {
+		lock := AccLock.queue[name]
+		<-lock
+	}
+	return reply, true, err
+}
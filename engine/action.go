@@ -45,29 +45,38 @@ type Action struct {
 	ExpirationString string
 	Weight           float64
 	Balance          *Balance
+	ProrationFactor  float64      // share of the schedule period the value was prorated to, set on the executed copies of the prorated actions
+	RolledOver       BalanceChain // balances created out of the unused units, set on the executed copies of the rollover actions
+	prorated         bool
+	rolloverExpiry   time.Time
 }
 
 const (
-	LOG             = "*log"
-	RESET_TRIGGERS  = "*reset_triggers"
-	SET_RECURRENT   = "*set_recurrent"
-	UNSET_RECURRENT = "*unset_recurrent"
-	ALLOW_NEGATIVE  = "*allow_negative"
-	DENY_NEGATIVE   = "*deny_negative"
-	RESET_ACCOUNT   = "*reset_account"
-	TOPUP_RESET     = "*topup_reset"
-	TOPUP           = "*topup"
-	DEBIT_RESET     = "*debit_reset"
-	DEBIT           = "*debit"
-	RESET_COUNTER   = "*reset_counter"
-	RESET_COUNTERS  = "*reset_counters"
-	ENABLE_ACCOUNT  = "*enable_account"
-	DISABLE_ACCOUNT = "*disable_account"
-	CALL_URL        = "*call_url"
-	CALL_URL_ASYNC  = "*call_url_async"
-	MAIL_ASYNC      = "*mail_async"
-	UNLIMITED       = "*unlimited"
-	CDRLOG          = "*cdrlog"
+	LOG                 = "*log"
+	RESET_TRIGGERS      = "*reset_triggers"
+	SET_RECURRENT       = "*set_recurrent"
+	UNSET_RECURRENT     = "*unset_recurrent"
+	ALLOW_NEGATIVE      = "*allow_negative"
+	DENY_NEGATIVE       = "*deny_negative"
+	RESET_ACCOUNT       = "*reset_account"
+	TOPUP_RESET         = "*topup_reset"
+	TOPUP               = "*topup"
+	DEBIT_RESET         = "*debit_reset"
+	DEBIT               = "*debit"
+	RESET_COUNTER       = "*reset_counter"
+	RESET_COUNTERS      = "*reset_counters"
+	ENABLE_ACCOUNT      = "*enable_account"
+	DISABLE_ACCOUNT     = "*disable_account"
+	CALL_URL            = "*call_url"
+	CALL_URL_ASYNC      = "*call_url_async"
+	MAIL_ASYNC          = "*mail_async"
+	UNLIMITED           = "*unlimited"
+	CDRLOG              = "*cdrlog"
+	CLOSE_BILLING_CYCLE = "*close_billing_cycle"
+	TRANSFER_BALANCE    = "*transfer_balance"
+	TOPUP_PRORATED      = "*topup_prorated"
+	DEBIT_PRORATED      = "*debit_prorated"
+	TOPUP_ROLLOVER      = "*topup_rollover"
 )
 
 type actionTypeFunc func(*Account, *StatsQueueTriggered, *Action, Actions) error
@@ -112,6 +121,16 @@ func getActionFunc(typ string) (actionTypeFunc, bool) {
 		return callUrlAsync, true
 	case MAIL_ASYNC:
 		return mailAsync, true
+	case CLOSE_BILLING_CYCLE:
+		return closeBillingCycleAction, true
+	case TRANSFER_BALANCE:
+		return transferBalanceAction, true
+	case TOPUP_PRORATED:
+		return topupProratedAction, true
+	case DEBIT_PRORATED:
+		return debitProratedAction, true
+	case TOPUP_ROLLOVER:
+		return topupRolloverAction, true
 	}
 	return nil, false
 }
@@ -297,7 +316,7 @@ func resetAccountAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Act
 	if ub == nil {
 		return errors.New("Nil user balance")
 	}
-	return genericReset(ub)
+	return genericReset(ub, a)
 }
 
 func topupResetAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
@@ -358,6 +377,44 @@ func resetCountersAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Ac
 	return
 }
 
+func closeBillingCycleAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
+	if ub == nil {
+		return errors.New("Nil user balance")
+	}
+	_, err = ub.CloseBillingCycles(time.Now())
+	return
+}
+
+// Moves the action balance value into the account given in the extra parameters.
+// The receiving account is locked together with the executing one only on scheduled executions.
+// The account receiving the transfer is locked here: the caller only holds the giving account, be it an action plan,
+// a trigger or a billing cycle. Waiting for it could deadlock with a transfer the other way, so busy receivers are retried
+// for a while and the transfer refused if still locked.
+func transferBalanceAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
+	if ub == nil {
+		return errors.New("Nil user balance")
+	}
+	if a.ExtraParameters == ub.Id {
+		return fmt.Errorf("cannot transfer within the same account %s", ub.Id)
+	}
+	for try := 0; try < TRANSFER_LOCK_TRIES; try++ {
+		if try > 0 {
+			time.Sleep(TRANSFER_LOCK_WAIT)
+		}
+		_, locked, err := AccLock.TryGuard(func() (interface{}, error) {
+			to, err := accountingStorage.GetAccount(a.ExtraParameters)
+			if err != nil {
+				return 0, fmt.Errorf("could not get the receiving account %s: %v", a.ExtraParameters, err)
+			}
+			return 0, TransferBalance(ub, to, a)
+		}, a.ExtraParameters)
+		if locked {
+			return err
+		}
+	}
+	return fmt.Errorf("receiving account %s locked by another operation", a.ExtraParameters)
+}
+
 func genericMakeNegative(a *Action) {
 	if a.Balance != nil && a.Balance.Value >= 0 { // only apply if not allready negative
 		a.Balance.Value = -a.Balance.Value
@@ -391,7 +448,9 @@ func disableUserAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Acti
 	return
 }
 
-func genericReset(ub *Account) error {
+func genericReset(ub *Account, a *Action) error {
+	ledger := newLedgerSnapshot(ub)
+	defer ledger.write("", a)
 	for k, _ := range ub.BalanceMap {
 		ub.BalanceMap[k] = BalanceChain{&Balance{Value: 0}}
 	}
@@ -40,6 +40,7 @@ type ActionTiming struct {
 	Timing     *RateInterval
 	Weight     float64
 	ActionsId  string
+	Timezone   string // the timing is scheduled in this zone, server local one if empty
 	actions    Actions
 	stCache    time.Time // cached time of the next start
 }
@@ -50,6 +51,12 @@ func (at *ActionTiming) GetNextStartTime(now time.Time) (t time.Time) {
 	if !at.stCache.IsZero() {
 		return at.stCache
 	}
+	at.stCache = at.nextStartTime(now)
+	return at.stCache
+}
+
+// Start of the first run after now, without caching it
+func (at *ActionTiming) nextStartTime(now time.Time) (t time.Time) {
 	i := at.Timing
 	if i == nil || i.Timing == nil {
 		return
@@ -64,8 +71,12 @@ func (at *ActionTiming) GetNextStartTime(now time.Time) (t time.Time) {
 	if len(i.Timing.Months) > 0 && len(i.Timing.MonthDays) == 0 {
 		i.Timing.MonthDays = append(i.Timing.MonthDays, 1)
 	}
-	at.stCache = cronexpr.MustParse(i.Timing.CronString()).Next(now)
-	return at.stCache
+	if at.Timezone != "" { // the cron expression follows the wall clock of the action plan
+		if loc, err := utils.GetLocation(at.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	return cronexpr.MustParse(i.Timing.CronString()).Next(now)
 }
 
 // To be deleted after the above solution proves reliable
@@ -265,10 +276,25 @@ func (at *ActionTiming) Execute() (err error) {
 				} else if ub.Disabled && a.ActionType != ENABLE_ACCOUNT {
 					return 0, fmt.Errorf("Account %s is disabled", ubId)
 				}
+				act := a
+				if a.isProrated() {
+					if act, err = at.prorateAction(a, ub, time.Now()); err != nil {
+						return 0, err
+					}
+				} else if a.ActionType == TOPUP_ROLLOVER {
+					if act, err = at.rolloverAction(a, time.Now()); err != nil {
+						return 0, err
+					}
+				}
 				//Logger.Info(fmt.Sprintf("Executing %v on %+v", a.ActionType, ub))
-				err = actionFunction(ub, nil, a, aac)
+				err = actionFunction(ub, nil, act, aac)
 				//Logger.Info(fmt.Sprintf("After execute, account: %+v", ub))
 				accountingStorage.SetAccount(ub)
+				if act.prorated {
+					at.logProrated(ub.Id, act)
+				} else if act != a {
+					at.logRollover(ub.Id, act)
+				}
 				return 0, nil
 			}, ubId)
 			if err != nil {
@@ -69,6 +69,29 @@ func TestActionTimingOnlyHour(t *testing.T) {
 	}
 }
 
+func TestActionTimingTimezoneDST(t *testing.T) {
+	for _, tc := range []struct {
+		timezone string
+		timing   *RITiming
+		now      time.Time
+		expected time.Time
+	}{
+		// daily at 08:00 in Berlin, the night in between switches to summer time
+		{"Europe/Berlin", &RITiming{StartTime: "08:00:00"},
+			time.Date(2015, 3, 28, 8, 0, 0, 0, time.UTC), time.Date(2015, 3, 29, 6, 0, 0, 0, time.UTC)},
+		// monthly top-up on the 1st at midnight in New York, before and after the switch to winter time
+		{"America/New_York", &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"},
+			time.Date(2015, 10, 31, 12, 0, 0, 0, time.UTC), time.Date(2015, 11, 1, 4, 0, 0, 0, time.UTC)},
+		{"America/New_York", &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"},
+			time.Date(2015, 11, 15, 12, 0, 0, 0, time.UTC), time.Date(2015, 12, 1, 5, 0, 0, 0, time.UTC)},
+	} {
+		at := &ActionTiming{Timing: &RateInterval{Timing: tc.timing}, Timezone: tc.timezone}
+		if st := at.GetNextStartTime(tc.now); !st.Equal(tc.expected) {
+			t.Errorf("Expected %v was %v", tc.expected, st.UTC())
+		}
+	}
+}
+
 func TestActionTimingHourYear(t *testing.T) {
 	at := &ActionTiming{Timing: &RateInterval{Timing: &RITiming{Years: utils.Years{2022}, StartTime: "10:01:00"}}}
 	st := at.GetNextStartTime(referenceDate)
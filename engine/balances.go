@@ -38,6 +38,8 @@ type Balance struct {
 	RatingSubject  string
 	Category       string
 	SharedGroup    string
+	Currency       string // currency of a monetary balance, empty for the default one
+	RolloverFrom   string // uuid of the balance the unused units were rolled over from, empty for the other balances
 	Timings        []*RITiming
 	TimingIDs      string
 	precision      int
@@ -60,7 +62,8 @@ func (b *Balance) Equal(o *Balance) bool {
 		bDestIds == oDestIds &&
 		b.RatingSubject == o.RatingSubject &&
 		b.Category == o.Category &&
-		b.SharedGroup == o.SharedGroup
+		b.SharedGroup == o.SharedGroup &&
+		b.Currency == o.Currency
 }
 
 func (b *Balance) MatchFilter(o *Balance) bool {
@@ -80,7 +83,8 @@ func (b *Balance) MatchFilter(o *Balance) bool {
 		(oDestIds == "" || bDestIds == oDestIds) &&
 		(o.RatingSubject == "" || b.RatingSubject == o.RatingSubject) &&
 		(o.Category == "" || b.Category == o.Category) &&
-		(o.SharedGroup == "" || b.SharedGroup == o.SharedGroup)
+		(o.SharedGroup == "" || b.SharedGroup == o.SharedGroup) &&
+		(o.Currency == "" || b.Currency == o.Currency)
 }
 
 // the default balance has no destinationid, Expirationdate or ratesubject
@@ -177,6 +181,8 @@ func (b *Balance) Clone() *Balance {
 		RatingSubject:  b.RatingSubject,
 		Category:       b.Category,
 		SharedGroup:    b.SharedGroup,
+		Currency:       b.Currency,
+		RolloverFrom:   b.RolloverFrom,
 		TimingIDs:      b.TimingIDs,
 		Timings:        b.Timings, // should not be a problem with aliasing
 	}
@@ -373,9 +379,11 @@ func (b *Balance) DebitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					continue
 				}
 				var moneyBal *Balance
+				var amount, exRate float64
 				for _, mb := range moneyBalances {
-					if mb.Value >= cost {
-						moneyBal = mb
+					// money balances in other currencies pay the converted cost
+					if rate, err := GetExchangeRate(ts.RateInterval.Rating.Currency, mb.Currency, cd.TimeStart); err == nil && mb.Value >= cost*(1+cd.getTaxRate())*rate {
+						moneyBal, amount, exRate = mb, cost*(1+cd.getTaxRate())*rate, rate
 						break
 					}
 				}
@@ -386,14 +394,18 @@ func (b *Balance) DebitUnits(cd *CallDescriptor, ub *Account, moneyBalances Bala
 					inc.UnitInfo = &UnitInfo{cc.Destination, seconds, cc.TOR}
 					if cost != 0 {
 						inc.BalanceInfo.MoneyBalanceUuid = moneyBal.Uuid
-						moneyBal.SubstractAmount(cost)
+						if exRate != 1 {
+							inc.BalanceInfo.ExchangeRate = exRate
+						}
+						inc.BalanceInfo.TaxRate = cd.getTaxRate()
+						moneyBal.SubstractAmount(amount)
 						cd.MaxCostSoFar += cost
 					}
 					inc.paid = true
 					if count {
 						ub.countUnits(&Action{BalanceType: cc.TOR, Direction: cc.Direction, Balance: &Balance{Value: seconds, DestinationIds: cc.Destination}})
 						if cost != 0 {
-							ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: cost, DestinationIds: cc.Destination}})
+							ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: amount, DestinationIds: cc.Destination}})
 						}
 					}
 				} else {
@@ -441,12 +453,21 @@ func (b *Balance) DebitMoney(cd *CallDescriptor, ub *Account, count bool, dryRun
 			continue
 		}
 		maxCost, strategy := ts.RateInterval.GetMaxCost()
+		// the costs are in the currency of the rates, the balance pays them converted into its own
+		exRate, err := GetExchangeRate(ts.RateInterval.Rating.Currency, b.Currency, cd.TimeStart)
+		if err != nil {
+			cc.Timespans = cc.Timespans[:tsIndex]
+			if len(cc.Timespans) == 0 {
+				cc = nil
+			}
+			return cc, err
+		}
 		//log.Printf("Timing: %+v", ts.RateInterval.Timing)
 		//log.Printf("Rate: %+v", ts.RateInterval.Rating)
 		for incIndex, inc := range ts.Increments {
 			// check standard subject tags
 			//log.Printf("INC: %+v", inc)
-			amount := inc.Cost
+			amount := inc.Cost * (1 + cd.getTaxRate()) * exRate
 			inc.paid = false
 			if strategy == utils.MAX_COST_DISCONNECT && cd.MaxCostSoFar >= maxCost {
 				// cat the entire current timespan
@@ -476,9 +497,13 @@ func (b *Balance) DebitMoney(cd *CallDescriptor, ub *Account, count bool, dryRun
 
 			if b.Value >= amount {
 				b.SubstractAmount(amount)
-				cd.MaxCostSoFar += amount
+				cd.MaxCostSoFar += inc.Cost
 				inc.BalanceInfo.MoneyBalanceUuid = b.Uuid
 				inc.BalanceInfo.AccountId = ub.Id
+				if exRate != 1 {
+					inc.BalanceInfo.ExchangeRate = exRate
+				}
+				inc.BalanceInfo.TaxRate = cd.getTaxRate()
 				inc.paid = true
 				if count {
 					ub.countUnits(&Action{BalanceType: utils.MONETARY, Direction: cc.Direction, Balance: &Balance{Value: amount, DestinationIds: cc.Destination}})
@@ -522,8 +547,10 @@ func (bc BalanceChain) Swap(i, j int) {
 
 func (bc BalanceChain) Less(j, i int) bool {
 	return bc[i].precision < bc[j].precision ||
-		(bc[i].precision == bc[j].precision && bc[i].Weight < bc[j].Weight)
-
+		(bc[i].precision == bc[j].precision && bc[i].Weight < bc[j].Weight) ||
+		// on the same footing the balance expiring first is used first, eg: rolled over units before the fresh bundle
+		(bc[i].precision == bc[j].precision && bc[i].Weight == bc[j].Weight &&
+			!bc[j].ExpirationDate.IsZero() && (bc[i].ExpirationDate.IsZero() || bc[j].ExpirationDate.Before(bc[i].ExpirationDate)))
 }
 
 func (bc BalanceChain) Sort() {
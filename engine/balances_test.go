@@ -21,6 +21,7 @@ package engine
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cgrates/cgrates/utils"
 )
@@ -80,6 +81,18 @@ func TestBalanceSortWeightLess(t *testing.T) {
 	}
 }
 
+func TestBalanceSortExpiration(t *testing.T) {
+	mb1 := &Balance{Weight: 1, precision: 1}
+	mb2 := &Balance{Weight: 1, precision: 1, ExpirationDate: time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)}
+	mb3 := &Balance{Weight: 1, precision: 1, ExpirationDate: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)}
+	mb4 := &Balance{Weight: 2, precision: 1}
+	bs := BalanceChain{mb1, mb2, mb3, mb4}
+	bs.Sort()
+	if bs[0] != mb4 || bs[1] != mb3 || bs[2] != mb2 || bs[3] != mb1 {
+		t.Error("Buckets not sorted by expiration: ", bs)
+	}
+}
+
 func TestBalanceEqual(t *testing.T) {
 	mb1 := &Balance{Weight: 1, precision: 1, RatingSubject: "1", DestinationIds: ""}
 	mb2 := &Balance{Weight: 1, precision: 1, RatingSubject: "1", DestinationIds: ""}
@@ -0,0 +1,89 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Cost of one call descriptor out of a batch, Error is set instead when it could not be rated
+type BatchCallCost struct {
+	CallCost *CallCost
+	Error    string
+}
+
+// Max session time of one call descriptor out of a batch
+type BatchMaxSessionTime struct {
+	MaxSessionTime float64
+	Error          string
+}
+
+// Runs the handler for each of the batch indexes, at most one goroutine per CPU at a time
+func runBatch(size int, handler func(idx int)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, runtime.NumCPU())
+	for idx := 0; idx < size; idx++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(idx int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			handler(idx)
+		}(idx)
+	}
+	wg.Wait()
+}
+
+// Rates the call descriptors concurrently, each under the lock of its account, results are in the order of the batch
+func GetCostBatch(cds []CallDescriptor) []*BatchCallCost {
+	results := make([]*BatchCallCost, len(cds))
+	runBatch(len(cds), func(idx int) {
+		cd := cds[idx]
+		result := new(BatchCallCost)
+		if r, err := AccLock.Guard(func() (interface{}, error) {
+			return cd.GetCost()
+		}, cd.GetAccountKey()); err != nil {
+			result.Error = err.Error()
+		} else if r != nil {
+			result.CallCost = r.(*CallCost)
+		}
+		results[idx] = result
+	})
+	return results
+}
+
+// Max session times of the call descriptors, calculated concurrently in the order of the batch
+func GetMaxSessionTimeBatch(cds []CallDescriptor) []*BatchMaxSessionTime {
+	results := make([]*BatchMaxSessionTime, len(cds))
+	runBatch(len(cds), func(idx int) {
+		cd := cds[idx]
+		result := new(BatchMaxSessionTime)
+		// locks the account together with its shared group members
+		if maxSessionTime, err := cd.GetMaxSessionDuration(); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.MaxSessionTime = float64(maxSessionTime)
+		}
+		results[idx] = result
+	})
+	return results
+}
@@ -0,0 +1,182 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Closed cycles kept on the account, which is loaded on every debit, the older ones are only found in the logs
+const MAX_CLOSED_BILLING_CYCLES = 1
+
+// Billing period of a postpaid account, closed by the *close_billing_cycle action
+type BillingCycle struct {
+	Period    string                // *monthly or *weekly
+	AnchorDay int                   // day of the month the cycles start on (the last one for shorter months), day of the week for weekly cycles with 0 for Sunday
+	ActionsId string                // executed on the account when a cycle is closed, eg: *call_url announcing it to the billing system
+	Start     time.Time             // start of the cycle in progress, zero until the first close
+	Closed    []*ClosedBillingCycle // snapshots of the last closed cycles, oldest first, at most MAX_CLOSED_BILLING_CYCLES
+}
+
+// Account state at the end of a billing cycle
+type ClosedBillingCycle struct {
+	Start        time.Time
+	End          time.Time
+	Balances     map[string]float64 // balance map key: total value of the balances
+	UnitCounters []*UnitsCounter    // usage counted during the cycle
+}
+
+func (bc *BillingCycle) Validate() error {
+	switch bc.Period {
+	case utils.META_MONTHLY:
+		if bc.AnchorDay < 1 || bc.AnchorDay > 31 {
+			return fmt.Errorf("invalid anchor day of month: %d", bc.AnchorDay)
+		}
+	case utils.META_WEEKLY:
+		if bc.AnchorDay < 0 || bc.AnchorDay > 6 {
+			return fmt.Errorf("invalid anchor day of week: %d", bc.AnchorDay)
+		}
+	default:
+		return fmt.Errorf("unsupported billing period: %s", bc.Period)
+	}
+	return nil
+}
+
+// Start of the cycle containing the time
+func (bc *BillingCycle) cycleStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch bc.Period {
+	case utils.META_WEEKLY:
+		return day.AddDate(0, 0, -((int(t.Weekday()) - bc.AnchorDay + 7) % 7))
+	case utils.META_MONTHLY:
+		start := monthAnchor(t.Year(), t.Month(), bc.AnchorDay, t.Location())
+		if t.Before(start) {
+			start = monthAnchor(t.Year(), t.Month()-1, bc.AnchorDay, t.Location())
+		}
+		return start
+	}
+	return time.Time{}
+}
+
+// End of the cycle starting at the time, which is the start of the next one
+func (bc *BillingCycle) cycleEnd(start time.Time) time.Time {
+	switch bc.Period {
+	case utils.META_WEEKLY:
+		return start.AddDate(0, 0, 7)
+	case utils.META_MONTHLY:
+		return monthAnchor(start.Year(), start.Month()+1, bc.AnchorDay, start.Location())
+	}
+	return time.Time{}
+}
+
+// The anchor day of the month, moved to the last day of the shorter months
+func monthAnchor(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc) // normalizes the month out of range
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Sets the billing cycle of the account, nil period removes it.
+// The cycle in progress and the closed ones are kept if the period and anchor day are not changing.
+func (acc *Account) SetBillingCycle(period string, anchorDay int, actionsId string, now time.Time) error {
+	if period == "" {
+		acc.BillingCycle = nil
+		return nil
+	}
+	bc := &BillingCycle{Period: period, AnchorDay: anchorDay, ActionsId: actionsId}
+	if err := bc.Validate(); err != nil {
+		return err
+	}
+	if old := acc.BillingCycle; old != nil && old.Period == period && old.AnchorDay == anchorDay {
+		bc.Start, bc.Closed = old.Start, old.Closed
+	}
+	if bc.Start.IsZero() {
+		bc.Start = bc.cycleStart(now)
+	}
+	acc.BillingCycle = bc
+	return nil
+}
+
+// Closes the billing cycles of the account ended by the time, snapshotting the balances and resetting the counters,
+// then runs the actions of the billing cycle to announce them. The account is not saved.
+func (acc *Account) CloseBillingCycles(now time.Time) (closed []*ClosedBillingCycle, err error) {
+	bc := acc.BillingCycle
+	if bc == nil {
+		return nil, fmt.Errorf("no billing cycle defined for account %s", acc.Id)
+	}
+	if err := bc.Validate(); err != nil {
+		return nil, err
+	}
+	if bc.Start.IsZero() {
+		bc.Start = bc.cycleStart(now)
+		return
+	}
+	for end := bc.cycleEnd(bc.Start); !end.After(now); end = bc.cycleEnd(bc.Start) {
+		cycle := &ClosedBillingCycle{Start: bc.Start, End: end, Balances: make(map[string]float64, len(acc.BalanceMap)), UnitCounters: acc.UnitCounters}
+		for key, chain := range acc.BalanceMap {
+			cycle.Balances[key] = chain.GetTotalValue()
+		}
+		bc.Closed = append(bc.Closed, cycle)
+		if len(bc.Closed) > MAX_CLOSED_BILLING_CYCLES {
+			bc.Closed = append([]*ClosedBillingCycle(nil), bc.Closed[len(bc.Closed)-MAX_CLOSED_BILLING_CYCLES:]...)
+		}
+		closed = append(closed, cycle)
+		Logger.Info(fmt.Sprintf("<BillingCycle> Closed the cycle %v - %v of account %s, balances: %v", cycle.Start, cycle.End, acc.Id, cycle.Balances))
+		acc.UnitCounters = make([]*UnitsCounter, 0)
+		acc.initCounters()
+		// the PeriodUsages are left alone: they follow the calendar periods of the tiered rates (day, month, year),
+		// not the cycle with its anchor day, and start again from zero by themselves when a new tier period begins
+		bc.Start = end
+	}
+	if len(closed) != 0 && bc.ActionsId != "" {
+		err = acc.executeBillingCycleActions()
+	}
+	return
+}
+
+// Runs the actions of the billing cycle on the account, announcing the closed cycle
+func (acc *Account) executeBillingCycleActions() error {
+	aac, err := accountingStorage.GetActions(acc.BillingCycle.ActionsId, false)
+	if err != nil {
+		return fmt.Errorf("could not get the billing cycle actions %s: %v", acc.BillingCycle.ActionsId, err)
+	}
+	aac.Sort()
+	for _, a := range aac {
+		if a.ActionType == CLOSE_BILLING_CYCLE {
+			continue
+		}
+		if a.Balance == nil {
+			a.Balance = &Balance{}
+		}
+		a.Balance.ExpirationDate, _ = utils.ParseDate(a.ExpirationString)
+		actionFunction, exists := getActionFunc(a.ActionType)
+		if !exists {
+			return fmt.Errorf("function type %v not available", a.ActionType)
+		}
+		if err := actionFunction(acc, nil, a, aac); err != nil {
+			Logger.Err(fmt.Sprintf("<BillingCycle> Error executing %s on account %s: %v", a.ActionType, acc.Id, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestBillingCycleValidate(t *testing.T) {
+	for _, bc := range []*BillingCycle{
+		&BillingCycle{Period: utils.META_MONTHLY, AnchorDay: 0},
+		&BillingCycle{Period: utils.META_MONTHLY, AnchorDay: 32},
+		&BillingCycle{Period: utils.META_WEEKLY, AnchorDay: 7},
+		&BillingCycle{Period: utils.META_DAILY, AnchorDay: 1},
+	} {
+		if bc.Validate() == nil {
+			t.Errorf("Invalid billing cycle accepted: %+v", bc)
+		}
+	}
+	if err := (&BillingCycle{Period: utils.META_MONTHLY, AnchorDay: 31}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBillingCycleMonthly(t *testing.T) {
+	bc := &BillingCycle{Period: utils.META_MONTHLY, AnchorDay: 15}
+	if start := bc.cycleStart(time.Date(2015, 3, 20, 10, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2015, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", start)
+	}
+	if start := bc.cycleStart(time.Date(2015, 1, 10, 10, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2014, 12, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", start)
+	}
+	// anchored on the last day for the shorter months
+	bc.AnchorDay = 31
+	if start := bc.cycleStart(time.Date(2015, 3, 20, 10, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2015, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", start)
+	}
+	if end := bc.cycleEnd(time.Date(2015, 2, 28, 0, 0, 0, 0, time.UTC)); !end.Equal(time.Date(2015, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle end: ", end)
+	}
+	if end := bc.cycleEnd(time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC)); !end.Equal(time.Date(2016, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle end: ", end)
+	}
+}
+
+func TestBillingCycleWeekly(t *testing.T) {
+	bc := &BillingCycle{Period: utils.META_WEEKLY, AnchorDay: int(time.Monday)}
+	// Sunday
+	if start := bc.cycleStart(time.Date(2015, 3, 22, 10, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2015, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", start)
+	}
+	if start := bc.cycleStart(time.Date(2015, 3, 23, 0, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2015, 3, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", start)
+	}
+	if end := bc.cycleEnd(time.Date(2015, 3, 23, 0, 0, 0, 0, time.UTC)); !end.Equal(time.Date(2015, 3, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle end: ", end)
+	}
+}
+
+func TestBillingCycleClose(t *testing.T) {
+	acc := &Account{Id: "*out:cgrates.org:postpaid",
+		BalanceMap:   map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: -7}, &Balance{Value: 2, DestinationIds: "NAT"}}},
+		UnitCounters: []*UnitsCounter{&UnitsCounter{BalanceType: utils.MONETARY, Direction: OUTBOUND, Balances: BalanceChain{&Balance{Value: 5}}}},
+	}
+	acc.addPeriodUsage(OUTBOUND, utils.VOICE, utils.META_YEARLY, time.Date(2015, 3, 20, 0, 0, 0, 0, time.UTC), time.Hour)
+	if _, err := acc.CloseBillingCycles(time.Now()); err == nil {
+		t.Error("Closed a cycle without billing cycle")
+	}
+	if err := acc.SetBillingCycle(utils.META_MONTHLY, 1, "", time.Date(2015, 3, 20, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if !acc.BillingCycle.Start.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong cycle start: ", acc.BillingCycle.Start)
+	}
+	if closed, err := acc.CloseBillingCycles(time.Date(2015, 3, 31, 0, 0, 0, 0, time.UTC)); err != nil || len(closed) != 0 {
+		t.Errorf("Closed the cycle in progress: %+v, %v", closed, err)
+	}
+	closed, err := acc.CloseBillingCycles(time.Date(2015, 5, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(closed) != 2 {
+		t.Fatalf("Wrong cycles closed: %+v, %v", closed, err)
+	}
+	if !closed[0].Start.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) || !closed[0].End.Equal(time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)) ||
+		closed[0].Balances[utils.MONETARY+OUTBOUND] != -5 || len(closed[0].UnitCounters) != 1 || closed[0].UnitCounters[0].Balances[0].Value != 5 {
+		t.Errorf("Wrong first closed cycle: %+v", closed[0])
+	}
+	if len(closed[1].UnitCounters) != 0 {
+		t.Errorf("Counters not reset: %+v", closed[1].UnitCounters)
+	}
+	// the tier usage keeps counting over the yearly tier period
+	if usage := acc.getPeriodUsage(OUTBOUND, utils.VOICE, utils.META_YEARLY, time.Date(2015, 5, 10, 0, 0, 0, 0, time.UTC)); usage != time.Hour {
+		t.Error("Yearly tier usage reset with the billing cycle: ", usage)
+	}
+	// only the last closed cycle stays on the account
+	if len(acc.BillingCycle.Closed) != 1 || acc.BillingCycle.Closed[0] != closed[1] || !acc.BillingCycle.Start.Equal(time.Date(2015, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong billing cycle: %+v", acc.BillingCycle)
+	}
+	// the closed cycles are kept while the period does not change
+	acc.SetBillingCycle(utils.META_MONTHLY, 1, "SOME_ACTIONS", time.Now())
+	if len(acc.BillingCycle.Closed) != 1 || acc.BillingCycle.ActionsId != "SOME_ACTIONS" {
+		t.Errorf("Wrong billing cycle: %+v", acc.BillingCycle)
+	}
+	if err := acc.SetBillingCycle(utils.META_WEEKLY, 9, "", time.Now()); err == nil || acc.BillingCycle.Period != utils.META_MONTHLY {
+		t.Error("Invalid billing cycle set")
+	}
+	acc.SetBillingCycle("", 0, "", time.Now())
+	if acc.BillingCycle != nil {
+		t.Error("Billing cycle not removed")
+	}
+}
+
+func TestBillingCycleCloseActions(t *testing.T) {
+	accountingStorage.SetActions("BC_CLOSED", Actions{&Action{ActionType: TOPUP, BalanceType: utils.MONETARY, Direction: OUTBOUND, Balance: &Balance{Value: 1}}})
+	accountingStorage.GetActions("BC_CLOSED", true) // caches them
+	acc := &Account{Id: "*out:cgrates.org:postpaid", BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 10}}},
+		BillingCycle: &BillingCycle{Period: utils.META_WEEKLY, AnchorDay: 1, ActionsId: "BC_CLOSED", Start: time.Date(2015, 3, 16, 0, 0, 0, 0, time.UTC)}}
+	a := &Action{ActionType: CLOSE_BILLING_CYCLE}
+	if err := closeBillingCycleAction(acc, nil, a, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(acc.BillingCycle.Closed) == 0 || acc.BillingCycle.Closed[0].Balances[utils.MONETARY+OUTBOUND] != 10 {
+		t.Errorf("Wrong closed cycles: %+v", acc.BillingCycle.Closed)
+	}
+	if acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 11 {
+		t.Error("Billing cycle actions not executed: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+}
@@ -29,9 +29,17 @@ import (
 type CallCost struct {
 	Direction, Category, Tenant, Subject, Account, Destination, TOR string
 	Cost                                                            float64
+	Currency                                                        string  // currency of the rates, empty for the default one
+	DebitedCost                                                     float64 // cost taken out of the account, in its own currency
+	DebitedCurrency                                                 string
+	Tax                                                             float64 // taxes on top of the net cost
+	GrossCost                                                       float64 // cost including the taxes
 	Timespans                                                       TimeSpans
+	ConnectFeeIncrement                                             *Increment // connect fee taken out of the balances, nil if not debited
+	MinCostIncrement                                                *Increment // top up debited for reaching the minimum cost of the call
 	deductConnectFee                                                bool
 	maxCostDisconect                                                bool
+	taxRate                                                         float64
 }
 
 // Merges the received timespan if they are similar (same activation period, same interval, same minute info.
@@ -52,6 +60,12 @@ func (cc *CallCost) Merge(other *CallCost) {
 		cc.Timespans = append(cc.Timespans, other.Timespans...)
 	}
 	cc.Cost += other.Cost
+	if cc.ConnectFeeIncrement == nil {
+		cc.ConnectFeeIncrement = other.ConnectFeeIncrement
+	}
+	if cc.MinCostIncrement == nil {
+		cc.MinCostIncrement = other.MinCostIncrement
+	}
 }
 
 func (cc *CallCost) GetStartTime() time.Time {
@@ -76,12 +90,88 @@ func (cc *CallCost) GetDuration() (td time.Duration) {
 }
 
 func (cc *CallCost) GetConnectFee() float64 {
+	if rating := cc.getFirstRating(); rating != nil {
+		return rating.ConnectFee
+	}
+	return 0
+}
+
+// The rating of the call start, carrying the per call rules (connect fee, minimum cost, short calls)
+func (cc *CallCost) getFirstRating() *RIRate {
 	if len(cc.Timespans) == 0 ||
-		cc.Timespans[0].RateInterval == nil ||
-		cc.Timespans[0].RateInterval.Rating == nil {
-		return 0
+		cc.Timespans[0].RateInterval == nil {
+		return nil
+	}
+	return cc.Timespans[0].RateInterval.Rating
+}
+
+func (cc *CallCost) GetMinCost() float64 {
+	if rating := cc.getFirstRating(); rating != nil {
+		return rating.MinCost
 	}
-	return cc.Timespans[0].RateInterval.Rating.ConnectFee
+	return 0
+}
+
+// Returns the usage the call is billed for, never less than the minimum duration of the rate
+func (cc *CallCost) GetBillableUsage(usage time.Duration) time.Duration {
+	if rating := cc.getFirstRating(); rating != nil && usage < rating.MinDuration {
+		return rating.MinDuration
+	}
+	return usage
+}
+
+// Calls shorter than the FreeUnder of the rate are not charged
+func (cc *CallCost) IsFreeUsage(usage time.Duration) bool {
+	rating := cc.getFirstRating()
+	return rating != nil && usage < rating.FreeUnder
+}
+
+// Applies the minimum cost and the free short calls rules on the cost of a call with the given usage
+func (cc *CallCost) applyShortCallRules(usage time.Duration) {
+	if cc.IsFreeUsage(usage) {
+		cc.Cost = 0
+		return
+	}
+	if minCost := cc.GetMinCost(); cc.Cost < minCost {
+		cc.Cost = minCost
+	}
+}
+
+// Returns the adjustments of the fees debited upfront for a session ending with the given usage,
+// usageCost being what remains charged for the timespans. An increment with negative cost charges
+// the part of the minimum cost left uncovered.
+func (cc *CallCost) GetFeeRefunds(usage time.Duration, usageCost float64) (refunds Increments) {
+	if cc.IsFreeUsage(usage) {
+		for _, increment := range []*Increment{cc.ConnectFeeIncrement, cc.MinCostIncrement} {
+			if increment != nil {
+				refunds = append(refunds, increment)
+			}
+		}
+		return
+	}
+	charged := usageCost
+	if cc.ConnectFeeIncrement != nil {
+		if usage == 0 && cc.getFirstRating().ConnectFeeOnAnswer {
+			refunds = append(refunds, cc.ConnectFeeIncrement)
+		} else {
+			charged += cc.ConnectFeeIncrement.Cost
+		}
+	}
+	topUp := 0.0
+	if minCost := cc.GetMinCost(); charged < minCost {
+		topUp = minCost - charged
+	}
+	feeIncrement := cc.MinCostIncrement
+	if feeIncrement == nil {
+		if topUp == 0 || cc.ConnectFeeIncrement == nil {
+			return // nothing debited upfront to adjust against
+		}
+		feeIncrement = &Increment{Cost: 0, BalanceInfo: cc.ConnectFeeIncrement.BalanceInfo}
+	}
+	if adjustment := utils.Round(feeIncrement.Cost-topUp, globalRoundingDecimals, utils.ROUNDING_MIDDLE); adjustment != 0 {
+		refunds = append(refunds, &Increment{Cost: adjustment, BalanceInfo: feeIncrement.BalanceInfo})
+	}
+	return
 }
 
 // Creates a CallDescriptor structure copying related data from CallCost
@@ -282,3 +282,36 @@ func TestCallCostToDataCostError(t *testing.T) {
 	}
 
 }*/
+
+func TestCallCostGetFeeRefunds(t *testing.T) {
+	rating := &RIRate{ConnectFee: 0.1, MinDuration: 30 * time.Second, MinCost: 0.5, FreeUnder: 5 * time.Second, ConnectFeeOnAnswer: true}
+	cc := &CallCost{
+		Timespans:           TimeSpans{&TimeSpan{RateInterval: &RateInterval{Rating: rating}}},
+		ConnectFeeIncrement: &Increment{Cost: 0.1, BalanceInfo: &BalanceInfo{MoneyBalanceUuid: "money"}},
+		MinCostIncrement:    &Increment{Cost: 0.1, BalanceInfo: &BalanceInfo{MoneyBalanceUuid: "money"}},
+	}
+	if usage := cc.GetBillableUsage(10 * time.Second); usage != 30*time.Second {
+		t.Error("Wrong billable usage: ", usage)
+	}
+	if usage := cc.GetBillableUsage(40 * time.Second); usage != 40*time.Second {
+		t.Error("Wrong billable usage: ", usage)
+	}
+	if refunds := cc.GetFeeRefunds(3*time.Second, 0); len(refunds) != 2 || refunds.GetTotalCost() != 0.2 {
+		t.Errorf("Fees of free call not refunded: %+v", refunds)
+	}
+	// the call reached the minimum cost on its own
+	if refunds := cc.GetFeeRefunds(40*time.Second, 0.6); len(refunds) != 1 || refunds[0].Cost != 0.1 {
+		t.Errorf("Top up not refunded: %+v", refunds)
+	}
+	if refunds := cc.GetFeeRefunds(30*time.Second, 0.3); len(refunds) != 0 {
+		t.Errorf("Wrong refunds: %+v", refunds)
+	}
+	// less usage left charged than at debit, the shortfall is charged
+	if refunds := cc.GetFeeRefunds(30*time.Second, 0.2); len(refunds) != 1 || refunds[0].Cost != -0.1 {
+		t.Errorf("Shortfall not charged: %+v", refunds)
+	}
+	rating.FreeUnder = 0
+	if refunds := cc.GetFeeRefunds(0, 0.3); len(refunds) != 2 || refunds[0] != cc.ConnectFeeIncrement || refunds[1].Cost != -0.1 {
+		t.Errorf("Connect fee of unanswered call not refunded: %+v", refunds)
+	}
+}
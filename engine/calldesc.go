@@ -118,12 +118,20 @@ type CallDescriptor struct {
 	RatingInfos                           RatingInfos
 	Increments                            Increments
 	TOR                                   string // used unit balances selector
+	CgrId                                 string // originating call, recorded in the accounts ledger
 	// session limits
 	MaxRate      float64
 	MaxRateUnit  time.Duration
 	MaxCostSoFar float64
 	account      *Account
-	testCallcost *CallCost // testing purpose only!
+	tierOffsets  map[string]time.Duration // usage cumulated in the tier periods before this call
+	tierLoc      *time.Location           // zone the tier periods are cut in, loaded by tierLocation
+	taxRate      float64                  // sum of the taxes on the call, loaded by getTaxRate
+	taxLoaded    bool
+	resolved     bool             // destination already looked up in the ported numbers
+	sandbox      *ratingSandbox   // isolated rating data of a what-if simulation, nil for the live one
+	trace        *CostExplanation // rating decisions recorded by ExplainCost, nil otherwise
+	testCallcost *CallCost        // testing purpose only!
 }
 
 func (cd *CallDescriptor) ValidateCallData() error {
@@ -158,6 +166,7 @@ Restores the activation periods for the specified prefix from storage.
 func (cd *CallDescriptor) LoadRatingPlans() (err error) {
 	err = cd.getRatingPlansForPrefix(cd.GetKey(cd.Subject), 1)
 	if err != nil || !cd.continousRatingInfos() {
+		cd.tracef(TRACE_FALLBACK, "no complete rating for subject %s, trying the default subject %s", cd.Subject, FALLBACK_SUBJECT)
 		// use the default subject
 		err = cd.getRatingPlansForPrefix(cd.GetKey(FALLBACK_SUBJECT), 1)
 	}
@@ -176,10 +185,12 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 		err = errors.New("Max fallback recursion depth reached!" + key)
 		return
 	}
-	rpf, err := dataStorage.GetRatingProfile(key, false)
+	rpf, err := cd.getRatingStorage().GetRatingProfile(key, false)
 	if err != nil || rpf == nil {
+		cd.tracef(TRACE_RATING_PROFILE, "no rating profile %s", key)
 		return err
 	}
+	cd.tracef(TRACE_RATING_PROFILE, "using rating profile %s", key)
 	if err = rpf.GetRatingPlansForPrefix(cd); err != nil || !cd.continousRatingInfos() {
 		// try rating profile fallback
 		recursionDepth++
@@ -195,6 +206,8 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 					Direction:   cd.Direction,
 					Tenant:      cd.Tenant,
 					Destination: cd.Destination,
+					sandbox:     cd.sandbox,
+					trace:       cd.trace,
 				}
 				if index == 0 {
 					tempCD.TimeStart = cd.TimeStart
@@ -207,6 +220,7 @@ func (cd *CallDescriptor) getRatingPlansForPrefix(key string, recursionDepth int
 					tempCD.TimeEnd = cd.RatingInfos[index+1].ActivationTime
 				}
 				for _, fbk := range ri.FallbackKeys {
+					cd.tracef(TRACE_FALLBACK, "rating from %v not covered, trying fallback key %s", tempCD.TimeStart, fbk)
 					if err := tempCD.getRatingPlansForPrefix(fbk, recursionDepth); err != nil {
 						continue
 					}
@@ -283,6 +297,7 @@ func (cd *CallDescriptor) GetKey(subject string) string {
 	// check if subject is alias
 	if rs, err := cache2go.GetCached(RP_ALIAS_PREFIX + utils.RatingSubjectAliasKey(cd.Tenant, subject)); err == nil {
 		realSubject := rs.(string)
+		cd.tracef(TRACE_ALIAS, "subject %s is an alias of %s", subject, realSubject)
 		subject = realSubject
 		cd.Subject = realSubject
 	}
@@ -339,6 +354,12 @@ func (cd *CallDescriptor) splitInTimeSpans() (timespans []*TimeSpan) {
 		//log.Printf("TS: %+v", timespans[i])
 		rp := timespans[i].ratingInfo
 		// Logger.Debug(fmt.Sprintf("rp: %+v", rp))
+		if rp.Timezone != "" { // match the timings on the wall clock of the rating profile
+			if loc, err := utils.GetLocation(rp.Timezone); err == nil {
+				timespans[i].TimeStart = timespans[i].TimeStart.In(loc)
+				timespans[i].TimeEnd = timespans[i].TimeEnd.In(loc)
+			}
+		}
 		//timespans[i].RatingPlan = nil
 		rp.RateIntervals.Sort()
 		for _, interval := range rp.RateIntervals {
@@ -366,6 +387,9 @@ func (cd *CallDescriptor) splitInTimeSpans() (timespans []*TimeSpan) {
 
 	//Logger.Debug(fmt.Sprintf("After SplitByRateInterval: %+v", timespans))
 	//log.Printf("After SplitByRateInterval: %+v", timespans[0].RateInterval.Timing)
+	if cd.LoopIndex == 0 {
+		cd.extendToMinDuration(timespans)
+	}
 	timespans = cd.roundTimeSpansToIncrement(timespans)
 	// Logger.Debug(fmt.Sprintf("After round: %+v", timespans))
 	//log.Printf("After round: %+v", timespans[0].RateInterval.Timing)
@@ -396,6 +420,19 @@ func (cd *CallDescriptor) roundTimeSpansToIncrement(timespans TimeSpans) []*Time
 	return timespans
 }
 
+// calls shorter than the minimum duration of the rate are billed as lasting that long,
+// the missing duration is added at the end of the last timespan
+func (cd *CallDescriptor) extendToMinDuration(timespans TimeSpans) {
+	lastTs := timespans[len(timespans)-1]
+	if lastTs.RateInterval == nil || lastTs.RateInterval.Rating == nil {
+		return
+	}
+	if missing := lastTs.RateInterval.Rating.MinDuration - lastTs.DurationIndex; missing > 0 {
+		lastTs.TimeEnd = lastTs.TimeEnd.Add(missing)
+		lastTs.DurationIndex += missing
+	}
+}
+
 // Returns call descripor's total duration
 func (cd *CallDescriptor) GetDuration() time.Duration {
 	return cd.TimeEnd.Sub(cd.TimeStart)
@@ -415,11 +452,13 @@ func (cd *CallDescriptor) GetCost() (*CallCost, error) {
 	if cd.TOR == "" {
 		cd.TOR = utils.VOICE
 	}
+	cd.resolveDestination()
 	err := cd.LoadRatingPlans()
 	if err != nil {
 		Logger.Err(fmt.Sprintf("error getting cost for key <%s>: %s", cd.GetKey(cd.Subject), err.Error()))
 		return &CallCost{Cost: -1}, err
 	}
+	cd.applyVolumeTiers()
 
 	timespans := cd.splitInTimeSpans()
 	cost := 0.0
@@ -432,14 +471,25 @@ func (cd *CallDescriptor) GetCost() (*CallCost, error) {
 		}
 		cost += ts.getCost()
 	}
+	cd.traceTimespans(timespans)
 	//startIndex := len(fmt.Sprintf("%s:%s:%s:", cd.Direction, cd.Tenant, cd.Category))
 	cc := cd.CreateCallCost()
 	cc.Cost = cost
 	cc.Timespans = timespans
+	cc.Currency = cc.GetCurrency()
+	if cd.LoopIndex == 0 {
+		cc.applyShortCallRules(cd.DurationIndex)
+		if cc.Cost != cost {
+			cd.tracef(TRACE_RATE_INTERVAL, "minimum cost and short call rules of the rate changed the cost from %v to %v", cost, cc.Cost)
+		}
+	}
 
 	// global rounding
 	roundingDecimals, roundingMethod := cc.GetLongestRounding()
+	unrounded := cc.Cost
 	cc.Cost = utils.Round(cc.Cost, roundingDecimals, roundingMethod)
+	cd.tracef(TRACE_ROUNDING, "cost %v rounded %s to %d decimals: %v", unrounded, roundingMethod, roundingDecimals, cc.Cost)
+	cc.applyTax(cd.getTaxRate(), roundingDecimals, roundingMethod)
 	//Logger.Info(fmt.Sprintf("<Rater> Get Cost: %s => %v", cd.GetKey(), cc))
 	cc.Timespans.Compress()
 	return cc, err
@@ -457,6 +507,12 @@ func (origCD *CallDescriptor) getMaxSessionDuration(origAcc *Account) (time.Dura
 	if account.AllowNegative {
 		return -1, nil
 	}
+	if account.CreditLimit > 0 {
+		// the default balance can go negative down to the credit limit
+		account.GetDefaultMoneyBalance(origCD.Direction).Value += account.CreditLimit
+	}
+	// what is reserved for the other sessions is not available to this one
+	account.withholdReservations(origCD.CgrId, time.Now())
 	if origCD.DurationIndex < origCD.TimeEnd.Sub(origCD.TimeStart) {
 		origCD.DurationIndex = origCD.TimeEnd.Sub(origCD.TimeStart)
 	}
@@ -503,20 +559,20 @@ func (origCD *CallDescriptor) getMaxSessionDuration(origAcc *Account) (time.Dura
 }
 
 func (cd *CallDescriptor) GetMaxSessionDuration() (duration time.Duration, err error) {
-	if account, err := cd.getAccount(); err != nil || account == nil {
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
 		Logger.Err(fmt.Sprintf("Could not get user balance for <%s>: %s.", cd.GetAccountKey(), err.Error()))
 		return 0, err
-	} else {
-		if memberIds, err := account.GetUniqueSharedGroupMembers(cd); err == nil {
-			AccLock.Guard(func() (interface{}, error) {
-				duration, err = cd.getMaxSessionDuration(account)
-				return 0, err
-			}, memberIds...)
-		} else {
+	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
 			return 0, err
 		}
-		return duration, err
-	}
+		duration, err = cd.getMaxSessionDuration(account)
+		return 0, err
+	}, lockIds...)
+	return duration, err
 }
 
 // Interface method used to add/substract an amount of cents or bonus seconds (as returned by GetCost method)
@@ -531,44 +587,62 @@ func (cd *CallDescriptor) debit(account *Account, dryRun bool, goNegative bool)
 	if cd.TOR == "" {
 		cd.TOR = utils.VOICE
 	}
+	cd.resolveDestination()
+	if cd.tierOffsets == nil {
+		cd.loadTierOffsets(account)
+	}
+	startTime := cd.TimeStart
 	//log.Printf("Debit CD: %+v", cd)
 	cc, err = account.debitCreditBalance(cd, !dryRun, dryRun, goNegative)
 	//log.Print("HERE: ", cc, err)
 	if err != nil {
 		Logger.Err(fmt.Sprintf("<Rater> Error getting cost for account key <%s>: %s", cd.GetAccountKey(), err.Error()))
+		cd.tracef(TRACE_BALANCE, "debit failed: %v", err)
 		//return
 	}
+	cd.traceBalanceUsage(cc)
+	if !dryRun {
+		account.addTieredUsage(cc, cd.tierLocation())
+	}
 	cost := 0.0
 	// calculate call cost after balances
 	if cc.deductConnectFee { // add back the connectFee
 		cost += cc.GetConnectFee()
 	}
+	if cc.MinCostIncrement != nil {
+		cost += cc.MinCostIncrement.Cost
+	}
 	for _, ts := range cc.Timespans {
 		cost += ts.getCost()
 		cost = utils.Round(cost, globalRoundingDecimals, utils.ROUNDING_MIDDLE) // just get rid of the extra decimals
 	}
 	cc.Cost = cost
+	cc.applyTax(cd.getTaxRate(), globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	cc.setDebitedCost(account, startTime)
 	cc.Timespans.Compress()
 	//log.Printf("OUT CC: ", cc)
 	return
 }
 
 func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
-	// lock all group members
-	if account, err := cd.getAccount(); err != nil || account == nil {
+	if cc := cd.getFreeCallCost(); cc != nil {
+		return cc, nil // nothing to take out of the balances
+	}
+	// lock all group members, the account is loaded again under the lock so no reservation is lost
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
 		Logger.Err(fmt.Sprintf("Could not get user balance for <%s>: %s.", cd.GetAccountKey(), err.Error()))
 		return nil, err
-	} else {
-		if memberIds, err := account.GetUniqueSharedGroupMembers(cd); err == nil {
-			AccLock.Guard(func() (interface{}, error) {
-				cc, err = cd.debit(account, false, true)
-				return 0, err
-			}, memberIds...)
-		} else {
-			return nil, err
-		}
-		return cc, err
 	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
+			return 0, err
+		}
+		cc, err = cd.debit(account, false, true)
+		return 0, err
+	}, lockIds...)
+	return cc, err
 }
 
 // Interface method used to add/substract an amount of cents or bonus seconds (as returned by GetCost method)
@@ -576,49 +650,69 @@ func (cd *CallDescriptor) Debit() (cc *CallCost, err error) {
 // This methods combines the Debit and GetMaxSessionDuration and will debit the max available time as returned
 // by the GetMaxSessionTime method. The amount filed has to be filled in call descriptor.
 func (cd *CallDescriptor) MaxDebit() (cc *CallCost, err error) {
-	if account, err := cd.getAccount(); err != nil || account == nil {
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
 		Logger.Err(fmt.Sprintf("Could not get user balance for <%s>: %s.", cd.GetAccountKey(), err.Error()))
 		return nil, err
-	} else {
-		//log.Printf("ACC: %+v", account)
-		if memberIds, err := account.GetUniqueSharedGroupMembers(cd); err == nil {
-			AccLock.Guard(func() (interface{}, error) {
-				remainingDuration, err := cd.getMaxSessionDuration(account)
-				//log.Print("AFTER MAX SESSION: ", cd)
-				if err != nil || remainingDuration == 0 {
-					cc, err = new(CallCost), fmt.Errorf("no more credit: %v", err)
-					return 0, err
-				}
-				//log.Print("Remaining: ", remainingDuration)
-				if remainingDuration > 0 { // for postpaying client returns -1
-					initialDuration := cd.GetDuration()
-					cd.TimeEnd = cd.TimeStart.Add(remainingDuration)
-					cd.DurationIndex -= initialDuration - remainingDuration
-				}
-				cc, err = cd.debit(account, false, true)
-				//log.Print(balanceMap[0].Value, balanceMap[1].Value)
-				return 0, err
-			}, memberIds...)
-		} else {
-			return nil, err
+	}
+	_, lockErr := AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
+			return 0, err
+		}
+		remainingDuration, err := cd.getMaxSessionDuration(account)
+		//log.Print("AFTER MAX SESSION: ", cd)
+		if err != nil || remainingDuration == 0 {
+			cc = new(CallCost)
+			return 0, nil // reported by the empty call cost
+		}
+		//log.Print("Remaining: ", remainingDuration)
+		if remainingDuration > 0 { // for postpaying client returns -1
+			initialDuration := cd.GetDuration()
+			cd.TimeEnd = cd.TimeStart.Add(remainingDuration)
+			cd.DurationIndex -= initialDuration - remainingDuration
 		}
+		cc, err = cd.debit(account, false, true)
+		//log.Print(balanceMap[0].Value, balanceMap[1].Value)
+		return 0, nil
+	}, lockIds...)
+	if lockErr != nil {
+		return nil, lockErr
 	}
 	return cc, err
 }
 
+// Returns the cost of a complete call shorter than the FreeUnder of its rate, nil for the calls to be charged
+func (cd *CallDescriptor) getFreeCallCost() *CallCost {
+	if cd.LoopIndex != 0 || cd.GetDuration() == 0 {
+		return nil
+	}
+	cc, err := cd.Clone().GetCost()
+	if err != nil || !cc.IsFreeUsage(cd.GetDuration()) {
+		return nil
+	}
+	return cc
+}
+
 func (cd *CallDescriptor) RefundIncrements() (left float64, err error) {
 	accountsCache := make(map[string]*Account)
+	ledger := newLedgerSnapshot(nil)
 	for _, increment := range cd.Increments {
 		account, found := accountsCache[increment.BalanceInfo.AccountId]
 		if !found {
 			if acc, err := accountingStorage.GetAccount(increment.BalanceInfo.AccountId); err == nil && acc != nil {
 				account = acc
 				accountsCache[increment.BalanceInfo.AccountId] = account
+				ledger.add(account)
 				defer accountingStorage.SetAccount(account)
 			}
 		}
 		account.refundIncrement(increment, cd.Direction, cd.TOR, true)
+		if account != nil && account.Id == cd.GetAccountKey() {
+			account.refundTieredUsage(cd.Direction, cd.TOR, cd.TimeStart.In(cd.tierLocation()), increment.Duration)
+		}
 	}
+	ledger.write(cd.CgrId, nil)
 	return 0.0, err
 }
 
@@ -641,6 +735,7 @@ func (cd *CallDescriptor) CreateCallCost() *CallCost {
 		Destination:      cd.Destination,
 		TOR:              cd.TOR,
 		deductConnectFee: cd.LoopIndex == 0,
+		taxRate:          cd.taxRate,
 	}
 }
 
@@ -662,7 +757,14 @@ func (cd *CallDescriptor) Clone() *CallDescriptor {
 		FallbackSubject: cd.FallbackSubject,
 		//RatingInfos:     cd.RatingInfos,
 		//Increments:      cd.Increments,
-		TOR: cd.TOR,
+		TOR:         cd.TOR,
+		tierOffsets: cd.tierOffsets,
+		tierLoc:     cd.tierLoc,
+		taxRate:     cd.taxRate,
+		taxLoaded:   cd.taxLoaded,
+		resolved:    cd.resolved,
+		sandbox:     cd.sandbox,
+		trace:       cd.trace,
 	}
 }
 
@@ -686,6 +788,7 @@ func (cd *CallDescriptor) GetLCRFromStorage() (*LCR, error) {
 }
 
 func (cd *CallDescriptor) GetLCR(stats StatsInterface) (*LCRCost, error) {
+	cd.resolveDestination()
 	lcr, err := cd.GetLCRFromStorage()
 	if err != nil {
 		return nil, err
@@ -197,6 +197,45 @@ func TestSplitSpansWeekend(t *testing.T) {
 	}
 }
 
+func TestSplitSpansTimezoneDST(t *testing.T) {
+	rateIntervals := []*RateInterval{
+		&RateInterval{
+			Timing: &RITiming{StartTime: "00:00:00"},
+			Rating: &RIRate{RoundingMethod: "*up", RoundingDecimals: 6,
+				Rates: RateGroups{&Rate{Value: 1, RateIncrement: time.Second, RateUnit: time.Second}}},
+		},
+		&RateInterval{
+			Timing: &RITiming{StartTime: "08:00:00"},
+			Rating: &RIRate{RoundingMethod: "*up", RoundingDecimals: 6,
+				Rates: RateGroups{&Rate{Value: 2, RateIncrement: time.Second, RateUnit: time.Second}}},
+		},
+	}
+	// Europe/Berlin switches from CET (+01:00) to CEST (+02:00) on 2015-03-29 at 02:00 local time
+	for _, call := range []struct {
+		start, end, peak time.Time
+	}{
+		{time.Date(2015, 3, 27, 6, 59, 30, 0, time.UTC), time.Date(2015, 3, 27, 7, 0, 30, 0, time.UTC), time.Date(2015, 3, 27, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2015, 3, 29, 0, 30, 0, 0, time.UTC), time.Date(2015, 3, 29, 7, 0, 0, 0, time.UTC), time.Date(2015, 3, 29, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2015, 3, 30, 5, 59, 30, 0, time.UTC), time.Date(2015, 3, 30, 6, 0, 30, 0, time.UTC), time.Date(2015, 3, 30, 6, 0, 0, 0, time.UTC)},
+	} {
+		cd := &CallDescriptor{Direction: OUTBOUND, Category: "call", TOR: utils.VOICE, Tenant: "tz.org", Subject: "1001", Destination: "49",
+			TimeStart: call.start, TimeEnd: call.end, DurationIndex: call.end.Sub(call.start),
+			RatingInfos: RatingInfos{&RatingInfo{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), RateIntervals: rateIntervals,
+				Timezone: "Europe/Berlin"}},
+		}
+		timespans := cd.splitInTimeSpans()
+		if len(timespans) != 2 {
+			t.Fatalf("Call %v: wrong number of timespans: %d", call.start, len(timespans))
+		}
+		if timespans[0].RateInterval.Timing.StartTime != "00:00:00" || timespans[1].RateInterval.Timing.StartTime != "08:00:00" {
+			t.Errorf("Call %v: wrong rate intervals: %+v %+v", call.start, timespans[0].RateInterval.Timing, timespans[1].RateInterval.Timing)
+		}
+		if !timespans[0].TimeEnd.Equal(call.peak) || !timespans[1].TimeStart.Equal(call.peak) {
+			t.Errorf("Call %v: peak should start at %v, got: %v", call.start, call.peak, timespans[1].TimeStart)
+		}
+	}
+}
+
 func TestSplitSpansRoundToIncrements(t *testing.T) {
 	t1 := time.Date(2013, time.October, 7, 14, 50, 0, 0, time.UTC)
 	t2 := time.Date(2013, time.October, 7, 14, 52, 12, 0, time.UTC)
@@ -0,0 +1,261 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// What one retention policy did (or would do on dry run) during a run
+type CdrArchiveReport struct {
+	Tenant      string
+	TOR         string
+	Action      string
+	OlderThan   time.Time // CDRs with setup time before this one are reached by the policy
+	Cdrs        int64     // number of CDRs reached, including the derived ones
+	ArchiveFile string    // where the CDRs were written, *archive only
+	DryRun      bool
+}
+
+// Applies the retention policies on the stored CDRs, deleting or moving them into compressed archives
+type CdrArchiver struct {
+	cdrDb CdrStorage
+	cfg   *config.CdrArchiverConfig
+	stop  chan struct{}
+}
+
+func NewCdrArchiver(cdrDb CdrStorage, cfg *config.CdrArchiverConfig) *CdrArchiver {
+	return &CdrArchiver{cdrDb: cdrDb, cfg: cfg, stop: make(chan struct{})}
+}
+
+// Runs the policies once every RunInterval, blocking till Stop is called
+func (self *CdrArchiver) Loop() {
+	for {
+		if reports, err := self.Run(self.cfg.DryRun); err != nil {
+			Logger.Err(fmt.Sprintf("<CdrArchiver> Run failed: %s", err.Error()))
+		} else {
+			for _, rpt := range reports {
+				Logger.Info(fmt.Sprintf("<CdrArchiver> %+v", rpt))
+			}
+		}
+		select {
+		case <-self.stop:
+			return
+		case <-time.After(self.cfg.RunInterval):
+		}
+	}
+}
+
+func (self *CdrArchiver) Stop() {
+	close(self.stop)
+}
+
+// Applies all policies once. On dry run the CDRs are only counted.
+func (self *CdrArchiver) Run(dryRun bool) ([]*CdrArchiveReport, error) {
+	now := time.Now()
+	reports := make([]*CdrArchiveReport, len(self.cfg.Policies))
+	for idx, plcy := range self.cfg.Policies {
+		rpt := &CdrArchiveReport{Tenant: plcy.Tenant, TOR: plcy.TOR, Action: plcy.Action,
+			OlderThan: now.AddDate(0, 0, -plcy.RetentionDays), DryRun: dryRun}
+		reports[idx] = rpt
+		fltrs := self.policyFilters(plcy, rpt.OlderThan)
+		if dryRun {
+			for _, fltr := range fltrs {
+				fltr.Count = true
+				if _, cnt, err := self.cdrDb.GetStoredCdrs(fltr); err != nil {
+					return nil, err
+				} else {
+					rpt.Cdrs += cnt
+				}
+			}
+			continue
+		}
+		if err := self.apply(plcy, fltrs, rpt); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+// Builds the filters for the CDRs reached by a policy.
+// Catch-all policies leave out the tenants and TORs having their own policies, hence the most specific policy applies.
+// The tenants with policies for some TORs only get a filter of their own for the other TORs.
+func (self *CdrArchiver) policyFilters(plcy *config.CdrRetentionPolicy, olderThan time.Time) []*utils.CdrsFilter {
+	fltr := &utils.CdrsFilter{SetupTimeEnd: &olderThan}
+	if plcy.Tenant != utils.ANY {
+		fltr.Tenants = []string{plcy.Tenant}
+		self.setPolicyTors(fltr, plcy, plcy.Tenant)
+		return []*utils.CdrsFilter{fltr}
+	}
+	self.setPolicyTors(fltr, plcy, utils.ANY)
+	fltrs := []*utils.CdrsFilter{fltr}
+	for _, other := range self.cfg.Policies {
+		if other.Tenant == utils.ANY || utils.IsSliceMember(fltr.NotTenants, other.Tenant) {
+			continue
+		}
+		fltr.NotTenants = append(fltr.NotTenants, other.Tenant)
+		var tenantTors []string
+		for _, tntPlcy := range self.cfg.Policies {
+			if tntPlcy.Tenant == other.Tenant {
+				tenantTors = append(tenantTors, tntPlcy.TOR)
+			}
+		}
+		if utils.IsSliceMember(tenantTors, utils.ANY) || utils.IsSliceMember(tenantTors, plcy.TOR) {
+			continue // all the CDRs of the tenant reached by this policy have a more specific one
+		}
+		tntFltr := &utils.CdrsFilter{SetupTimeEnd: &olderThan, Tenants: []string{other.Tenant}}
+		self.setPolicyTors(tntFltr, plcy, utils.ANY)
+		if plcy.TOR == utils.ANY {
+			for _, tor := range tenantTors {
+				if !utils.IsSliceMember(tntFltr.NotTors, tor) {
+					tntFltr.NotTors = append(tntFltr.NotTors, tor)
+				}
+			}
+		}
+		fltrs = append(fltrs, tntFltr)
+	}
+	return fltrs
+}
+
+// Restricts the filter to the TOR of the policy, or leaves out the TORs having their own policies within the tenant
+func (self *CdrArchiver) setPolicyTors(fltr *utils.CdrsFilter, plcy *config.CdrRetentionPolicy, tenant string) {
+	if plcy.TOR != utils.ANY {
+		fltr.Tors = []string{plcy.TOR}
+		return
+	}
+	for _, other := range self.cfg.Policies {
+		if other.Tenant == tenant && other.TOR != utils.ANY && !utils.IsSliceMember(fltr.NotTors, other.TOR) {
+			fltr.NotTors = append(fltr.NotTors, other.TOR)
+		}
+	}
+}
+
+// Processes the CDRs in batches, removing them once archived
+func (self *CdrArchiver) apply(plcy *config.CdrRetentionPolicy, fltrs []*utils.CdrsFilter, rpt *CdrArchiveReport) error {
+	var archive *cdrArchiveFile
+	defer func() {
+		if archive != nil {
+			if err := archive.close(); err != nil {
+				Logger.Err(fmt.Sprintf("<CdrArchiver> Closing archive %s: %s", archive.fPath, err.Error()))
+			}
+		}
+	}()
+	for _, fltr := range fltrs {
+		if err := self.applyFilter(plcy, fltr, rpt, &archive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Archives and removes the CDRs matching one of the filters of the policy, the archive is opened with the first CDRs
+func (self *CdrArchiver) applyFilter(plcy *config.CdrRetentionPolicy, fltr *utils.CdrsFilter, rpt *CdrArchiveReport, archive **cdrArchiveFile) error {
+	fltr.Paginator = utils.Paginator{Limit: utils.IntPointer(self.cfg.BatchSize)}
+	var lastCgrIds []string
+	for {
+		cdrs, _, err := self.cdrDb.GetStoredCdrs(fltr)
+		if err != nil {
+			return err
+		}
+		if len(cdrs) == 0 {
+			return nil
+		}
+		var cgrIds []string
+		for _, cdr := range cdrs {
+			if utils.IsSliceMember(lastCgrIds, cdr.CgrId) { // Protect against looping forever on the same CDRs
+				return fmt.Errorf("CDR with cgrid %s still present after removal", cdr.CgrId)
+			}
+			if !utils.IsSliceMember(cgrIds, cdr.CgrId) {
+				cgrIds = append(cgrIds, cdr.CgrId)
+			}
+		}
+		// A batch can end in the middle of the derived CDRs, make sure we get all of them before removing
+		if cdrs, _, err = self.cdrDb.GetStoredCdrs(&utils.CdrsFilter{CgrIds: cgrIds}); err != nil {
+			return err
+		}
+		if plcy.Action == utils.META_ARCHIVE {
+			if *archive == nil {
+				if *archive, err = newCdrArchiveFile(self.cfg.ArchiveDir, plcy); err != nil {
+					return err
+				}
+				rpt.ArchiveFile = (*archive).fPath
+			}
+			if err := (*archive).write(cdrs); err != nil {
+				return err
+			}
+		}
+		if err := self.cdrDb.PurgeStoredCdrs(cgrIds); err != nil {
+			return err
+		}
+		rpt.Cdrs += int64(len(cdrs))
+		lastCgrIds = cgrIds
+	}
+}
+
+// Gzip compressed file with one exported CDR (including cost details) per line
+type cdrArchiveFile struct {
+	fPath string
+	fd    *os.File
+	gzw   *gzip.Writer
+	enc   *json.Encoder
+}
+
+func newCdrArchiveFile(archiveDir string, plcy *config.CdrRetentionPolicy) (*cdrArchiveFile, error) {
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return nil, err
+	}
+	fName := fmt.Sprintf("cdrs_%s_%s_%s.json.gz", strings.TrimPrefix(plcy.Tenant, "*"), strings.TrimPrefix(plcy.TOR, "*"), time.Now().Format("20060102150405"))
+	fPath := path.Join(archiveDir, fName)
+	fd, err := os.OpenFile(fPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	gzw := gzip.NewWriter(fd)
+	return &cdrArchiveFile{fPath: fPath, fd: fd, gzw: gzw, enc: json.NewEncoder(gzw)}, nil
+}
+
+func (self *cdrArchiveFile) write(cdrs []*StoredCdr) error {
+	for _, cdr := range cdrs {
+		if err := self.enc.Encode(cdr.AsExternalCdr()); err != nil {
+			return err
+		}
+	}
+	// Keep what we have written safe before the CDRs get removed
+	if err := self.gzw.Flush(); err != nil {
+		return err
+	}
+	return self.fd.Sync()
+}
+
+func (self *cdrArchiveFile) close() error {
+	if err := self.gzw.Close(); err != nil {
+		self.fd.Close()
+		return err
+	}
+	return self.fd.Close()
+}
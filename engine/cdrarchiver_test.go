@@ -0,0 +1,172 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/config"
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Keeps CDRs in memory, understanding only the filters used by CdrArchiver
+type archiverCdrStorage struct {
+	CdrStorage
+	cdrs []*StoredCdr
+}
+
+func (self *archiverCdrStorage) GetStoredCdrs(fltr *utils.CdrsFilter) ([]*StoredCdr, int64, error) {
+	var cdrs []*StoredCdr
+	for _, cdr := range self.cdrs {
+		if (len(fltr.CgrIds) != 0 && !utils.IsSliceMember(fltr.CgrIds, cdr.CgrId)) ||
+			(len(fltr.Tenants) != 0 && !utils.IsSliceMember(fltr.Tenants, cdr.Tenant)) || utils.IsSliceMember(fltr.NotTenants, cdr.Tenant) ||
+			(len(fltr.Tors) != 0 && !utils.IsSliceMember(fltr.Tors, cdr.TOR)) || utils.IsSliceMember(fltr.NotTors, cdr.TOR) ||
+			(fltr.SetupTimeEnd != nil && !cdr.SetupTime.Before(*fltr.SetupTimeEnd)) {
+			continue
+		}
+		if fltr.Limit != nil && len(cdrs) == *fltr.Limit {
+			break
+		}
+		cdrs = append(cdrs, cdr)
+	}
+	if fltr.Count {
+		return nil, int64(len(cdrs)), nil
+	}
+	return cdrs, 0, nil
+}
+
+func (self *archiverCdrStorage) PurgeStoredCdrs(cgrIds []string) error {
+	var cdrs []*StoredCdr
+	for _, cdr := range self.cdrs {
+		if !utils.IsSliceMember(cgrIds, cdr.CgrId) {
+			cdrs = append(cdrs, cdr)
+		}
+	}
+	self.cdrs = cdrs
+	return nil
+}
+
+func TestCdrArchiverPolicyFilter(t *testing.T) {
+	cfg := &config.CdrArchiverConfig{Policies: []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{Tenant: "cgrates.org", TOR: utils.ANY},
+		&config.CdrRetentionPolicy{Tenant: "cgrates.org", TOR: utils.SMS},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.DATA},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.ANY},
+	}}
+	ca := NewCdrArchiver(nil, cfg)
+	olderThan := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	eFltrs := []*utils.CdrsFilter{
+		&utils.CdrsFilter{Tenants: []string{"cgrates.org"}, NotTors: []string{utils.SMS}, SetupTimeEnd: &olderThan},
+		&utils.CdrsFilter{Tenants: []string{"cgrates.org"}, Tors: []string{utils.SMS}, SetupTimeEnd: &olderThan},
+		&utils.CdrsFilter{NotTenants: []string{"cgrates.org"}, Tors: []string{utils.DATA}, SetupTimeEnd: &olderThan},
+		&utils.CdrsFilter{NotTenants: []string{"cgrates.org"}, NotTors: []string{utils.DATA}, SetupTimeEnd: &olderThan},
+	}
+	for idx, plcy := range cfg.Policies {
+		if fltrs := ca.policyFilters(plcy, olderThan); len(fltrs) != 1 || !reflect.DeepEqual(eFltrs[idx], fltrs[0]) {
+			t.Errorf("Policy %d, expecting: %+v, received: %+v", idx, eFltrs[idx], fltrs)
+		}
+	}
+	// a tenant with a policy for one TOR only is still reached by the catch-all policies for the other TORs
+	cfg = &config.CdrArchiverConfig{Policies: []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{Tenant: "cgrates.org", TOR: utils.SMS},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.VOICE},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.SMS},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.ANY},
+	}}
+	ca = NewCdrArchiver(nil, cfg)
+	eFltrsList := [][]*utils.CdrsFilter{
+		[]*utils.CdrsFilter{&utils.CdrsFilter{Tenants: []string{"cgrates.org"}, Tors: []string{utils.SMS}, SetupTimeEnd: &olderThan}},
+		[]*utils.CdrsFilter{
+			&utils.CdrsFilter{NotTenants: []string{"cgrates.org"}, Tors: []string{utils.VOICE}, SetupTimeEnd: &olderThan},
+			&utils.CdrsFilter{Tenants: []string{"cgrates.org"}, Tors: []string{utils.VOICE}, SetupTimeEnd: &olderThan}},
+		[]*utils.CdrsFilter{&utils.CdrsFilter{NotTenants: []string{"cgrates.org"}, Tors: []string{utils.SMS}, SetupTimeEnd: &olderThan}},
+		[]*utils.CdrsFilter{
+			&utils.CdrsFilter{NotTenants: []string{"cgrates.org"}, NotTors: []string{utils.VOICE, utils.SMS}, SetupTimeEnd: &olderThan},
+			&utils.CdrsFilter{Tenants: []string{"cgrates.org"}, NotTors: []string{utils.SMS, utils.VOICE}, SetupTimeEnd: &olderThan}},
+	}
+	for idx, plcy := range cfg.Policies {
+		if fltrs := ca.policyFilters(plcy, olderThan); !reflect.DeepEqual(eFltrsList[idx], fltrs) {
+			t.Errorf("Policy %d, expecting: %+v, received: %+v", idx, eFltrsList[idx], fltrs)
+		}
+	}
+}
+
+func TestCdrArchiverRun(t *testing.T) {
+	archiveDir, err := ioutil.TempDir("", "cdr_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(archiveDir)
+	old, recent := time.Now().AddDate(0, 0, -40), time.Now().AddDate(0, 0, -5)
+	cdrDb := &archiverCdrStorage{cdrs: []*StoredCdr{
+		&StoredCdr{CgrId: "old1", MediationRunId: utils.DEFAULT_RUNID, Tenant: "cgrates.org", TOR: utils.VOICE, SetupTime: old},
+		&StoredCdr{CgrId: "old1", MediationRunId: "derived", Tenant: "cgrates.org", TOR: utils.VOICE, SetupTime: old},
+		&StoredCdr{CgrId: "old2", MediationRunId: utils.DEFAULT_RUNID, Tenant: "cgrates.org", TOR: utils.VOICE, SetupTime: old},
+		&StoredCdr{CgrId: "old3", MediationRunId: utils.DEFAULT_RUNID, Tenant: "itsyscom.com", TOR: utils.VOICE, SetupTime: old},
+		&StoredCdr{CgrId: "recent1", MediationRunId: utils.DEFAULT_RUNID, Tenant: "cgrates.org", TOR: utils.VOICE, SetupTime: recent},
+	}}
+	cfg := &config.CdrArchiverConfig{ArchiveDir: archiveDir, BatchSize: 1, Policies: []*config.CdrRetentionPolicy{
+		&config.CdrRetentionPolicy{Tenant: "cgrates.org", TOR: utils.ANY, RetentionDays: 30, Action: utils.META_ARCHIVE},
+		&config.CdrRetentionPolicy{Tenant: utils.ANY, TOR: utils.ANY, RetentionDays: 60, Action: utils.META_DELETE},
+	}}
+	ca := NewCdrArchiver(cdrDb, cfg)
+	if rpts, err := ca.Run(true); err != nil {
+		t.Fatal(err)
+	} else if rpts[0].Cdrs != 3 || rpts[1].Cdrs != 0 || len(cdrDb.cdrs) != 5 {
+		t.Errorf("Unexpected dry run: %+v, %+v", rpts[0], rpts[1])
+	}
+	rpts, err := ca.Run(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpts[0].Cdrs != 3 || rpts[0].ArchiveFile == "" || rpts[1].Cdrs != 0 {
+		t.Errorf("Unexpected run: %+v, %+v", rpts[0], rpts[1])
+	}
+	if len(cdrDb.cdrs) != 2 || cdrDb.cdrs[0].CgrId != "old3" || cdrDb.cdrs[1].CgrId != "recent1" {
+		t.Error("Wrong CDRs left: ", cdrDb.cdrs)
+	}
+	fd, err := os.Open(rpts[0].ArchiveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	gzr, err := gzip.NewReader(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archived []string
+	scanner := bufio.NewScanner(gzr)
+	for scanner.Scan() {
+		var cdr ExternalCdr
+		if err := json.Unmarshal(scanner.Bytes(), &cdr); err != nil {
+			t.Fatal(err)
+		}
+		archived = append(archived, cdr.CgrId+"_"+cdr.MediationRunId)
+	}
+	if eArchived := []string{"old1_" + utils.DEFAULT_RUNID, "old1_derived", "old2_" + utils.DEFAULT_RUNID}; !reflect.DeepEqual(eArchived, archived) {
+		t.Errorf("Expecting: %v, received: %v", eArchived, archived)
+	}
+}
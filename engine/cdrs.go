@@ -220,6 +220,7 @@ func (self *CdrServer) getCostFromRater(storedCdr *StoredCdr) (*CallCost, error)
 		TimeStart:     storedCdr.AnswerTime,
 		TimeEnd:       storedCdr.AnswerTime.Add(storedCdr.Usage),
 		DurationIndex: storedCdr.Usage,
+		CgrId:         storedCdr.CgrId,
 	}
 	if utils.IsSliceMember([]string{utils.META_PSEUDOPREPAID, utils.META_POSTPAID, utils.PSEUDOPREPAID, utils.POSTPAID}, storedCdr.ReqType) {
 		if err = self.rater.Debit(cd, cc); err == nil { // Debit has occured, we are forced to write the log, even if CDR store is disabled
@@ -297,6 +298,12 @@ func (self *CdrServer) rateCDR(storedCdr *StoredCdr) error {
 		return errCost
 	} else if qryCC != nil {
 		storedCdr.Cost = qryCC.Cost
+		storedCdr.Currency = qryCC.Currency
+		storedCdr.DebitedCost = qryCC.DebitedCost
+		storedCdr.DebitedCurrency = qryCC.DebitedCurrency
+		storedCdr.Tax = qryCC.Tax
+		storedCdr.GrossCost = qryCC.GrossCost
+		storedCdr.ResolvedDestination = qryCC.Destination
 		storedCdr.CostDetails = qryCC
 	}
 	return nil
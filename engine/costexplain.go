@@ -0,0 +1,221 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Stages of the cost explanation steps
+const (
+	TRACE_DESTINATION    = "destination"
+	TRACE_ALIAS          = "alias"
+	TRACE_RATING_PROFILE = "rating_profile"
+	TRACE_FALLBACK       = "fallback"
+	TRACE_RATE_INTERVAL  = "rate_interval"
+	TRACE_ROUNDING       = "rounding"
+	TRACE_BALANCE        = "balance"
+)
+
+// One decision taken while costing a call
+type CostTraceStep struct {
+	Stage   string
+	Message string
+}
+
+// Step by step trace of how the cost of a call was calculated
+type CostExplanation struct {
+	Steps        []*CostTraceStep
+	CallCost     *CallCost
+	Error        string // rating failure, the steps show how far it went
+	balancesOnly bool   // the call is already rated, only the balance decisions are recorded
+}
+
+// Costs the call through GetCost recording the rating decisions on the way, then shows which balances
+// of the account would pay for it on a dry run debit, leaving the account untouched
+func (cd *CallDescriptor) ExplainCost() *CostExplanation {
+	ce := &CostExplanation{}
+	cd.trace = ce
+	defer func() { cd.trace = nil }()
+	cc, err := cd.GetCost()
+	if err != nil {
+		ce.Error = err.Error()
+		return ce
+	}
+	ce.CallCost = cc
+	ce.balancesOnly = true
+	account, err := cd.getAccount()
+	if err != nil || account == nil {
+		cd.tracef(TRACE_BALANCE, "no usable account %s, balances not checked: %v", cd.GetAccountKey(), err)
+		return ce
+	}
+	memberIds, err := account.GetUniqueSharedGroupMembers(cd)
+	if err != nil {
+		cd.tracef(TRACE_BALANCE, "cannot get the shared groups of account %s: %v", account.Id, err)
+		return ce
+	}
+	dryCD := cd.Clone()
+	dryCD.RatingInfos = nil
+	AccLock.Guard(func() (interface{}, error) {
+		dryCD.debit(account.Clone(), true, false)
+		return 0, nil
+	}, memberIds...)
+	return ce
+}
+
+func (cd *CallDescriptor) tracef(stage, format string, args ...interface{}) {
+	if cd.trace == nil || (cd.trace.balancesOnly && stage != TRACE_BALANCE) {
+		return
+	}
+	cd.trace.Steps = append(cd.trace.Steps, &CostTraceStep{Stage: stage, Message: fmt.Sprintf(format, args...)})
+}
+
+// Records the rate interval chosen for each of the timespans
+func (cd *CallDescriptor) traceTimespans(timespans TimeSpans) {
+	if cd.trace == nil {
+		return
+	}
+	for _, ts := range timespans {
+		if ts.RateInterval == nil {
+			cd.tracef(TRACE_RATE_INTERVAL, "%v - %v: no rate interval", ts.TimeStart, ts.TimeEnd)
+			continue
+		}
+		if ri := ts.ratingInfo; ri != nil {
+			cd.tracef(TRACE_RATE_INTERVAL, "%v - %v: rating plan %s of %s, destination %s on prefix %s",
+				ts.TimeStart, ts.TimeEnd, ri.RatingPlanId, ri.MatchedSubject, ri.MatchedDestId, ri.MatchedPrefix)
+		}
+		rate, rateIncrement, rateUnit := ts.RateInterval.GetRateParameters(ts.GetGroupStart())
+		cd.tracef(TRACE_RATE_INTERVAL, "%v - %v: timing %s, weight %v, rate %v per %v in increments of %v, rounding %s to %d decimals, cost %v",
+			ts.TimeStart, ts.TimeEnd, ts.RateInterval.Timing.describe(), ts.RateInterval.Weight, rate, rateUnit, rateIncrement,
+			ts.RateInterval.Rating.RoundingMethod, ts.RateInterval.Rating.RoundingDecimals, ts.getCost())
+	}
+}
+
+func (rit *RITiming) describe() string {
+	if rit == nil {
+		return "always"
+	}
+	desc := fmt.Sprintf("years %v months %v month days %v week days %v from %s", rit.Years, rit.Months, rit.MonthDays, rit.WeekDays, rit.StartTime)
+	if rit.EndTime != "" {
+		desc += " to " + rit.EndTime
+	}
+	if rit.Calendar != "" {
+		if rit.ExcludeCalendar {
+			desc += " except the days of calendar " + rit.Calendar
+		} else {
+			desc += " and the days of calendar " + rit.Calendar
+		}
+	}
+	return desc
+}
+
+// Records why the balances of the given type were considered for paying the call or skipped
+func (cd *CallDescriptor) traceBalances(acc *Account, balanceType string, useful BalanceChain) {
+	if cd.trace == nil {
+		return
+	}
+	for _, b := range acc.BalanceMap[balanceType] {
+		var reason string
+		switch {
+		case b.IsExpired():
+			reason = fmt.Sprintf("expired on %v", b.ExpirationDate)
+		case !acc.AllowNegative && b.SharedGroup == "" && b.Value <= 0:
+			reason = "empty"
+		case !b.MatchCategory(cd.Category):
+			reason = fmt.Sprintf("not for category %s", cd.Category)
+		case !useful.HasBalance(b):
+			reason = fmt.Sprintf("destinations %s not matching %s", b.DestinationIds, cd.Destination)
+		case !b.IsActiveAt(cd.TimeStart):
+			reason = "timings not active at the call start"
+		}
+		if reason != "" {
+			cd.tracef(TRACE_BALANCE, "%s balance %s skipped: %s", balanceType, balanceRef(b.Uuid), reason)
+		} else {
+			cd.tracef(TRACE_BALANCE, "%s balance %s considered: value %v, weight %v", balanceType, balanceRef(b.Uuid), b.Value, b.Weight)
+		}
+	}
+	for _, b := range useful {
+		if b.account != nil && b.account.Id != acc.Id {
+			cd.tracef(TRACE_BALANCE, "%s balance %s of account %s considered through shared group %s", balanceType, balanceRef(b.Uuid), b.account.Id, b.SharedGroup)
+		}
+	}
+}
+
+// Records what each balance paid out of the call
+func (cd *CallDescriptor) traceBalanceUsage(cc *CallCost) {
+	if cd.trace == nil {
+		return
+	}
+	var order []string
+	durations := make(map[string]time.Duration)
+	costs := make(map[string]float64)
+	for _, ts := range cc.Timespans {
+		for _, incr := range ts.Increments {
+			if incr.BalanceInfo == nil {
+				continue
+			}
+			if incr.BalanceInfo.UnitBalanceUuid != "" || incr.UnitInfo != nil {
+				ref := "units " + balanceRef(incr.BalanceInfo.UnitBalanceUuid)
+				if _, seen := durations[ref]; !seen {
+					order = append(order, ref)
+				}
+				durations[ref] += incr.Duration
+			}
+			if incr.BalanceInfo.MoneyBalanceUuid != "" {
+				ref := "money " + balanceRef(incr.BalanceInfo.MoneyBalanceUuid)
+				if _, seen := durations[ref]; !seen {
+					order = append(order, ref)
+				}
+				durations[ref] += incr.Duration
+				costs[ref] += incr.Cost
+			}
+		}
+	}
+	for _, fee := range []*Increment{cc.ConnectFeeIncrement, cc.MinCostIncrement} {
+		if fee != nil && fee.BalanceInfo != nil {
+			cd.tracef(TRACE_BALANCE, "money balance %s pays the fee of %v", balanceRef(fee.BalanceInfo.MoneyBalanceUuid), fee.Cost)
+		}
+	}
+	if len(order) == 0 {
+		cd.tracef(TRACE_BALANCE, "no balance pays for the call")
+	}
+	for _, ref := range order {
+		cd.tracef(TRACE_BALANCE, "%s balance pays %v of the call, cost %v", ref, durations[ref], utils.Round(costs[ref], globalRoundingDecimals, utils.ROUNDING_MIDDLE))
+	}
+}
+
+func balanceRef(uuid string) string {
+	if uuid == "" {
+		return "<no uuid>"
+	}
+	return uuid
+}
+
+// String form of the trace, one step per line
+func (ce *CostExplanation) String() string {
+	lines := make([]string, len(ce.Steps))
+	for i, step := range ce.Steps {
+		lines[i] = fmt.Sprintf("[%s] %s", step.Stage, step.Message)
+	}
+	return strings.Join(lines, "\n")
+}
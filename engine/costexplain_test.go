@@ -0,0 +1,80 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExplainCost(t *testing.T) {
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 34, 10, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "a1",
+		Account:     "minu",
+		Destination: "0723",
+	}
+	acnt, err := accountingStorage.GetAccount(cd.GetAccountKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := acnt.BalanceMap["*voice*out"].GetTotalValue()
+	expected, err := cd.Clone().GetCost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ce := cd.ExplainCost()
+	if ce.Error != "" || ce.CallCost == nil || ce.CallCost.Cost != expected.Cost {
+		t.Fatalf("Explained cost differs from GetCost: %+v", ce)
+	}
+	stages := make(map[string]int)
+	for _, step := range ce.Steps {
+		stages[step.Stage]++
+	}
+	for _, stage := range []string{TRACE_ALIAS, TRACE_RATING_PROFILE, TRACE_DESTINATION, TRACE_RATE_INTERVAL, TRACE_ROUNDING, TRACE_BALANCE} {
+		if stages[stage] == 0 {
+			t.Errorf("No %s step in:\n%s", stage, ce)
+		}
+	}
+	if acnt, err = accountingStorage.GetAccount(cd.GetAccountKey()); err != nil {
+		t.Fatal(err)
+	} else if after := acnt.BalanceMap["*voice*out"].GetTotalValue(); after != before {
+		t.Errorf("Explaining debited the account: %v -> %v", before, after)
+	}
+}
+
+func TestExplainCostNoRating(t *testing.T) {
+	cd := &CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 34, 10, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "nosuchtenant",
+		Subject:     "nosuchsubject",
+		Destination: "0723",
+	}
+	ce := cd.ExplainCost()
+	if ce.Error == "" || len(ce.Steps) == 0 || ce.Steps[len(ce.Steps)-1].Stage != TRACE_RATING_PROFILE {
+		t.Errorf("Failure not explained: %+v\n%s", ce, ce)
+	}
+}
@@ -20,11 +20,13 @@ package engine
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
-
-	"github.com/cgrates/cgrates/cache2go"
+	"sync"
+	"time"
 
 	"github.com/cgrates/cgrates/history"
+	"github.com/cgrates/cgrates/utils"
 )
 
 /*
@@ -71,37 +73,108 @@ func (d *Destination) GetHistoryRecord() history.Record {
 	}
 }
 
+// Destination ids indexed on their prefixes, built out of the destinations cached by CacheRating.
+// While caching, the changes go into a staged copy which replaces the live index only on commit.
+var (
+	dstIndex        = NewPrefixTrie()
+	dstIndexStaged  *PrefixTrie
+	dstIndexStaging bool
+	dstIndexUpdated time.Time
+	dstIndexMux     sync.RWMutex
+)
+
+// Starts staging the index changes, called together with cache2go.BeginTransaction
+func beginDestinationIndexing() {
+	dstIndexMux.Lock()
+	defer dstIndexMux.Unlock()
+	dstIndexStaging = true
+	dstIndexStaged = nil
+}
+
+// Applies or drops the staged changes
+func endDestinationIndexing(commit bool) {
+	dstIndexMux.Lock()
+	defer dstIndexMux.Unlock()
+	if commit && dstIndexStaged != nil {
+		dstIndex = dstIndexStaged
+		dstIndexUpdated = time.Now()
+	}
+	dstIndexStaged = nil
+	dstIndexStaging = false
+}
+
+// Drops all the prefixes, to be rebuilt out of the destinations loaded afterwards
+func resetDestinationIndex() {
+	dstIndexMux.Lock()
+	defer dstIndexMux.Unlock()
+	if dstIndexStaging {
+		dstIndexStaged = NewPrefixTrie()
+		return
+	}
+	dstIndex = NewPrefixTrie()
+	dstIndexUpdated = time.Now()
+}
+
+// Index to be changed, the live one gets copied on the first change while staging. Needs dstIndexMux locked.
+func writableDestinationIndex() *PrefixTrie {
+	if !dstIndexStaging {
+		dstIndexUpdated = time.Now()
+		return dstIndex
+	}
+	if dstIndexStaged == nil {
+		dstIndexStaged = dstIndex.Clone()
+	}
+	return dstIndexStaged
+}
+
+// Indexes the destination on its prefixes
+func indexDestination(dest *Destination) {
+	dstIndexMux.Lock()
+	defer dstIndexMux.Unlock()
+	idx := writableDestinationIndex()
+	for _, p := range dest.Prefixes {
+		idx.Add(p, dest.Id)
+	}
+}
+
+// Returns the cached prefixes matching the number together with their destination ids, the longest prefix first
+func MatchDestinations(number string) []*PrefixMatch {
+	dstIndexMux.RLock()
+	defer dstIndexMux.RUnlock()
+	return dstIndex.Match(number, MIN_PREFIX_MATCH)
+}
+
 // Reverse search in cache to see if prefix belongs to destination id
 func CachedDestHasPrefix(destId, prefix string) bool {
-	if cached, err := cache2go.GetCached(DESTINATION_PREFIX + prefix); err == nil {
-		_, found := cached.(map[interface{}]struct{})[destId]
-		return found
+	dstIndexMux.RLock()
+	defer dstIndexMux.RUnlock()
+	return dstIndex.HasId(prefix, destId)
+}
+
+// Number of cached destination prefixes
+func CountCachedDestPrefixes() int {
+	dstIndexMux.RLock()
+	defer dstIndexMux.RUnlock()
+	return dstIndex.Len()
+}
+
+// Time passed since the index holding the prefix was last updated
+func CachedDestPrefixAge(prefix string) (time.Duration, error) {
+	dstIndexMux.RLock()
+	defer dstIndexMux.RUnlock()
+	if len(dstIndex.Get(prefix)) == 0 {
+		return 0, errors.New(utils.ERR_NOT_FOUND)
 	}
-	return false
+	return time.Since(dstIndexUpdated), nil
 }
 
+// Removes the destinations out of the index, the ids can come with DESTINATION_PREFIX as out of the storage keys
 func CleanStalePrefixes(destIds []string) {
-	prefixMap, err := cache2go.GetAllEntries(DESTINATION_PREFIX)
-	if err != nil {
-		return
-	}
-	for prefix, idIDs := range prefixMap {
-		dIDs := idIDs.Value().(map[interface{}]struct{})
-		changed := false
-		for _, searchedDID := range destIds {
-			if _, found := dIDs[searchedDID]; found {
-				if len(dIDs) == 1 {
-					// remove de prefix from cache
-					cache2go.RemKey(DESTINATION_PREFIX + prefix)
-				} else {
-					// delete the destination from list and put the new list in chache
-					delete(dIDs, searchedDID)
-					changed = true
-				}
-			}
-		}
-		if changed {
-			cache2go.Cache(DESTINATION_PREFIX+prefix, dIDs)
-		}
+	ids := make([]string, len(destIds))
+	for i, dId := range destIds {
+		ids[i] = strings.TrimPrefix(dId, DESTINATION_PREFIX)
 	}
+	dstIndexMux.Lock()
+	defer dstIndexMux.Unlock()
+	writableDestinationIndex().RemoveIds(ids...)
 }
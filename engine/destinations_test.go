@@ -82,7 +82,7 @@ func TestDestinationGetExists(t *testing.T) {
 
 func TestDestinationGetExistsCache(t *testing.T) {
 	dataStorage.GetDestination("NAT")
-	if _, err := cache2go.GetCached(DESTINATION_PREFIX + "0256"); err != nil {
+	if len(MatchDestinations("0256")) == 0 {
 		t.Error("Destination not cached:", err)
 	}
 }
@@ -126,20 +126,41 @@ func TestNonCachedDestWrongPrefix(t *testing.T) {
 }
 
 func TestCleanStalePrefixes(t *testing.T) {
-	x := struct{}{}
-	cache2go.Cache(DESTINATION_PREFIX+"1", map[interface{}]struct{}{"D1": x, "D2": x})
-	cache2go.Cache(DESTINATION_PREFIX+"2", map[interface{}]struct{}{"D1": x})
-	cache2go.Cache(DESTINATION_PREFIX+"3", map[interface{}]struct{}{"D2": x})
+	indexDestination(&Destination{Id: "D1", Prefixes: []string{"1", "2"}})
+	indexDestination(&Destination{Id: "D2", Prefixes: []string{"1", "3"}})
 	CleanStalePrefixes([]string{"D1"})
-	if r, err := cache2go.GetCached(DESTINATION_PREFIX + "1"); err != nil || len(r.(map[interface{}]struct{})) != 1 {
+	if r := dstIndex.Get("1"); len(r) != 1 {
 		t.Error("Error cleaning stale destination ids", r)
 	}
-	if r, err := cache2go.GetCached(DESTINATION_PREFIX + "2"); err == nil {
+	if r := dstIndex.Get("2"); len(r) != 0 {
 		t.Error("Error removing stale prefix: ", r)
 	}
-	if r, err := cache2go.GetCached(DESTINATION_PREFIX + "3"); err != nil || len(r.(map[interface{}]struct{})) != 1 {
+	if r := dstIndex.Get("3"); len(r) != 1 {
 		t.Error("Error performing stale cleaning: ", r)
 	}
+	CleanStalePrefixes([]string{DESTINATION_PREFIX + "D2"})
+	if r := dstIndex.Get("1"); len(r) != 0 {
+		t.Error("Error cleaning stale destination key", r)
+	}
+}
+
+func TestDestinationIndexingRollback(t *testing.T) {
+	beginDestinationIndexing()
+	indexDestination(&Destination{Id: "STAGED", Prefixes: []string{"99911"}})
+	if CachedDestHasPrefix("STAGED", "99911") {
+		t.Error("Staged prefix visible before commit")
+	}
+	endDestinationIndexing(false)
+	if CachedDestHasPrefix("STAGED", "99911") {
+		t.Error("Staged prefix visible after rollback")
+	}
+	beginDestinationIndexing()
+	indexDestination(&Destination{Id: "STAGED", Prefixes: []string{"99911"}})
+	endDestinationIndexing(true)
+	if !CachedDestHasPrefix("STAGED", "99911") || !CachedDestHasPrefix("NAT", "0256") {
+		t.Error("Staged prefix not committed")
+	}
+	CleanStalePrefixes([]string{"STAGED"})
 }
 
 /********************************* Benchmarks **********************************/
@@ -0,0 +1,159 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Conversion rates from one currency into another, changing over time
+type ExchangeRate struct {
+	Id    string // FromCurrency:ToCurrency
+	Rates ExchangeRateActivations
+}
+
+type ExchangeRateActivation struct {
+	ActivationTime time.Time
+	Rate           float64 // units of the destination currency for one unit of the source currency
+}
+
+type ExchangeRateActivations []*ExchangeRateActivation
+
+func (eras ExchangeRateActivations) Len() int {
+	return len(eras)
+}
+
+func (eras ExchangeRateActivations) Swap(i, j int) {
+	eras[i], eras[j] = eras[j], eras[i]
+}
+
+func (eras ExchangeRateActivations) Less(i, j int) bool {
+	return eras[i].ActivationTime.Before(eras[j].ActivationTime)
+}
+
+func (eras ExchangeRateActivations) Sort() {
+	sort.Sort(eras)
+}
+
+func ExchangeRateKey(fromCurrency, toCurrency string) string {
+	return utils.ConcatenatedKey(fromCurrency, toCurrency)
+}
+
+// Returns the rate active at the specified time, 0 if none was activated yet
+func (er *ExchangeRate) GetRateAt(t time.Time) (rate float64) {
+	er.Rates.Sort()
+	for _, era := range er.Rates {
+		if era.ActivationTime.After(t) {
+			break
+		}
+		rate = era.Rate
+	}
+	return
+}
+
+// Adds the activation replacing an existing one at the same time
+func (er *ExchangeRate) AddActivation(eras ...*ExchangeRateActivation) {
+	for _, era := range eras {
+		found := false
+		for _, existing := range er.Rates {
+			if existing.ActivationTime.Equal(era.ActivationTime) {
+				existing.Rate = era.Rate
+				found = true
+				break
+			}
+		}
+		if !found {
+			er.Rates = append(er.Rates, era)
+		}
+	}
+	er.Rates.Sort()
+}
+
+// Returns the rate converting amounts between the two currencies at the specified time.
+// An empty currency stands for the one of the deployment so no conversion happens.
+// When only the opposite conversion is defined its inverse is used.
+func GetExchangeRate(fromCurrency, toCurrency string, t time.Time) (float64, error) {
+	if fromCurrency == "" || toCurrency == "" || fromCurrency == toCurrency {
+		return 1, nil
+	}
+	if er, err := dataStorage.GetExchangeRate(ExchangeRateKey(fromCurrency, toCurrency), false); err == nil && er != nil {
+		if rate := er.GetRateAt(t); rate > 0 {
+			return rate, nil
+		}
+	}
+	if er, err := dataStorage.GetExchangeRate(ExchangeRateKey(toCurrency, fromCurrency), false); err == nil && er != nil {
+		if rate := er.GetRateAt(t); rate > 0 {
+			return 1 / rate, nil
+		}
+	}
+	return 0, fmt.Errorf("%s:no exchange rate from %s to %s at %v", utils.ERR_NOT_FOUND, fromCurrency, toCurrency, t)
+}
+
+// Amount taken out of the money balance for the cost, in the currency of that balance and including the taxes
+func (bi *BalanceInfo) GetMoneyAmount(cost float64) float64 {
+	amount := cost * (1 + bi.TaxRate)
+	if bi.ExchangeRate > 0 {
+		return amount * bi.ExchangeRate
+	}
+	return amount
+}
+
+// Currency of the rates the cost was calculated with
+func (cc *CallCost) GetCurrency() string {
+	for _, ts := range cc.Timespans {
+		if ts.RateInterval != nil && ts.RateInterval.Rating != nil && ts.RateInterval.Rating.Currency != "" {
+			return ts.RateInterval.Rating.Currency
+		}
+	}
+	return ""
+}
+
+// Currency the account keeps its money in, the one of the first monetary balance having it set
+func (acc *Account) GetCurrency(direction string) string {
+	for _, b := range acc.BalanceMap[utils.MONETARY+direction] {
+		if b.Currency != "" {
+			return b.Currency
+		}
+	}
+	return ""
+}
+
+// Records the cost converted into the currency of the account it was debited from
+func (cc *CallCost) setDebitedCost(acc *Account, t time.Time) {
+	cc.Currency = cc.GetCurrency()
+	cc.DebitedCost, cc.DebitedCurrency = cc.GrossCost, cc.Currency
+	if acc == nil {
+		return
+	}
+	accCurrency := acc.GetCurrency(cc.Direction)
+	if accCurrency == "" || accCurrency == cc.Currency {
+		return
+	}
+	rate, err := GetExchangeRate(cc.Currency, accCurrency, t)
+	if err != nil {
+		Logger.Warning(fmt.Sprintf("<Rater> Cannot convert cost of account <%s>: %s", acc.Id, err.Error()))
+		return
+	}
+	cc.DebitedCost = utils.Round(cc.GrossCost*rate, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	cc.DebitedCurrency = accCurrency
+}
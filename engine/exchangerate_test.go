@@ -0,0 +1,91 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExchangeRateGetRateAt(t *testing.T) {
+	er := &ExchangeRate{Id: ExchangeRateKey("GBP", "EUR")}
+	er.AddActivation(&ExchangeRateActivation{ActivationTime: time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC), Rate: 1.4},
+		&ExchangeRateActivation{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.3})
+	er.AddActivation(&ExchangeRateActivation{ActivationTime: time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC), Rate: 1.35})
+	if len(er.Rates) != 2 {
+		t.Fatal("Activation at the same time not replaced: ", er.Rates)
+	}
+	if rate := er.GetRateAt(time.Date(2014, 12, 31, 0, 0, 0, 0, time.UTC)); rate != 0 {
+		t.Error("Rate before the first activation: ", rate)
+	}
+	if rate := er.GetRateAt(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)); rate != 1.3 {
+		t.Error("Wrong rate: ", rate)
+	}
+	if rate := er.GetRateAt(time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 1.35 {
+		t.Error("Wrong rate: ", rate)
+	}
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	er := &ExchangeRate{Id: ExchangeRateKey("GBP", "EUR")}
+	er.AddActivation(&ExchangeRateActivation{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.25})
+	if err := dataStorage.SetExchangeRate(er); err != nil {
+		t.Fatal(err)
+	}
+	chf := &ExchangeRate{Id: ExchangeRateKey("GBP", "CHF")}
+	chf.AddActivation(&ExchangeRateActivation{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.5})
+	if err := dataStorage.SetExchangeRate(chf); err != nil {
+		t.Fatal(err)
+	}
+	t1 := time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)
+	// read from cache, stored rates are seen after reloading it
+	if _, err := GetExchangeRate("GBP", "CHF", t1); err == nil {
+		t.Error("Rate found before caching")
+	}
+	if err := dataStorage.CacheRating([]string{}, []string{}, []string{}, []string{}, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if rate, err := GetExchangeRate("GBP", "EUR", t1); err != nil || rate != 1.25 {
+		t.Error("Wrong direct rate: ", rate, err)
+	}
+	if rate, err := GetExchangeRate("GBP", "CHF", t1); err != nil || rate != 1.5 {
+		t.Error("Wrong rate after caching: ", rate, err)
+	}
+	if rate, err := GetExchangeRate("EUR", "GBP", t1); err != nil || rate != 0.8 {
+		t.Error("Wrong inverse rate: ", rate, err)
+	}
+	if rate, err := GetExchangeRate("", "GBP", t1); err != nil || rate != 1 {
+		t.Error("Default currency should not be converted: ", rate, err)
+	}
+	if _, err := GetExchangeRate("GBP", "EUR", time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Rate found before activation")
+	}
+	if _, err := GetExchangeRate("GBP", "USD", t1); err == nil {
+		t.Error("Rate found for unknown currencies")
+	}
+}
+
+func TestBalanceInfoGetMoneyAmount(t *testing.T) {
+	if amount := (&BalanceInfo{}).GetMoneyAmount(0.5); amount != 0.5 {
+		t.Error("Wrong unconverted amount: ", amount)
+	}
+	if amount := (&BalanceInfo{ExchangeRate: 0.8}).GetMoneyAmount(0.5); amount != 0.4 {
+		t.Error("Wrong converted amount: ", amount)
+	}
+}
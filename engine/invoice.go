@@ -0,0 +1,297 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Invoice line types
+const (
+	INVOICE_USAGE     = "*usage"
+	INVOICE_RECURRING = "*recurring"
+	INVOICE_CREDIT    = "*credit"
+)
+
+// Charges of an account over one billing period
+type Invoice struct {
+	Number      int64 // sequential, given when first stored and kept on regeneration
+	AccountId   string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Lines       []*InvoiceLine
+	Net         float64
+	Tax         float64
+	Gross       float64
+	Generated   time.Time
+}
+
+// Charges of the same kind aggregated into one invoice line
+type InvoiceLine struct {
+	Type          string        // *usage for the rated CDRs, *recurring for the debits of the actions, *credit for what they gave back
+	Category      string        // category of the CDRs, id of the actions for the recurring charges
+	DestinationId string        // destination the CDRs were rated on
+	Quantity      int64         // number of CDRs or of debits
+	Usage         time.Duration // cumulated usage of the CDRs
+	Net           float64
+	Tax           float64
+	Gross         float64
+}
+
+// Aggregates the rated CDRs and the money charged by the actions into invoice lines grouped by category and destination.
+// The taxes of the CDRs are the ones calculated when rating, the charges of the actions are taxed with taxRate.
+func NewInvoice(accountId string, periodStart, periodEnd time.Time, cdrs []*StoredCdr, ledger []*LedgerEntry,
+	taxRate func(t time.Time) float64) *Invoice {
+	inv := &Invoice{AccountId: accountId, PeriodStart: periodStart, PeriodEnd: periodEnd, Generated: time.Now()}
+	lines := make(map[string]*InvoiceLine)
+	getLine := func(typ, category, destId string) *InvoiceLine {
+		key := utils.ConcatenatedKey(typ, category, destId)
+		line, exists := lines[key]
+		if !exists {
+			line = &InvoiceLine{Type: typ, Category: category, DestinationId: destId}
+			lines[key] = line
+			inv.Lines = append(inv.Lines, line)
+		}
+		return line
+	}
+	for _, cdr := range cdrs {
+		if cdr.Cost < 0 { // not rated
+			continue
+		}
+		line := getLine(INVOICE_USAGE, cdr.Category, cdrDestinationId(cdr))
+		line.Quantity++
+		line.Usage += cdr.Usage
+		line.Net += cdr.Cost
+		line.Tax += cdr.Tax
+		if cdr.GrossCost != 0 {
+			line.Gross += cdr.GrossCost
+		} else {
+			line.Gross += cdr.Cost + cdr.Tax
+		}
+	}
+	for _, entry := range ledger {
+		if !strings.HasPrefix(entry.BalanceType, utils.MONETARY) || !isInvoiceCharge(entry) {
+			continue
+		}
+		lineType := INVOICE_RECURRING
+		if entry.Delta > 0 { // corrections giving money back, eg: prorated debits on cancellation
+			lineType = INVOICE_CREDIT
+		}
+		line := getLine(lineType, entry.ActionId, "")
+		line.Quantity++
+		net := -entry.Delta
+		tax := utils.Round(net*taxRate(entry.Timestamp), globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+		line.Net += net
+		line.Tax += tax
+		line.Gross += net + tax
+	}
+	sort.Sort(InvoiceLines(inv.Lines))
+	for _, line := range inv.Lines {
+		line.Net = utils.Round(line.Net, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+		line.Tax = utils.Round(line.Tax, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+		line.Gross = utils.Round(line.Gross, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+		inv.Net += line.Net
+		inv.Tax += line.Tax
+		inv.Gross += line.Gross
+	}
+	inv.Net = utils.Round(inv.Net, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	inv.Tax = utils.Round(inv.Tax, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	inv.Gross = utils.Round(inv.Gross, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	return inv
+}
+
+// Tells the ledger movements charged to the customer: the debit actions, together with their corrections, and the
+// prorated topups taken back on cancellation. Resets, transfers, rollovers and topups only move credit around.
+func isInvoiceCharge(entry *LedgerEntry) bool {
+	switch entry.ActionType {
+	case DEBIT, DEBIT_PRORATED:
+		return entry.Delta != 0
+	case TOPUP_PRORATED:
+		return entry.Delta < 0
+	}
+	return false
+}
+
+// Destination id the CDR was rated on, the dialed number when the cost details are missing
+func cdrDestinationId(cdr *StoredCdr) string {
+	if cdr.CostDetails != nil {
+		for _, ts := range cdr.CostDetails.Timespans {
+			if ts.MatchedDestId != "" {
+				return ts.MatchedDestId
+			}
+		}
+	}
+	return cdr.Destination
+}
+
+// Builds the invoice of the account for the period out of the CDRs and the ledger kept in StorDb, then stores it.
+// With the number of an existing invoice the invoice is regenerated, keeping its number. New invoices are refused
+// for periods overlapping the ones invoiced already, those are to be regenerated.
+func GenerateInvoice(cdrDb CdrStorage, tenant, account, direction string, periodStart, periodEnd time.Time, number int64) (*Invoice, error) {
+	accountId := utils.AccountKey(tenant, account, direction)
+	if number == 0 {
+		invs, err := cdrDb.GetInvoices(accountId, 0)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the invoices: %v", err)
+		}
+		for _, inv := range invs {
+			if inv.PeriodStart.Before(periodEnd) && periodStart.Before(inv.PeriodEnd) {
+				return nil, fmt.Errorf("%s:invoice %d covers the period, use RegenerateInvoice", utils.ERR_EXISTS, inv.Number)
+			}
+		}
+	}
+	minCost := 0.0
+	cdrs, _, err := cdrDb.GetStoredCdrs(&utils.CdrsFilter{
+		RunIds:          []string{utils.DEFAULT_RUNID},
+		Directions:      []string{direction},
+		Tenants:         []string{tenant},
+		Accounts:        []string{account},
+		AnswerTimeStart: &periodStart,
+		AnswerTimeEnd:   &periodEnd,
+		CostStart:       &minCost,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get the CDRs: %v", err)
+	}
+	ledger, err := cdrDb.GetLedgerEntries(accountId, periodStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the ledger: %v", err)
+	}
+	inv := NewInvoice(accountId, periodStart, periodEnd, cdrs, ledger, func(t time.Time) float64 {
+		return GetTaxRate(tenant, "", "", t) // the taxes on all categories and destinations
+	})
+	inv.Number = number
+	if err := cdrDb.SetInvoice(inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Builds again an invoice stored before, over the same period, eg: after rerating its CDRs
+func RegenerateInvoice(cdrDb CdrStorage, number int64) (*Invoice, error) {
+	invs, err := cdrDb.GetInvoices("", number)
+	if err != nil {
+		return nil, err
+	}
+	if len(invs) == 0 {
+		return nil, errors.New(utils.ERR_NOT_FOUND)
+	}
+	inv := invs[0]
+	accIdParts := strings.SplitN(inv.AccountId, utils.CONCATENATED_KEY_SEP, 3)
+	if len(accIdParts) != 3 {
+		return nil, fmt.Errorf("invalid account id: %s", inv.AccountId)
+	}
+	return GenerateInvoice(cdrDb, accIdParts[1], accIdParts[2], accIdParts[0], inv.PeriodStart, inv.PeriodEnd, inv.Number)
+}
+
+// Invoice export formats
+const (
+	INVOICE_FORMAT_JSON = "*json"
+	INVOICE_FORMAT_CSV  = "*csv"
+	INVOICE_FORMAT_HTML = "*html"
+)
+
+// Renders the invoice in one of the export formats, the HTML one with the default template if tmpl is nil
+func (inv *Invoice) Render(w io.Writer, format string, tmpl *template.Template) error {
+	switch format {
+	case INVOICE_FORMAT_JSON:
+		out, err := json.MarshalIndent(inv, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case INVOICE_FORMAT_CSV:
+		return inv.writeCsv(w)
+	case INVOICE_FORMAT_HTML:
+		if tmpl == nil {
+			tmpl = defaultInvoiceTemplate
+		}
+		return tmpl.Execute(w, inv)
+	}
+	return fmt.Errorf("unsupported invoice format: %s", format)
+}
+
+func (inv *Invoice) writeCsv(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	records := [][]string{
+		[]string{"Number", "Account", "PeriodStart", "PeriodEnd"},
+		[]string{strconv.FormatInt(inv.Number, 10), inv.AccountId, inv.PeriodStart.Format(time.RFC3339), inv.PeriodEnd.Format(time.RFC3339)},
+		[]string{"Type", "Category", "DestinationId", "Quantity", "Usage", "Net", "Tax", "Gross"},
+	}
+	for _, line := range inv.Lines {
+		records = append(records, []string{line.Type, line.Category, line.DestinationId, strconv.FormatInt(line.Quantity, 10),
+			strconv.FormatFloat(line.Usage.Seconds(), 'f', -1, 64), formatAmount(line.Net, 0, -1), formatAmount(line.Tax, 0, -1), formatAmount(line.Gross, 0, -1)})
+	}
+	records = append(records, []string{"*total", "", "", "", "", formatAmount(inv.Net, 0, -1), formatAmount(inv.Tax, 0, -1), formatAmount(inv.Gross, 0, -1)})
+	if err := csvWriter.WriteAll(records); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
+
+var defaultInvoiceTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Invoice {{.Number}}</title></head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Account: {{.AccountId}}<br>Period: {{.PeriodStart.Format "2006-01-02"}} - {{.PeriodEnd.Format "2006-01-02"}}</p>
+<table>
+<tr><th>Type</th><th>Category</th><th>Destination</th><th>Quantity</th><th>Usage</th><th>Net</th><th>Tax</th><th>Gross</th></tr>
+{{range .Lines}}<tr><td>{{.Type}}</td><td>{{.Category}}</td><td>{{.DestinationId}}</td><td>{{.Quantity}}</td><td>{{.Usage}}</td><td>{{.Net}}</td><td>{{.Tax}}</td><td>{{.Gross}}</td></tr>
+{{end}}<tr><th colspan="5">Total</th><th>{{.Net}}</th><th>{{.Tax}}</th><th>{{.Gross}}</th></tr>
+</table>
+</body>
+</html>
+`))
+
+type InvoiceLines []*InvoiceLine
+
+func (il InvoiceLines) Len() int {
+	return len(il)
+}
+
+func (il InvoiceLines) Swap(i, j int) {
+	il[i], il[j] = il[j], il[i]
+}
+
+// Position of the line types on the invoice
+var invoiceLineOrder = map[string]int{INVOICE_USAGE: 0, INVOICE_RECURRING: 1, INVOICE_CREDIT: 2}
+
+// Usage lines first, then the recurring charges and the credits, each by category and destination
+func (il InvoiceLines) Less(i, j int) bool {
+	if il[i].Type != il[j].Type {
+		return invoiceLineOrder[il[i].Type] < invoiceLineOrder[il[j].Type]
+	}
+	if il[i].Category != il[j].Category {
+		return il[i].Category < il[j].Category
+	}
+	return il[i].DestinationId < il[j].DestinationId
+}
@@ -0,0 +1,174 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func testInvoice() *Invoice {
+	answerTime := time.Date(2015, 3, 10, 10, 0, 0, 0, time.UTC)
+	cdrs := []*StoredCdr{
+		&StoredCdr{Category: "call", Destination: "49151", Usage: time.Minute, Cost: 1, Tax: 0.19, GrossCost: 1.19, AnswerTime: answerTime,
+			CostDetails: &CallCost{Timespans: TimeSpans{&TimeSpan{MatchedDestId: "GERMANY_MOBILE"}}}},
+		&StoredCdr{Category: "call", Destination: "49152", Usage: 2 * time.Minute, Cost: 2, Tax: 0.38, GrossCost: 2.38, AnswerTime: answerTime,
+			CostDetails: &CallCost{Timespans: TimeSpans{&TimeSpan{MatchedDestId: "GERMANY_MOBILE"}}}},
+		&StoredCdr{Category: "call", Destination: "4930", Usage: time.Minute, Cost: 0.5, AnswerTime: answerTime},
+		&StoredCdr{Category: "sms", Destination: "49151", Usage: 1, Cost: 0.1, Tax: 0.019, GrossCost: 0.119, AnswerTime: answerTime},
+		&StoredCdr{Category: "call", Destination: "4930", Usage: time.Minute, Cost: -1, AnswerTime: answerTime}, // not rated
+	}
+	ledger := []*LedgerEntry{
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -10, ActionId: "MONTHLY_FEE", ActionType: DEBIT, Timestamp: answerTime},
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: 20, ActionId: "TOPUP", ActionType: TOPUP, Timestamp: answerTime},             // credit
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -0.5, CgrId: "call1", Timestamp: answerTime},                                 // already on the CDRs
+		&LedgerEntry{BalanceType: utils.VOICE + OUTBOUND, Delta: -60, ActionId: "MINUTES_RESET", ActionType: DEBIT, Timestamp: answerTime},       // units, not charged
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -5, ActionId: "RESET", ActionType: RESET_ACCOUNT, Timestamp: answerTime},     // not a charge
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -3, ActionId: "MOVE", ActionType: TRANSFER_BALANCE, Timestamp: answerTime},   // not a charge
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -4, ActionId: "LINE_FEE", ActionType: DEBIT_PRORATED, Timestamp: answerTime}, // prorated charge
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: 1, ActionId: "LINE_FEE", ActionType: DEBIT_PRORATED, Timestamp: answerTime},  // given back on cancellation
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: 2, ActionId: "BUNDLE", ActionType: TOPUP_PRORATED, Timestamp: answerTime},    // prorated topup, not a charge
+		&LedgerEntry{BalanceType: utils.MONETARY + OUTBOUND, Delta: -0.5, ActionId: "BUNDLE", ActionType: TOPUP_PRORATED, Timestamp: answerTime}, // taken back on cancellation
+	}
+	return NewInvoice("*out:cgrates.org:1001", time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC), cdrs, ledger,
+		func(t time.Time) float64 { return 0.19 })
+}
+
+func TestInvoiceNew(t *testing.T) {
+	inv := testInvoice()
+	expected := []*InvoiceLine{
+		&InvoiceLine{Type: INVOICE_USAGE, Category: "call", DestinationId: "4930", Quantity: 1, Usage: time.Minute, Net: 0.5, Gross: 0.5},
+		&InvoiceLine{Type: INVOICE_USAGE, Category: "call", DestinationId: "GERMANY_MOBILE", Quantity: 2, Usage: 3 * time.Minute, Net: 3, Tax: 0.57, Gross: 3.57},
+		&InvoiceLine{Type: INVOICE_USAGE, Category: "sms", DestinationId: "49151", Quantity: 1, Usage: 1, Net: 0.1, Tax: 0.019, Gross: 0.119},
+		&InvoiceLine{Type: INVOICE_RECURRING, Category: "BUNDLE", Quantity: 1, Net: 0.5, Tax: 0.095, Gross: 0.595},
+		&InvoiceLine{Type: INVOICE_RECURRING, Category: "LINE_FEE", Quantity: 1, Net: 4, Tax: 0.76, Gross: 4.76},
+		&InvoiceLine{Type: INVOICE_RECURRING, Category: "MONTHLY_FEE", Quantity: 1, Net: 10, Tax: 1.9, Gross: 11.9},
+		&InvoiceLine{Type: INVOICE_CREDIT, Category: "LINE_FEE", Quantity: 1, Net: -1, Tax: -0.19, Gross: -1.19},
+	}
+	if !reflect.DeepEqual(inv.Lines, expected) {
+		for _, line := range inv.Lines {
+			t.Logf("%+v", line)
+		}
+		t.Error("Wrong invoice lines")
+	}
+	if inv.Net != 17.1 || inv.Tax != 3.154 || inv.Gross != 20.254 {
+		t.Errorf("Wrong invoice totals: %v %v %v", inv.Net, inv.Tax, inv.Gross)
+	}
+}
+
+func TestInvoiceRender(t *testing.T) {
+	inv := testInvoice()
+	inv.Number = 7
+	var out bytes.Buffer
+	if err := inv.Render(&out, INVOICE_FORMAT_JSON, nil); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Invoice
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded.Number != 7 || len(decoded.Lines) != 7 {
+		t.Errorf("Wrong JSON invoice: %s, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := inv.Render(&out, INVOICE_FORMAT_CSV, nil); err != nil {
+		t.Fatal(err)
+	}
+	csvLines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(csvLines) != 11 || csvLines[1] != "7,*out:cgrates.org:1001,2015-03-01T00:00:00Z,2015-04-01T00:00:00Z" ||
+		csvLines[4] != "*usage,call,GERMANY_MOBILE,2,180,3,0.57,3.57" || csvLines[10] != "*total,,,,,17.1,3.154,20.254" {
+		t.Errorf("Wrong CSV invoice: %q", csvLines)
+	}
+	out.Reset()
+	if err := inv.Render(&out, INVOICE_FORMAT_HTML, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "<h1>Invoice 7</h1>") || !strings.Contains(out.String(), "<td>MONTHLY_FEE</td>") {
+		t.Error("Wrong HTML invoice: ", out.String())
+	}
+	out.Reset()
+	tmpl := template.Must(template.New("custom").Parse(`{{.Number}}:{{.Gross}}`))
+	if err := inv.Render(&out, INVOICE_FORMAT_HTML, tmpl); err != nil || out.String() != "7:20.254" {
+		t.Errorf("Wrong custom template rendering: %s, %v", out.String(), err)
+	}
+	if err := inv.Render(&out, "*pdf", nil); err == nil {
+		t.Error("Rendered unsupported format")
+	}
+}
+
+// Keeps the invoices in memory, without CDRs nor ledger
+type invoiceRecorder struct {
+	CdrStorage
+	invoices []*Invoice
+}
+
+func (ir *invoiceRecorder) GetStoredCdrs(*utils.CdrsFilter) ([]*StoredCdr, int64, error) {
+	return nil, 0, nil
+}
+
+func (ir *invoiceRecorder) GetLedgerEntries(string, time.Time, time.Time) ([]*LedgerEntry, error) {
+	return nil, nil
+}
+
+func (ir *invoiceRecorder) SetInvoice(inv *Invoice) error {
+	if inv.Number == 0 {
+		inv.Number = int64(len(ir.invoices) + 1)
+		ir.invoices = append(ir.invoices, inv)
+	} else {
+		ir.invoices[inv.Number-1] = inv
+	}
+	return nil
+}
+
+func (ir *invoiceRecorder) GetInvoices(accountId string, number int64) (invs []*Invoice, err error) {
+	for _, inv := range ir.invoices {
+		if (accountId == "" || inv.AccountId == accountId) && (number == 0 || inv.Number == number) {
+			invs = append(invs, inv)
+		}
+	}
+	return
+}
+
+func TestInvoiceGenerateDuplicate(t *testing.T) {
+	ir := new(invoiceRecorder)
+	march, april, may := time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, 5, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := GenerateInvoice(ir, "cgrates.org", "1001", OUTBOUND, march, april, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateInvoice(ir, "cgrates.org", "1001", OUTBOUND, march, april, 0); err == nil || !strings.HasPrefix(err.Error(), utils.ERR_EXISTS) {
+		t.Error("Expecting the period already invoiced, received: ", err)
+	}
+	if _, err := GenerateInvoice(ir, "cgrates.org", "1001", OUTBOUND, march.AddDate(0, 0, 15), may, 0); err == nil {
+		t.Error("Invoiced an overlapping period")
+	}
+	// the next period and the other accounts are not affected
+	if _, err := GenerateInvoice(ir, "cgrates.org", "1001", OUTBOUND, april, may, 0); err != nil {
+		t.Error(err)
+	}
+	if _, err := GenerateInvoice(ir, "cgrates.org", "1002", OUTBOUND, march, april, 0); err != nil {
+		t.Error(err)
+	}
+	if inv, err := RegenerateInvoice(ir, 1); err != nil || inv.Number != 1 || len(ir.invoices) != 3 {
+		t.Errorf("Regenerate failed: %+v, %v", inv, err)
+	}
+}
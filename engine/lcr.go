@@ -24,7 +24,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cgrates/cgrates/cache2go"
 	"github.com/cgrates/cgrates/utils"
 )
 
@@ -170,17 +169,12 @@ func (es LCREntriesSorter) Sort() {
 
 func (lcra *LCRActivation) GetLCREntryForPrefix(destination string) *LCREntry {
 	var potentials LCREntriesSorter
-	for _, p := range utils.SplitPrefix(destination, MIN_PREFIX_MATCH) {
-		if x, err := cache2go.GetCached(DESTINATION_PREFIX + p); err == nil {
-
-			destIds := x.(map[interface{}]struct{})
-			for idId := range destIds {
-				dId := idId.(string)
-				for _, entry := range lcra.Entries {
-					if entry.DestinationId == dId {
-						entry.precision = len(p)
-						potentials = append(potentials, entry)
-					}
+	for _, match := range MatchDestinations(destination) {
+		for _, dId := range match.Ids {
+			for _, entry := range lcra.Entries {
+				if entry.DestinationId == dId {
+					entry.precision = len(match.Prefix)
+					potentials = append(potentials, entry)
 				}
 			}
 		}
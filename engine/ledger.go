@@ -0,0 +1,178 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// One movement on an account balance, never changed once written
+type LedgerEntry struct {
+	AccountId   string
+	BalanceType string // balance map key, eg: *monetary*out
+	BalanceUuid string
+	BalanceId   string
+	Delta       float64 // positive for credit, negative for debit
+	Value       float64 // balance value after the change
+	CgrId       string  // originating call/session, empty when not coming out of rating
+	ActionId    string  // originating action, empty when not coming out of actions
+	ActionType  string  // type of the originating action, telling the charges apart from the other balance movements
+	Timestamp   time.Time
+}
+
+// Remembers the balance values before an operation so the movements can be written into ledger afterwards
+type ledgerSnapshot struct {
+	accounts map[*Account]bool
+	values   map[*Balance]float64
+	owners   map[*Balance]*Account
+	types    map[*Balance]string // balance map key, kept for the balances removed in the meantime
+}
+
+// Captures the balances of the account together with the ones of other accounts present in the chains (shared groups),
+// with a nil account the accounts are added later on
+func newLedgerSnapshot(acc *Account, chains ...BalanceChain) *ledgerSnapshot {
+	ls := &ledgerSnapshot{accounts: make(map[*Account]bool), values: make(map[*Balance]float64),
+		owners: make(map[*Balance]*Account), types: make(map[*Balance]string)}
+	if acc != nil {
+		ls.add(acc)
+	}
+	for _, bc := range chains {
+		for _, b := range bc {
+			if b.account != nil {
+				ls.add(b.account)
+			}
+		}
+	}
+	return ls
+}
+
+func (ls *ledgerSnapshot) add(acc *Account) {
+	if ls.accounts[acc] {
+		return
+	}
+	ls.accounts[acc] = true
+	for balanceType, bc := range acc.BalanceMap {
+		for _, b := range bc {
+			ls.values[b] = b.Value
+			ls.owners[b] = acc
+			ls.types[b] = balanceType
+		}
+	}
+}
+
+// Compares the captured values with the current ones, the balances removed meanwhile (expired, reset) end at zero
+func (ls *ledgerSnapshot) entries(cgrId string, a *Action) (entries []*LedgerEntry) {
+	now := time.Now()
+	var actionId, actionType string
+	if a != nil {
+		actionId, actionType = a.Id, a.ActionType
+	}
+	present := make(map[*Balance]bool, len(ls.values))
+	newEntry := func(acc *Account, balanceType string, b *Balance, before float64) *LedgerEntry {
+		return &LedgerEntry{
+			AccountId:   acc.Id,
+			BalanceType: balanceType,
+			BalanceUuid: b.Uuid,
+			BalanceId:   b.Id,
+			Delta:       b.Value - before,
+			Value:       b.Value,
+			CgrId:       cgrId,
+			ActionId:    actionId,
+			ActionType:  actionType,
+			Timestamp:   now,
+		}
+	}
+	for acc := range ls.accounts {
+		for balanceType, bc := range acc.BalanceMap {
+			for _, b := range bc {
+				present[b] = true
+				before := ls.values[b] // balances created in the meantime start from zero
+				if b.Value == before {
+					continue
+				}
+				entries = append(entries, newEntry(acc, balanceType, b, before))
+			}
+		}
+	}
+	for b, before := range ls.values {
+		if present[b] || before == 0 {
+			continue
+		}
+		entry := newEntry(ls.owners[b], ls.types[b], b, before)
+		entry.Delta, entry.Value = -before, 0
+		entries = append(entries, entry)
+	}
+	sort.Sort(LedgerEntries(entries))
+	return
+}
+
+// Queues the movements since snapshot on their accounts, they reach the ledger once the accounts are saved.
+// The action is nil for the movements not coming out of actions.
+func (ls *ledgerSnapshot) write(cgrId string, a *Action) {
+	if cdrStorage == nil {
+		return
+	}
+	accounts := make(map[string]*Account, len(ls.accounts))
+	for acc := range ls.accounts {
+		accounts[acc.Id] = acc
+	}
+	for _, entry := range ls.entries(cgrId, a) {
+		acc := accounts[entry.AccountId]
+		acc.ledger = append(acc.ledger, entry)
+	}
+}
+
+// Writes the queued movements into the ledger of the CDR storage, called by the storages after saving the account
+func (acc *Account) writeLedger() {
+	entries := acc.ledger
+	acc.ledger = nil
+	if cdrStorage == nil {
+		return
+	}
+	for _, entry := range entries {
+		if err := cdrStorage.SetLedgerEntry(entry); err != nil {
+			Logger.Err(fmt.Sprintf("<Ledger> Could not write entry %+v: %s", entry, err.Error()))
+		}
+	}
+}
+
+type LedgerEntries []*LedgerEntry
+
+func (le LedgerEntries) Len() int {
+	return len(le)
+}
+
+func (le LedgerEntries) Swap(i, j int) {
+	le[i], le[j] = le[j], le[i]
+}
+
+func (le LedgerEntries) Less(i, j int) bool {
+	if !le[i].Timestamp.Equal(le[j].Timestamp) {
+		return le[i].Timestamp.Before(le[j].Timestamp)
+	}
+	if le[i].AccountId != le[j].AccountId {
+		return le[i].AccountId < le[j].AccountId
+	}
+	if le[i].BalanceType != le[j].BalanceType {
+		return le[i].BalanceType < le[j].BalanceType
+	}
+	return le[i].BalanceUuid < le[j].BalanceUuid
+}
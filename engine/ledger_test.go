@@ -0,0 +1,149 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Only records the ledger, the rest of the CdrStorage is not used by these tests
+type ledgerRecorder struct {
+	CdrStorage
+	entries []*LedgerEntry
+}
+
+func (lr *ledgerRecorder) SetLedgerEntry(entry *LedgerEntry) error {
+	lr.entries = append(lr.entries, entry)
+	return nil
+}
+
+func TestLedgerSnapshotEntries(t *testing.T) {
+	ub := &Account{
+		Id: "*out:cgrates.org:ledger",
+		BalanceMap: map[string]BalanceChain{
+			utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "m1", Value: 10}},
+			utils.VOICE + OUTBOUND:    BalanceChain{&Balance{Uuid: "v1", Id: "NAT", Value: 60}, &Balance{Uuid: "v2", Value: 30}},
+		},
+	}
+	ls := newLedgerSnapshot(ub)
+	ub.BalanceMap[utils.MONETARY+OUTBOUND][0].SubstractAmount(2.5)
+	ub.BalanceMap[utils.VOICE+OUTBOUND][0].Value = 0
+	ub.BalanceMap[utils.SMS+OUTBOUND] = BalanceChain{&Balance{Uuid: "s1", Value: 100}}
+	entries := ls.entries("CGRID", &Action{Id: "ACTS", ActionType: DEBIT})
+	eEntries := []*LedgerEntry{
+		&LedgerEntry{AccountId: ub.Id, BalanceType: utils.MONETARY + OUTBOUND, BalanceUuid: "m1", Delta: -2.5, Value: 7.5},
+		&LedgerEntry{AccountId: ub.Id, BalanceType: utils.SMS + OUTBOUND, BalanceUuid: "s1", Delta: 100, Value: 100},
+		&LedgerEntry{AccountId: ub.Id, BalanceType: utils.VOICE + OUTBOUND, BalanceUuid: "v1", BalanceId: "NAT", Delta: -60, Value: 0},
+	}
+	if len(entries) != len(eEntries) {
+		t.Fatalf("Expecting %d entries, received: %+v", len(eEntries), entries)
+	}
+	for i, entry := range entries {
+		if entry.CgrId != "CGRID" || entry.ActionId != "ACTS" || entry.ActionType != DEBIT || entry.Timestamp.IsZero() {
+			t.Errorf("Wrong origin on entry: %+v", entry)
+		}
+		entry.CgrId, entry.ActionId, entry.ActionType, entry.Timestamp = "", "", "", time.Time{}
+		if *entry != *eEntries[i] {
+			t.Errorf("Expecting: %+v, received: %+v", eEntries[i], entry)
+		}
+	}
+}
+
+func TestLedgerTopupAction(t *testing.T) {
+	lr := new(ledgerRecorder)
+	cdrStorage = lr
+	defer func() { cdrStorage = nil }()
+	ub := &Account{
+		Id:         "*out:cgrates.org:ledger",
+		BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "m1", Value: 100}}},
+	}
+	topupAction(ub, nil, &Action{Id: "TOPUP10", ActionType: TOPUP, BalanceType: utils.MONETARY, Direction: OUTBOUND, Balance: &Balance{Value: 10}}, nil)
+	if len(lr.entries) != 0 {
+		t.Fatal("Ledger written before saving the account: ", lr.entries)
+	}
+	if err := accountingStorage.SetAccount(ub); err != nil {
+		t.Fatal(err)
+	}
+	if len(lr.entries) != 1 {
+		t.Fatal("Expecting one ledger entry, received: ", lr.entries)
+	}
+	if entry := lr.entries[0]; entry.AccountId != ub.Id || entry.BalanceUuid != "m1" || entry.Delta != 10 || entry.Value != 110 || entry.ActionId != "TOPUP10" || entry.ActionType != TOPUP {
+		t.Errorf("Unexpected ledger entry: %+v", entry)
+	}
+	// dry run debits do not reach the ledger
+	lr.entries = nil
+	cd := &CallDescriptor{
+		Direction:   OUTBOUND,
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "rif",
+		Destination: "0723",
+		TimeStart:   time.Date(2013, 10, 4, 15, 46, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 4, 15, 46, 10, 0, time.UTC),
+		CgrId:       "CGRID",
+	}
+	if _, err := ub.debitCreditBalance(cd, false, true, true); err != nil {
+		t.Error(err)
+	}
+	if len(lr.entries) != 0 || len(ub.ledger) != 0 {
+		t.Error("Dry run written into ledger: ", lr.entries, ub.ledger)
+	}
+}
+
+func TestLedgerResetAndExpiry(t *testing.T) {
+	lr := new(ledgerRecorder)
+	cdrStorage = lr
+	defer func() { cdrStorage = nil }()
+	ub := &Account{
+		Id: "*out:cgrates.org:ledger_reset",
+		BalanceMap: map[string]BalanceChain{
+			utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "m1", Value: 10}},
+			utils.VOICE + OUTBOUND: BalanceChain{&Balance{Uuid: "v1", Value: 60},
+				&Balance{Uuid: "v2", Value: 30, ExpirationDate: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+	}
+	ub.CleanExpiredBalances()
+	if err := accountingStorage.SetAccount(ub); err != nil {
+		t.Fatal(err)
+	}
+	if len(lr.entries) != 1 {
+		t.Fatal("Expecting one ledger entry, received: ", lr.entries)
+	}
+	if entry := lr.entries[0]; entry.BalanceUuid != "v2" || entry.BalanceType != utils.VOICE+OUTBOUND || entry.Delta != -30 || entry.Value != 0 || entry.ActionId != "" {
+		t.Errorf("Unexpected expiry entry: %+v", entry)
+	}
+	lr.entries = nil
+	if err := resetAccountAction(ub, nil, &Action{Id: "RESET"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := accountingStorage.SetAccount(ub); err != nil {
+		t.Fatal(err)
+	}
+	if len(lr.entries) != 2 {
+		t.Fatal("Expecting two ledger entries, received: ", lr.entries)
+	}
+	for _, entry := range lr.entries {
+		if entry.ActionId != "RESET" || entry.Value != 0 || (entry.BalanceUuid == "m1" && entry.Delta != -10) || (entry.BalanceUuid == "v1" && entry.Delta != -60) {
+			t.Errorf("Unexpected reset entry: %+v", entry)
+		}
+	}
+}
@@ -32,12 +32,13 @@ import (
 )
 
 func InitDataDb(cfg *config.CGRConfig) error {
-	ratingDb, err := ConfigureRatingStorage(cfg.RatingDBType, cfg.RatingDBHost, cfg.RatingDBPort, cfg.RatingDBName, cfg.RatingDBUser, cfg.RatingDBPass, cfg.DBDataEncoding)
+	ratingDb, err := ConfigureRatingStorage(cfg.RatingDBType, cfg.RatingDBHost, cfg.RatingDBPort, cfg.RatingDBName, cfg.RatingDBUser, cfg.RatingDBPass, cfg.DBDataEncoding,
+		cfg.RatingDBSentinels, cfg.RatingDBMasterName)
 	if err != nil {
 		return err
 	}
 	accountDb, err := ConfigureAccountingStorage(cfg.AccountDBType, cfg.AccountDBHost, cfg.AccountDBPort, cfg.AccountDBName,
-		cfg.AccountDBUser, cfg.AccountDBPass, cfg.DBDataEncoding)
+		cfg.AccountDBUser, cfg.AccountDBPass, cfg.DBDataEncoding, cfg.AccountDBSentinels, cfg.AccountDBMasterName)
 	if err != nil {
 		return err
 	}
@@ -105,7 +106,11 @@ func LoadTariffPlanFromFolder(tpPath string, ratingDb RatingStorage, accountingD
 		path.Join(tpPath, utils.ACTION_TRIGGERS_CSV),
 		path.Join(tpPath, utils.ACCOUNT_ACTIONS_CSV),
 		path.Join(tpPath, utils.DERIVED_CHARGERS_CSV),
-		path.Join(tpPath, utils.CDR_STATS_CSV))
+		path.Join(tpPath, utils.CDR_STATS_CSV),
+		path.Join(tpPath, utils.EXCHANGE_RATES_CSV),
+		path.Join(tpPath, utils.TAX_RULES_CSV),
+		path.Join(tpPath, utils.PORTED_NUMBERS_CSV),
+		path.Join(tpPath, utils.CALENDARS_CSV))
 	if err := loader.LoadAll(); err != nil {
 		return fmt.Errorf("%s:%s", utils.ERR_SERVER_ERROR, err.Error())
 	}
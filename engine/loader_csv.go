@@ -54,14 +54,18 @@ type CSVReader struct {
 	lcrs              map[string]*LCR
 	derivedChargers   map[string]utils.DerivedChargers
 	cdrStats          map[string]*CdrStats
+	exchangeRates     map[string]*ExchangeRate
+	taxRules          map[string]*TaxRules
+	portedNumbers     map[string]*PortedNumber
+	calendars         map[string]utils.CalendarDays
 	// file names
 	destinationsFn, ratesFn, destinationratesFn, timingsFn, destinationratetimingsFn, ratingprofilesFn,
-	sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn string
+	sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn, exchangeRatesFn, taxRulesFn, portedNumbersFn, calendarsFn string
 }
 
 func NewFileCSVReader(dataStorage RatingStorage, accountingStorage AccountingStorage, sep rune,
 	destinationsFn, timingsFn, ratesFn, destinationratesFn, destinationratetimingsFn, ratingprofilesFn, sharedgroupsFn, lcrFn,
-	actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn string) *CSVReader {
+	actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn, exchangeRatesFn, taxRulesFn, portedNumbersFn, calendarsFn string) *CSVReader {
 	c := new(CSVReader)
 	c.sep = sep
 	c.dataStorage = dataStorage
@@ -80,20 +84,24 @@ func NewFileCSVReader(dataStorage RatingStorage, accountingStorage AccountingSto
 	c.lcrs = make(map[string]*LCR)
 	c.derivedChargers = make(map[string]utils.DerivedChargers)
 	c.cdrStats = make(map[string]*CdrStats)
+	c.exchangeRates = make(map[string]*ExchangeRate)
+	c.taxRules = make(map[string]*TaxRules)
+	c.portedNumbers = make(map[string]*PortedNumber)
+	c.calendars = make(map[string]utils.CalendarDays)
 	c.readerFunc = openFileCSVReader
 	c.rpAliases = make(map[string]string)
 	c.accAliases = make(map[string]string)
 	c.destinationsFn, c.timingsFn, c.ratesFn, c.destinationratesFn, c.destinationratetimingsFn, c.ratingprofilesFn,
-		c.sharedgroupsFn, c.lcrFn, c.actionsFn, c.actiontimingsFn, c.actiontriggersFn, c.accountactionsFn, c.derivedChargersFn, c.cdrStatsFn = destinationsFn, timingsFn,
-		ratesFn, destinationratesFn, destinationratetimingsFn, ratingprofilesFn, sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn
+		c.sharedgroupsFn, c.lcrFn, c.actionsFn, c.actiontimingsFn, c.actiontriggersFn, c.accountactionsFn, c.derivedChargersFn, c.cdrStatsFn, c.exchangeRatesFn, c.taxRulesFn, c.portedNumbersFn, c.calendarsFn = destinationsFn, timingsFn,
+		ratesFn, destinationratesFn, destinationratetimingsFn, ratingprofilesFn, sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn, exchangeRatesFn, taxRulesFn, portedNumbersFn, calendarsFn
 	return c
 }
 
 func NewStringCSVReader(dataStorage RatingStorage, accountingStorage AccountingStorage, sep rune,
 	destinationsFn, timingsFn, ratesFn, destinationratesFn, destinationratetimingsFn, ratingprofilesFn, sharedgroupsFn, lcrFn,
-	actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn string) *CSVReader {
+	actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn, exchangeRatesFn, taxRulesFn, portedNumbersFn, calendarsFn string) *CSVReader {
 	c := NewFileCSVReader(dataStorage, accountingStorage, sep, destinationsFn, timingsFn, ratesFn, destinationratesFn, destinationratetimingsFn,
-		ratingprofilesFn, sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn)
+		ratingprofilesFn, sharedgroupsFn, lcrFn, actionsFn, actiontimingsFn, actiontriggersFn, accountactionsFn, derivedChargersFn, cdrStatsFn, exchangeRatesFn, taxRulesFn, portedNumbersFn, calendarsFn)
 	c.readerFunc = openStringCSVReader
 	return c
 }
@@ -177,6 +185,14 @@ func (csvr *CSVReader) ShowStatistics() {
 	log.Print("LCR rules: ", len(csvr.lcrs))
 	// cdr stats
 	log.Print("CDR stats: ", len(csvr.cdrStats))
+	// exchange rates
+	log.Print("Exchange rates: ", len(csvr.exchangeRates))
+	// tax rules
+	log.Print("Tax rules: ", len(csvr.taxRules))
+	// ported numbers
+	log.Print("Ported numbers: ", len(csvr.portedNumbers))
+	// calendars
+	log.Print("Calendars: ", len(csvr.calendars))
 }
 
 func (csvr *CSVReader) WriteToDatabase(flush, verbose bool) (err error) {
@@ -187,6 +203,9 @@ func (csvr *CSVReader) WriteToDatabase(flush, verbose bool) (err error) {
 	}
 	if flush {
 		dataStorage.Flush("")
+		if err = SetCurrentVersions(dataStorage, VER_RATING_DB, VER_ACCOUNTING_DB); err != nil {
+			return err
+		}
 	}
 	if verbose {
 		log.Print("Destinations:")
@@ -338,6 +357,39 @@ func (csvr *CSVReader) WriteToDatabase(flush, verbose bool) (err error) {
 			log.Print("\t", sq.Id)
 		}
 	}
+	if verbose {
+		log.Print("Exchange Rates:")
+	}
+	for _, er := range csvr.exchangeRates {
+		if err = dataStorage.SetExchangeRate(er); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", er.Id)
+		}
+	}
+	if verbose {
+		log.Print("Tax Rules:")
+	}
+	for _, tr := range csvr.taxRules {
+		if err = dataStorage.SetTaxRules(tr); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", tr.Id)
+		}
+	}
+	if verbose {
+		log.Print("Ported Numbers:")
+	}
+	for _, pn := range csvr.portedNumbers {
+		if err = setPortedNumber(dataStorage, pn); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print("\t", pn.Id)
+		}
+	}
 	return
 }
 
@@ -443,6 +495,12 @@ func (csvr *CSVReader) LoadDestinationRates() (err error) {
 			log.Printf("Error parsing max cost from: %v", record[5])
 			return err
 		}
+		minCost, err := strconv.ParseFloat(ValueOrDefault(record[10], "0"), 64)
+		if err != nil {
+			log.Printf("Error parsing min cost from: %v", record[10])
+			return err
+		}
+		connectFeeOnAnswer, _ := strconv.ParseBool(record[12])
 		destinationExists := record[1] == utils.ANY
 		if !destinationExists {
 			_, destinationExists = csvr.destinations[record[1]]
@@ -459,15 +517,24 @@ func (csvr *CSVReader) LoadDestinationRates() (err error) {
 			DestinationRateId: tag,
 			DestinationRates: []*utils.DestinationRate{
 				&utils.DestinationRate{
-					DestinationId:    record[1],
-					Rate:             r,
-					RoundingMethod:   record[3],
-					RoundingDecimals: roundingDecimals,
-					MaxCost:          maxCost,
-					MaxCostStrategy:  record[6],
+					DestinationId:      record[1],
+					Rate:               r,
+					RoundingMethod:     record[3],
+					RoundingDecimals:   roundingDecimals,
+					MaxCost:            maxCost,
+					MaxCostStrategy:    record[6],
+					TierPeriod:         record[7],
+					Currency:           record[8],
+					MinDuration:        record[9],
+					MinCost:            minCost,
+					FreeUnder:          record[11],
+					ConnectFeeOnAnswer: connectFeeOnAnswer,
 				},
 			},
 		}
+		if _, _, err := dr.DestinationRates[0].GetShortCallDurations(); err != nil {
+			return fmt.Errorf("Cannot parse short call durations for destination rate %v: %v", tag, err)
+		}
 		existingDR, exists := csvr.destinationRates[tag]
 		if exists {
 			existingDR.DestinationRates = append(existingDR.DestinationRates, dr.DestinationRates[0])
@@ -495,6 +562,9 @@ func (csvr *CSVReader) LoadRatingPlans() (err error) {
 		if !exists {
 			return fmt.Errorf("Could not get timing for tag %v", record[2])
 		}
+		if err := SetTimingHolidays(t, csvr.calendars); err != nil {
+			return err
+		}
 		drs, exists := csvr.destinationRates[record[1]]
 		if !exists {
 			return fmt.Errorf("Could not find destination rate for tag %v", record[1])
@@ -552,11 +622,15 @@ func (csvr *CSVReader) LoadRatingProfiles() (err error) {
 		if !exists {
 			return fmt.Errorf("Could not load rating plans for tag: %v", record[5])
 		}
+		if _, err := utils.GetLocation(record[8]); err != nil {
+			return fmt.Errorf("Cannot load time zone %v: %v", record[8], err)
+		}
 		rpa := &RatingPlanActivation{
 			ActivationTime:  at,
 			RatingPlanId:    record[5],
 			FallbackKeys:    utils.FallbackSubjKeys(direction, tenant, tor, fallbacksubject),
 			CdrStatQueueIds: strings.Split(record[7], utils.INFIELD_SEP),
+			Timezone:        record[8],
 		}
 		rp.RatingPlanActivations = append(rp.RatingPlanActivations, rpa)
 		csvr.ratingProfiles[rp.Id] = rp
@@ -713,13 +787,19 @@ func (csvr *CSVReader) LoadActions() (err error) {
 			timingIds := strings.Split(a.Balance.TimingIDs, utils.INFIELD_SEP)
 			for _, timingID := range timingIds {
 				if timing, found := csvr.timings[timingID]; found {
+					if err := SetTimingHolidays(timing, csvr.calendars); err != nil {
+						return err
+					}
 					a.Balance.Timings = append(a.Balance.Timings, &RITiming{
-						Years:     timing.Years,
-						Months:    timing.Months,
-						MonthDays: timing.MonthDays,
-						WeekDays:  timing.WeekDays,
-						StartTime: timing.StartTime,
-						EndTime:   timing.EndTime,
+						Years:           timing.Years,
+						Months:          timing.Months,
+						MonthDays:       timing.MonthDays,
+						WeekDays:        timing.WeekDays,
+						StartTime:       timing.StartTime,
+						EndTime:         timing.EndTime,
+						Calendar:        timing.Calendar,
+						ExcludeCalendar: timing.ExcludeCalendar,
+						Holidays:        timing.Holidays,
 					})
 				} else {
 					return fmt.Errorf("Could not find timing: %v", timingID)
@@ -764,6 +844,9 @@ func (csvr *CSVReader) LoadActionTimings() (err error) {
 		if err != nil {
 			return fmt.Errorf("ActionTiming: Could not parse action timing weight: %v", err)
 		}
+		if _, err := utils.GetLocation(record[4]); err != nil {
+			return fmt.Errorf("ActionTiming: Cannot load time zone %v: %v", record[4], err)
+		}
 		at := &ActionTiming{
 			Uuid:   utils.GenUUID(),
 			Id:     record[0],
@@ -778,6 +861,7 @@ func (csvr *CSVReader) LoadActionTimings() (err error) {
 				},
 			},
 			ActionsId: record[1],
+			Timezone:  record[4],
 		}
 		csvr.actionsTimings[tag] = append(csvr.actionsTimings[tag], at)
 	}
@@ -1018,11 +1102,106 @@ func (csvr *CSVReader) LoadCdrStats() (err error) {
 }
 
 // Automated loading
+func (csvr *CSVReader) LoadExchangeRates() (err error) {
+	csvReader, fp, err := csvr.readerFunc(csvr.exchangeRatesFn, csvr.sep, utils.EXCHANGE_RATES_NRCOLS)
+	if err != nil {
+		log.Print("Could not load exchange rates file: ", err)
+		// allow writing of the other values
+		return nil
+	}
+	if fp != nil {
+		defer fp.Close()
+	}
+	for record, err := csvReader.Read(); err == nil; record, err = csvReader.Read() {
+		rate, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return fmt.Errorf("Error parsing exchange rate from: %v", record[4])
+		}
+		if err := UpdateExchangeRates(csvr.exchangeRates, &utils.TPExchangeRate{
+			FromCurrency:   record[1],
+			ToCurrency:     record[2],
+			ActivationTime: record[3],
+			Rate:           rate,
+		}); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+func (csvr *CSVReader) LoadTaxRules() (err error) {
+	csvReader, fp, err := csvr.readerFunc(csvr.taxRulesFn, csvr.sep, utils.TAX_RULES_NRCOLS)
+	if err != nil {
+		log.Print("Could not load tax rules file: ", err)
+		// allow writing of the other values
+		return nil
+	}
+	if fp != nil {
+		defer fp.Close()
+	}
+	for record, err := csvReader.Read(); err == nil; record, err = csvReader.Read() {
+		rate, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return fmt.Errorf("Error parsing tax rate from: %v", record[5])
+		}
+		if err := UpdateTaxRules(csvr.taxRules, record[0], &utils.TPTaxRule{
+			Tenant:         record[1],
+			Category:       record[2],
+			DestinationId:  record[3],
+			ActivationTime: record[4],
+			Rate:           rate,
+		}); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+func (csvr *CSVReader) LoadPortedNumbers() (err error) {
+	csvReader, fp, err := csvr.readerFunc(csvr.portedNumbersFn, csvr.sep, utils.PORTED_NUMBERS_NRCOLS)
+	if err != nil {
+		log.Print("Could not load ported numbers file: ", err)
+		// allow writing of the other values
+		return nil
+	}
+	if fp != nil {
+		defer fp.Close()
+	}
+	for record, err := csvReader.Read(); err == nil; record, err = csvReader.Read() {
+		if err := UpdatePortedNumbers(csvr.portedNumbers, &utils.TPPortedNumber{Number: record[1], RoutingPrefix: record[2]}); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+func (csvr *CSVReader) LoadCalendars() (err error) {
+	csvReader, fp, err := csvr.readerFunc(csvr.calendarsFn, csvr.sep, utils.CALENDARS_NRCOLS)
+	if err != nil {
+		log.Print("Could not load calendars file: ", err)
+		// allow writing of the other values
+		return nil
+	}
+	if fp != nil {
+		defer fp.Close()
+	}
+	for record, err := csvReader.Read(); err == nil; record, err = csvReader.Read() {
+		yearly, _ := strconv.ParseBool(record[2])
+		if err := UpdateCalendars(csvr.calendars, record[0], &utils.TPCalendarDay{Date: record[1], Yearly: yearly}); err != nil {
+			return err
+		}
+	}
+	return
+}
+
 func (csvr *CSVReader) LoadAll() error {
 	var err error
 	if err = csvr.LoadDestinations(); err != nil {
 		return err
 	}
+	if err = csvr.LoadCalendars(); err != nil {
+		return err
+	}
 	if err = csvr.LoadTimings(); err != nil {
 		return err
 	}
@@ -1062,6 +1241,15 @@ func (csvr *CSVReader) LoadAll() error {
 	if err = csvr.LoadCdrStats(); err != nil {
 		return err
 	}
+	if err = csvr.LoadExchangeRates(); err != nil {
+		return err
+	}
+	if err = csvr.LoadTaxRules(); err != nil {
+		return err
+	}
+	if err = csvr.LoadPortedNumbers(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1140,6 +1328,30 @@ func (csvr *CSVReader) GetLoadedIds(categ string) ([]string, error) {
 			i++
 		}
 		return keys, nil
+	case EXCHANGE_RATE_PREFIX:
+		keys := make([]string, len(csvr.exchangeRates))
+		i := 0
+		for k := range csvr.exchangeRates {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
+	case TAX_RULES_PREFIX:
+		keys := make([]string, len(csvr.taxRules))
+		i := 0
+		for k := range csvr.taxRules {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
+	case PORTED_NUMBER_PREFIX:
+		keys := make([]string, len(csvr.portedNumbers))
+		i := 0
+		for k := range csvr.portedNumbers {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
 	case SHARED_GROUP_PREFIX:
 		keys := make([]string, len(csvr.sharedGroups))
 		i := 0
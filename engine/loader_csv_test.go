@@ -53,6 +53,7 @@ WEEKENDS,*any,*any,*any,6;7,00:00:00
 ONE_TIME_RUN,2012,,,,*asap
 ALWAYS,*any,*any,*any,*any,00:00:00
 ASAP,*any,*any,*any,*any,*asap
+WORKDAYS_NOHOL,*any,*any,*any,1;2;3;4;5;!*holidays:DE,00:00:00
 `
 	rates = `
 R1,0,0.2,60,1,0
@@ -71,24 +72,24 @@ R_URG,0,0,1,1,0
 MX,0,1,1s,1s,0
 `
 	destinationRates = `
-RT_STANDARD,GERMANY,R1,*middle,4,0,
-RT_STANDARD,GERMANY_O2,R2,*middle,4,0,
-RT_STANDARD,GERMANY_PREMIUM,R2,*middle,4,0,
-RT_DEFAULT,ALL,R2,*middle,4,0,
-RT_STD_WEEKEND,GERMANY,R2,*middle,4,0,
-RT_STD_WEEKEND,GERMANY_O2,R3,*middle,4,0,
-P1,NAT,R4,*middle,4,0,
-P2,NAT,R5,*middle,4,0,
-T1,NAT,LANDLINE_OFFPEAK,*middle,4,0,
-T2,GERMANY,GBP_72,*middle,4,0,
-T2,GERMANY_O2,GBP_70,*middle,4,0,
-T2,GERMANY_PREMIUM,GBP_71,*middle,4,0,
-DR_UK_Mobile_BIG5_PKG,DST_UK_Mobile_BIG5,RT_UK_Mobile_BIG5_PKG,*middle,4,,
-DR_UK_Mobile_BIG5,DST_UK_Mobile_BIG5,RT_UK_Mobile_BIG5,*middle,4,,
-DATA_RATE,*any,LANDLINE_OFFPEAK,*middle,4,0,
-RT_URG,URG,R_URG,*middle,4,0,
-MX_FREE,RET,MX,*middle,4,10,*free
-MX_DISC,RET,MX,*middle,4,10,*disconnect
+RT_STANDARD,GERMANY,R1,*middle,4,0,,,,,,,
+RT_STANDARD,GERMANY_O2,R2,*middle,4,0,,,,,,,
+RT_STANDARD,GERMANY_PREMIUM,R2,*middle,4,0,,,,,,,
+RT_DEFAULT,ALL,R2,*middle,4,0,,,,,,,
+RT_STD_WEEKEND,GERMANY,R2,*middle,4,0,,,,,,,
+RT_STD_WEEKEND,GERMANY_O2,R3,*middle,4,0,,,,,,,
+P1,NAT,R4,*middle,4,0,,,,,,,
+P2,NAT,R5,*middle,4,0,,,,,,,
+T1,NAT,LANDLINE_OFFPEAK,*middle,4,0,,,,,,,
+T2,GERMANY,GBP_72,*middle,4,0,,,,,,,
+T2,GERMANY_O2,GBP_70,*middle,4,0,,,,,,,
+T2,GERMANY_PREMIUM,GBP_71,*middle,4,0,,,,,,,
+DR_UK_Mobile_BIG5_PKG,DST_UK_Mobile_BIG5,RT_UK_Mobile_BIG5_PKG,*middle,4,,,,,,,,
+DR_UK_Mobile_BIG5,DST_UK_Mobile_BIG5,RT_UK_Mobile_BIG5,*middle,4,,,,,,,,
+DATA_RATE,*any,LANDLINE_OFFPEAK,*middle,4,0,,,,,,,
+RT_URG,URG,R_URG,*middle,4,0,,,,,,,
+MX_FREE,RET,MX,*middle,4,10,*free,,,,,,
+MX_DISC,RET,MX,*middle,4,10,*disconnect,,,,,,
 `
 	ratingPlans = `
 STANDARD,RT_STANDARD,WORKDAYS_00,10
@@ -113,26 +114,26 @@ RP_MX,MX_DISC,WORKDAYS_00,10
 RP_MX,MX_FREE,WORKDAYS_18,10
 `
 	ratingProfiles = `
-*out,CUSTOMER_1,0,rif:from:tm,2012-01-01T00:00:00Z,PREMIUM,danb,
-*out,CUSTOMER_1,0,rif:from:tm,2012-02-28T00:00:00Z,STANDARD,danb,
-*out,CUSTOMER_2,0,danb:87.139.12.167,2012-01-01T00:00:00Z,STANDARD,danb,
-*out,CUSTOMER_1,0,danb,2012-01-01T00:00:00Z,PREMIUM,,
-*out,vdf,0,rif,2012-01-01T00:00:00Z,EVENING,,
-*out,vdf,0,rif,2012-02-28T00:00:00Z,EVENING,,
-*out,vdf,0,minu;a1;a2;a3,2012-01-01T00:00:00Z,EVENING,,
-*out,vdf,0,*any,2012-02-28T00:00:00Z,EVENING,,
-*out,vdf,0,one,2012-02-28T00:00:00Z,STANDARD,,
-*out,vdf,0,inf,2012-02-28T00:00:00Z,STANDARD,inf,
-*out,vdf,0,fall,2012-02-28T00:00:00Z,PREMIUM,rif,
-*out,test,0,trp,2013-10-01T00:00:00Z,TDRT,rif;danb,
-*out,vdf,0,fallback1,2013-11-18T13:45:00Z,G,fallback2,
-*out,vdf,0,fallback1,2013-11-18T13:46:00Z,G,fallback2,
-*out,vdf,0,fallback1,2013-11-18T13:47:00Z,G,fallback2,
-*out,vdf,0,fallback2,2013-11-18T13:45:00Z,R,rif,
-*out,cgrates.org,call,*any,2013-01-06T00:00:00Z,RP_UK,,
-*out,cgrates.org,call,discounted_minutes,2013-01-06T00:00:00Z,RP_UK_Mobile_BIG5_PKG,,
-*out,cgrates.org,data,rif,2013-01-06T00:00:00Z,RP_DATA,,
-*out,cgrates.org,call,max,2013-03-23T00:00:00Z,RP_MX,,
+*out,CUSTOMER_1,0,rif:from:tm,2012-01-01T00:00:00Z,PREMIUM,danb,,
+*out,CUSTOMER_1,0,rif:from:tm,2012-02-28T00:00:00Z,STANDARD,danb,,
+*out,CUSTOMER_2,0,danb:87.139.12.167,2012-01-01T00:00:00Z,STANDARD,danb,,
+*out,CUSTOMER_1,0,danb,2012-01-01T00:00:00Z,PREMIUM,,,
+*out,vdf,0,rif,2012-01-01T00:00:00Z,EVENING,,,
+*out,vdf,0,rif,2012-02-28T00:00:00Z,EVENING,,,
+*out,vdf,0,minu;a1;a2;a3,2012-01-01T00:00:00Z,EVENING,,,
+*out,vdf,0,*any,2012-02-28T00:00:00Z,EVENING,,,
+*out,vdf,0,one,2012-02-28T00:00:00Z,STANDARD,,,
+*out,vdf,0,inf,2012-02-28T00:00:00Z,STANDARD,inf,,
+*out,vdf,0,fall,2012-02-28T00:00:00Z,PREMIUM,rif,,
+*out,test,0,trp,2013-10-01T00:00:00Z,TDRT,rif;danb,,
+*out,vdf,0,fallback1,2013-11-18T13:45:00Z,G,fallback2,,
+*out,vdf,0,fallback1,2013-11-18T13:46:00Z,G,fallback2,,
+*out,vdf,0,fallback1,2013-11-18T13:47:00Z,G,fallback2,,
+*out,vdf,0,fallback2,2013-11-18T13:45:00Z,R,rif,,
+*out,cgrates.org,call,*any,2013-01-06T00:00:00Z,RP_UK,,,
+*out,cgrates.org,call,discounted_minutes,2013-01-06T00:00:00Z,RP_UK_Mobile_BIG5_PKG,,,
+*out,cgrates.org,data,rif,2013-01-06T00:00:00Z,RP_DATA,,,
+*out,cgrates.org,call,max,2013-03-23T00:00:00Z,RP_MX,,,
 `
 	sharedGroups = `
 SG1,*any,*lowest,
@@ -158,13 +159,13 @@ EE0,*allow_negative,,,*monetary,*out,,,,,*unlimited,,0,10,10
 DEFEE,*cdrlog,"{""Category"":""^ddi"",""MediationRunId"":""^did_run""}",,,,,,,,,,,,10
 `
 	actionTimings = `
-MORE_MINUTES,MINI,ONE_TIME_RUN,10
-MORE_MINUTES,SHARED,ONE_TIME_RUN,10
-TOPUP10_AT,TOPUP10_AC,ASAP,10
-TOPUP10_AT,TOPUP10_AC1,ASAP,10
-TOPUP_SHARED0_AT,SE0,ASAP,10
-TOPUP_SHARED10_AT,SE10,ASAP,10
-TOPUP_EMPTY_AT,EE0,ASAP,10
+MORE_MINUTES,MINI,ONE_TIME_RUN,10,
+MORE_MINUTES,SHARED,ONE_TIME_RUN,10,
+TOPUP10_AT,TOPUP10_AC,ASAP,10,
+TOPUP10_AT,TOPUP10_AC1,ASAP,10,
+TOPUP_SHARED0_AT,SE0,ASAP,10,
+TOPUP_SHARED10_AT,SE10,ASAP,10,
+TOPUP_EMPTY_AT,EE0,ASAP,10,
 `
 
 	actionTriggers = `
@@ -201,6 +202,30 @@ CDRST1,,,ACD,,,,,,,,,,,,,,,,,,,STANDARD_TRIGGER
 CDRST1,,,ACC,,,,,,,,,,,,,,,,,,,
 CDRST2,10,10m,ASR,,,,,,,cgrates.org,call,,,,,,,,,,,
 CDRST2,,,ACD,,,,,,,,,,,,,,,,,,,
+`
+	exchangeRates = `
+#Tag[0],FromCurrency[1],ToCurrency[2],ActivationTime[3],Rate[4]
+EXR_EUR,EUR,USD,2014-01-01T00:00:00Z,1.35
+EXR_EUR,EUR,USD,2015-01-01T00:00:00Z,1.1
+EXR_GBP,GBP,EUR,2014-01-01T00:00:00Z,1.25
+`
+	taxRules = `
+#Tag[0],Tenant[1],Category[2],DestinationId[3],ActivationTime[4],Rate[5]
+VAT,tax.org,*any,*any,2014-01-01T00:00:00Z,0.19
+VAT,tax.org,*any,*any,2015-01-01T00:00:00Z,0.2
+EXCISE,tax.org,call,PSTN_71,2014-01-01T00:00:00Z,0.05
+`
+	portedNumbers = `
+#Tag[0],Number[1],RoutingPrefix[2]
+NP_D262,4971123456,D262
+NP_D262,4971123457,D262
+NP_BACK,4971123458,
+`
+	calendars = `
+#Tag[0],Date[1],Yearly[2]
+DE,2015-01-01,true
+DE,2015-04-06,false
+DE,2015-12-25,true
 `
 )
 
@@ -208,8 +233,9 @@ var csvr *CSVReader
 
 func init() {
 	csvr = NewStringCSVReader(dataStorage, accountingStorage, ',', destinations, timings, rates, destinationRates, ratingPlans, ratingProfiles,
-		sharedGroups, lcrs, actions, actionTimings, actionTriggers, accountActions, derivedCharges, cdrStats)
+		sharedGroups, lcrs, actions, actionTimings, actionTriggers, accountActions, derivedCharges, cdrStats, exchangeRates, taxRules, portedNumbers, calendars)
 	csvr.LoadDestinations()
+	csvr.LoadCalendars()
 	csvr.LoadTimings()
 	csvr.LoadRates()
 	csvr.LoadDestinationRates()
@@ -223,6 +249,9 @@ func init() {
 	csvr.LoadAccountActions()
 	csvr.LoadDerivedChargers()
 	csvr.LoadCdrStats()
+	csvr.LoadExchangeRates()
+	csvr.LoadTaxRules()
+	csvr.LoadPortedNumbers()
 	csvr.WriteToDatabase(false, false)
 	dataStorage.CacheRating(nil, nil, nil, nil, nil)
 	accountingStorage.CacheAccounting(nil, nil, nil, nil)
@@ -275,7 +304,7 @@ func TestLoadDestinations(t *testing.T) {
 }
 
 func TestLoadTimimgs(t *testing.T) {
-	if len(csvr.timings) != 6 {
+	if len(csvr.timings) != 7 {
 		t.Error("Failed to load timings: ", csvr.timings)
 	}
 	timing := csvr.timings["WORKDAYS_00"]
@@ -322,6 +351,10 @@ func TestLoadTimimgs(t *testing.T) {
 	}) {
 		t.Error("Error loading timing: ", timing)
 	}
+	timing = csvr.timings["WORKDAYS_NOHOL"]
+	if timing.Calendar != "DE" || !timing.ExcludeCalendar || !reflect.DeepEqual(timing.WeekDays, utils.WeekDays{1, 2, 3, 4, 5}) {
+		t.Error("Error loading timing: ", timing)
+	}
 }
 
 func TestLoadRates(t *testing.T) {
@@ -1022,3 +1055,56 @@ func TestLoadCdrStats(t *testing.T) {
 		t.Error("Unexpected stats", csvr.cdrStats[cdrStats1.Id])
 	}
 }
+
+func TestLoadExchangeRates(t *testing.T) {
+	if len(csvr.exchangeRates) != 2 {
+		t.Error("Failed to load exchange rates: ", csvr.exchangeRates)
+	}
+	eurUsd := &ExchangeRate{
+		Id: "EUR:USD",
+		Rates: ExchangeRateActivations{
+			&ExchangeRateActivation{ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.35},
+			&ExchangeRateActivation{ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 1.1},
+		},
+	}
+	if !reflect.DeepEqual(csvr.exchangeRates[eurUsd.Id], eurUsd) {
+		t.Errorf("Unexpected exchange rate %+v", csvr.exchangeRates[eurUsd.Id])
+	}
+}
+
+func TestLoadTaxRules(t *testing.T) {
+	if len(csvr.taxRules) != 1 {
+		t.Error("Failed to load tax rules: ", csvr.taxRules)
+	}
+	expected := &TaxRules{
+		Id: "tax.org",
+		Rules: []*TaxRule{
+			&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.19},
+			&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.2},
+			&TaxRule{Id: "EXCISE", Category: "call", DestinationId: "PSTN_71", ActivationTime: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.05},
+		},
+	}
+	if !reflect.DeepEqual(csvr.taxRules[expected.Id], expected) {
+		t.Errorf("Unexpected tax rules %+v", csvr.taxRules[expected.Id])
+	}
+}
+
+func TestLoadPortedNumbers(t *testing.T) {
+	if len(csvr.portedNumbers) != 3 {
+		t.Error("Failed to load ported numbers: ", csvr.portedNumbers)
+	}
+	expected := &PortedNumber{Id: "4971123456", RoutingPrefix: "D262"}
+	if !reflect.DeepEqual(csvr.portedNumbers[expected.Id], expected) {
+		t.Errorf("Unexpected ported number %+v", csvr.portedNumbers[expected.Id])
+	}
+	if pn := csvr.portedNumbers["4971123458"]; pn == nil || pn.RoutingPrefix != "" {
+		t.Errorf("Unexpected ported back number %+v", pn)
+	}
+}
+
+func TestLoadCalendars(t *testing.T) {
+	expected := utils.CalendarDays{{Month: time.January, Day: 1}, {Year: 2015, Month: time.April, Day: 6}, {Month: time.December, Day: 25}}
+	if len(csvr.calendars) != 1 || !reflect.DeepEqual(csvr.calendars["DE"], expected) {
+		t.Error("Failed to load calendars: ", csvr.calendars)
+	}
+}
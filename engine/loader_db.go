@@ -51,6 +51,10 @@ type DbReader struct {
 	lcrs             map[string]*LCR
 	derivedChargers  map[string]utils.DerivedChargers
 	cdrStats         map[string]*CdrStats
+	exchangeRates    map[string]*ExchangeRate
+	taxRules         map[string]*TaxRules
+	portedNumbers    map[string]*PortedNumber
+	calendars        map[string]utils.CalendarDays
 }
 
 func NewDbReader(storDB LoadStorage, ratingDb RatingStorage, accountDb AccountingStorage, tpid string) *DbReader {
@@ -73,6 +77,10 @@ func NewDbReader(storDB LoadStorage, ratingDb RatingStorage, accountDb Accountin
 	c.destinations = make(map[string]*Destination)
 	c.cdrStats = make(map[string]*CdrStats)
 	c.derivedChargers = make(map[string]utils.DerivedChargers)
+	c.exchangeRates = make(map[string]*ExchangeRate)
+	c.taxRules = make(map[string]*TaxRules)
+	c.portedNumbers = make(map[string]*PortedNumber)
+	c.calendars = make(map[string]utils.CalendarDays)
 	return c
 }
 
@@ -130,12 +138,23 @@ func (dbr *DbReader) ShowStatistics() {
 	log.Print("Derived Chargers: ", len(dbr.derivedChargers))
 	// lcr rules
 	log.Print("LCR rules: ", len(dbr.lcrs))
+	// exchange rates
+	log.Print("Exchange rates: ", len(dbr.exchangeRates))
+	// tax rules
+	log.Print("Tax rules: ", len(dbr.taxRules))
+	// ported numbers
+	log.Print("Ported numbers: ", len(dbr.portedNumbers))
+	// calendars
+	log.Print("Calendars: ", len(dbr.calendars))
 }
 
 func (dbr *DbReader) WriteToDatabase(flush, verbose bool) (err error) {
 	storage := dbr.dataDb
 	if flush {
 		storage.Flush("")
+		if err = SetCurrentVersions(storage, VER_RATING_DB, VER_ACCOUNTING_DB); err != nil {
+			return err
+		}
 	}
 	if verbose {
 		log.Print("Destinations")
@@ -287,6 +306,39 @@ func (dbr *DbReader) WriteToDatabase(flush, verbose bool) (err error) {
 			log.Print(sq.Id)
 		}
 	}
+	if verbose {
+		log.Print("Exchange Rates")
+	}
+	for _, er := range dbr.exchangeRates {
+		if err = dataStorage.SetExchangeRate(er); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print(er.Id)
+		}
+	}
+	if verbose {
+		log.Print("Tax Rules")
+	}
+	for _, tr := range dbr.taxRules {
+		if err = dataStorage.SetTaxRules(tr); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print(tr.Id)
+		}
+	}
+	if verbose {
+		log.Print("Ported Numbers")
+	}
+	for _, pn := range dbr.portedNumbers {
+		if err = setPortedNumber(storage, pn); err != nil {
+			return err
+		}
+		if verbose {
+			log.Print(pn.Id)
+		}
+	}
 	return
 }
 
@@ -330,6 +382,9 @@ func (dbr *DbReader) LoadDestinationRates() (err error) {
 			if !exists {
 				return fmt.Errorf("Could not find rate for tag %v", dr.RateId)
 			}
+			if _, _, err := dr.GetShortCallDurations(); err != nil {
+				return fmt.Errorf("Cannot parse short call durations for destination rate %v: %v", drs.DestinationRateId, err)
+			}
 			dr.Rate = rate
 			destinationExists := dr.DestinationId == utils.ANY
 			if !destinationExists {
@@ -358,6 +413,9 @@ func (dbr *DbReader) LoadRatingPlans() error {
 			if !exists {
 				return fmt.Errorf("Could not get timing for tag %v", rplBnd.TimingId)
 			}
+			if err := dbr.setTimingHolidays(t); err != nil {
+				return err
+			}
 			rplBnd.SetTiming(t)
 			drs, exists := dbr.destinationRates[rplBnd.DestinationRatesId]
 			if !exists {
@@ -405,12 +463,16 @@ func (dbr *DbReader) LoadRatingProfiles() error {
 					return fmt.Errorf("Could not load rating plans for tag: %v", tpRa.RatingPlanId)
 				}
 			}
+			if _, err := utils.GetLocation(tpRa.Timezone); err != nil {
+				return fmt.Errorf("Cannot load time zone %v: %v", tpRa.Timezone, err)
+			}
 			rpf.RatingPlanActivations = append(rpf.RatingPlanActivations,
 				&RatingPlanActivation{
 					ActivationTime:  at,
 					RatingPlanId:    tpRa.RatingPlanId,
 					FallbackKeys:    utils.FallbackSubjKeys(tpRpf.Direction, tpRpf.Tenant, tpRpf.Category, tpRa.FallbackSubjects),
 					CdrStatQueueIds: strings.Split(tpRa.CdrStatQueueIds, utils.INFIELD_SEP),
+					Timezone:        tpRa.Timezone,
 				})
 		}
 		dbr.ratingProfiles[tpRpf.KeyId()] = rpf
@@ -434,6 +496,9 @@ func (dbr *DbReader) LoadRatingPlanByTag(tag string) (bool, error) {
 				return false, fmt.Errorf("No Timings profile with id %s: %v", rp.TimingId, err)
 			}
 			tpTmng := NewTiming(tm[rp.TimingId].TimingId, tm[rp.TimingId].Years, tm[rp.TimingId].Months, tm[rp.TimingId].MonthDays, tm[rp.TimingId].WeekDays, tm[rp.TimingId].Time)
+			if err := dbr.setTimingHolidays(tpTmng); err != nil {
+				return false, err
+			}
 			rp.SetTiming(tpTmng)
 			drm, err := dbr.storDb.GetTpDestinationRates(dbr.tpid, rp.DestinationRatesId, nil)
 			if err != nil || len(drm) == 0 {
@@ -493,12 +558,16 @@ func (dbr *DbReader) LoadRatingProfileFiltered(qriedRpf *utils.TPRatingProfile)
 					return fmt.Errorf("Could not load rating plans for tag: %v", tpRa.RatingPlanId)
 				}
 			}
+			if _, err := utils.GetLocation(tpRa.Timezone); err != nil {
+				return fmt.Errorf("Cannot load time zone %v: %v", tpRa.Timezone, err)
+			}
 			resultRatingProfile.RatingPlanActivations = append(resultRatingProfile.RatingPlanActivations,
 				&RatingPlanActivation{
 					ActivationTime:  at,
 					RatingPlanId:    tpRa.RatingPlanId,
 					FallbackKeys:    utils.FallbackSubjKeys(tpRpf.Direction, tpRpf.Tenant, tpRpf.Category, tpRa.FallbackSubjects),
 					CdrStatQueueIds: strings.Split(tpRa.CdrStatQueueIds, utils.INFIELD_SEP),
+					Timezone:        tpRa.Timezone,
 				})
 		}
 		if err := dbr.dataDb.SetRatingProfile(resultRatingProfile); err != nil {
@@ -583,13 +652,19 @@ func (dbr *DbReader) LoadActions() (err error) {
 				timingIds := strings.Split(acts[idx].Balance.TimingIDs, utils.INFIELD_SEP)
 				for _, timingID := range timingIds {
 					if timing, found := dbr.timings[timingID]; found {
+						if err := dbr.setTimingHolidays(timing); err != nil {
+							return err
+						}
 						acts[idx].Balance.Timings = append(acts[idx].Balance.Timings, &RITiming{
-							Years:     timing.Years,
-							Months:    timing.Months,
-							MonthDays: timing.MonthDays,
-							WeekDays:  timing.WeekDays,
-							StartTime: timing.StartTime,
-							EndTime:   timing.EndTime,
+							Years:           timing.Years,
+							Months:          timing.Months,
+							MonthDays:       timing.MonthDays,
+							WeekDays:        timing.WeekDays,
+							StartTime:       timing.StartTime,
+							EndTime:         timing.EndTime,
+							Calendar:        timing.Calendar,
+							ExcludeCalendar: timing.ExcludeCalendar,
+							Holidays:        timing.Holidays,
 						})
 					} else {
 						return fmt.Errorf("Could not find timing: %v", timingID)
@@ -618,6 +693,9 @@ func (dbr *DbReader) LoadActionTimings() (err error) {
 			if !exists {
 				return fmt.Errorf("ActionTiming: Could not load the timing for tag: %v", at.TimingId)
 			}
+			if _, err := utils.GetLocation(at.Timezone); err != nil {
+				return fmt.Errorf("ActionTiming: Cannot load time zone %v: %v", at.Timezone, err)
+			}
 			actTmg := &ActionTiming{
 				Uuid:   utils.GenUUID(),
 				Id:     atId,
@@ -632,6 +710,7 @@ func (dbr *DbReader) LoadActionTimings() (err error) {
 					},
 				},
 				ActionsId: at.ActionsId,
+				Timezone:  at.Timezone,
 			}
 			dbr.actionsTimings[atId] = append(dbr.actionsTimings[atId], actTmg)
 		}
@@ -766,6 +845,9 @@ func (dbr *DbReader) LoadAccountActionsFiltered(qriedAA *utils.TPAccountActions)
 					return fmt.Errorf("No Timing with id <%s>", at.TimingId)
 				}
 				t := NewTiming(timingsMap[at.TimingId].TimingId, timingsMap[at.TimingId].Years, timingsMap[at.TimingId].Months, timingsMap[at.TimingId].MonthDays, timingsMap[at.TimingId].WeekDays, timingsMap[at.TimingId].Time)
+				if _, err := utils.GetLocation(at.Timezone); err != nil {
+					return fmt.Errorf("ActionTiming: Cannot load time zone %v: %v", at.Timezone, err)
+				}
 				actTmg := &ActionTiming{
 					Uuid:   utils.GenUUID(),
 					Id:     accountAction.ActionPlanId,
@@ -779,6 +861,7 @@ func (dbr *DbReader) LoadAccountActionsFiltered(qriedAA *utils.TPAccountActions)
 						},
 					},
 					ActionsId: at.ActionsId,
+					Timezone:  at.Timezone,
 				}
 				// collect action ids from timings
 				actionsIds = append(actionsIds, actTmg.ActionsId)
@@ -968,12 +1051,103 @@ func (dbr *DbReader) LoadCdrStats() error {
 	return dbr.LoadCdrStatsByTag("", false)
 }
 
+func (dbr *DbReader) LoadExchangeRates() error {
+	storErs, err := dbr.storDb.GetTpExchangeRates(dbr.tpid, "")
+	if err != nil {
+		return err
+	}
+	for _, tpErs := range storErs {
+		for _, tpEr := range tpErs {
+			if err := UpdateExchangeRates(dbr.exchangeRates, tpEr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dbr *DbReader) LoadTaxRules() error {
+	storTrs, err := dbr.storDb.GetTpTaxRules(dbr.tpid, "")
+	if err != nil {
+		return err
+	}
+	for taxId, tpTrs := range storTrs {
+		for _, tpTr := range tpTrs {
+			if err := UpdateTaxRules(dbr.taxRules, taxId, tpTr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dbr *DbReader) LoadPortedNumbersByTag(tag string, save bool) error {
+	storPns, err := dbr.storDb.GetTpPortedNumbers(dbr.tpid, tag)
+	if err != nil {
+		return err
+	}
+	var loadedNumbers []string
+	for _, tpPns := range storPns {
+		for _, tpPn := range tpPns {
+			if err := UpdatePortedNumbers(dbr.portedNumbers, tpPn); err != nil {
+				return err
+			}
+			loadedNumbers = append(loadedNumbers, tpPn.Number)
+		}
+	}
+	if save {
+		for _, number := range loadedNumbers {
+			if err := setPortedNumber(dbr.dataDb, dbr.portedNumbers[number]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dbr *DbReader) LoadPortedNumbers() error {
+	return dbr.LoadPortedNumbersByTag("", false)
+}
+
+func (dbr *DbReader) LoadCalendars() error {
+	return dbr.loadCalendarsByTag("")
+}
+
+func (dbr *DbReader) loadCalendarsByTag(tag string) error {
+	storCals, err := dbr.storDb.GetTpCalendars(dbr.tpid, tag)
+	if err != nil {
+		return err
+	}
+	for calId, tpDays := range storCals {
+		delete(dbr.calendars, calId)
+		for _, tpDay := range tpDays {
+			if err := UpdateCalendars(dbr.calendars, calId, tpDay); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Copies the holidays into the timing, the calendar is queried out of storDb when not already loaded
+func (dbr *DbReader) setTimingHolidays(tm *utils.TPTiming) error {
+	if _, loaded := dbr.calendars[tm.Calendar]; tm.Calendar != "" && !loaded {
+		if err := dbr.loadCalendarsByTag(tm.Calendar); err != nil {
+			return err
+		}
+	}
+	return SetTimingHolidays(tm, dbr.calendars)
+}
+
 // Automated loading
 func (dbr *DbReader) LoadAll() error {
 	var err error
 	if err = dbr.LoadDestinations(); err != nil {
 		return err
 	}
+	if err = dbr.LoadCalendars(); err != nil {
+		return err
+	}
 	if err = dbr.LoadTimings(); err != nil {
 		return err
 	}
@@ -1007,6 +1181,15 @@ func (dbr *DbReader) LoadAll() error {
 	if err = dbr.LoadDerivedChargers(); err != nil {
 		return err
 	}
+	if err = dbr.LoadExchangeRates(); err != nil {
+		return err
+	}
+	if err = dbr.LoadTaxRules(); err != nil {
+		return err
+	}
+	if err = dbr.LoadPortedNumbers(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1093,6 +1276,30 @@ func (dbr *DbReader) GetLoadedIds(categ string) ([]string, error) {
 			i++
 		}
 		return keys, nil
+	case EXCHANGE_RATE_PREFIX:
+		keys := make([]string, len(dbr.exchangeRates))
+		i := 0
+		for k := range dbr.exchangeRates {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
+	case TAX_RULES_PREFIX:
+		keys := make([]string, len(dbr.taxRules))
+		i := 0
+		for k := range dbr.taxRules {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
+	case PORTED_NUMBER_PREFIX:
+		keys := make([]string, len(dbr.portedNumbers))
+		i := 0
+		for k := range dbr.portedNumbers {
+			keys[i] = k
+			i++
+		}
+		return keys, nil
 	}
 	return nil, errors.New("Unsupported category")
 }
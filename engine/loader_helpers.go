@@ -116,6 +116,10 @@ type TPLoader interface {
 	LoadActionTriggers() error
 	LoadAccountActions() error
 	LoadDerivedChargers() error
+	LoadExchangeRates() error
+	LoadTaxRules() error
+	LoadPortedNumbers() error
+	LoadCalendars() error
 	LoadAll() error
 	GetLoadedIds(string) ([]string, error)
 	ShowStatistics()
@@ -161,7 +165,18 @@ func NewTiming(timingInfo ...string) (rt *utils.TPTiming) {
 	rt.Years.Parse(timingInfo[1], utils.INFIELD_SEP)
 	rt.Months.Parse(timingInfo[2], utils.INFIELD_SEP)
 	rt.MonthDays.Parse(timingInfo[3], utils.INFIELD_SEP)
-	rt.WeekDays.Parse(timingInfo[4], utils.INFIELD_SEP)
+	var weekDays []string
+	for _, wd := range strings.Split(timingInfo[4], utils.INFIELD_SEP) {
+		if strings.HasPrefix(wd, utils.META_HOLIDAYS) {
+			rt.Calendar = wd[len(utils.META_HOLIDAYS):]
+		} else if strings.HasPrefix(wd, "!"+utils.META_HOLIDAYS) {
+			rt.Calendar = wd[len(utils.META_HOLIDAYS)+1:]
+			rt.ExcludeCalendar = true
+		} else {
+			weekDays = append(weekDays, wd)
+		}
+	}
+	rt.WeekDays.Parse(strings.Join(weekDays, utils.INFIELD_SEP), utils.INFIELD_SEP)
 	times := strings.Split(timingInfo[5], utils.INFIELD_SEP)
 	rt.StartTime = times[0]
 	if len(times) > 1 {
@@ -170,6 +185,79 @@ func NewTiming(timingInfo ...string) (rt *utils.TPTiming) {
 	return
 }
 
+// Copies the days of the holiday calendar referenced by the timing
+func SetTimingHolidays(tm *utils.TPTiming, calendars map[string]utils.CalendarDays) error {
+	if tm.Calendar == "" {
+		return nil
+	}
+	days, exists := calendars[tm.Calendar]
+	if !exists {
+		return fmt.Errorf("Could not get calendar for tag %v", tm.Calendar)
+	}
+	tm.Holidays = days
+	return nil
+}
+
+// Adds the tariff plan calendar days to the ones of the calendar
+func UpdateCalendars(cals map[string]utils.CalendarDays, calId string, tpDay *utils.TPCalendarDay) error {
+	days := cals[calId]
+	if err := days.Add(tpDay.Date, tpDay.Yearly); err != nil {
+		return fmt.Errorf("Cannot parse calendar date from %v", tpDay.Date)
+	}
+	cals[calId] = days
+	return nil
+}
+
+// Adds the tariff plan exchange rate to the ones indexed on currencies
+func UpdateExchangeRates(ers map[string]*ExchangeRate, tpEr *utils.TPExchangeRate) error {
+	at, err := utils.ParseTimeDetectLayout(tpEr.ActivationTime)
+	if err != nil {
+		return fmt.Errorf("Cannot parse activation time from %v", tpEr.ActivationTime)
+	}
+	if tpEr.FromCurrency == "" || tpEr.ToCurrency == "" || tpEr.Rate <= 0 {
+		return fmt.Errorf("Invalid exchange rate %+v", tpEr)
+	}
+	key := ExchangeRateKey(tpEr.FromCurrency, tpEr.ToCurrency)
+	er, exists := ers[key]
+	if !exists {
+		er = &ExchangeRate{Id: key}
+		ers[key] = er
+	}
+	er.AddActivation(&ExchangeRateActivation{ActivationTime: at, Rate: tpEr.Rate})
+	return nil
+}
+
+// Adds the tariff plan tax rule to the ones of its tenant
+func UpdateTaxRules(trs map[string]*TaxRules, taxId string, tpTr *utils.TPTaxRule) error {
+	at, err := utils.ParseTimeDetectLayout(tpTr.ActivationTime)
+	if err != nil {
+		return fmt.Errorf("Cannot parse activation time from %v", tpTr.ActivationTime)
+	}
+	if taxId == "" || tpTr.Rate < 0 {
+		return fmt.Errorf("Invalid tax rule %s: %+v", taxId, tpTr)
+	}
+	tenant := tpTr.Tenant
+	if tenant == "" {
+		tenant = utils.ANY
+	}
+	tr, exists := trs[tenant]
+	if !exists {
+		tr = &TaxRules{Id: tenant}
+		trs[tenant] = tr
+	}
+	tr.AddRule(&TaxRule{Id: taxId, Category: tpTr.Category, DestinationId: tpTr.DestinationId, ActivationTime: at, Rate: tpTr.Rate})
+	return nil
+}
+
+// Adds the tariff plan ported number, an empty routing prefix is kept so the porting gets removed on write
+func UpdatePortedNumbers(pns map[string]*PortedNumber, tpPn *utils.TPPortedNumber) error {
+	if tpPn.Number == "" {
+		return fmt.Errorf("Invalid ported number: %+v", tpPn)
+	}
+	pns[tpPn.Number] = &PortedNumber{Id: tpPn.Number, RoutingPrefix: tpPn.RoutingPrefix}
+	return nil
+}
+
 func UpdateCdrStats(cs *CdrStats, triggers ActionTriggerPriotityList, tpCs *utils.TPCdrStat) {
 	if tpCs.QueueLength != "" {
 		if qi, err := strconv.Atoi(tpCs.QueueLength); err == nil {
@@ -327,21 +415,31 @@ func NewRatingPlan(timing *utils.TPTiming, weight string) (drt *utils.TPRatingPl
 }
 
 func GetRateInterval(rpl *utils.TPRatingPlanBinding, dr *utils.DestinationRate) (i *RateInterval) {
+	minDuration, freeUnder, _ := dr.GetShortCallDurations() // checked when loading the destination rates
 	i = &RateInterval{
 		Timing: &RITiming{
-			Years:     rpl.Timing().Years,
-			Months:    rpl.Timing().Months,
-			MonthDays: rpl.Timing().MonthDays,
-			WeekDays:  rpl.Timing().WeekDays,
-			StartTime: rpl.Timing().StartTime,
+			Years:           rpl.Timing().Years,
+			Months:          rpl.Timing().Months,
+			MonthDays:       rpl.Timing().MonthDays,
+			WeekDays:        rpl.Timing().WeekDays,
+			StartTime:       rpl.Timing().StartTime,
+			Calendar:        rpl.Timing().Calendar,
+			ExcludeCalendar: rpl.Timing().ExcludeCalendar,
+			Holidays:        rpl.Timing().Holidays,
 		},
 		Weight: rpl.Weight,
 		Rating: &RIRate{
-			ConnectFee:       dr.Rate.RateSlots[0].ConnectFee,
-			RoundingMethod:   dr.RoundingMethod,
-			RoundingDecimals: dr.RoundingDecimals,
-			MaxCost:          dr.MaxCost,
-			MaxCostStrategy:  dr.MaxCostStrategy,
+			ConnectFee:         dr.Rate.RateSlots[0].ConnectFee,
+			RoundingMethod:     dr.RoundingMethod,
+			RoundingDecimals:   dr.RoundingDecimals,
+			MaxCost:            dr.MaxCost,
+			MaxCostStrategy:    dr.MaxCostStrategy,
+			TierPeriod:         dr.TierPeriod,
+			Currency:           dr.Currency,
+			MinDuration:        minDuration,
+			MinCost:            dr.MinCost,
+			FreeUnder:          freeUnder,
+			ConnectFeeOnAnswer: dr.ConnectFeeOnAnswer,
 		},
 	}
 	for _, rl := range dr.Rate.RateSlots {
@@ -398,20 +496,32 @@ var FileValidators = map[string]*FileLineRegexValidator{
 		regexp.MustCompile(`(?:\w+\s*,\s*){1}(?:\+?\d+.?\d*){1}$`),
 		"Tag([0-9A-Za-z_]),Prefix([0-9])"},
 	utils.TIMINGS_CSV: &FileLineRegexValidator{utils.TIMINGS_NRCOLS,
-		regexp.MustCompile(`(?:\w+\s*,\s*){1}(?:\*any\s*,\s*|(?:\d{1,4};?)+\s*,\s*|\s*,\s*){4}(?:\d{2}:\d{2}:\d{2}|\*asap){1}$`),
-		"Tag([0-9A-Za-z_]),Years([0-9;]|*any|<empty>),Months([0-9;]|*any|<empty>),MonthDays([0-9;]|*any|<empty>),WeekDays([0-9;]|*any|<empty>),Time([0-9:]|*asap)"},
+		regexp.MustCompile(`(?:\w+\s*,\s*){1}(?:\*any\s*,\s*|(?:\d{1,4};?)+\s*,\s*|\s*,\s*){3}(?:\*any\s*,\s*|(?:(?:\d{1,4}|!?\*holidays:\w+);?)+\s*,\s*|\s*,\s*){1}(?:\d{2}:\d{2}:\d{2}|\*asap){1}$`),
+		"Tag([0-9A-Za-z_]),Years([0-9;]|*any|<empty>),Months([0-9;]|*any|<empty>),MonthDays([0-9;]|*any|<empty>),WeekDays([0-9;]|*any|<empty>|[!]*holidays:<calendar>),Time([0-9:]|*asap)"},
 	utils.RATES_CSV: &FileLineRegexValidator{utils.RATES_NRCOLS,
 		regexp.MustCompile(`(?:\w+\s*),(?:\d+\.*\d*s*),(?:\d+\.*\d*s*),(?:\d+\.*\d*(ns|us|µs|ms|s|m|h)*\s*),(?:\d+\.*\d*(ns|us|µs|ms|s|m|h)*\s*),(?:\d+\.*\d*(ns|us|µs|ms|s|m|h)*\s*)$`),
 		"Tag([0-9A-Za-z_]),ConnectFee([0-9.]),Rate([0-9.]),RateUnit([0-9.]ns|us|µs|ms|s|m|h),RateIncrementStart([0-9.]ns|us|µs|ms|s|m|h),GroupIntervalStart([0-9.]ns|us|µs|ms|s|m|h)"},
 	utils.DESTINATION_RATES_CSV: &FileLineRegexValidator{utils.DESTINATION_RATES_NRCOLS,
-		regexp.MustCompile(`^(?:\w+\s*),(?:\w+\s*|\*any),(?:\w+\s*),(?:\*up|\*down|\*middle),(?:\d+),(?:\d+\.*\d*s*)?,(?:\*free|\*diconnect)?$`),
-		"Tag([0-9A-Za-z_]),DestinationsTag([0-9A-Za-z_]|*any),RatesTag([0-9A-Za-z_]),RoundingMethod(*up|*middle|*down),RoundingDecimals([0-9.])"},
+		regexp.MustCompile(`^(?:\w+\s*),(?:\w+\s*|\*any),(?:\w+\s*),(?:\*up|\*down|\*middle),(?:\d+),(?:\d+\.*\d*s*)?,(?:\*free|\*diconnect)?,(?:\*daily|\*monthly|\*yearly)?,(?:[A-Za-z]+)?,(?:\d+\.?\d*[smh]?)?,(?:\d+\.?\d*)?,(?:\d+\.?\d*[smh]?)?,(?:true|false)?$`),
+		"Tag([0-9A-Za-z_]),DestinationsTag([0-9A-Za-z_]|*any),RatesTag([0-9A-Za-z_]),RoundingMethod(*up|*middle|*down),RoundingDecimals([0-9.]),MaxCost([0-9.]),MaxCostStrategy(*free|*disconnect),TierPeriod(*daily|*monthly|*yearly),Currency([A-Za-z]),MinDuration([0-9.smh]),MinCost([0-9.]),FreeUnder([0-9.smh]),ConnectFeeOnAnswer(true|false)"},
+	utils.EXCHANGE_RATES_CSV: &FileLineRegexValidator{utils.EXCHANGE_RATES_NRCOLS,
+		regexp.MustCompile(`^(?:\w+\s*),(?:[A-Za-z]+),(?:[A-Za-z]+),(?:\S+),(?:\d+\.?\d*)$`),
+		"Tag([0-9A-Za-z_]),FromCurrency([A-Za-z]),ToCurrency([A-Za-z]),ActivationTime([0-9T:X]),Rate([0-9.])"},
+	utils.TAX_RULES_CSV: &FileLineRegexValidator{utils.TAX_RULES_NRCOLS,
+		regexp.MustCompile(`^(?:\w+\s*),(?:[0-9A-Za-z_\.]+\s*|\*any),(?:\w+\s*|\*any),(?:\w+\s*|\*any),(?:\S+),(?:\d+\.?\d*)$`),
+		"Tag([0-9A-Za-z_]),Tenant([0-9A-Za-z_]|*any),Category([0-9A-Za-z_]|*any),DestinationId([0-9A-Za-z_]|*any),ActivationTime([0-9T:X]),Rate([0-9.])"},
+	utils.PORTED_NUMBERS_CSV: &FileLineRegexValidator{utils.PORTED_NUMBERS_NRCOLS,
+		regexp.MustCompile(`^(?:\w+\s*),(?:\+?\d+),(?:\w*)$`),
+		"Tag([0-9A-Za-z_]),Number([0-9+]),RoutingPrefix([0-9A-Za-z_])"},
+	utils.CALENDARS_CSV: &FileLineRegexValidator{utils.CALENDARS_NRCOLS,
+		regexp.MustCompile(`^(?:\w+\s*),(?:\d{4}-\d{2}-\d{2}),(?:true|false)?$`),
+		"Tag([0-9A-Za-z_]),Date(YYYY-MM-DD),Yearly(true|false)"},
 	utils.RATING_PLANS_CSV: &FileLineRegexValidator{utils.DESTRATE_TIMINGS_NRCOLS,
 		regexp.MustCompile(`(?:\w+\s*,\s*){3}(?:\d+.?\d*){1}$`),
 		"Tag([0-9A-Za-z_]),DestinationRatesTag([0-9A-Za-z_]),TimingProfile([0-9A-Za-z_]),Weight([0-9.])"},
 	utils.RATING_PROFILES_CSV: &FileLineRegexValidator{utils.RATE_PROFILES_NRCOLS,
-		regexp.MustCompile(`^(?:\*out\s*),(?:[0-9A-Za-z_\.]+\s*),(?:\w+\s*),(?:\*any\s*|(\w+;?)+\s*),(?:\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z),(?:\w+\s*),(?:\w+\s*)?,(?:\w+\s*)?$`),
-		"Direction(*out),Tenant([0-9A-Za-z_]),Category([0-9A-Za-z_]),Subject([0-9A-Za-z_]|*any),ActivationTime([0-9T:X]),RatingPlanId([0-9A-Za-z_]),RatesFallbackSubject([0-9A-Za-z_]|<empty>),CdrStatQueueIds([0-9A-Za-z_]|<empty>)"},
+		regexp.MustCompile(`^(?:\*out\s*),(?:[0-9A-Za-z_\.]+\s*),(?:\w+\s*),(?:\*any\s*|(\w+;?)+\s*),(?:\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z),(?:\w+\s*),(?:\w+\s*)?,(?:\w+\s*)?,(?:[\w/+-]+\s*)?$`),
+		"Direction(*out),Tenant([0-9A-Za-z_]),Category([0-9A-Za-z_]),Subject([0-9A-Za-z_]|*any),ActivationTime([0-9T:X]),RatingPlanId([0-9A-Za-z_]),RatesFallbackSubject([0-9A-Za-z_]|<empty>),CdrStatQueueIds([0-9A-Za-z_]|<empty>),Timezone([0-9A-Za-z_/+-]|<empty>)"},
 	utils.SHARED_GROUPS_CSV: &FileLineRegexValidator{utils.SHARED_GROUPS_NRCOLS,
 		regexp.MustCompile(`(?:\w+\s*),(?:\*?\w+\s*),(?:\*\w+\s*),(?:\*?\w]+\s*)?`),
 		"Id([0-9A-Za-z_]),Account(*?[0-9A-Za-z_]),Strategy(*[0-9A-Za-z_]),RatingSubject(*?[0-9A-Za-z_])"},
@@ -419,8 +529,8 @@ var FileValidators = map[string]*FileLineRegexValidator{
 		regexp.MustCompile(`^(?:\w+\s*),(?:\*\w+\s*),(?:\S+\s*)?,(?:\w+\s*)?,(?:\*\w+\s*)?,(?:\*out\s*)?,(?:\*?\w+\s*)?,(?:\*any|\w+\s*)?,(?:\w+\s*)?,(?:\w+\s*)?,(?:\*\w+\s*|\+\d+[smh]\s*|\d+\s*)?,(?:[0-9A-Za-z_;]*)?,(?:\d+\s*)?,(?:\d+\.?\d*\s*)?,(?:\d+\.?\d*\s*)$`),
 		"Tag([0-9A-Za-z_]),Action([0-9A-Za-z_]),ExtraParameters([0-9A-Za-z_:;]),BalanceTag([0-9A-Za-z_]),BalanceType([*a-z_]),Direction(*out),Category([0-9A-Za-z_]),DestinationTag([0-9A-Za-z_]|*any),RatingSubject([0-9A-Za-z_]),SharedGroup([0-9A-Za-z_]),ExpiryTime(*[a-z_]|+[0-9][smh]|[0-9]),TimingTags(([0-9A-Za-z_];?)*),Units([0-9]),BalanceWeight([0-9.]),Weight([0-9.])"},
 	utils.ACTION_PLANS_CSV: &FileLineRegexValidator{utils.ACTION_PLANS_NRCOLS,
-		regexp.MustCompile(`(?:\w+\s*,\s*){3}(?:\d+\.?\d*){1}`),
-		"Tag([0-9A-Za-z_]),ActionsTag([0-9A-Za-z_]),TimingTag([0-9A-Za-z_]),Weight([0-9.])"},
+		regexp.MustCompile(`(?:\w+\s*,\s*){3}(?:\d+\.?\d*){1},(?:[\w/+-]+\s*)?$`),
+		"Tag([0-9A-Za-z_]),ActionsTag([0-9A-Za-z_]),TimingTag([0-9A-Za-z_]),Weight([0-9.]),Timezone([0-9A-Za-z_/+-]|<empty>)"},
 	utils.ACTION_TRIGGERS_CSV: &FileLineRegexValidator{utils.ACTION_TRIGGERS_NRCOLS, regexp.MustCompile(`(?:\w+),(?:\w+)?,(?:\*\w+),(?:\d+\.?\d*),(?:true|false)?,(?:\d+[smh]?),(?:\w+\s*)?,(?:\*\w+)?,(?:\*out)?,(?:\w+|\*any)?,(?:\w+|\*any)?,(?:\w+|\*any)?,(?:\w+|\*any)?,(?:\*\w+\s*|\+\d+[smh]\s*|\d+\s*)?,(?:[0-9A-Za-z_;]*)?,(?:\d+\.?\d*)?,(?:\d+)?,(?:\w+),(?:\d+\.?\d*)$`),
 		"Tag([0-9A-Za-z_]),UniqueId([0-9A-Za-z_]),ThresholdType(*[a-z_]),ThresholdValue([0-9]+),Recurrent(true|false),MinSleep([0-9]+)?,BalanceTag([0-9A-Za-z_]),BalanceType(*[a-z_]),BalanceDirection(*out),BalanceCategory([a-z_]),BalanceDestinationTag([0-9A-Za-z_]|*all),BalanceRatingSubject(*[a-z_]),BalanceSharedGroup(*[a-z_]),BalanceExpiryTime(*[a-z_]|+[0-9][smh]|[0-9]),BalanceTimingTags(([0-9A-Za-z_];?)*)BalanceWeight(*[a-z_]),StatsMinQueuedItems([0-9]+),ActionsTag([0-9A-Za-z_]),Weight([0-9]+)"},
 	utils.ACCOUNT_ACTIONS_CSV: &FileLineRegexValidator{utils.ACCOUNT_ACTIONS_NRCOLS,
@@ -45,21 +45,22 @@ DUMMY,INVALID;DATA
 RT_DATA_2c,0,0.002,10,10,0
 `
 
-var destRatesSample = `#Tag,DestinationsTag,RatesTag,MaxCost,MaxCostStrategy
-DR_RETAIL,GERMANY,RT_1CENT,*up,0,0,
+var destRatesSample = `#Tag,DestinationsTag,RatesTag,MaxCost,MaxCostStrategy,TierPeriod,Currency,MinDuration,MinCost,FreeUnder,ConnectFeeOnAnswer
+DR_RETAIL,GERMANY,RT_1CENT,*up,0,0,,,,,,,
 DUMMY,INVALID;DATA
-DR_DATA_1,*any,RT_DATA_2c,*up,2,0,
-_TNT_1211_01_V_ANY,CST_1246534_BRB02,C_TNT_1211_01_V_1246534_BRB02_ANY,*up,2,,
+DR_DATA_1,*any,RT_DATA_2c,*up,2,0,,,,,,,
+_TNT_1211_01_V_ANY,CST_1246534_BRB02,C_TNT_1211_01_V_1246534_BRB02_ANY,*up,2,,,,,,,,
+DR_SHORT,DST_1002,RT_1CENT,*up,4,0,,,,30s,0.5,5s,true
 `
 var ratingPlansSample = `#Tag,DestinationRatesTag,TimingTag,Weight
 RP_RETAIL,DR_RETAIL,ALWAYS,10
 DUMMY,INVALID;DATA
 `
 
-var ratingProfilesSample = `#Tenant,TOR,Direction,Subject,ActivationTime,RatingPlanTag,FallbackSubject
-*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_RETAIL,,
+var ratingProfilesSample = `#Tenant,TOR,Direction,Subject,ActivationTime,RatingPlanTag,FallbackSubject,CdrStatQueueIds,Timezone
+*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_RETAIL,,,
 DUMMY,INVALID;DATA
-*out,cgrates.org,call,subj1;alias1,2012-01-01T00:00:00Z,RP_RETAIL,,
+*out,cgrates.org,call,subj1;alias1,2012-01-01T00:00:00Z,RP_RETAIL,,,America/Argentina/Buenos_Aires
 `
 
 var actionsSample = `#ActionsTag[0],Action[1],ExtraParameters[2],BalanceTag[3],BalanceType[4],Direction[5],Category[6],DestinationTag[7],RatingSubject[8],SharedGroup[9],ExpiryTime[10],TimingTags[11],Units[12],BalanceWeight[13],Weight[14]
@@ -72,8 +73,8 @@ TOPUP_RST_SHARED_5,*topup_reset,param&some,,*monetary,*out,,*any,subj,SHARED_A,*
 DEFEE,*cdrlog,"{""Category"":""^ddi"",""MediationRunId"":""^did_run""}",,,,,,,,,,,,10
 `
 
-var actionTimingsSample = `#Tag,ActionsTag,TimingTag,Weight
-PREPAID_10,PREPAID_10,ASAP,10
+var actionTimingsSample = `#Tag,ActionsTag,TimingTag,Weight,Timezone
+PREPAID_10,PREPAID_10,ASAP,10,Europe/Berlin
 DUMMY,INVALID;DATA
 `
 
@@ -199,7 +200,7 @@ func TestDestRatesValidator(t *testing.T) {
 			if valid {
 				t.Error("Validation passed for invalid line", string(ln))
 			}
-		case 2, 4, 5:
+		case 2, 4, 5, 6:
 			if !valid {
 				t.Error("Validation did not pass for valid line", string(ln))
 			}
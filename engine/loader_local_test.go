@@ -56,25 +56,28 @@ func TestConnDataDbs(t *testing.T) {
 	}
 	lCfg, _ = config.NewDefaultCGRConfig()
 	var err error
-	if ratingDbCsv, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "4", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding); err != nil {
+	if ratingDbCsv, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "4", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding,
+		lCfg.RatingDBSentinels, lCfg.RatingDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
-	if ratingDbStor, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "5", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding); err != nil {
+	if ratingDbStor, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "5", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding,
+		lCfg.RatingDBSentinels, lCfg.RatingDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
-	if ratingDbApier, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "6", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding); err != nil {
+	if ratingDbApier, err = ConfigureRatingStorage(lCfg.RatingDBType, lCfg.RatingDBHost, lCfg.RatingDBPort, "6", lCfg.RatingDBUser, lCfg.RatingDBPass, lCfg.DBDataEncoding,
+		lCfg.RatingDBSentinels, lCfg.RatingDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
 	if accountDbCsv, err = ConfigureAccountingStorage(lCfg.AccountDBType, lCfg.AccountDBHost, lCfg.AccountDBPort, "7",
-		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding); err != nil {
+		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding, lCfg.AccountDBSentinels, lCfg.AccountDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
 	if accountDbStor, err = ConfigureAccountingStorage(lCfg.AccountDBType, lCfg.AccountDBHost, lCfg.AccountDBPort, "8",
-		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding); err != nil {
+		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding, lCfg.AccountDBSentinels, lCfg.AccountDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
 	if accountDbApier, err = ConfigureAccountingStorage(lCfg.AccountDBType, lCfg.AccountDBHost, lCfg.AccountDBPort, "9",
-		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding); err != nil {
+		lCfg.AccountDBUser, lCfg.AccountDBPass, lCfg.DBDataEncoding, lCfg.AccountDBSentinels, lCfg.AccountDBMasterName); err != nil {
 		t.Fatal("Error on ratingDb connection: ", err.Error())
 	}
 	for _, db := range []Storage{ratingDbCsv, ratingDbStor, ratingDbApier, accountDbCsv, accountDbStor, accountDbApier} {
@@ -131,6 +134,10 @@ func TestLoadFromCSV(t *testing.T) {
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.ACCOUNT_ACTIONS_CSV),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.DERIVED_CHARGERS_CSV),
 		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.CDR_STATS_CSV),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.EXCHANGE_RATES_CSV),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.TAX_RULES_CSV),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.PORTED_NUMBERS_CSV),
+		path.Join(*dataDir, "tariffplans", *tpCsvScenario, utils.CALENDARS_CSV),
 	)
 
 	if err = loader.LoadDestinations(); err != nil {
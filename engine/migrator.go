@@ -0,0 +1,314 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Transforms the data of one item from a version into the next one, returns the number of objects changed
+type migrationStep func(m *Migrator) (int, error)
+
+// Steps indexed on the version they migrate from, nil when only the version number changes.
+// The fields added to the stored structures default to the previous behaviour, hence most steps only bump the version.
+var migrations = map[string]map[int64]migrationStep{
+	VER_RATING_DB: map[int64]migrationStep{
+		0: nil,
+		1: nil, // RIRate.TierPeriod
+		2: nil, // RIRate.Currency, exchange rates
+		3: nil, // tax rules
+		4: nil, // ported numbers
+		5: nil, // RITiming holiday calendars
+		6: nil, // RatingPlanActivation.Timezone
+		7: nil, // RIRate.MinDuration, MinCost, FreeUnder and ConnectFeeOnAnswer
+	},
+	VER_ACCOUNTING_DB: map[int64]migrationStep{
+		0: migrateAccountingV0,
+		1: nil, // Account.PeriodUsages
+		2: nil, // Balance.Currency
+		3: nil, // ActionTiming.Timezone
+		4: nil, // Account.Reservations
+		5: nil, // Account.CreditLimit and BillingCycle
+		6: nil, // Account.ActivationDate and CancellationDate, Action.ProrationFactor
+		7: nil, // Balance.RolloverFrom, Action.RolledOver
+		8: migrateAccountingV8,
+	},
+	VER_STOR_DB: map[int64]migrationStep{
+		0: nil, // the versions table gets created when stamping
+		1: sqlSchemaStep(
+			"CREATE TABLE ledger (<id>, account_id VARCHAR(192) NOT NULL, balance_type VARCHAR(32) NOT NULL, balance_uuid VARCHAR(64) NOT NULL, "+
+				"balance_id VARCHAR(64) NOT NULL, delta NUMERIC(20,4) NOT NULL, value NUMERIC(20,4) NOT NULL, cgrid VARCHAR(40) NOT NULL, "+
+				"action_id VARCHAR(64) NOT NULL, created_at TIMESTAMP NULL)",
+			"CREATE INDEX account_time_idx ON ledger (account_id, created_at)"),
+		2: sqlSchemaStep(
+			"ALTER TABLE tp_destination_rates ADD COLUMN tier_period VARCHAR(16) NOT NULL DEFAULT ''"),
+		3: sqlSchemaStep(
+			"ALTER TABLE cost_details ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT ''",
+			"ALTER TABLE cost_details ADD COLUMN debited_cost NUMERIC(20,4) NOT NULL DEFAULT 0",
+			"ALTER TABLE cost_details ADD COLUMN debited_currency VARCHAR(8) NOT NULL DEFAULT ''",
+			"ALTER TABLE rated_cdrs ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT ''",
+			"ALTER TABLE rated_cdrs ADD COLUMN debited_cost NUMERIC(20,4) DEFAULT NULL",
+			"ALTER TABLE rated_cdrs ADD COLUMN debited_currency VARCHAR(8) NOT NULL DEFAULT ''",
+			"ALTER TABLE tp_destination_rates ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT ''",
+			"CREATE TABLE tp_exchange_rates (<id>, tpid VARCHAR(64) NOT NULL, tag VARCHAR(64) NOT NULL, from_currency VARCHAR(8) NOT NULL, "+
+				"to_currency VARCHAR(8) NOT NULL, activation_time VARCHAR(24) NOT NULL, rate NUMERIC(20,8) NOT NULL, created_at TIMESTAMP NULL, "+
+				"UNIQUE (tpid, tag, from_currency, to_currency, activation_time))",
+			"CREATE INDEX tpexchangerates_tpid_idx ON tp_exchange_rates (tpid)",
+			"CREATE INDEX tpexchangerates_idx ON tp_exchange_rates (tpid, tag)"),
+		4: sqlSchemaStep(
+			"ALTER TABLE cost_details ADD COLUMN tax NUMERIC(20,4) NOT NULL DEFAULT 0",
+			"ALTER TABLE cost_details ADD COLUMN gross_cost NUMERIC(20,4) NOT NULL DEFAULT 0",
+			"ALTER TABLE rated_cdrs ADD COLUMN tax NUMERIC(20,4) DEFAULT NULL",
+			"ALTER TABLE rated_cdrs ADD COLUMN gross_cost NUMERIC(20,4) DEFAULT NULL",
+			"CREATE TABLE tp_tax_rules (<id>, tpid VARCHAR(64) NOT NULL, tag VARCHAR(64) NOT NULL, tenant VARCHAR(64) NOT NULL, "+
+				"category VARCHAR(32) NOT NULL, destination_id VARCHAR(64) NOT NULL, activation_time VARCHAR(24) NOT NULL, rate NUMERIC(20,8) NOT NULL, "+
+				"created_at TIMESTAMP NULL, UNIQUE (tpid, tag, tenant, category, destination_id, activation_time))",
+			"CREATE INDEX tptaxrules_tpid_idx ON tp_tax_rules (tpid)",
+			"CREATE INDEX tptaxrules_idx ON tp_tax_rules (tpid, tag)"),
+		5: sqlSchemaStep(
+			"ALTER TABLE rated_cdrs ADD COLUMN resolved_destination VARCHAR(128) NOT NULL DEFAULT ''",
+			"CREATE TABLE tp_ported_numbers (<id>, tpid VARCHAR(64) NOT NULL, tag VARCHAR(64) NOT NULL, number VARCHAR(64) NOT NULL, "+
+				"routing_prefix VARCHAR(32) NOT NULL, created_at TIMESTAMP NULL, UNIQUE (tpid, tag, number))",
+			"CREATE INDEX tpportednumbers_tpid_idx ON tp_ported_numbers (tpid)",
+			"CREATE INDEX tpportednumbers_idx ON tp_ported_numbers (tpid, tag)"),
+		6: sqlSchemaStep(
+			"CREATE TABLE tp_calendars (<id>, tpid VARCHAR(64) NOT NULL, tag VARCHAR(64) NOT NULL, date VARCHAR(10) NOT NULL, "+
+				"yearly BOOLEAN NOT NULL, created_at TIMESTAMP NULL, UNIQUE (tpid, tag, date))",
+			"CREATE INDEX tpcalendars_tpid_idx ON tp_calendars (tpid)",
+			"CREATE INDEX tpcalendars_idx ON tp_calendars (tpid, tag)"),
+		7: sqlSchemaStep(
+			"ALTER TABLE tp_rating_profiles ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT ''",
+			"ALTER TABLE tp_action_plans ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT ''"),
+		8: sqlSchemaStep(
+			"ALTER TABLE tp_destination_rates ADD COLUMN min_duration VARCHAR(16) NOT NULL DEFAULT ''",
+			"ALTER TABLE tp_destination_rates ADD COLUMN min_cost NUMERIC(7,4) NOT NULL DEFAULT 0",
+			"ALTER TABLE tp_destination_rates ADD COLUMN free_under VARCHAR(16) NOT NULL DEFAULT ''",
+			"ALTER TABLE tp_destination_rates ADD COLUMN connect_fee_on_answer BOOLEAN NOT NULL DEFAULT '0'"),
+		9: sqlSchemaStep(
+			"CREATE TABLE invoices (<id>, account_id VARCHAR(192) NOT NULL, period_start TIMESTAMP NULL, period_end TIMESTAMP NULL, "+
+				"net NUMERIC(20,4) NOT NULL, tax NUMERIC(20,4) NOT NULL, gross NUMERIC(20,4) NOT NULL, content TEXT, "+
+				"created_at TIMESTAMP NULL, updated_at TIMESTAMP NULL)",
+			"CREATE INDEX account_period_idx ON invoices (account_id, period_start)"),
+		// the entries written before have no action type and are left out of the invoices
+		10: sqlSchemaStep(
+			"ALTER TABLE ledger ADD COLUMN action_type VARCHAR(24) NOT NULL DEFAULT ''"),
+	},
+}
+
+// Auto increment primary key of the tables created by the StorDb migrations, replacing <id> in the statements
+var sqlIdColumns = map[string]string{
+	utils.MYSQL:    "id int(11) NOT NULL AUTO_INCREMENT PRIMARY KEY",
+	utils.POSTGRES: "id SERIAL PRIMARY KEY",
+	utils.SQLITE:   "id INTEGER PRIMARY KEY AUTOINCREMENT",
+}
+
+// Runs the schema statements on the SQL StorDbs, the document based ones take the new fields as they come.
+// Returns the number of statements run, or the ones which would run on dry run.
+func sqlSchemaStep(statements ...string) migrationStep {
+	return func(m *Migrator) (int, error) {
+		var db *sql.DB
+		var dialect string
+		switch storDb := m.storDb.(type) {
+		case *MySQLStorage:
+			db, dialect = storDb.Db, utils.MYSQL
+		case *PostgresStorage:
+			db, dialect = storDb.Db, utils.POSTGRES
+		case *SQLiteStorage:
+			db, dialect = storDb.Db, utils.SQLITE
+		default:
+			return 0, nil
+		}
+		if m.dryRun {
+			return len(statements), nil
+		}
+		for idx, stmt := range statements {
+			stmt = strings.Replace(stmt, "<id>", sqlIdColumns[dialect], 1)
+			if _, err := db.Exec(stmt); err != nil {
+				return idx, fmt.Errorf("%s: %v", stmt, err)
+			}
+		}
+		return len(statements), nil
+	}
+}
+
+// Brings the stored data to the versions of the running code
+type Migrator struct {
+	ratingDb  RatingStorage
+	accountDb AccountingStorage
+	storDb    Storage
+	dryRun    bool
+}
+
+// Any of the databases can be nil, in which case it will not be migrated
+func NewMigrator(ratingDb RatingStorage, accountDb AccountingStorage, storDb Storage, dryRun bool) *Migrator {
+	return &Migrator{ratingDb: ratingDb, accountDb: accountDb, storDb: storDb, dryRun: dryRun}
+}
+
+func (m *Migrator) storage(item string) Storage {
+	switch item {
+	case VER_RATING_DB:
+		if m.ratingDb != nil {
+			return m.ratingDb
+		}
+	case VER_ACCOUNTING_DB:
+		if m.accountDb != nil {
+			return m.accountDb
+		}
+	case VER_STOR_DB:
+		return m.storDb
+	}
+	return nil
+}
+
+// Migrates one item step by step up to the current version.
+// In dry run mode the steps only report what they would change and no version gets written.
+func (m *Migrator) Migrate(item string) error {
+	storage := m.storage(item)
+	if storage == nil {
+		return fmt.Errorf("no database to migrate for %s", item)
+	}
+	current, hasIt := CurrentVersions[item]
+	if !hasIt {
+		return fmt.Errorf("unknown version item: %s", item)
+	}
+	version, err := storage.GetVersion(item)
+	if err != nil {
+		if err.Error() != utils.ERR_NOT_FOUND {
+			return err
+		}
+		version = 0 // data written before versioning
+	}
+	if version > current {
+		return fmt.Errorf("%s has version %d, newer than %d known by this migrator", item, version, current)
+	}
+	for ; version < current; version++ {
+		step, hasIt := migrations[item][version]
+		if !hasIt {
+			return fmt.Errorf("no migration for %s from version %d", item, version)
+		}
+		if step != nil {
+			changed, err := step(m)
+			if err != nil {
+				return fmt.Errorf("migrating %s from version %d: %v", item, version, err)
+			}
+			Logger.Info(fmt.Sprintf("<Migrator> %s from version %d to %d, objects changed: %d", item, version, version+1, changed))
+		}
+		if m.dryRun {
+			continue
+		}
+		if err := storage.SetVersion(item, version+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version 8 kept all the closed billing cycles on the account
+func migrateAccountingV8(m *Migrator) (changed int, err error) {
+	if m.accountDb == nil {
+		return 0, errors.New("no accounting database")
+	}
+	acntKeys, err := m.accountDb.GetKeysForPrefix(ACCOUNT_PREFIX)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range acntKeys {
+		acnt, err := m.accountDb.GetAccount(key[len(ACCOUNT_PREFIX):])
+		if err != nil {
+			return changed, err
+		}
+		if acnt.BillingCycle == nil || len(acnt.BillingCycle.Closed) <= MAX_CLOSED_BILLING_CYCLES {
+			continue
+		}
+		changed++
+		if m.dryRun {
+			continue
+		}
+		acnt.BillingCycle.Closed = acnt.BillingCycle.Closed[len(acnt.BillingCycle.Closed)-MAX_CLOSED_BILLING_CYCLES:]
+		if err := m.accountDb.SetAccount(acnt); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// Version 0 left balances and action timings without the unique identifiers the code relies on today
+func migrateAccountingV0(m *Migrator) (changed int, err error) {
+	if m.accountDb == nil {
+		return 0, errors.New("no accounting database")
+	}
+	acntKeys, err := m.accountDb.GetKeysForPrefix(ACCOUNT_PREFIX)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range acntKeys {
+		acnt, err := m.accountDb.GetAccount(key[len(ACCOUNT_PREFIX):])
+		if err != nil {
+			return changed, err
+		}
+		dirty := false
+		for _, bc := range acnt.BalanceMap {
+			for _, b := range bc {
+				if b.Uuid == "" {
+					b.Uuid = utils.GenUUID()
+					dirty = true
+				}
+			}
+		}
+		if !dirty {
+			continue
+		}
+		changed++
+		if m.dryRun {
+			continue
+		}
+		if err := m.accountDb.SetAccount(acnt); err != nil {
+			return changed, err
+		}
+	}
+	apls, err := m.accountDb.GetAllActionTimings()
+	if err != nil {
+		return changed, err
+	}
+	for key, apl := range apls {
+		dirty := false
+		for _, at := range apl {
+			if at.Uuid == "" {
+				at.Uuid = utils.GenUUID()
+				dirty = true
+			}
+		}
+		if !dirty {
+			continue
+		}
+		changed++
+		if m.dryRun {
+			continue
+		}
+		if err := m.accountDb.SetActionTimings(key, apl); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
@@ -62,16 +62,22 @@ type TpRate struct {
 }
 
 type TpDestinationRate struct {
-	Id               int64
-	Tpid             string
-	Tag              string
-	DestinationsTag  string
-	RatesTag         string
-	RoundingMethod   string
-	RoundingDecimals int
-	MaxCost          float64
-	MaxCostStrategy  string
-	CreatedAt        time.Time
+	Id                 int64
+	Tpid               string
+	Tag                string
+	DestinationsTag    string
+	RatesTag           string
+	RoundingMethod     string
+	RoundingDecimals   int
+	MaxCost            float64
+	MaxCostStrategy    string
+	TierPeriod         string
+	Currency           string
+	MinDuration        string
+	MinCost            float64
+	FreeUnder          string
+	ConnectFeeOnAnswer bool
+	CreatedAt          time.Time
 }
 
 type TpRatingPlan struct {
@@ -96,6 +102,7 @@ type TpRatingProfile struct {
 	RatingPlanTag    string
 	FallbackSubjects string
 	CdrStatQueueIds  string
+	Timezone         string
 	CreatedAt        time.Time
 }
 
@@ -155,6 +162,7 @@ type TpActionPlan struct {
 	ActionsTag string
 	TimingTag  string
 	Weight     float64
+	Timezone   string
 	CreatedAt  time.Time
 }
 
@@ -217,6 +225,47 @@ type TpSharedGroup struct {
 	CreatedAt     time.Time
 }
 
+type TpTaxRule struct {
+	Id             int64
+	Tpid           string
+	Tag            string
+	Tenant         string
+	Category       string
+	DestinationId  string
+	ActivationTime string
+	Rate           float64
+	CreatedAt      time.Time
+}
+
+type TpPortedNumber struct {
+	Id            int64
+	Tpid          string
+	Tag           string
+	Number        string
+	RoutingPrefix string
+	CreatedAt     time.Time
+}
+
+type TpCalendar struct {
+	Id        int64
+	Tpid      string
+	Tag       string
+	Date      string
+	Yearly    bool
+	CreatedAt time.Time
+}
+
+type TpExchangeRate struct {
+	Id             int64
+	Tpid           string
+	Tag            string
+	FromCurrency   string
+	ToCurrency     string
+	ActivationTime string
+	Rate           float64
+	CreatedAt      time.Time
+}
+
 type TpDerivedCharger struct {
 	Id                   int64
 	Tpid                 string
@@ -326,51 +375,107 @@ func (t TblCdrsExtra) TableName() string {
 }
 
 type TblCostDetail struct {
-	Id          int64
-	Cgrid       string
-	Runid       string
-	Tor         string
-	Direction   string
-	Tenant      string
-	Category    string
-	Account     string
-	Subject     string
-	Destination string
-	Cost        float64
-	Timespans   string
-	CostSource  string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   time.Time
-}
-
-func (t TblCostDetail) TableName() string {
-	return utils.TBL_COST_DETAILS
-}
-
-type TblRatedCdr struct {
 	Id              int64
 	Cgrid           string
 	Runid           string
-	Reqtype         string
+	Tor             string
 	Direction       string
 	Tenant          string
 	Category        string
 	Account         string
 	Subject         string
 	Destination     string
-	SetupTime       time.Time
-	AnswerTime      time.Time
-	Usage           float64
-	Supplier        string
-	DisconnectCause string
 	Cost            float64
-	ExtraInfo       string
+	Currency        string
+	DebitedCost     float64
+	DebitedCurrency string
+	Tax             float64
+	GrossCost       float64
+	Timespans       string
+	CostSource      string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       time.Time
 }
 
+func (t TblCostDetail) TableName() string {
+	return utils.TBL_COST_DETAILS
+}
+
+type TblRatedCdr struct {
+	Id                  int64
+	Cgrid               string
+	Runid               string
+	Reqtype             string
+	Direction           string
+	Tenant              string
+	Category            string
+	Account             string
+	Subject             string
+	Destination         string
+	SetupTime           time.Time
+	AnswerTime          time.Time
+	Usage               float64
+	Supplier            string
+	DisconnectCause     string
+	Cost                float64
+	Currency            string
+	DebitedCost         float64
+	DebitedCurrency     string
+	Tax                 float64
+	GrossCost           float64
+	ResolvedDestination string
+	ExtraInfo           string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           time.Time
+}
+
 func (t TblRatedCdr) TableName() string {
 	return utils.TBL_RATED_CDRS
 }
+
+type TblVersion struct {
+	Id      int64
+	Item    string
+	Version int64
+}
+
+func (t TblVersion) TableName() string {
+	return utils.TBL_VERSIONS
+}
+
+type TblLedger struct {
+	Id          int64
+	AccountId   string
+	BalanceType string
+	BalanceUuid string
+	BalanceId   string
+	Delta       float64
+	Value       float64
+	Cgrid       string
+	ActionId    string
+	ActionType  string
+	CreatedAt   time.Time
+}
+
+func (t TblLedger) TableName() string {
+	return utils.TBL_LEDGER
+}
+
+type TblInvoice struct {
+	Id          int64
+	AccountId   string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Net         float64
+	Tax         float64
+	Gross       float64
+	Content     string // the invoice as JSON
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (t TblInvoice) TableName() string {
+	return utils.TBL_INVOICES
+}
@@ -0,0 +1,153 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Periods the usage of an account is cumulated on for volume tiered rates
+var tierPeriods = []string{utils.META_DAILY, utils.META_MONTHLY, utils.META_YEARLY}
+
+// Usage of an account cumulated since the start of a tier period
+type PeriodUsage struct {
+	PeriodStart time.Time
+	Usage       time.Duration
+}
+
+// Returns the start of the tier period containing the time, zero time for unknown periods.
+// The time has to be in the zone given by tierLocation so all the paths cut the periods alike.
+func tierPeriodStart(period string, t time.Time) time.Time {
+	switch period {
+	case utils.META_DAILY:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case utils.META_MONTHLY:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case utils.META_YEARLY:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// Usage cumulated in the period containing the time, a new period starts from zero
+func (acc *Account) getPeriodUsage(direction, tor, period string, t time.Time) time.Duration {
+	pu, exists := acc.PeriodUsages[utils.ConcatenatedKey(direction, tor, period)]
+	if !exists || !pu.PeriodStart.Equal(tierPeriodStart(period, t)) {
+		return 0
+	}
+	return pu.Usage
+}
+
+// Adds the usage to the period containing the time, negative usage is refunded only from the current period
+func (acc *Account) addPeriodUsage(direction, tor, period string, t time.Time, usage time.Duration) {
+	key := utils.ConcatenatedKey(direction, tor, period)
+	start := tierPeriodStart(period, t)
+	pu, exists := acc.PeriodUsages[key]
+	if !exists || !pu.PeriodStart.Equal(start) {
+		if usage <= 0 {
+			return
+		}
+		if acc.PeriodUsages == nil {
+			acc.PeriodUsages = make(map[string]*PeriodUsage)
+		}
+		pu = &PeriodUsage{PeriodStart: start}
+		acc.PeriodUsages[key] = pu
+	}
+	pu.Usage += usage
+	if pu.Usage < 0 {
+		pu.Usage = 0
+	}
+}
+
+// Cumulates the usage rated on tiered rates into the periods of those rates, cut in the zone given
+func (acc *Account) addTieredUsage(cc *CallCost, loc *time.Location) {
+	for _, ts := range cc.Timespans {
+		if ts.RateInterval == nil || ts.RateInterval.Rating == nil || ts.RateInterval.Rating.TierPeriod == "" {
+			continue
+		}
+		acc.addPeriodUsage(cc.Direction, cc.TOR, ts.RateInterval.Rating.TierPeriod, ts.TimeStart.In(loc), ts.GetDuration())
+	}
+}
+
+// Takes out refunded usage from all the periods of the account
+func (acc *Account) refundTieredUsage(direction, tor string, t time.Time, usage time.Duration) {
+	for _, period := range tierPeriods {
+		acc.addPeriodUsage(direction, tor, period, t, -usage)
+	}
+}
+
+// Remembers the usage the account cumulated before this call.
+// The usage of the call already debited in previous loops is not counted since the rates see it in the duration index.
+func (cd *CallDescriptor) loadTierOffsets(acc *Account) {
+	cd.tierOffsets = make(map[string]time.Duration, len(tierPeriods))
+	if acc == nil {
+		return
+	}
+	debited := cd.DurationIndex - cd.GetDuration()
+	start := cd.TimeStart.In(cd.tierLocation())
+	for _, period := range tierPeriods {
+		if offset := acc.getPeriodUsage(cd.Direction, cd.TOR, period, start) - debited; offset > 0 {
+			cd.tierOffsets[period] = offset
+		}
+	}
+}
+
+// Returns the zone of the rating profile the call is rated on, the one of the call start if it has none.
+// The timespans are moved in the same zone when split so the periods match the timings of the profile.
+func (cd *CallDescriptor) tierLocation() *time.Location {
+	if cd.tierLoc != nil {
+		return cd.tierLoc
+	}
+	cd.tierLoc = cd.TimeStart.Location()
+	ris := cd.RatingInfos
+	if len(ris) == 0 { // not rated yet, look up the profile without touching the call
+		ratingCD := cd.Clone()
+		ratingCD.trace = nil
+		if ratingCD.LoadRatingPlans() == nil {
+			ris = ratingCD.RatingInfos
+		}
+	}
+	if len(ris) > 0 && ris[0].Timezone != "" {
+		if loc, err := utils.GetLocation(ris[0].Timezone); err == nil {
+			cd.tierLoc = loc
+		}
+	}
+	return cd.tierLoc
+}
+
+// Moves the tiered rates with the period usage so they can be split the same way as the rates inside one call
+func (cd *CallDescriptor) applyVolumeTiers() {
+	for _, ri := range cd.RatingInfos {
+		for _, interval := range ri.RateIntervals {
+			if interval.Rating == nil || interval.Rating.TierPeriod == "" || interval.Rating.tierShifted {
+				continue
+			}
+			if cd.tierOffsets == nil {
+				acc, err := cd.getAccount()
+				if err != nil {
+					acc = nil
+				}
+				cd.loadTierOffsets(acc)
+			}
+			interval.Rating = interval.Rating.shiftTiers(cd.tierOffsets[interval.Rating.TierPeriod])
+		}
+	}
+}
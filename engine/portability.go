@@ -0,0 +1,64 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Number moved to another operator, rated on the routing prefix of that operator instead of its own prefix
+type PortedNumber struct {
+	Id            string // the full number
+	RoutingPrefix string // prepended to the number before destination matching, eg: D262
+}
+
+// Returns the number prefixed with its routing prefix if ported, unchanged otherwise
+func ResolvePortedNumber(number string) string {
+	return resolvePortedNumber(dataStorage, number)
+}
+
+func resolvePortedNumber(ratingDb RatingStorage, number string) string {
+	if number == "" || number == utils.ANY {
+		return number
+	}
+	if pn, err := ratingDb.GetPortedNumber(number); err == nil && pn != nil && pn.RoutingPrefix != "" {
+		return pn.RoutingPrefix + number
+	}
+	return number
+}
+
+// Writes the loaded ported number, the ones without routing prefix were ported back and get removed
+func setPortedNumber(ratingDb RatingStorage, pn *PortedNumber) error {
+	if pn.RoutingPrefix == "" {
+		return ratingDb.RemovePortedNumber(pn.Id)
+	}
+	return ratingDb.SetPortedNumber(pn)
+}
+
+// Replaces the destination with the resolved one, consulted once per call descriptor before destination matching
+func (cd *CallDescriptor) resolveDestination() {
+	if cd.resolved {
+		return
+	}
+	if resolved := resolvePortedNumber(cd.getRatingStorage(), cd.Destination); resolved != cd.Destination {
+		cd.tracef(TRACE_DESTINATION, "number %s ported, rated as %s", cd.Destination, resolved)
+		cd.Destination = resolved
+	}
+	cd.resolved = true
+}
@@ -0,0 +1,69 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestResolvePortedNumber(t *testing.T) {
+	// numbers loaded out of the csv test data
+	if number := ResolvePortedNumber("4971123456"); number != "D2624971123456" {
+		t.Error("Ported number not resolved: ", number)
+	}
+	if number := ResolvePortedNumber("4971123458"); number != "4971123458" {
+		t.Error("Ported back number resolved: ", number)
+	}
+	if number := ResolvePortedNumber(utils.ANY); number != utils.ANY {
+		t.Error("Wrong resolved *any: ", number)
+	}
+}
+
+func TestPortedNumberIncrementalLoad(t *testing.T) {
+	csvr := NewStringCSVReader(dataStorage, accountingStorage, ',', "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "",
+		`NP_D263,4971123457,D263
+NP_BACK,4971123456,`, "")
+	if err := csvr.LoadPortedNumbers(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.WriteToDatabase(false, false); err != nil {
+		t.Fatal(err)
+	}
+	if number := ResolvePortedNumber("4971123457"); number != "D2634971123457" {
+		t.Error("Ported number not updated: ", number)
+	}
+	if _, err := dataStorage.GetPortedNumber("4971123456"); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Ported back number not removed: ", err)
+	}
+	// restore the csv test data
+	dataStorage.SetPortedNumber(&PortedNumber{Id: "4971123456", RoutingPrefix: "D262"})
+	dataStorage.SetPortedNumber(&PortedNumber{Id: "4971123457", RoutingPrefix: "D262"})
+}
+
+func TestCallDescriptorResolveDestination(t *testing.T) {
+	cd := &CallDescriptor{Destination: "4971123456"}
+	cd.resolveDestination()
+	clone := cd.Clone()
+	clone.resolveDestination() // already resolved, not prefixed twice
+	if cd.Destination != "D2624971123456" || clone.Destination != "D2624971123456" {
+		t.Error("Wrong resolved destination: ", cd.Destination, clone.Destination)
+	}
+}
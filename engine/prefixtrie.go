@@ -0,0 +1,194 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"sort"
+)
+
+// Compact prefix tree indexing ids (eg: destination ids) on the prefixes they were added with.
+// Numbers are matched walking down once instead of looking up each of their prefixes.
+type PrefixTrie struct {
+	root     *trieNode
+	prefixes int // number of prefixes holding ids
+}
+
+type trieNode struct {
+	char     byte
+	children []*trieNode // sorted on char, kept as slice since number prefixes branch on few digits
+	ids      []string
+}
+
+// One of the prefixes matching a number together with the ids indexed on it
+type PrefixMatch struct {
+	Prefix string
+	Ids    []string
+}
+
+func NewPrefixTrie() *PrefixTrie {
+	return &PrefixTrie{root: new(trieNode)}
+}
+
+func (tn *trieNode) childIndex(c byte) int {
+	return sort.Search(len(tn.children), func(i int) bool { return tn.children[i].char >= c })
+}
+
+func (tn *trieNode) child(c byte) *trieNode {
+	if idx := tn.childIndex(c); idx < len(tn.children) && tn.children[idx].char == c {
+		return tn.children[idx]
+	}
+	return nil
+}
+
+func (tn *trieNode) addChild(c byte) *trieNode {
+	idx := tn.childIndex(c)
+	if idx < len(tn.children) && tn.children[idx].char == c {
+		return tn.children[idx]
+	}
+	node := &trieNode{char: c}
+	tn.children = append(tn.children, nil)
+	copy(tn.children[idx+1:], tn.children[idx:])
+	tn.children[idx] = node
+	return node
+}
+
+func (tn *trieNode) hasId(id string) bool {
+	for _, existing := range tn.ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Indexes the id on the prefix, adding it twice has no effect
+func (pt *PrefixTrie) Add(prefix, id string) {
+	node := pt.root
+	for i := 0; i < len(prefix); i++ {
+		node = node.addChild(prefix[i])
+	}
+	if node.hasId(id) {
+		return
+	}
+	if len(node.ids) == 0 {
+		pt.prefixes++
+	}
+	node.ids = append(node.ids, id)
+}
+
+func (pt *PrefixTrie) getNode(prefix string) *trieNode {
+	node := pt.root
+	for i := 0; i < len(prefix) && node != nil; i++ {
+		node = node.child(prefix[i])
+	}
+	return node
+}
+
+// Returns the ids indexed exactly on the prefix
+func (pt *PrefixTrie) Get(prefix string) []string {
+	if node := pt.getNode(prefix); node != nil {
+		return node.ids
+	}
+	return nil
+}
+
+// Checks if the id is indexed exactly on the prefix
+func (pt *PrefixTrie) HasId(prefix, id string) bool {
+	node := pt.getNode(prefix)
+	return node != nil && node.hasId(id)
+}
+
+// Returns the prefixes of the number having ids indexed, the longest first.
+// Prefixes shorter than minLength are not considered, same as utils.SplitPrefix.
+func (pt *PrefixTrie) Match(number string, minLength int) (matches []*PrefixMatch) {
+	node := pt.root
+	for i := 0; i < len(number); i++ {
+		if node = node.child(number[i]); node == nil {
+			break
+		}
+		if i+1 >= minLength && len(node.ids) != 0 {
+			matches = append(matches, &PrefixMatch{Prefix: number[:i+1], Ids: node.ids})
+		}
+	}
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return
+}
+
+// Removes the ids from all the prefixes, dropping the branches left empty
+func (pt *PrefixTrie) RemoveIds(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	removed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+	pt.removeIds(pt.root, removed)
+}
+
+// Returns true if the node can be dropped from its parent
+func (pt *PrefixTrie) removeIds(node *trieNode, removed map[string]bool) bool {
+	if len(node.ids) != 0 {
+		var kept []string // new slice since matches handed out may still share the old one
+		for _, id := range node.ids {
+			if !removed[id] {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			pt.prefixes--
+		}
+		node.ids = kept
+	}
+	var children []*trieNode
+	for _, child := range node.children {
+		if !pt.removeIds(child, removed) {
+			children = append(children, child)
+		}
+	}
+	node.children = children
+	return len(node.ids) == 0 && len(node.children) == 0
+}
+
+// Number of prefixes having ids indexed
+func (pt *PrefixTrie) Len() int {
+	return pt.prefixes
+}
+
+// Deep copy, changing the clone does not affect the original
+func (pt *PrefixTrie) Clone() *PrefixTrie {
+	return &PrefixTrie{root: pt.root.clone(), prefixes: pt.prefixes}
+}
+
+func (tn *trieNode) clone() *trieNode {
+	clone := &trieNode{char: tn.char}
+	if len(tn.ids) != 0 {
+		clone.ids = make([]string, len(tn.ids))
+		copy(clone.ids, tn.ids)
+	}
+	if len(tn.children) != 0 {
+		clone.children = make([]*trieNode, len(tn.children))
+		for i, child := range tn.children {
+			clone.children[i] = child.clone()
+		}
+	}
+	return clone
+}
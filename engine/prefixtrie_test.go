@@ -0,0 +1,169 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/cgrates/cgrates/cache2go"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestPrefixTrieMatch(t *testing.T) {
+	pt := NewPrefixTrie()
+	pt.Add("49", "GERMANY")
+	pt.Add("4915", "GERMANY_MOBILE")
+	pt.Add("4915", "DE_PREMIUM")
+	pt.Add("4915", "GERMANY_MOBILE")
+	pt.Add("41", "SWITZERLAND")
+	if pt.Len() != 3 {
+		t.Error("Wrong number of prefixes: ", pt.Len())
+	}
+	matches := pt.Match("491511223344", 0)
+	if len(matches) != 2 || matches[0].Prefix != "4915" || matches[1].Prefix != "49" {
+		t.Fatalf("Wrong matches: %+v", matches)
+	}
+	if !reflect.DeepEqual(matches[0].Ids, []string{"GERMANY_MOBILE", "DE_PREMIUM"}) {
+		t.Error("Wrong ids: ", matches[0].Ids)
+	}
+	if matches := pt.Match("491511223344", 3); len(matches) != 1 || matches[0].Prefix != "4915" {
+		t.Errorf("Minimum length not considered: %+v", matches)
+	}
+	if matches := pt.Match("40", 0); len(matches) != 0 {
+		t.Errorf("Unexpected matches: %+v", matches)
+	}
+	if !pt.HasId("41", "SWITZERLAND") || pt.HasId("4", "SWITZERLAND") || pt.HasId("41", "GERMANY") {
+		t.Error("Wrong HasId")
+	}
+}
+
+func TestPrefixTrieRemoveIds(t *testing.T) {
+	pt := NewPrefixTrie()
+	pt.Add("49", "GERMANY")
+	pt.Add("4915", "GERMANY_MOBILE")
+	pt.Add("4916", "GERMANY_MOBILE")
+	pt.Add("4916", "DE_PREMIUM")
+	clone := pt.Clone()
+	pt.RemoveIds("GERMANY_MOBILE")
+	if pt.Len() != 2 || pt.Get("4915") != nil || !reflect.DeepEqual(pt.Get("4916"), []string{"DE_PREMIUM"}) {
+		t.Errorf("Wrong removal, len: %d", pt.Len())
+	}
+	if len(pt.root.child('4').child('9').children) != 1 {
+		t.Error("Empty branch not dropped")
+	}
+	if clone.Len() != 3 || len(clone.Get("4916")) != 2 {
+		t.Error("Clone changed together with the original")
+	}
+}
+
+/********************************* Benchmarks **********************************/
+
+// Random-like numbering plan: 200k prefixes of 4 to 8 digits spread over 2000 destinations
+func benchPrefixes() (prefixes, ids []string) {
+	for i := 0; i < 200000; i++ {
+		prefix := fmt.Sprintf("%d", 1000+(i*7919)%99999000)
+		prefixes = append(prefixes, prefix)
+		ids = append(ids, fmt.Sprintf("DST_%d", i%2000))
+	}
+	return
+}
+
+func benchNumbers(prefixes []string) (numbers []string) {
+	for i := 0; i < 1000; i++ {
+		numbers = append(numbers, prefixes[(i*197)%len(prefixes)]+"123456")
+	}
+	return
+}
+
+func BenchmarkPrefixTrieMatch(b *testing.B) {
+	prefixes, ids := benchPrefixes()
+	pt := NewPrefixTrie()
+	for i, p := range prefixes {
+		pt.Add(p, ids[i])
+	}
+	numbers := benchNumbers(prefixes)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pt.Match(numbers[i%len(numbers)], MIN_PREFIX_MATCH)
+	}
+}
+
+// Lookup as done before the trie: one cache query for each prefix of the number
+func BenchmarkPrefixCacheMatch(b *testing.B) {
+	prefixes, ids := benchPrefixes()
+	cache2go.RemPrefixKey(TEMP_DESTINATION_PREFIX)
+	for i, p := range prefixes {
+		cache2go.CachePush(TEMP_DESTINATION_PREFIX+p, ids[i])
+	}
+	defer cache2go.RemPrefixKey(TEMP_DESTINATION_PREFIX)
+	numbers := benchNumbers(prefixes)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range utils.SplitPrefix(numbers[i%len(numbers)], MIN_PREFIX_MATCH) {
+			if x, err := cache2go.GetCached(TEMP_DESTINATION_PREFIX + p); err == nil {
+				_ = x.(map[interface{}]struct{})
+			}
+		}
+	}
+}
+
+func heapInUse() int64 {
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	return int64(ms.HeapInuse)
+}
+
+// Reports the heap taken by the index as bytes/index
+func BenchmarkPrefixTrieMemory(b *testing.B) {
+	prefixes, ids := benchPrefixes()
+	b.ReportAllocs()
+	var used int64
+	for i := 0; i < b.N; i++ {
+		before := heapInUse()
+		pt := NewPrefixTrie()
+		for j, p := range prefixes {
+			pt.Add(p, ids[j])
+		}
+		used += heapInUse() - before
+		runtime.KeepAlive(pt)
+	}
+	b.ReportMetric(float64(used)/float64(b.N), "bytes/index")
+}
+
+func BenchmarkPrefixCacheMemory(b *testing.B) {
+	prefixes, ids := benchPrefixes()
+	b.ReportAllocs()
+	var used int64
+	for i := 0; i < b.N; i++ {
+		cache2go.RemPrefixKey(TEMP_DESTINATION_PREFIX)
+		before := heapInUse()
+		for j, p := range prefixes {
+			cache2go.CachePush(TEMP_DESTINATION_PREFIX+p, ids[j])
+		}
+		used += heapInUse() - before
+	}
+	cache2go.RemPrefixKey(TEMP_DESTINATION_PREFIX)
+	b.ReportMetric(float64(used)/float64(b.N), "bytes/index")
+}
@@ -0,0 +1,214 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func topupProratedAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
+	if ub == nil {
+		return errors.New("Nil user balance")
+	}
+	if !a.prorated {
+		return errors.New("prorated actions can only be executed by action plans")
+	}
+	if a.Balance.Value == 0 { // account not in service during the period
+		return
+	}
+	genericMakeNegative(a)
+	return genericDebit(ub, a, false)
+}
+
+func debitProratedAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
+	if ub == nil {
+		return errors.New("Nil user balance")
+	}
+	if !a.prorated {
+		return errors.New("prorated actions can only be executed by action plans")
+	}
+	if a.Balance.Value == 0 {
+		return
+	}
+	return genericDebit(ub, a, false)
+}
+
+func (a *Action) isProrated() bool {
+	return a.ActionType == TOPUP_PRORATED || a.ActionType == DEBIT_PRORATED
+}
+
+// Copy of the action with the value multiplied by the proration factor
+func (a *Action) prorate(factor float64) *Action {
+	pa := *a
+	pa.Balance = a.Balance.Clone()
+	pa.Balance.Value = utils.Round(a.Balance.Value*factor, globalRoundingDecimals, utils.ROUNDING_MIDDLE)
+	pa.ProrationFactor = factor
+	pa.prorated = true
+	return &pa
+}
+
+// Schedule period containing the moment, from the run at or before it until the next one
+func (at *ActionTiming) getPeriod(t time.Time) (start, end time.Time, err error) {
+	if at.Timing == nil || at.Timing.Timing == nil || at.IsASAP() {
+		return start, end, fmt.Errorf("action timing %s has no recurring schedule", at.Id)
+	}
+	end = at.nextStartTime(t)
+	if end.IsZero() {
+		return start, end, fmt.Errorf("action timing %s does not run after %v", at.Id, t)
+	}
+	after := at.nextStartTime(end)
+	if after.IsZero() {
+		return start, end, fmt.Errorf("action timing %s runs only once", at.Id)
+	}
+	// the periods can differ in length (months, multiple days), look back further until finding the previous run
+	for lookback, i := 2*after.Sub(end), 0; start.IsZero() && i < 10; lookback, i = 2*lookback, i+1 {
+		for run := at.nextStartTime(end.Add(-lookback)); run.Before(end); run = at.nextStartTime(run) {
+			start = run
+		}
+	}
+	if start.IsZero() {
+		return start, end, fmt.Errorf("could not find the run of action timing %s before %v", at.Id, end)
+	}
+	return
+}
+
+// Share of the period the service is provided for, between the activation and the cancellation (zero when active)
+func prorationFactor(start, end, activation, cancellation time.Time) float64 {
+	from, to := start, end
+	if activation.After(from) {
+		from = activation
+	}
+	if !cancellation.IsZero() && cancellation.Before(to) {
+		to = cancellation
+	}
+	if !to.After(from) {
+		return 0
+	}
+	return float64(to.Sub(from)) / float64(end.Sub(start))
+}
+
+// Prorates the action executed now on the account to the share of the current schedule period the account is in service for
+func (at *ActionTiming) prorateAction(a *Action, acc *Account, now time.Time) (*Action, error) {
+	start, end, err := at.getPeriod(now)
+	if err != nil {
+		return nil, err
+	}
+	return a.prorate(prorationFactor(start, end, acc.ActivationDate, acc.CancellationDate)), nil
+}
+
+// Writes the copy of an action executed on one account into the action log, for the values differing from account to account
+func (at *ActionTiming) logAccountAction(accId string, a *Action) {
+	accAt := *at
+	accAt.AccountIds = []string{accId}
+	storageLogger.LogActionTiming(SCHED_SOURCE, &accAt, Actions{a})
+}
+
+// Logs the prorated action executed on one account together with its proration factor
+func (at *ActionTiming) logProrated(accId string, a *Action) {
+	Logger.Info(fmt.Sprintf("<ActionTiming> Executed %s of %s on account %s with proration factor %v",
+		a.ActionType, a.Id, accId, a.ProrationFactor))
+	at.logAccountAction(accId, a)
+}
+
+// Executes the prorated actions of the timing on an account activated in the current period,
+// charging the share of the period left without waiting for the next run
+func (at *ActionTiming) ExecuteProrated(accId string, now time.Time) error {
+	aac, err := at.getActions()
+	if err != nil {
+		return err
+	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		acc, err := accountingStorage.GetAccount(accId)
+		if err != nil {
+			return 0, err
+		}
+		executed := false
+		for _, a := range aac {
+			if !a.isProrated() {
+				continue
+			}
+			pa, err := at.prorateAction(a, acc, now)
+			if err != nil {
+				return 0, err
+			}
+			actionFunction, _ := getActionFunc(a.ActionType)
+			if err := actionFunction(acc, nil, pa, aac); err != nil {
+				return 0, err
+			}
+			at.logProrated(accId, pa)
+			executed = true
+		}
+		if !executed {
+			return 0, nil
+		}
+		return 0, accountingStorage.SetAccount(acc)
+	}, accId)
+	return err
+}
+
+// Ends the service of the account at the cancellation date. The prorated charges the action plans executed for the
+// periods up to now are corrected for the cancellation, the runs to come are prorated by the action plans themselves.
+// The account has to be locked and saved by the caller.
+func (acc *Account) Cancel(cancellation, now time.Time) error {
+	if cancellation.Before(acc.ActivationDate) {
+		return fmt.Errorf("cancellation date %v before the activation date %v", cancellation, acc.ActivationDate)
+	}
+	plans, err := accountingStorage.GetAllActionTimings()
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		for _, at := range plan {
+			if !utils.IsSliceMember(at.AccountIds, acc.Id) {
+				continue
+			}
+			aac, err := at.getActions()
+			if err != nil {
+				return err
+			}
+			for _, a := range aac {
+				if !a.isProrated() {
+					continue
+				}
+				for start, end, err := at.getPeriod(cancellation); err == nil && !start.After(now); start, end, err = at.getPeriod(end) {
+					charged := prorationFactor(start, end, acc.ActivationDate, acc.CancellationDate)
+					due := prorationFactor(start, end, acc.ActivationDate, cancellation)
+					if due == charged {
+						continue
+					}
+					// negative factor when giving back, the correction is credited for the debits and debited for the topups
+					ca := a.prorate(due - charged)
+					if a.ActionType == TOPUP_PRORATED {
+						ca.Balance.Value = -ca.Balance.Value
+					}
+					if err := genericDebit(acc, ca, false); err != nil {
+						return err
+					}
+					at.logProrated(acc.Id, ca)
+				}
+			}
+		}
+	}
+	acc.CancellationDate = cancellation
+	return nil
+}
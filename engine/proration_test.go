@@ -0,0 +1,124 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestProrationGetPeriod(t *testing.T) {
+	at := &ActionTiming{Timezone: "UTC", Timing: &RateInterval{Timing: &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"}}}
+	start, end, err := at.getPeriod(time.Date(2015, 2, 20, 10, 0, 0, 0, time.UTC))
+	if err != nil || !start.Equal(time.Date(2015, 2, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong monthly period: ", start, end, err)
+	}
+	// the run itself starts a new period
+	if start, end, err = at.getPeriod(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)); err != nil ||
+		!start.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong monthly period: ", start, end, err)
+	}
+	// periods of different length
+	at = &ActionTiming{Timezone: "UTC", Timing: &RateInterval{Timing: &RITiming{MonthDays: utils.MonthDays{1, 2}, StartTime: "00:00:00"}}}
+	if start, end, err = at.getPeriod(time.Date(2015, 2, 28, 0, 0, 0, 0, time.UTC)); err != nil ||
+		!start.Equal(time.Date(2015, 2, 2, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong period: ", start, end, err)
+	}
+	at = &ActionTiming{Timing: &RateInterval{Timing: &RITiming{StartTime: ASAP}}}
+	if _, _, err = at.getPeriod(time.Now()); err == nil {
+		t.Error("Period for an *asap timing")
+	}
+}
+
+func TestProrationFactor(t *testing.T) {
+	start, end := time.Date(2015, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)
+	if f := prorationFactor(start, end, time.Time{}, time.Time{}); f != 1 {
+		t.Error("Wrong factor: ", f)
+	}
+	if f := prorationFactor(start, end, time.Date(2015, 2, 15, 0, 0, 0, 0, time.UTC), time.Time{}); f != 0.5 {
+		t.Error("Wrong factor: ", f)
+	}
+	if f := prorationFactor(start, end, time.Date(2015, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2015, 2, 8, 0, 0, 0, 0, time.UTC)); f != 0.25 {
+		t.Error("Wrong factor: ", f)
+	}
+	if f := prorationFactor(start, end, time.Date(2015, 3, 15, 0, 0, 0, 0, time.UTC), time.Time{}); f != 0 {
+		t.Error("Wrong factor: ", f)
+	}
+}
+
+func TestProratedDebitActionTiming(t *testing.T) {
+	at := &ActionTiming{Id: "MONTHLY_FEE", AccountIds: []string{"*out:cgrates.org:prorated"},
+		Timing: &RateInterval{Timing: &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"}}}
+	start, end, err := at.getPeriod(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountingStorage.SetAccount(&Account{Id: "*out:cgrates.org:prorated", ActivationDate: start.Add(end.Sub(start) / 2),
+		BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 100}}}})
+	at.SetActions(Actions{&Action{Id: "FEE", ActionType: DEBIT_PRORATED, BalanceType: utils.MONETARY, Direction: OUTBOUND, Balance: &Balance{Value: 10}}})
+	if err := at.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:cgrates.org:prorated"); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 95 {
+		t.Error("Wrong prorated debit: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	// the configured action stays untouched for the other accounts
+	if at.actions[0].Balance.Value != 10 || at.actions[0].ProrationFactor != 0 {
+		t.Errorf("Action modified: %+v", at.actions[0])
+	}
+	if err := debitProratedAction(&Account{}, nil, at.actions[0], nil); err == nil {
+		t.Error("Prorated action executed outside action plan")
+	}
+}
+
+func TestProratedExecuteAndCancel(t *testing.T) {
+	at := &ActionTiming{Id: "MONTHLY_FEE", ActionsId: "PRORATED_FEE", AccountIds: []string{"*out:cgrates.org:cancelled"},
+		Timing: &RateInterval{Timing: &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"}}}
+	accountingStorage.SetActions("PRORATED_FEE", Actions{&Action{Id: "FEE", ActionType: DEBIT_PRORATED, BalanceType: utils.MONETARY,
+		Direction: OUTBOUND, Balance: &Balance{Value: 10}}})
+	accountingStorage.GetActions("PRORATED_FEE", true)
+	accountingStorage.SetActionTimings("PRORATED_PLAN", ActionPlan{at})
+	defer accountingStorage.SetActionTimings("PRORATED_PLAN", nil)
+	now := time.Now()
+	start, end, err := at.getPeriod(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := &Account{Id: "*out:cgrates.org:cancelled", ActivationDate: start.Add(end.Sub(start) / 2),
+		BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 100}}}}
+	accountingStorage.SetAccount(acc)
+	if err := at.ExecuteProrated(acc.Id, now); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ = accountingStorage.GetAccount(acc.Id); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 95 {
+		t.Error("Wrong prorated debit: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	// cancelling half way through the remaining of the period gives back half of the charge
+	if err := acc.Cancel(start.Add(end.Sub(start)*3/4), now); err != nil {
+		t.Fatal(err)
+	}
+	if acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 97.5 || acc.CancellationDate.IsZero() {
+		t.Error("Wrong cancellation refund: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	if err := acc.Cancel(start, now); err == nil {
+		t.Error("Cancelled before activation")
+	}
+}
@@ -44,7 +44,10 @@ type RITiming struct {
 	Months             utils.Months
 	MonthDays          utils.MonthDays
 	WeekDays           utils.WeekDays
-	StartTime, EndTime string // ##:##:## format
+	StartTime, EndTime string             // ##:##:## format
+	Calendar           string             // holiday calendar, its days activate the timing whatever the week day
+	ExcludeCalendar    bool               // the days of the calendar disable the timing instead
+	Holidays           utils.CalendarDays // days of the calendar, copied in at load time
 	cronString         string
 }
 
@@ -134,8 +137,14 @@ func (rit *RITiming) IsActiveAt(t time.Time, endTime bool) bool {
 	if len(rit.MonthDays) > 0 && !rit.MonthDays.Contains(t.Day()) {
 		return false
 	}
-	// check for weekdays
-	if len(rit.WeekDays) > 0 && !rit.WeekDays.Contains(t.Weekday()) {
+	// check for holidays and weekdays
+	if rit.Calendar != "" && rit.Holidays.Contains(t) {
+		if rit.ExcludeCalendar {
+			return false
+		}
+	} else if rit.Calendar != "" && !rit.ExcludeCalendar && len(rit.WeekDays) == 0 {
+		return false // active on holidays only
+	} else if len(rit.WeekDays) > 0 && !rit.WeekDays.Contains(t.Weekday()) {
 		return false
 	}
 	// check for start hour
@@ -178,22 +187,62 @@ func (rit *RITiming) Stringify() string {
 
 // Separate structure used for rating plan size optimization
 type RIRate struct {
-	ConnectFee       float64
-	RoundingMethod   string
-	RoundingDecimals int
-	MaxCost          float64
-	MaxCostStrategy  string
-	Rates            RateGroups // GroupRateInterval (start time): Rate
+	ConnectFee         float64
+	RoundingMethod     string
+	RoundingDecimals   int
+	MaxCost            float64
+	MaxCostStrategy    string
+	TierPeriod         string        // when set the GroupIntervalStart of the rates applies on the account usage cumulated over the period
+	Currency           string        // currency of the costs, empty for the default one
+	MinDuration        time.Duration // calls shorter than this are billed as lasting MinDuration
+	MinCost            float64       // minimum cost charged for a connected call
+	FreeUnder          time.Duration // calls shorter than this are not charged at all
+	ConnectFeeOnAnswer bool          // the connect fee is charged only for answered calls
+	Rates              RateGroups    // GroupRateInterval (start time): Rate
+	tierShifted        bool          // rates already moved with the period usage of the account
 }
 
 func (rir *RIRate) Stringify() string {
 	str := fmt.Sprintf("%v %v %v %v %v", rir.ConnectFee, rir.RoundingMethod, rir.RoundingDecimals, rir.MaxCost, rir.MaxCostStrategy)
+	if rir.TierPeriod != "" {
+		str += " " + rir.TierPeriod
+	}
+	if rir.Currency != "" {
+		str += " " + rir.Currency
+	}
+	if rir.MinDuration != 0 || rir.MinCost != 0 || rir.FreeUnder != 0 || rir.ConnectFeeOnAnswer {
+		str += fmt.Sprintf(" %v %v %v %v", rir.MinDuration, rir.MinCost, rir.FreeUnder, rir.ConnectFeeOnAnswer)
+	}
 	for _, r := range rir.Rates {
 		str += r.Stringify()
 	}
 	return utils.Sha1(str)[:8]
 }
 
+// Returns a copy of the rating having the rates moved back with the usage cumulated before the call,
+// only the last of the tiers already passed is kept and it starts from the beginning of the call
+func (rir *RIRate) shiftTiers(offset time.Duration) *RIRate {
+	shifted := *rir
+	shifted.tierShifted = true
+	shifted.Rates = nil
+	rates := make(RateGroups, len(rir.Rates)) // the rating is shared with the cached rating plans
+	copy(rates, rir.Rates)
+	rates.Sort()
+	for idx, rate := range rates {
+		start := rate.GroupIntervalStart - offset
+		if start <= 0 {
+			if idx < len(rates)-1 && rates[idx+1].GroupIntervalStart-offset <= 0 {
+				continue
+			}
+			start = 0
+		}
+		r := *rate
+		r.GroupIntervalStart = start
+		shifted.Rates = append(shifted.Rates, &r)
+	}
+	return &shifted
+}
+
 type Rate struct {
 	GroupIntervalStart time.Duration
 	Value              float64
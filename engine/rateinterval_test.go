@@ -141,6 +141,24 @@ func TestRateIntervalHours(t *testing.T) {
 	}
 }
 
+func TestRateIntervalHolidays(t *testing.T) {
+	holidays := utils.CalendarDays{{Month: time.December, Day: 25}, {Year: 2015, Month: time.April, Day: 6}}
+	workdays := &RateInterval{Timing: &RITiming{WeekDays: utils.WeekDays{1, 2, 3, 4, 5}, Calendar: "DE", ExcludeCalendar: true, Holidays: holidays}}
+	weekends := &RateInterval{Timing: &RITiming{WeekDays: utils.WeekDays{time.Saturday, time.Sunday}, Calendar: "DE", Holidays: holidays}}
+	holidaysOnly := &RateInterval{Timing: &RITiming{Calendar: "DE", Holidays: holidays}}
+	easterMonday := time.Date(2015, time.April, 6, 10, 0, 0, 0, time.UTC)
+	monday := time.Date(2015, time.April, 13, 10, 0, 0, 0, time.UTC)
+	if workdays.Contains(easterMonday, false) || !workdays.Contains(monday, false) {
+		t.Error("Workdays interval should skip holidays only")
+	}
+	if !weekends.Contains(easterMonday, false) || weekends.Contains(monday, false) {
+		t.Error("Weekend interval should apply on holidays as well")
+	}
+	if !holidaysOnly.Contains(time.Date(2016, time.December, 25, 10, 0, 0, 0, time.UTC), false) || holidaysOnly.Contains(monday, false) {
+		t.Error("Holidays interval should apply on holidays only")
+	}
+}
+
 func TestRateIntervalEverything(t *testing.T) {
 	i := &RateInterval{
 		Timing: &RITiming{
@@ -354,3 +372,30 @@ func BenchmarkRateIntervalContainsDate(b *testing.B) {
 		i.Contains(d, false)
 	}
 }
+
+func TestRIRateShiftTiers(t *testing.T) {
+	rir := &RIRate{TierPeriod: utils.META_MONTHLY, Rates: RateGroups{
+		&Rate{GroupIntervalStart: 0, Value: 0.1},
+		&Rate{GroupIntervalStart: 1000 * time.Minute, Value: 0.05},
+		&Rate{GroupIntervalStart: 2000 * time.Minute, Value: 0.02},
+	}}
+	shifted := rir.shiftTiers(1500 * time.Minute)
+	if len(shifted.Rates) != 2 || shifted.Rates[0].GroupIntervalStart != 0 || shifted.Rates[0].Value != 0.05 ||
+		shifted.Rates[1].GroupIntervalStart != 500*time.Minute || shifted.Rates[1].Value != 0.02 || !shifted.tierShifted {
+		t.Errorf("Wrong shifted tiers: %+v", shifted.Rates)
+	}
+	if rir.Rates[1].GroupIntervalStart != 1000*time.Minute || rir.tierShifted {
+		t.Error("Original rating changed: ", rir.Rates[1])
+	}
+	if shifted := rir.shiftTiers(3000 * time.Minute); len(shifted.Rates) != 1 || shifted.Rates[0].Value != 0.02 {
+		t.Errorf("Wrong shifted tiers: %+v", shifted.Rates)
+	}
+	// the shared rates are not sorted in place
+	rir.Rates[0], rir.Rates[2] = rir.Rates[2], rir.Rates[0]
+	if shifted := rir.shiftTiers(1500 * time.Minute); len(shifted.Rates) != 2 || shifted.Rates[0].Value != 0.05 {
+		t.Errorf("Wrong shifted tiers: %+v", shifted.Rates)
+	}
+	if rir.Rates[0].Value != 0.02 || rir.Rates[2].Value != 0.1 {
+		t.Error("Original rates sorted: ", rir.Rates)
+	}
+}
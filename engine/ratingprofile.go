@@ -25,7 +25,6 @@ import (
 	"sort"
 	"time"
 
-	"github.com/cgrates/cgrates/cache2go"
 	"github.com/cgrates/cgrates/history"
 	"github.com/cgrates/cgrates/utils"
 )
@@ -40,6 +39,7 @@ type RatingPlanActivation struct {
 	RatingPlanId    string
 	FallbackKeys    []string
 	CdrStatQueueIds []string
+	Timezone        string // the timings of the rating plan are evaluated in this zone, server local one if empty
 }
 
 func (rpa *RatingPlanActivation) Equal(orpa *RatingPlanActivation) bool {
@@ -88,6 +88,7 @@ type RatingInfo struct {
 	ActivationTime time.Time
 	RateIntervals  RateIntervalList
 	FallbackKeys   []string
+	Timezone       string
 }
 
 type RatingInfos []*RatingInfo
@@ -116,11 +117,12 @@ func (ris RatingInfos) String() string {
 func (rp *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error) {
 	var ris RatingInfos
 	for index, rpa := range rp.RatingPlanActivations.GetActiveForCall(cd) {
-		rpl, err := dataStorage.GetRatingPlan(rpa.RatingPlanId, false)
+		rpl, err := cd.getRatingStorage().GetRatingPlan(rpa.RatingPlanId, false)
 		if err != nil || rpl == nil {
 			Logger.Err(fmt.Sprintf("Error checking destination: %v", err))
 			continue
 		}
+		cd.tracef(TRACE_RATING_PROFILE, "rating plan %s activated at %v", rpa.RatingPlanId, rpa.ActivationTime)
 		prefix := ""
 		destinationId := ""
 		var rps RateIntervalList
@@ -132,18 +134,13 @@ func (rp *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error)
 				destinationId = utils.ANY
 			}
 		} else {
-			for _, p := range utils.SplitPrefix(cd.Destination, MIN_PREFIX_MATCH) {
-				if x, err := cache2go.GetCached(DESTINATION_PREFIX + p); err == nil {
-
-					destIds := x.(map[interface{}]struct{})
-					for idId := range destIds {
-						dId := idId.(string)
-						if _, ok := rpl.DestinationRates[dId]; ok {
-							rps = rpl.RateIntervalList(dId)
-							prefix = p
-							destinationId = dId
-							break
-						}
+			for _, match := range cd.matchDestinations(cd.Destination) {
+				for _, dId := range match.Ids {
+					if _, ok := rpl.DestinationRates[dId]; ok {
+						rps = rpl.RateIntervalList(dId)
+						prefix = match.Prefix
+						destinationId = dId
+						break
 					}
 				}
 				if rps != nil {
@@ -158,6 +155,11 @@ func (rp *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error)
 				}
 			}
 		}
+		if len(prefix) > 0 {
+			cd.tracef(TRACE_DESTINATION, "destination %s matched %s on prefix %s in rating plan %s", cd.Destination, destinationId, prefix, rpl.Id)
+		} else {
+			cd.tracef(TRACE_DESTINATION, "no destination of rating plan %s matches %s", rpl.Id, cd.Destination)
+		}
 		// check if it's the first ri and add a blank one for the initial part not covered
 		if index == 0 && cd.TimeStart.Before(rpa.ActivationTime) {
 			ris = append(ris, &RatingInfo{
@@ -176,7 +178,8 @@ func (rp *RatingProfile) GetRatingPlansForPrefix(cd *CallDescriptor) (err error)
 				MatchedDestId:  destinationId,
 				ActivationTime: rpa.ActivationTime,
 				RateIntervals:  rps,
-				FallbackKeys:   rpa.FallbackKeys})
+				FallbackKeys:   rpa.FallbackKeys,
+				Timezone:       rpa.Timezone})
 		} else {
 			// add for fallback information
 			ris = append(ris, &RatingInfo{
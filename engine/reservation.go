@@ -0,0 +1,215 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Part of the account balances put aside for a session, not available to the other calls until
+// committed or released. Stored with the account so it survives restarts.
+type Reservation struct {
+	Id       string             // session the reservation was made for (the CgrId of the call)
+	Usage    time.Duration      // usage covered by the reserved amounts
+	Amounts  map[string]float64 // balance uuid: value reserved out of it, in the units of the balance
+	Expires  time.Time          // dropped after, if neither committed nor released
+	Reserved time.Time
+}
+
+func (r *Reservation) IsExpired(now time.Time) bool {
+	return !r.Expires.IsZero() && !r.Expires.After(now)
+}
+
+// Asks for reserving the balances needed by the usage of the call descriptor
+type ReservationRequest struct {
+	CallDescriptor CallDescriptor // CgrId identifies the session, TimeStart to TimeEnd the usage to reserve
+	TTL            time.Duration  // how long the reservation is kept without commit or release, 0 for unlimited
+}
+
+// Drops the expired reservations
+func (acc *Account) purgeReservations(now time.Time) {
+	for id, r := range acc.Reservations {
+		if r.IsExpired(now) {
+			delete(acc.Reservations, id)
+		}
+	}
+}
+
+// Takes out of the balances the amounts reserved for the other sessions.
+// Only to be used on clones, the reserved amounts are still in the stored balances.
+func (acc *Account) withholdReservations(exceptId string, now time.Time) {
+	for id, r := range acc.Reservations {
+		if id == exceptId || r.IsExpired(now) {
+			continue
+		}
+		for uuid, amount := range r.Amounts {
+			for _, chain := range acc.BalanceMap {
+				if b := chain.GetBalance(uuid); b != nil {
+					b.Value -= amount
+					break
+				}
+			}
+		}
+	}
+}
+
+// Returns the ids to lock for changing the balances or the reservations of the account: the account and its shared group members.
+// The account read here only finds the keys, the one changed is loaded again under the lock.
+func (cd *CallDescriptor) accountLockIds() ([]string, error) {
+	account, err := cd.getAccount()
+	if err != nil || account == nil {
+		return nil, fmt.Errorf("could not get the account %s: %v", cd.GetAccountKey(), err)
+	}
+	memberIds, err := account.GetUniqueSharedGroupMembers(cd)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsSliceMember(memberIds, account.Id) {
+		memberIds = append(memberIds, account.Id)
+	}
+	return memberIds, nil
+}
+
+// Loads the account from storage, to be called with the account locked so no other change is lost
+func (cd *CallDescriptor) getLockedAccount() (*Account, error) {
+	cd.account = nil // drop the copy read before locking
+	account, err := cd.getAccount()
+	if err != nil || account == nil {
+		return nil, fmt.Errorf("could not get the account %s: %v", cd.GetAccountKey(), err)
+	}
+	return account, nil
+}
+
+// Puts aside the balances for the usage of the call, as much of it as the account can pay.
+// A new reservation for the same session replaces the previous one.
+func (cd *CallDescriptor) Reserve(ttl time.Duration) (reservation *Reservation, err error) {
+	if cd.CgrId == "" {
+		return nil, errors.New("missing session id (CgrId)")
+	}
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
+		return nil, err
+	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		account.purgeReservations(now)
+		delete(account.Reservations, cd.CgrId)
+		usage, err := cd.getMaxSessionDuration(account)
+		if err != nil {
+			return nil, err
+		}
+		if usage == 0 {
+			return nil, errors.New(utils.ERR_INSUFFICIENT_CREDIT)
+		}
+		reservation = &Reservation{Id: cd.CgrId, Usage: cd.GetDuration(), Amounts: make(map[string]float64), Reserved: now}
+		if ttl > 0 {
+			reservation.Expires = now.Add(ttl)
+		}
+		if usage > 0 { // limited by the credit, -1 for the accounts allowed to go negative
+			reservation.Usage = usage
+		}
+		// the reserved amounts are what a debit of the usage would take out of the balances
+		dryCD := cd.Clone()
+		dryCD.TimeEnd = dryCD.TimeStart.Add(reservation.Usage)
+		if dryCD.DurationIndex < reservation.Usage {
+			dryCD.DurationIndex = reservation.Usage
+		}
+		dryAccount := account.Clone()
+		dryAccount.withholdReservations(cd.CgrId, now)
+		before := make(map[string]float64)
+		for _, chain := range dryAccount.BalanceMap {
+			for _, b := range chain {
+				before[b.Uuid] = b.Value
+			}
+		}
+		dryCD.debit(dryAccount, true, true)
+		for _, chain := range dryAccount.BalanceMap {
+			for _, b := range chain {
+				if amount := utils.Round(before[b.Uuid]-b.Value, globalRoundingDecimals, utils.ROUNDING_MIDDLE); amount > 0 {
+					reservation.Amounts[b.Uuid] = amount
+				}
+			}
+		}
+		if account.Reservations == nil {
+			account.Reservations = make(map[string]*Reservation)
+		}
+		account.Reservations[cd.CgrId] = reservation
+		return nil, accountingStorage.SetAccount(account)
+	}, lockIds...)
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Debits the actual usage of the session out of the balances, releasing what was reserved for it
+func (cd *CallDescriptor) CommitReservation() (cc *CallCost, err error) {
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
+		return nil, err
+	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
+			return nil, err
+		}
+		if _, has := account.Reservations[cd.CgrId]; !has {
+			return nil, errors.New(utils.ERR_NOT_FOUND)
+		}
+		account.purgeReservations(time.Now())
+		delete(account.Reservations, cd.CgrId)
+		if cd.GetDuration() == 0 { // nothing used, the debit would not save the account
+			cc = cd.CreateCallCost()
+			return cc, accountingStorage.SetAccount(account)
+		}
+		// saves the account, without the reservation
+		cc, err = cd.debit(account, false, true)
+		return cc, err
+	}, lockIds...)
+	return
+}
+
+// Gives back to the other calls what was reserved for the session, without debiting anything
+func (cd *CallDescriptor) ReleaseReservation() error {
+	lockIds, err := cd.accountLockIds()
+	if err != nil {
+		return err
+	}
+	_, err = AccLock.Guard(func() (interface{}, error) {
+		account, err := cd.getLockedAccount()
+		if err != nil {
+			return nil, err
+		}
+		if _, has := account.Reservations[cd.CgrId]; !has {
+			return nil, errors.New(utils.ERR_NOT_FOUND)
+		}
+		account.purgeReservations(time.Now())
+		delete(account.Reservations, cd.CgrId)
+		return nil, accountingStorage.SetAccount(account)
+	}, lockIds...)
+	return err
+}
@@ -0,0 +1,184 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func reservationCallDescriptor(cgrId string, usage time.Duration) *CallDescriptor {
+	timeStart := time.Date(2012, 2, 2, 17, 30, 0, 0, time.UTC)
+	return &CallDescriptor{
+		CgrId:       cgrId,
+		Direction:   OUTBOUND,
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "rif",
+		Account:     "reserve",
+		Destination: "0256",
+		TimeStart:   timeStart,
+		TimeEnd:     timeStart.Add(usage),
+	}
+}
+
+func setReservationAccount(t *testing.T) {
+	acc := &Account{Id: "*out:vdf:reserve", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "reserve_money", Id: utils.META_DEFAULT, Value: 300}}}}
+	if err := accountingStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReservationReserve(t *testing.T) {
+	setReservationAccount(t)
+	if d, err := reservationCallDescriptor("other", 2*time.Minute).GetMaxSessionDuration(); err != nil || d != 2*time.Minute {
+		t.Fatalf("Wrong max session time before reservation: %v, %v", d, err)
+	}
+	r, err := reservationCallDescriptor("s1", 3*time.Minute).Reserve(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 connect fee + 1 per second
+	if r.Usage != 3*time.Minute || r.Amounts["reserve_money"] != 181 || r.Expires.IsZero() {
+		t.Errorf("Wrong reservation: %+v", r)
+	}
+	acc, _ := accountingStorage.GetAccount("*out:vdf:reserve")
+	if acc.Reservations["s1"] == nil || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 300 {
+		t.Errorf("Reservation not stored or balance touched: %+v", acc)
+	}
+	// 119 left for the others
+	if d, err := reservationCallDescriptor("other", 2*time.Minute).GetMaxSessionDuration(); err != nil || d != 0 {
+		t.Errorf("Reserved amount available to other calls: %v, %v", d, err)
+	}
+	if d, err := reservationCallDescriptor("other", time.Minute).GetMaxSessionDuration(); err != nil || d != time.Minute {
+		t.Errorf("Wrong max session time next to reservation: %v, %v", d, err)
+	}
+	// the session itself still sees its own reservation
+	if d, err := reservationCallDescriptor("s1", 3*time.Minute).GetMaxSessionDuration(); err != nil || d != 3*time.Minute {
+		t.Errorf("Wrong max session time for the reserving session: %v, %v", d, err)
+	}
+	if _, err := reservationCallDescriptor("s2", 2*time.Minute).Reserve(0); err == nil || err.Error() != utils.ERR_INSUFFICIENT_CREDIT {
+		t.Error("Reserved more than available: ", err)
+	}
+	if _, err := reservationCallDescriptor("", time.Minute).Reserve(0); err == nil {
+		t.Error("Reserved without session id")
+	}
+}
+
+func TestReservationCommit(t *testing.T) {
+	setReservationAccount(t)
+	if _, err := reservationCallDescriptor("s1", 3*time.Minute).Reserve(0); err != nil {
+		t.Fatal(err)
+	}
+	cc, err := reservationCallDescriptor("s1", time.Minute).CommitReservation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.Cost != 61 {
+		t.Error("Wrong committed cost: ", cc.Cost)
+	}
+	acc, _ := accountingStorage.GetAccount("*out:vdf:reserve")
+	if len(acc.Reservations) != 0 || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 239 {
+		t.Errorf("Wrong account after commit: %+v", acc)
+	}
+	if _, err := reservationCallDescriptor("s1", time.Minute).CommitReservation(); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Committed twice: ", err)
+	}
+}
+
+func TestReservationRelease(t *testing.T) {
+	setReservationAccount(t)
+	if _, err := reservationCallDescriptor("s1", 3*time.Minute).Reserve(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := reservationCallDescriptor("s1", 0).ReleaseReservation(); err != nil {
+		t.Fatal(err)
+	}
+	acc, _ := accountingStorage.GetAccount("*out:vdf:reserve")
+	if len(acc.Reservations) != 0 || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 300 {
+		t.Errorf("Wrong account after release: %+v", acc)
+	}
+	if err := reservationCallDescriptor("s1", 0).ReleaseReservation(); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Released twice: ", err)
+	}
+}
+
+func TestReservationExpired(t *testing.T) {
+	setReservationAccount(t)
+	acc, _ := accountingStorage.GetAccount("*out:vdf:reserve")
+	acc.Reservations = map[string]*Reservation{"old": &Reservation{Id: "old", Usage: 5 * time.Minute,
+		Amounts: map[string]float64{"reserve_money": 300}, Expires: time.Now().Add(-time.Minute)}}
+	accountingStorage.SetAccount(acc)
+	if d, err := reservationCallDescriptor("other", 2*time.Minute).GetMaxSessionDuration(); err != nil || d != 2*time.Minute {
+		t.Errorf("Expired reservation still withheld: %v, %v", d, err)
+	}
+	if _, err := reservationCallDescriptor("s1", time.Minute).Reserve(0); err != nil {
+		t.Fatal(err)
+	}
+	acc, _ = accountingStorage.GetAccount("*out:vdf:reserve")
+	if _, has := acc.Reservations["old"]; has || acc.Reservations["s1"] == nil {
+		t.Errorf("Expired reservation not purged: %+v", acc.Reservations)
+	}
+}
+
+func TestReservationStaleAccount(t *testing.T) {
+	setReservationAccount(t)
+	cd := reservationCallDescriptor("s1", 3*time.Minute)
+	if _, err := cd.getAccount(); err != nil { // cached on the call descriptor
+		t.Fatal(err)
+	}
+	// topped up by another call before the reservation gets the lock
+	acc, _ := accountingStorage.GetAccount("*out:vdf:reserve")
+	acc.BalanceMap[utils.MONETARY+OUTBOUND][0].Value = 1000
+	if err := accountingStorage.SetAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cd.Reserve(0); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:vdf:reserve"); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 1000 || acc.Reservations["s1"] == nil {
+		t.Errorf("Account overwritten with the stale one: %+v", acc)
+	}
+}
+
+func TestReservationDebitStaleAccount(t *testing.T) {
+	setReservationAccount(t)
+	debitCD := reservationCallDescriptor("other", time.Minute)
+	maxDebitCD := reservationCallDescriptor("other", time.Minute)
+	for _, cd := range []*CallDescriptor{debitCD, maxDebitCD} { // cached before the reservation
+		if _, err := cd.getAccount(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := reservationCallDescriptor("s1", time.Minute).Reserve(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := debitCD.Debit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := maxDebitCD.MaxDebit(); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:vdf:reserve"); acc.Reservations["s1"] == nil || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 178 {
+		t.Errorf("Reservation lost by the debits: %+v", acc)
+	}
+}
@@ -119,6 +119,61 @@ func (rs *Responder) GetMaxSessionTime(arg CallDescriptor, reply *float64) (err
 	return
 }
 
+// Rates many call descriptors in one request, the errors are returned per item
+func (rs *Responder) GetCostBatch(args []CallDescriptor, reply *[]*BatchCallCost) (err error) {
+	if rs.Bal != nil {
+		return rs.callRater("Responder.GetCostBatch", args, reply)
+	}
+	*reply = GetCostBatch(args)
+	return
+}
+
+func (rs *Responder) GetMaxSessionTimeBatch(args []CallDescriptor, reply *[]*BatchMaxSessionTime) (err error) {
+	if rs.Bal != nil {
+		return rs.callRater("Responder.GetMaxSessionTimeBatch", args, reply)
+	}
+	*reply = GetMaxSessionTimeBatch(args)
+	return
+}
+
+// Puts aside the balances needed by the session, reducing the max session time of the other calls on the account
+func (rs *Responder) ReserveBalance(arg ReservationRequest, reply *Reservation) (err error) {
+	if rs.Bal != nil {
+		return rs.callRater("Responder.ReserveBalance", arg, reply)
+	}
+	r, err := arg.CallDescriptor.Reserve(arg.TTL)
+	if err != nil {
+		return err
+	}
+	*reply = *r
+	return
+}
+
+// Debits the actual usage of the session, dropping its reservation
+func (rs *Responder) CommitReservation(arg CallDescriptor, reply *CallCost) (err error) {
+	if rs.Bal != nil {
+		return rs.callRater("Responder.CommitReservation", arg, reply)
+	}
+	r, err := arg.CommitReservation()
+	if err != nil {
+		return err
+	}
+	*reply = *r
+	return
+}
+
+// Drops the reservation of the session without debiting anything
+func (rs *Responder) ReleaseReservation(arg CallDescriptor, reply *string) (err error) {
+	if rs.Bal != nil {
+		return rs.callRater("Responder.ReleaseReservation", arg, reply)
+	}
+	if err = arg.ReleaseReservation(); err != nil {
+		return err
+	}
+	*reply = utils.OK
+	return
+}
+
 // Returns MaxSessionTime for an event received in SessionManager, considering DerivedCharging for it
 func (rs *Responder) GetDerivedMaxSessionTime(ev StoredCdr, reply *float64) error {
 	if rs.Bal != nil {
@@ -205,7 +260,8 @@ func (rs *Responder) GetSessionRuns(ev StoredCdr, sRuns *[]*SessionRun) error {
 			Subject:     ev.GetSubject(dc.SubjectField),
 			Account:     ev.GetAccount(dc.AccountField),
 			Destination: ev.GetDestination(dc.DestinationField),
-			TimeStart:   startTime}
+			TimeStart:   startTime,
+			CgrId:       ev.GetCgrId()}
 		sesRuns = append(sesRuns, &SessionRun{DerivedCharger: dc, CallDescriptor: cd})
 	}
 	*sRuns = sesRuns
@@ -325,6 +381,26 @@ func (rs *Responder) callMethod(key *CallDescriptor, method string) (reply float
 	return
 }
 
+/*
+The function that sends the request to one of the raters using balancer, without locking here.
+Used for the methods locking the accounts themselves on the rater (batches, reservations).
+*/
+func (rs *Responder) callRater(method string, args interface{}, reply interface{}) (err error) {
+	err = errors.New("") //not nil value
+	for err != nil {
+		client := rs.Bal.Balance()
+		if client == nil {
+			Logger.Info("<Balancer> Waiting for raters to register...")
+			time.Sleep(1 * time.Second) // wait one second and retry
+		} else {
+			if err = client.Call(method, args, reply); err != nil {
+				Logger.Err(fmt.Sprintf("<Balancer> Got en error from rater: %v", err))
+			}
+		}
+	}
+	return
+}
+
 /*
 RPC method that receives a rater address, connects to it and ads the pair to the rater list for balancing
 */
@@ -367,6 +443,14 @@ func (rw *ResponderWorker) Call(serviceMethod string, args interface{}, reply in
 		switch reply.(type) {
 		case *CallCost:
 			rep := reply.(*CallCost)
+			if methodName == "CommitReservation" {
+				cc, err := cd.CommitReservation()
+				if err != nil {
+					return err
+				}
+				*rep = *cc
+				return nil
+			}
 			method := reflect.ValueOf(&cd).MethodByName(methodName)
 			ret := method.Call([]reflect.Value{})
 			*rep = *(ret[0].Interface().(*CallCost))
@@ -375,6 +459,28 @@ func (rw *ResponderWorker) Call(serviceMethod string, args interface{}, reply in
 			method := reflect.ValueOf(&cd).MethodByName(methodName)
 			ret := method.Call([]reflect.Value{})
 			*rep = *(ret[0].Interface().(*float64))
+		case *string:
+			if methodName == "ReleaseReservation" {
+				if err := cd.ReleaseReservation(); err != nil {
+					return err
+				}
+				*(reply.(*string)) = utils.OK
+			}
+		}
+	case ReservationRequest:
+		req := args.(ReservationRequest)
+		r, err := req.CallDescriptor.Reserve(req.TTL)
+		if err != nil {
+			return err
+		}
+		*(reply.(*Reservation)) = *r
+	case []CallDescriptor:
+		cds := args.([]CallDescriptor)
+		switch methodName {
+		case "GetCostBatch":
+			*(reply.(*[]*BatchCallCost)) = GetCostBatch(cds)
+		case "GetMaxSessionTimeBatch":
+			*(reply.(*[]*BatchMaxSessionTime)) = GetMaxSessionTimeBatch(cds)
 		}
 	case string:
 		switch methodName {
@@ -398,6 +504,11 @@ type Connector interface {
 	MaxDebit(CallDescriptor, *CallCost) error
 	RefundIncrements(CallDescriptor, *float64) error
 	GetMaxSessionTime(CallDescriptor, *float64) error
+	GetCostBatch([]CallDescriptor, *[]*BatchCallCost) error
+	GetMaxSessionTimeBatch([]CallDescriptor, *[]*BatchMaxSessionTime) error
+	ReserveBalance(ReservationRequest, *Reservation) error
+	CommitReservation(CallDescriptor, *CallCost) error
+	ReleaseReservation(CallDescriptor, *string) error
 	GetDerivedChargers(utils.AttrDerivedChargers, *utils.DerivedChargers) error
 	GetDerivedMaxSessionTime(StoredCdr, *float64) error
 	GetSessionRuns(StoredCdr, *[]*SessionRun) error
@@ -429,6 +540,26 @@ func (rcc *RPCClientConnector) GetMaxSessionTime(cd CallDescriptor, resp *float6
 	return rcc.Client.Call("Responder.GetMaxSessionTime", cd, resp)
 }
 
+func (rcc *RPCClientConnector) GetCostBatch(cds []CallDescriptor, reply *[]*BatchCallCost) error {
+	return rcc.Client.Call("Responder.GetCostBatch", cds, reply)
+}
+
+func (rcc *RPCClientConnector) GetMaxSessionTimeBatch(cds []CallDescriptor, reply *[]*BatchMaxSessionTime) error {
+	return rcc.Client.Call("Responder.GetMaxSessionTimeBatch", cds, reply)
+}
+
+func (rcc *RPCClientConnector) ReserveBalance(req ReservationRequest, reply *Reservation) error {
+	return rcc.Client.Call("Responder.ReserveBalance", req, reply)
+}
+
+func (rcc *RPCClientConnector) CommitReservation(cd CallDescriptor, cc *CallCost) error {
+	return rcc.Client.Call("Responder.CommitReservation", cd, cc)
+}
+
+func (rcc *RPCClientConnector) ReleaseReservation(cd CallDescriptor, reply *string) error {
+	return rcc.Client.Call("Responder.ReleaseReservation", cd, reply)
+}
+
 func (rcc *RPCClientConnector) GetDerivedMaxSessionTime(ev StoredCdr, reply *float64) error {
 	return rcc.Client.Call("Responder.GetDerivedMaxSessionTime", ev, reply)
 }
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cgrates/cgrates/balancer2go"
 	"github.com/cgrates/cgrates/config"
 	"github.com/cgrates/cgrates/utils"
 )
@@ -151,11 +152,11 @@ func TestGetSessionRuns(t *testing.T) {
 	sesRuns := make([]*SessionRun, 0)
 	eSRuns := []*SessionRun{
 		&SessionRun{DerivedCharger: extra1DC,
-			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "0", Tenant: "vdf", Subject: "rif", Account: "minitsboy", Destination: "0256", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC)}},
+			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "0", Tenant: "vdf", Subject: "rif", Account: "minitsboy", Destination: "0256", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC), CgrId: cdr.CgrId}},
 		&SessionRun{DerivedCharger: extra2DC,
-			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "call", Tenant: "vdf", Subject: "ivo", Account: "ivo", Destination: "1002", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC)}},
+			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "call", Tenant: "vdf", Subject: "ivo", Account: "ivo", Destination: "1002", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC), CgrId: cdr.CgrId}},
 		&SessionRun{DerivedCharger: dfDC,
-			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "call", Tenant: "vdf", Subject: "dan2", Account: "dan2", Destination: "1002", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC)}}}
+			CallDescriptor: &CallDescriptor{Direction: "*out", Category: "call", Tenant: "vdf", Subject: "dan2", Account: "dan2", Destination: "1002", TimeStart: time.Date(2013, 11, 7, 8, 42, 26, 0, time.UTC), CgrId: cdr.CgrId}}}
 	if err := rsponder.GetSessionRuns(cdr, &sesRuns); err != nil {
 		t.Error(err)
 	} else if !reflect.DeepEqual(eSRuns, sesRuns) {
@@ -522,3 +523,58 @@ func TestGetLCR(t *testing.T) {
 		t.Errorf("Expecting: %+v, received: %+v", eQosLcr.SupplierCosts[0], lcrQ.SupplierCosts[0])
 	}
 }
+
+func TestResponderGetCostBatch(t *testing.T) {
+	t1 := time.Date(2012, time.February, 2, 17, 30, 0, 0, time.UTC)
+	t2 := time.Date(2012, time.February, 2, 18, 30, 0, 0, time.UTC)
+	cds := []CallDescriptor{
+		CallDescriptor{Direction: "*out", Category: "0", Tenant: "vdf", Subject: "rif", Destination: "0256", TimeStart: t1, TimeEnd: t2},
+		CallDescriptor{Direction: "*out", Category: "0", Tenant: "nosuchtenant", Subject: "rif", Destination: "0256", TimeStart: t1, TimeEnd: t2},
+		CallDescriptor{Direction: "*out", Category: "0", Tenant: "vdf", Subject: "rif", Destination: "0256", TimeStart: t1, TimeEnd: t2},
+	}
+	bal := balancer2go.NewBalancer()
+	bal.AddClient("local", new(ResponderWorker))
+	for _, rs := range []*Responder{&Responder{}, &Responder{Bal: bal}} {
+		var results []*BatchCallCost
+		if err := rs.GetCostBatch(cds, &results); err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Wrong number of results: %+v", results)
+		}
+		for _, idx := range []int{0, 2} {
+			if results[idx].Error != "" || results[idx].CallCost == nil || results[idx].CallCost.Cost != 2701 {
+				t.Errorf("Wrong result %d: %+v", idx, results[idx])
+			}
+		}
+		if results[1].Error == "" || results[1].CallCost != nil {
+			t.Errorf("Error not returned for the item: %+v", results[1])
+		}
+	}
+}
+
+func TestResponderGetMaxSessionTimeBatch(t *testing.T) {
+	cd := CallDescriptor{
+		TimeStart:   time.Date(2013, 10, 21, 18, 34, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2013, 10, 21, 18, 35, 0, 0, time.UTC),
+		Direction:   "*out",
+		Category:    "0",
+		Tenant:      "vdf",
+		Subject:     "minu",
+		Account:     "minu",
+		Destination: "0723",
+	}
+	var expected float64
+	if err := new(Responder).GetMaxSessionTime(*cd.Clone(), &expected); err != nil {
+		t.Fatal(err)
+	}
+	var results []*BatchMaxSessionTime
+	if err := new(Responder).GetMaxSessionTimeBatch([]CallDescriptor{*cd.Clone(), *cd.Clone()}, &results); err != nil {
+		t.Fatal(err)
+	}
+	for idx, result := range results {
+		if result.Error != "" || result.MaxSessionTime != expected {
+			t.Errorf("Wrong result %d, expecting %v: %+v", idx, expected, result)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Rollover settings of the *topup_rollover action, JSON encoded in its ExtraParameters, eg: {"Periods":2,"MaxValue":600}
+type RolloverParams struct {
+	Periods  int     // runs of the action plan the rolled over units are kept for
+	MaxValue float64 // most units kept out of one balance over all the periods, 0 for no limit
+}
+
+func parseRolloverParams(extraParams string) (*RolloverParams, error) {
+	params := new(RolloverParams)
+	if extraParams != "" {
+		if err := json.Unmarshal([]byte(extraParams), params); err != nil {
+			return nil, fmt.Errorf("invalid rollover parameters %s: %v", extraParams, err)
+		}
+	}
+	if params.Periods < 1 || params.MaxValue < 0 {
+		return nil, fmt.Errorf("invalid rollover parameters: %+v", params)
+	}
+	return params, nil
+}
+
+// Moves the unused units of the balances matching the action into balances expiring after the configured number of
+// periods, then resets and tops them up as *topup_reset does. The rolled over balances are not reset nor rolled again,
+// they expire before the bundle so they are used first.
+func topupRolloverAction(ub *Account, sq *StatsQueueTriggered, a *Action, acs Actions) (err error) {
+	if ub == nil {
+		return errors.New("Nil user balance")
+	}
+	if a.rolloverExpiry.IsZero() {
+		return errors.New("rollover actions can only be executed by action plans")
+	}
+	params, err := parseRolloverParams(a.ExtraParameters)
+	if err != nil {
+		return err
+	}
+	if ub.BalanceMap == nil {
+		ub.BalanceMap = make(map[string]BalanceChain, 0)
+	}
+	id := a.BalanceType + a.Direction
+	ub.CleanExpiredBalances()
+	ledger := newLedgerSnapshot(ub)
+	var rolled BalanceChain
+	for _, b := range ub.BalanceMap[id] {
+		// each balance rolls into its own, keeping destinations, category and rating subject apart
+		if b.IsExpired() || b.Value <= 0 || b.SharedGroup != "" || b.RolloverFrom != "" || !b.MatchFilter(a.Balance) {
+			continue
+		}
+		if b.Uuid == "" {
+			b.Uuid = utils.GenUUID()
+		}
+		units := b.Value
+		if params.MaxValue > 0 {
+			kept := 0.0
+			for _, rb := range ub.BalanceMap[id] {
+				if rb.RolloverFrom == b.Uuid && !rb.IsExpired() {
+					kept += rb.Value
+				}
+			}
+			units = math.Min(units, params.MaxValue-kept)
+		}
+		if units <= 0 {
+			continue
+		}
+		b.SubstractAmount(units)
+		rb := b.Clone()
+		rb.Uuid = utils.GenUUID()
+		rb.Id = "" // the id stays with the bundle
+		rb.Value = units
+		rb.ExpirationDate = a.rolloverExpiry
+		rb.RolloverFrom = b.Uuid
+		rb.dirty = true
+		rolled = append(rolled, rb)
+	}
+	// reset and topup of the bundle, leaving out the units rolled over now and in the previous periods
+	found := false
+	for _, b := range ub.BalanceMap[id] {
+		if b.IsExpired() || b.RolloverFrom != "" || !b.MatchFilter(a.Balance) {
+			continue
+		}
+		b.Value = 0
+		b.SubstractAmount(-a.Balance.Value)
+		found = true
+	}
+	if !found {
+		b := a.Balance.Clone()
+		if b.Uuid == "" {
+			b.Uuid = utils.GenUUID()
+		}
+		b.dirty = true
+		ub.BalanceMap[id] = append(ub.BalanceMap[id], b)
+	}
+	ub.BalanceMap[id] = append(ub.BalanceMap[id], rolled...)
+	ledger.write("", a)
+	a.RolledOver = rolled
+	ub.joinSharedGroup(a.Balance.SharedGroup)
+	ub.executeActionTriggers(nil)
+	return nil
+}
+
+// Copy of the rollover action executed now, with the rolled over units expiring after the configured number of runs
+func (at *ActionTiming) rolloverAction(a *Action, now time.Time) (*Action, error) {
+	params, err := parseRolloverParams(a.ExtraParameters)
+	if err != nil {
+		return nil, err
+	}
+	if at.Timing == nil || at.Timing.Timing == nil || at.IsASAP() {
+		return nil, fmt.Errorf("action timing %s has no recurring schedule", at.Id)
+	}
+	expiry := now
+	for i := 0; i < params.Periods; i++ {
+		if expiry = at.nextStartTime(expiry); expiry.IsZero() {
+			return nil, fmt.Errorf("action timing %s does not run %d more times", at.Id, params.Periods)
+		}
+	}
+	ra := *a
+	ra.Balance = a.Balance.Clone()
+	ra.rolloverExpiry = expiry
+	return &ra, nil
+}
+
+// Logs the units the rollover action executed on one account kept out of each balance
+func (at *ActionTiming) logRollover(accId string, a *Action) {
+	for _, rb := range a.RolledOver {
+		Logger.Info(fmt.Sprintf("<ActionTiming> Rolled over %v units of balance %s on account %s, expiring %v",
+			rb.Value, rb.RolloverFrom, accId, rb.ExpirationDate))
+	}
+	at.logAccountAction(accId, a)
+}
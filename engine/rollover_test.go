@@ -0,0 +1,98 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestRolloverParams(t *testing.T) {
+	if params, err := parseRolloverParams(`{"Periods":2,"MaxValue":600}`); err != nil || params.Periods != 2 || params.MaxValue != 600 {
+		t.Error("Wrong params: ", params, err)
+	}
+	if _, err := parseRolloverParams(""); err == nil {
+		t.Error("Missing periods accepted")
+	}
+	if _, err := parseRolloverParams(`{"Periods":1,"MaxValue":-1}`); err == nil {
+		t.Error("Negative max accepted")
+	}
+}
+
+func TestTopupRolloverActionTiming(t *testing.T) {
+	accountingStorage.SetAccount(&Account{Id: "*out:cgrates.org:rollover", BalanceMap: map[string]BalanceChain{
+		utils.VOICE + OUTBOUND: BalanceChain{&Balance{Uuid: "nat", Value: 100, DestinationIds: "NAT"}, &Balance{Uuid: "ret", Value: 50, DestinationIds: "RET"}}}})
+	at := &ActionTiming{Id: "MONTHLY_BUNDLE", AccountIds: []string{"*out:cgrates.org:rollover"},
+		Timing: &RateInterval{Timing: &RITiming{MonthDays: utils.MonthDays{1}, StartTime: "00:00:00"}}}
+	at.SetActions(Actions{
+		&Action{Id: "NAT_BUNDLE", ActionType: TOPUP_ROLLOVER, BalanceType: utils.VOICE, Direction: OUTBOUND, ExtraParameters: `{"Periods":2,"MaxValue":120}`,
+			Balance: &Balance{Value: 200, DestinationIds: "NAT"}},
+		&Action{Id: "RET_BUNDLE", ActionType: TOPUP_ROLLOVER, BalanceType: utils.VOICE, Direction: OUTBOUND, ExtraParameters: `{"Periods":1}`,
+			Balance: &Balance{Value: 100, DestinationIds: "RET"}}})
+	now := time.Now()
+	if err := at.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	acc, _ := accountingStorage.GetAccount("*out:cgrates.org:rollover")
+	bc := acc.BalanceMap[utils.VOICE+OUTBOUND]
+	if len(bc) != 4 || bc.GetBalance("nat").Value != 200 || bc.GetBalance("ret").Value != 100 {
+		t.Fatalf("Wrong balances after rollover: %+v", bc)
+	}
+	for _, b := range bc[2:] {
+		switch b.RolloverFrom {
+		case "nat":
+			if b.Value != 100 || b.DestinationIds != "NAT" || !b.ExpirationDate.Equal(at.nextStartTime(at.nextStartTime(now))) {
+				t.Errorf("Wrong rolled over balance: %+v", b)
+			}
+		case "ret":
+			if b.Value != 50 || b.DestinationIds != "RET" || !b.ExpirationDate.Equal(at.nextStartTime(now)) {
+				t.Errorf("Wrong rolled over balance: %+v", b)
+			}
+		default:
+			t.Errorf("Unexpected balance: %+v", b)
+		}
+	}
+	// the rolled over units expire first, so they are used before the bundle
+	bc.Sort()
+	if bc[0].RolloverFrom == "" || bc[1].RolloverFrom == "" {
+		t.Errorf("Bundle used before the rolled over units: %+v", bc)
+	}
+	if !bc[0].MatchFilter(&Balance{DestinationIds: bc[0].DestinationIds}) {
+		t.Error("Rolled over balance not matching the filters of its bundle")
+	}
+	// the second period only rolls over up to the maximum, the rolled over units are not reset
+	if err := at.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	acc, _ = accountingStorage.GetAccount("*out:cgrates.org:rollover")
+	natRolled := 0.0
+	for _, b := range acc.BalanceMap[utils.VOICE+OUTBOUND] {
+		if b.RolloverFrom == "nat" {
+			natRolled += b.Value
+		}
+	}
+	if natRolled != 120 || acc.BalanceMap[utils.VOICE+OUTBOUND].GetBalance("nat").Value != 200 {
+		t.Errorf("Wrong capped rollover: %v, %+v", natRolled, acc.BalanceMap[utils.VOICE+OUTBOUND])
+	}
+	if err := topupRolloverAction(acc, nil, at.actions[0], nil); err == nil {
+		t.Error("Rollover executed outside action plan")
+	}
+}
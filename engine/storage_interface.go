@@ -23,6 +23,7 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"reflect"
+	"time"
 
 	"github.com/cgrates/cgrates/utils"
 	"github.com/ugorji/go/codec"
@@ -42,6 +43,9 @@ const (
 	LCR_PREFIX                = "lcr_"
 	DERIVEDCHARGERS_PREFIX    = "dcs_"
 	CDR_STATS_PREFIX          = "cst_"
+	EXCHANGE_RATE_PREFIX      = "exr_"
+	TAX_RULES_PREFIX          = "tax_"
+	PORTED_NUMBER_PREFIX      = "npn_"
 	TEMP_DESTINATION_PREFIX   = "tmp_"
 	LOG_CALL_COST_PREFIX      = "cco_"
 	LOG_ACTION_TIMMING_PREFIX = "ltm_"
@@ -49,6 +53,7 @@ const (
 	LOG_ERR                   = "ler_"
 	LOG_CDR                   = "cdr_"
 	LOG_MEDIATED_CDR          = "mcd_"
+	VERSION_PREFIX            = "ver_"
 	// sources
 	SESSION_MANAGER_SOURCE       = "SMR"
 	MEDIATOR_SOURCE              = "MED"
@@ -65,6 +70,8 @@ type Storage interface {
 	Close()
 	Flush(string) error
 	GetKeysForPrefix(string) ([]string, error)
+	GetVersion(string) (int64, error)
+	SetVersion(string, int64) error
 }
 
 // Interface for storage providers.
@@ -87,6 +94,13 @@ type RatingStorage interface {
 	SetCdrStats(*CdrStats) error
 	GetCdrStats(string) (*CdrStats, error)
 	GetAllCdrStats() ([]*CdrStats, error)
+	GetExchangeRate(string, bool) (*ExchangeRate, error)
+	SetExchangeRate(*ExchangeRate) error
+	GetTaxRules(string, bool) (*TaxRules, error)
+	SetTaxRules(*TaxRules) error
+	GetPortedNumber(string) (*PortedNumber, error)
+	SetPortedNumber(*PortedNumber) error
+	RemovePortedNumber(string) error
 }
 
 type AccountingStorage interface {
@@ -118,6 +132,11 @@ type CdrStorage interface {
 	GetCallCostLog(cgrid, source, runid string) (*CallCost, error)
 	GetStoredCdrs(*utils.CdrsFilter) ([]*StoredCdr, int64, error)
 	RemStoredCdrs([]string) error
+	PurgeStoredCdrs([]string) error
+	SetLedgerEntry(*LedgerEntry) error
+	GetLedgerEntries(accountId string, timeStart, timeEnd time.Time) ([]*LedgerEntry, error)
+	SetInvoice(*Invoice) error
+	GetInvoices(accountId string, number int64) ([]*Invoice, error)
 }
 
 type LogStorage interface {
@@ -156,6 +175,17 @@ type LoadStorage interface {
 	SetTPSharedGroups(string, map[string][]*utils.TPSharedGroup) error
 	GetTpSharedGroups(string, string) (map[string][]*utils.TPSharedGroup, error)
 
+	SetTPExchangeRates(string, map[string][]*utils.TPExchangeRate) error
+	GetTpExchangeRates(string, string) (map[string][]*utils.TPExchangeRate, error)
+
+	SetTPTaxRules(string, map[string][]*utils.TPTaxRule) error
+	GetTpTaxRules(string, string) (map[string][]*utils.TPTaxRule, error)
+
+	SetTPPortedNumbers(string, map[string][]*utils.TPPortedNumber) error
+	GetTpPortedNumbers(string, string) (map[string][]*utils.TPPortedNumber, error)
+	SetTPCalendars(string, map[string][]*utils.TPCalendarDay) error
+	GetTpCalendars(string, string) (map[string][]*utils.TPCalendarDay, error)
+
 	SetTPCdrStats(string, map[string][]*utils.TPCdrStat) error
 	GetTpCdrStats(string, string) (map[string][]*utils.TPCdrStat, error)
 
@@ -24,8 +24,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cgrates/cgrates/cache2go"
@@ -33,26 +35,91 @@ import (
 )
 
 type MapStorage struct {
-	dict map[string][]byte
-	ms   Marshaler
+	dict          map[string][]byte
+	ms            Marshaler
+	mu            sync.RWMutex
+	dataDir       string        // set for the persistent storage, holds the snapshot and the mutations log
+	aof           *os.File      // append-only mutations log, nil when running purely in memory
+	stopSnapshots chan struct{} // stops the periodic snapshots
+	isolated      bool          // keeps the shared cache and destination index untouched
 }
 
 func NewMapStorage() (*MapStorage, error) {
 	return &MapStorage{dict: make(map[string][]byte), ms: NewCodecMsgpackMarshaler()}, nil
 }
 
+// In-memory storage not sharing the cache and destination index with the live one, used for simulations
+func NewIsolatedMapStorage() (*MapStorage, error) {
+	return &MapStorage{dict: make(map[string][]byte), ms: NewCodecMsgpackMarshaler(), isolated: true}, nil
+}
+
 func NewMapStorageJson() (*MapStorage, error) {
 	return &MapStorage{dict: make(map[string][]byte), ms: new(JSONBufMarshaler)}, nil
 }
 
-func (ms *MapStorage) Close() {}
+func (ms *MapStorage) Close() {
+	if !releaseInternalMapStorage(ms) { // still used by the other database in the same folder
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.aof == nil { // in memory or already closed
+		return
+	}
+	if ms.stopSnapshots != nil {
+		close(ms.stopSnapshots)
+	}
+	if err := ms.snapshot(); err != nil {
+		Logger.Err(fmt.Sprintf("<MapStorage> Could not write snapshot on close: %v", err))
+	}
+	ms.aof.Close()
+	ms.aof = nil
+}
 
 func (ms *MapStorage) Flush(ignore string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 	ms.dict = make(map[string][]byte)
-	return nil
+	return ms.snapshot()
+}
+
+func (ms *MapStorage) get(key string) (value []byte, ok bool) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	value, ok = ms.dict[key]
+	return
+}
+
+func (ms *MapStorage) set(key string, value []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.dict[key] = value
+	return ms.logMutation(MAP_OP_SET, key, value)
+}
+
+func (ms *MapStorage) del(key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.dict, key)
+	return ms.logMutation(MAP_OP_DEL, key, nil)
+}
+
+func (ms *MapStorage) GetVersion(item string) (version int64, err error) {
+	if values, ok := ms.get(VERSION_PREFIX + item); ok {
+		version, err = strconv.ParseInt(string(values), 10, 64)
+	} else {
+		return 0, errors.New(utils.ERR_NOT_FOUND)
+	}
+	return
+}
+
+func (ms *MapStorage) SetVersion(item string, version int64) error {
+	return ms.set(VERSION_PREFIX+item, []byte(strconv.FormatInt(version, 10)))
 }
 
 func (ms *MapStorage) GetKeysForPrefix(prefix string) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	keysForPrefix := make([]string, 0)
 	for key := range ms.dict {
 		if strings.HasPrefix(key, prefix) {
@@ -62,10 +129,12 @@ func (ms *MapStorage) GetKeysForPrefix(prefix string) ([]string, error) {
 	return keysForPrefix, nil
 }
 
-func (ms *MapStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []string) error {
+func (ms *MapStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []string) (err error) {
 	cache2go.BeginTransaction()
-	if dKeys == nil || (float64(cache2go.CountEntries(DESTINATION_PREFIX))*DESTINATIONS_LOAD_THRESHOLD < float64(len(dKeys))) {
-		cache2go.RemPrefixKey(DESTINATION_PREFIX)
+	beginDestinationIndexing()
+	defer func() { endDestinationIndexing(err == nil) }()
+	if dKeys == nil || (float64(CountCachedDestPrefixes())*DESTINATIONS_LOAD_THRESHOLD < float64(len(dKeys))) {
+		resetDestinationIndex()
 	} else {
 		CleanStalePrefixes(dKeys)
 	}
@@ -81,7 +150,11 @@ func (ms *MapStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []str
 	if lcrKeys == nil {
 		cache2go.RemPrefixKey(LCR_PREFIX)
 	}
-	for k, _ := range ms.dict {
+	// few of them, always reloaded in full
+	cache2go.RemPrefixKey(EXCHANGE_RATE_PREFIX)
+	cache2go.RemPrefixKey(TAX_RULES_PREFIX)
+	keys, _ := ms.GetKeysForPrefix("")
+	for _, k := range keys {
 		if strings.HasPrefix(k, DESTINATION_PREFIX) {
 			if _, err := ms.GetDestination(k[len(DESTINATION_PREFIX):]); err != nil {
 				cache2go.RollbackTransaction()
@@ -116,6 +189,18 @@ func (ms *MapStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []str
 				return err
 			}
 		}
+		if strings.HasPrefix(k, EXCHANGE_RATE_PREFIX) {
+			if _, err := ms.GetExchangeRate(k[len(EXCHANGE_RATE_PREFIX):], true); err != nil {
+				cache2go.RollbackTransaction()
+				return err
+			}
+		}
+		if strings.HasPrefix(k, TAX_RULES_PREFIX) {
+			if _, err := ms.GetTaxRules(k[len(TAX_RULES_PREFIX):], true); err != nil {
+				cache2go.RollbackTransaction()
+				return err
+			}
+		}
 	}
 	cache2go.CommitTransaction()
 	return nil
@@ -135,7 +220,8 @@ func (ms *MapStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []strin
 	if dcsKeys == nil {
 		cache2go.RemPrefixKey(DERIVEDCHARGERS_PREFIX)
 	}
-	for k, _ := range ms.dict {
+	keys, _ := ms.GetKeysForPrefix("")
+	for _, k := range keys {
 		if strings.HasPrefix(k, ACTION_PREFIX) {
 			cache2go.RemKey(k)
 			if _, err := ms.GetActions(k[len(ACTION_PREFIX):], true); err != nil {
@@ -173,10 +259,10 @@ func (ms *MapStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []strin
 func (ms *MapStorage) HasData(categ, subject string) (bool, error) {
 	switch categ {
 	case DESTINATION_PREFIX:
-		_, exists := ms.dict[DESTINATION_PREFIX+subject]
+		_, exists := ms.get(DESTINATION_PREFIX + subject)
 		return exists, nil
 	case RATING_PLAN_PREFIX:
-		_, exists := ms.dict[RATING_PLAN_PREFIX+subject]
+		_, exists := ms.get(RATING_PLAN_PREFIX + subject)
 		return exists, nil
 	}
 	return false, errors.New("Unsupported category")
@@ -184,14 +270,14 @@ func (ms *MapStorage) HasData(categ, subject string) (bool, error) {
 
 func (ms *MapStorage) GetRatingPlan(key string, skipCache bool) (rp *RatingPlan, err error) {
 	key = RATING_PLAN_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(*RatingPlan), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		b := bytes.NewBuffer(values)
 		r, err := zlib.NewReader(b)
 		if err != nil {
@@ -204,7 +290,9 @@ func (ms *MapStorage) GetRatingPlan(key string, skipCache bool) (rp *RatingPlan,
 		r.Close()
 		rp = new(RatingPlan)
 		err = ms.ms.Unmarshal(out, rp)
-		cache2go.Cache(key, rp)
+		if !ms.isolated {
+			cache2go.Cache(key, rp)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -213,11 +301,14 @@ func (ms *MapStorage) GetRatingPlan(key string, skipCache bool) (rp *RatingPlan,
 
 func (ms *MapStorage) SetRatingPlan(rp *RatingPlan) (err error) {
 	result, err := ms.ms.Marshal(rp)
+	if err != nil {
+		return err
+	}
 	var b bytes.Buffer
 	w := zlib.NewWriter(&b)
 	w.Write(result)
 	w.Close()
-	ms.dict[RATING_PLAN_PREFIX+rp.Id] = b.Bytes()
+	err = ms.set(RATING_PLAN_PREFIX+rp.Id, b.Bytes())
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Record(rp.GetHistoryRecord(), &response)
@@ -228,18 +319,20 @@ func (ms *MapStorage) SetRatingPlan(rp *RatingPlan) (err error) {
 
 func (ms *MapStorage) GetRatingProfile(key string, skipCache bool) (rpf *RatingProfile, err error) {
 	key = RATING_PROFILE_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(*RatingProfile), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		rpf = new(RatingProfile)
 
 		err = ms.ms.Unmarshal(values, rpf)
-		cache2go.Cache(key, rpf)
+		if !ms.isolated {
+			cache2go.Cache(key, rpf)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -248,7 +341,10 @@ func (ms *MapStorage) GetRatingProfile(key string, skipCache bool) (rpf *RatingP
 
 func (ms *MapStorage) SetRatingProfile(rpf *RatingProfile) (err error) {
 	result, err := ms.ms.Marshal(rpf)
-	ms.dict[RATING_PROFILE_PREFIX+rpf.Id] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(RATING_PROFILE_PREFIX+rpf.Id, result)
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Record(rpf.GetHistoryRecord(), &response)
@@ -259,16 +355,18 @@ func (ms *MapStorage) SetRatingProfile(rpf *RatingProfile) (err error) {
 
 func (ms *MapStorage) GetLCR(key string, skipCache bool) (lcr *LCR, err error) {
 	key = LCR_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(*LCR), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		err = ms.ms.Unmarshal(values, &lcr)
-		cache2go.Cache(key, lcr)
+		if !ms.isolated {
+			cache2go.Cache(key, lcr)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -277,23 +375,28 @@ func (ms *MapStorage) GetLCR(key string, skipCache bool) (lcr *LCR, err error) {
 
 func (ms *MapStorage) SetLCR(lcr *LCR) (err error) {
 	result, err := ms.ms.Marshal(lcr)
-	ms.dict[LCR_PREFIX+lcr.GetId()] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(LCR_PREFIX+lcr.GetId(), result)
 	//cache2go.Cache(LCR_PREFIX+key, lcr)
 	return
 }
 
 func (ms *MapStorage) GetRpAlias(key string, skipCache bool) (alias string, err error) {
 	key = RP_ALIAS_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(string), nil
 		} else {
 			return "", err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		alias = string(values)
-		cache2go.Cache(key, alias)
+		if !ms.isolated {
+			cache2go.Cache(key, alias)
+		}
 	} else {
 		return "", errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -301,24 +404,28 @@ func (ms *MapStorage) GetRpAlias(key string, skipCache bool) (alias string, err
 }
 
 func (ms *MapStorage) SetRpAlias(key, alias string) (err error) {
-	ms.dict[RP_ALIAS_PREFIX+key] = []byte(alias)
+	err = ms.set(RP_ALIAS_PREFIX+key, []byte(alias))
 	//cache2go.Cache(ALIAS_PREFIX+key, alias)
 	return
 }
 
 func (ms *MapStorage) RemoveRpAliases(tenantRtSubjects []*TenantRatingSubject) (err error) {
-	for key, _ := range ms.dict {
+	keys, _ := ms.GetKeysForPrefix(RP_ALIAS_PREFIX)
+	for _, key := range keys {
+		alsSubj, err := ms.GetRpAlias(key[len(RP_ALIAS_PREFIX):], true)
+		if err != nil {
+			return err
+		}
 		for _, tntRtSubj := range tenantRtSubjects {
 			tenantPrfx := RP_ALIAS_PREFIX + tntRtSubj.Tenant + utils.CONCATENATED_KEY_SEP
-			if strings.HasPrefix(key, RP_ALIAS_PREFIX) {
-				alsSubj, err := ms.GetRpAlias(key[len(RP_ALIAS_PREFIX):], true)
-				if err != nil {
-					return err
-				}
-				if len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && tntRtSubj.Subject == alsSubj {
+			if len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && tntRtSubj.Subject == alsSubj {
+				if !ms.isolated {
 					cache2go.RemKey(key)
-					delete(ms.dict, key)
 				}
+				if err = ms.del(key); err != nil {
+					return err
+				}
+				break
 			}
 		}
 	}
@@ -328,7 +435,7 @@ func (ms *MapStorage) RemoveRpAliases(tenantRtSubjects []*TenantRatingSubject) (
 func (ms *MapStorage) GetRPAliases(tenant, subject string, skipCache bool) (aliases []string, err error) {
 	tenantPrfx := RP_ALIAS_PREFIX + tenant + utils.CONCATENATED_KEY_SEP
 	var alsKeys []string
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		alsKeys = cache2go.GetEntriesKeys(tenantPrfx)
 	}
 	for _, key := range alsKeys {
@@ -340,6 +447,8 @@ func (ms *MapStorage) GetRPAliases(tenant, subject string, skipCache bool) (alia
 		}
 	}
 	if len(alsKeys) == 0 {
+		ms.mu.RLock()
+		defer ms.mu.RUnlock()
 		for key, value := range ms.dict {
 			if strings.HasPrefix(key, RP_ALIAS_PREFIX) && len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && subject == string(value) {
 				aliases = append(aliases, key[len(tenantPrfx):])
@@ -351,16 +460,18 @@ func (ms *MapStorage) GetRPAliases(tenant, subject string, skipCache bool) (alia
 
 func (ms *MapStorage) GetAccAlias(key string, skipCache bool) (alias string, err error) {
 	key = ACC_ALIAS_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(string), nil
 		} else {
 			return "", err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		alias = string(values)
-		cache2go.Cache(key, alias)
+		if !ms.isolated {
+			cache2go.Cache(key, alias)
+		}
 	} else {
 		return "", errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -368,17 +479,25 @@ func (ms *MapStorage) GetAccAlias(key string, skipCache bool) (alias string, err
 }
 
 func (ms *MapStorage) SetAccAlias(key, alias string) (err error) {
-	ms.dict[ACC_ALIAS_PREFIX+key] = []byte(alias)
+	err = ms.set(ACC_ALIAS_PREFIX+key, []byte(alias))
 	//cache2go.Cache(ALIAS_PREFIX+key, alias)
 	return
 }
 
 func (ms *MapStorage) RemoveAccAliases(tenantAccounts []*TenantAccount) (err error) {
-	for key, value := range ms.dict {
+	keys, _ := ms.GetKeysForPrefix(ACC_ALIAS_PREFIX)
+	for _, key := range keys {
+		value, ok := ms.get(key)
+		if !ok {
+			continue
+		}
 		for _, tntAcnt := range tenantAccounts {
 			tenantPrfx := ACC_ALIAS_PREFIX + tntAcnt.Tenant + utils.CONCATENATED_KEY_SEP
-			if strings.HasPrefix(key, ACC_ALIAS_PREFIX) && len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && tntAcnt.Account == string(value) {
-				delete(ms.dict, key)
+			if len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && tntAcnt.Account == string(value) {
+				if err = ms.del(key); err != nil {
+					return
+				}
+				break
 			}
 		}
 	}
@@ -386,6 +505,8 @@ func (ms *MapStorage) RemoveAccAliases(tenantAccounts []*TenantAccount) (err err
 }
 
 func (ms *MapStorage) GetAccountAliases(tenant, account string, skipCache bool) (aliases []string, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	for key, value := range ms.dict {
 		tenantPrfx := ACC_ALIAS_PREFIX + tenant + utils.CONCATENATED_KEY_SEP
 		if strings.HasPrefix(key, ACC_ALIAS_PREFIX) && len(key) >= len(tenantPrfx) && key[:len(tenantPrfx)] == tenantPrfx && account == string(value) {
@@ -397,7 +518,7 @@ func (ms *MapStorage) GetAccountAliases(tenant, account string, skipCache bool)
 
 func (ms *MapStorage) GetDestination(key string) (dest *Destination, err error) {
 	key = DESTINATION_PREFIX + key
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		b := bytes.NewBuffer(values)
 		r, err := zlib.NewReader(b)
 		if err != nil {
@@ -411,8 +532,8 @@ func (ms *MapStorage) GetDestination(key string) (dest *Destination, err error)
 		dest = new(Destination)
 		err = ms.ms.Unmarshal(out, dest)
 		// create optimized structure
-		for _, p := range dest.Prefixes {
-			cache2go.CachePush(DESTINATION_PREFIX+p, dest.Id)
+		if !ms.isolated {
+			indexDestination(dest)
 		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
@@ -422,11 +543,14 @@ func (ms *MapStorage) GetDestination(key string) (dest *Destination, err error)
 
 func (ms *MapStorage) SetDestination(dest *Destination) (err error) {
 	result, err := ms.ms.Marshal(dest)
+	if err != nil {
+		return err
+	}
 	var b bytes.Buffer
 	w := zlib.NewWriter(&b)
 	w.Write(result)
 	w.Close()
-	ms.dict[DESTINATION_PREFIX+dest.Id] = b.Bytes()
+	err = ms.set(DESTINATION_PREFIX+dest.Id, b.Bytes())
 	response := 0
 	if historyScribe != nil {
 		go historyScribe.Record(dest.GetHistoryRecord(), &response)
@@ -437,16 +561,18 @@ func (ms *MapStorage) SetDestination(dest *Destination) (err error) {
 
 func (ms *MapStorage) GetActions(key string, skipCache bool) (as Actions, err error) {
 	key = ACTION_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(Actions), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		err = ms.ms.Unmarshal(values, &as)
-		cache2go.Cache(key, as)
+		if !ms.isolated {
+			cache2go.Cache(key, as)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -455,23 +581,28 @@ func (ms *MapStorage) GetActions(key string, skipCache bool) (as Actions, err er
 
 func (ms *MapStorage) SetActions(key string, as Actions) (err error) {
 	result, err := ms.ms.Marshal(&as)
-	ms.dict[ACTION_PREFIX+key] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(ACTION_PREFIX+key, result)
 	//cache2go.Cache(ACTION_PREFIX+key, as)
 	return
 }
 
 func (ms *MapStorage) GetSharedGroup(key string, skipCache bool) (sg *SharedGroup, err error) {
 	key = SHARED_GROUP_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(*SharedGroup), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		err = ms.ms.Unmarshal(values, &sg)
-		cache2go.Cache(key, sg)
+		if !ms.isolated {
+			cache2go.Cache(key, sg)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -480,13 +611,16 @@ func (ms *MapStorage) GetSharedGroup(key string, skipCache bool) (sg *SharedGrou
 
 func (ms *MapStorage) SetSharedGroup(sg *SharedGroup) (err error) {
 	result, err := ms.ms.Marshal(sg)
-	ms.dict[SHARED_GROUP_PREFIX+sg.Id] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(SHARED_GROUP_PREFIX+sg.Id, result)
 	//cache2go.Cache(SHARED_GROUP_PREFIX+key, sg)
 	return
 }
 
 func (ms *MapStorage) GetAccount(key string) (ub *Account, err error) {
-	if values, ok := ms.dict[ACCOUNT_PREFIX+key]; ok {
+	if values, ok := ms.get(ACCOUNT_PREFIX + key); ok {
 		ub = &Account{Id: key}
 		err = ms.ms.Unmarshal(values, ub)
 	} else {
@@ -499,22 +633,32 @@ func (ms *MapStorage) SetAccount(ub *Account) (err error) {
 	// never override existing account with an empty one
 	// UPDATE: if all balances expired and were clean it makes
 	// sense to write empty balance map
+	acc := ub // the ledger queued by the caller is written once the account is saved
 	if len(ub.BalanceMap) == 0 {
 		if ac, err := ms.GetAccount(ub.Id); err == nil && !ac.allBalancesExpired() {
 			ac.ActionTriggers = ub.ActionTriggers
 			ac.UnitCounters = ub.UnitCounters
 			ac.AllowNegative = ub.AllowNegative
+			ac.CreditLimit = ub.CreditLimit
+			ac.BillingCycle = ub.BillingCycle
+			ac.ActivationDate = ub.ActivationDate
+			ac.CancellationDate = ub.CancellationDate
 			ac.Disabled = ub.Disabled
 			ub = ac
 		}
 	}
 	result, err := ms.ms.Marshal(ub)
-	ms.dict[ACCOUNT_PREFIX+ub.Id] = result
+	if err != nil {
+		return err
+	}
+	if err = ms.set(ACCOUNT_PREFIX+ub.Id, result); err == nil {
+		acc.writeLedger()
+	}
 	return
 }
 
 func (ms *MapStorage) GetActionTimings(key string) (ats ActionPlan, err error) {
-	if values, ok := ms.dict[ACTION_TIMING_PREFIX+key]; ok {
+	if values, ok := ms.get(ACTION_TIMING_PREFIX + key); ok {
 		err = ms.ms.Unmarshal(values, &ats)
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
@@ -525,16 +669,21 @@ func (ms *MapStorage) GetActionTimings(key string) (ats ActionPlan, err error) {
 func (ms *MapStorage) SetActionTimings(key string, ats ActionPlan) (err error) {
 	if len(ats) == 0 {
 		// delete the key
-		delete(ms.dict, ACTION_TIMING_PREFIX+key)
+		err = ms.del(ACTION_TIMING_PREFIX + key)
 		return
 	}
 	result, err := ms.ms.Marshal(&ats)
-	ms.dict[ACTION_TIMING_PREFIX+key] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(ACTION_TIMING_PREFIX+key, result)
 	return
 }
 
 func (ms *MapStorage) GetAllActionTimings() (ats map[string]ActionPlan, err error) {
 	ats = make(map[string]ActionPlan)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	for key, value := range ms.dict {
 		if !strings.HasPrefix(key, ACTION_TIMING_PREFIX) {
 			continue
@@ -549,16 +698,18 @@ func (ms *MapStorage) GetAllActionTimings() (ats map[string]ActionPlan, err erro
 
 func (ms *MapStorage) GetDerivedChargers(key string, skipCache bool) (dcs utils.DerivedChargers, err error) {
 	key = DERIVEDCHARGERS_PREFIX + key
-	if !skipCache {
+	if !skipCache && !ms.isolated {
 		if x, err := cache2go.GetCached(key); err == nil {
 			return x.(utils.DerivedChargers), nil
 		} else {
 			return nil, err
 		}
 	}
-	if values, ok := ms.dict[key]; ok {
+	if values, ok := ms.get(key); ok {
 		err = ms.ms.Unmarshal(values, &dcs)
-		cache2go.Cache(key, dcs)
+		if !ms.isolated {
+			cache2go.Cache(key, dcs)
+		}
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
 	}
@@ -567,18 +718,24 @@ func (ms *MapStorage) GetDerivedChargers(key string, skipCache bool) (dcs utils.
 
 func (ms *MapStorage) SetDerivedChargers(key string, dcs utils.DerivedChargers) error {
 	result, err := ms.ms.Marshal(dcs)
-	ms.dict[DERIVEDCHARGERS_PREFIX+key] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(DERIVEDCHARGERS_PREFIX+key, result)
 	return err
 }
 
 func (ms *MapStorage) SetCdrStats(cs *CdrStats) error {
 	result, err := ms.ms.Marshal(cs)
-	ms.dict[CDR_STATS_PREFIX+cs.Id] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(CDR_STATS_PREFIX+cs.Id, result)
 	return err
 }
 
 func (ms *MapStorage) GetCdrStats(key string) (cs *CdrStats, err error) {
-	if values, ok := ms.dict[CDR_STATS_PREFIX+key]; ok {
+	if values, ok := ms.get(CDR_STATS_PREFIX + key); ok {
 		err = ms.ms.Unmarshal(values, &cs)
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
@@ -587,6 +744,8 @@ func (ms *MapStorage) GetCdrStats(key string) (cs *CdrStats, err error) {
 }
 
 func (ms *MapStorage) GetAllCdrStats() (css []*CdrStats, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	for key, value := range ms.dict {
 		if !strings.HasPrefix(key, CDR_STATS_PREFIX) {
 			continue
@@ -598,14 +757,92 @@ func (ms *MapStorage) GetAllCdrStats() (css []*CdrStats, err error) {
 	return
 }
 
+func (ms *MapStorage) GetExchangeRate(key string, skipCache bool) (er *ExchangeRate, err error) {
+	key = EXCHANGE_RATE_PREFIX + key
+	if !skipCache && !ms.isolated {
+		if x, err := cache2go.GetCached(key); err == nil {
+			return x.(*ExchangeRate), nil
+		} else {
+			return nil, err
+		}
+	}
+	if values, ok := ms.get(key); ok {
+		er = new(ExchangeRate)
+		err = ms.ms.Unmarshal(values, er)
+		if !ms.isolated {
+			cache2go.Cache(key, er)
+		}
+	} else {
+		return nil, errors.New(utils.ERR_NOT_FOUND)
+	}
+	return
+}
+
+func (ms *MapStorage) SetExchangeRate(er *ExchangeRate) error {
+	result, err := ms.ms.Marshal(er)
+	if err != nil {
+		return err
+	}
+	return ms.set(EXCHANGE_RATE_PREFIX+er.Id, result)
+}
+
+func (ms *MapStorage) GetTaxRules(key string, skipCache bool) (trs *TaxRules, err error) {
+	key = TAX_RULES_PREFIX + key
+	if !skipCache && !ms.isolated {
+		if x, err := cache2go.GetCached(key); err == nil {
+			return x.(*TaxRules), nil
+		} else {
+			return nil, err
+		}
+	}
+	if values, ok := ms.get(key); ok {
+		trs = new(TaxRules)
+		err = ms.ms.Unmarshal(values, trs)
+		if !ms.isolated {
+			cache2go.Cache(key, trs)
+		}
+	} else {
+		return nil, errors.New(utils.ERR_NOT_FOUND)
+	}
+	return
+}
+
+func (ms *MapStorage) SetTaxRules(trs *TaxRules) error {
+	result, err := ms.ms.Marshal(trs)
+	if err != nil {
+		return err
+	}
+	return ms.set(TAX_RULES_PREFIX+trs.Id, result)
+}
+
+func (ms *MapStorage) GetPortedNumber(key string) (pn *PortedNumber, err error) {
+	if values, ok := ms.get(PORTED_NUMBER_PREFIX + key); ok {
+		pn = &PortedNumber{Id: key, RoutingPrefix: string(values)}
+	} else {
+		return nil, errors.New(utils.ERR_NOT_FOUND)
+	}
+	return
+}
+
+func (ms *MapStorage) SetPortedNumber(pn *PortedNumber) error {
+	return ms.set(PORTED_NUMBER_PREFIX+pn.Id, []byte(pn.RoutingPrefix))
+}
+
+func (ms *MapStorage) RemovePortedNumber(key string) error {
+	return ms.del(PORTED_NUMBER_PREFIX + key)
+}
+
 func (ms *MapStorage) LogCallCost(cgrid, source, runid string, cc *CallCost) error {
 	result, err := ms.ms.Marshal(cc)
-	ms.dict[LOG_CALL_COST_PREFIX+source+runid+"_"+cgrid] = result
+	if err != nil {
+		return err
+	}
+	err = ms.set(LOG_CALL_COST_PREFIX+source+runid+"_"+cgrid, result)
 	return err
 }
 
 func (ms *MapStorage) GetCallCostLog(cgrid, source, runid string) (cc *CallCost, err error) {
-	if values, ok := ms.dict[LOG_CALL_COST_PREFIX+source+runid+"_"+cgrid]; ok {
+	if values, ok := ms.get(LOG_CALL_COST_PREFIX + source + runid + "_" + cgrid); ok {
 		err = ms.ms.Unmarshal(values, &cc)
 	} else {
 		return nil, errors.New(utils.ERR_NOT_FOUND)
@@ -622,7 +859,7 @@ func (ms *MapStorage) LogActionTrigger(ubId, source string, at *ActionTrigger, a
 	if err != nil {
 		return
 	}
-	ms.dict[LOG_ACTION_TRIGGER_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano)] = []byte(fmt.Sprintf("%s*%s*%s", ubId, string(mat), string(mas)))
+	err = ms.set(LOG_ACTION_TRIGGER_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%s*%s*%s", ubId, string(mat), string(mas))))
 	return
 }
 
@@ -635,11 +872,11 @@ func (ms *MapStorage) LogActionTiming(source string, at *ActionTiming, as Action
 	if err != nil {
 		return
 	}
-	ms.dict[LOG_ACTION_TIMMING_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano)] = []byte(fmt.Sprintf("%s*%s", string(mat), string(mas)))
+	err = ms.set(LOG_ACTION_TIMMING_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%s*%s", string(mat), string(mas))))
 	return
 }
 
 func (ms *MapStorage) LogError(uuid, source, runid, errstr string) (err error) {
-	ms.dict[LOG_ERR+source+runid+"_"+uuid] = []byte(errstr)
-	return nil
+	err = ms.set(LOG_ERR+source+runid+"_"+uuid, []byte(errstr))
+	return
 }
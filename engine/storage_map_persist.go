@@ -0,0 +1,202 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+const (
+	MAP_SNAPSHOT_FILE     = "snapshot.db"
+	MAP_LOG_FILE          = "mutations.log"
+	MAP_SNAPSHOT_INTERVAL = 5 * time.Minute
+	MAP_OP_SET            = "set"
+	MAP_OP_DEL            = "del"
+)
+
+// One entry in the append-only mutation log
+type mapLogRecord struct {
+	Op    string
+	Key   string
+	Value []byte
+}
+
+// Opens a MapStorage persisted inside dataDir. The content is restored out of the last snapshot
+// and the mutations logged after it, a new snapshot is written every snapshotInterval and on Close.
+// Each mutation is synced to the log before the storage call returns, so it survives a crash of the machine too.
+func NewMapStoragePersistent(dataDir string, snapshotInterval time.Duration, mrshlerStr string) (*MapStorage, error) {
+	var mrshler Marshaler
+	if mrshlerStr == utils.MSGPACK {
+		mrshler = NewCodecMsgpackMarshaler()
+	} else if mrshlerStr == utils.JSON {
+		mrshler = new(JSONMarshaler)
+	} else {
+		return nil, fmt.Errorf("Unsupported marshaler: %v", mrshlerStr)
+	}
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		return nil, err
+	}
+	ms := &MapStorage{dict: make(map[string][]byte), ms: mrshler, dataDir: dataDir}
+	if err := ms.restore(); err != nil {
+		return nil, err
+	}
+	// Compact the replayed log so we start clean, this also drops a partially written tail record
+	if err := ms.snapshot(); err != nil {
+		ms.aof.Close()
+		return nil, err
+	}
+	if snapshotInterval > 0 {
+		ms.stopSnapshots = make(chan struct{})
+		go ms.snapshotLoop(snapshotInterval)
+	}
+	return ms, nil
+}
+
+// Loads the snapshot and replays the mutations log on top of it
+func (ms *MapStorage) restore() error {
+	if content, err := ioutil.ReadFile(path.Join(ms.dataDir, MAP_SNAPSHOT_FILE)); err == nil {
+		dict := make(map[string][]byte)
+		if err := ms.ms.Unmarshal(content, &dict); err != nil {
+			return fmt.Errorf("cannot load snapshot: %v", err)
+		}
+		ms.dict = dict
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	aof, err := os.OpenFile(path.Join(ms.dataDir, MAP_LOG_FILE), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	ms.aof = aof
+	r := bufio.NewReader(aof)
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err == io.EOF {
+			break
+		} else if err != nil {
+			Logger.Warning(fmt.Sprintf("<MapStorage> Ignoring truncated record at the end of the mutations log: %v", err))
+			break
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			Logger.Warning(fmt.Sprintf("<MapStorage> Ignoring truncated record at the end of the mutations log: %v", err))
+			break
+		}
+		var rec mapLogRecord
+		if err := ms.ms.Unmarshal(buf, &rec); err != nil {
+			Logger.Warning(fmt.Sprintf("<MapStorage> Ignoring corrupted record at the end of the mutations log: %v", err))
+			break
+		}
+		switch rec.Op {
+		case MAP_OP_SET:
+			ms.dict[rec.Key] = rec.Value
+		case MAP_OP_DEL:
+			delete(ms.dict, rec.Key)
+		}
+	}
+	return nil
+}
+
+// Appends a mutation to the log, noop if the storage is not persistent. Caller must hold the lock.
+func (ms *MapStorage) logMutation(op, key string, value []byte) error {
+	if ms.aof == nil {
+		return nil
+	}
+	result, err := ms.ms.Marshal(&mapLogRecord{Op: op, Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4, 4+len(result))
+	binary.BigEndian.PutUint32(buf, uint32(len(result)))
+	// Single write so the record does not get interleaved with others
+	if _, err := ms.aof.Write(append(buf, result...)); err != nil {
+		Logger.Err(fmt.Sprintf("<MapStorage> Cannot write to the mutations log: %v", err))
+		return err
+	}
+	if err := ms.aof.Sync(); err != nil {
+		Logger.Err(fmt.Sprintf("<MapStorage> Cannot sync the mutations log: %v", err))
+		return err
+	}
+	return nil
+}
+
+// Writes the whole dictionary into the snapshot file and empties the mutations log. Caller must hold the lock.
+func (ms *MapStorage) snapshot() error {
+	if ms.aof == nil {
+		return nil
+	}
+	result, err := ms.ms.Marshal(ms.dict)
+	if err != nil {
+		return err
+	}
+	snapshotPath := path.Join(ms.dataDir, MAP_SNAPSHOT_FILE)
+	tmpPath := snapshotPath + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(result); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Rename is atomic, we either keep the old snapshot or get the new one
+	if err := os.Rename(tmpPath, snapshotPath); err != nil {
+		return err
+	}
+	// Replaying the old log over the new snapshot would be harmless, so a crash in between is safe
+	return ms.aof.Truncate(0)
+}
+
+// Forces a snapshot of the persistent storage
+func (ms *MapStorage) Snapshot() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.snapshot()
+}
+
+func (ms *MapStorage) snapshotLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ms.stopSnapshots:
+			return
+		case <-ticker.C:
+			if err := ms.Snapshot(); err != nil {
+				Logger.Err(fmt.Sprintf("<MapStorage> Could not write snapshot: %v", err))
+			}
+		}
+	}
+}
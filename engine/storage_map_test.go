@@ -0,0 +1,150 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func testMapStoragePersistent(t *testing.T, marshaler string) {
+	dataDir, err := ioutil.TempDir("", "cgr_internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+	ms, err := NewMapStoragePersistent(dataDir, 0, marshaler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acnt := &Account{Id: "*out:cgrates.org:1001", BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 10}}}}
+	if err := ms.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.SetActionTimings("MORE_MINUTES", ActionPlan{&ActionTiming{Id: "some_uuid", ActionsId: "MINI"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Snapshot in the middle, the rest of the changes will be replayed out of the mutations log
+	if err := ms.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	acnt.BalanceMap[utils.MONETARY+OUTBOUND][0].Value = 5
+	if err := ms.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.SetActionTimings("MORE_MINUTES", nil); err != nil { // removes the key
+		t.Fatal(err)
+	}
+	ms.aof.Close() // simulate a crash, no final snapshot
+	if ms, err = NewMapStoragePersistent(dataDir, 0, marshaler); err != nil {
+		t.Fatal(err)
+	}
+	if rcv, err := ms.GetAccount(acnt.Id); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 5 {
+		t.Errorf("Unexpected account restored: %+v", rcv)
+	}
+	if _, err := ms.GetActionTimings("MORE_MINUTES"); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Removed action timings restored: ", err)
+	}
+	ms.Close()
+	if _, err := os.Stat(path.Join(dataDir, MAP_SNAPSHOT_FILE)); err != nil {
+		t.Error("No snapshot written on close: ", err)
+	}
+}
+
+func TestMapStoragePersistentMsgpack(t *testing.T) {
+	testMapStoragePersistent(t, utils.MSGPACK)
+}
+
+func TestMapStoragePersistentJson(t *testing.T) {
+	testMapStoragePersistent(t, utils.JSON)
+}
+
+func TestMapStoragePersistentTruncatedLog(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "cgr_internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+	ms, err := NewMapStoragePersistent(dataDir, 0, utils.MSGPACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.SetRpAlias("cgrates.org:1001", "1002"); err != nil {
+		t.Fatal(err)
+	}
+	// Half written record at the end of the log
+	if _, err := ms.aof.Write([]byte{0, 0, 1, 0, 'x'}); err != nil {
+		t.Fatal(err)
+	}
+	ms.aof.Close()
+	if ms, err = NewMapStoragePersistent(dataDir, 0, utils.MSGPACK); err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+	if alias, err := ms.GetRpAlias("cgrates.org:1001", true); err != nil {
+		t.Error(err)
+	} else if alias != "1002" {
+		t.Error("Unexpected alias restored: ", alias)
+	}
+}
+
+func TestMapStorageInternalShared(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "cgr_internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+	ratingMs, err := internalMapStorage(dataDir, utils.MSGPACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountingMs, err := internalMapStorage(dataDir, utils.MSGPACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratingMs != accountingMs {
+		t.Fatal("Storage not shared inside the same folder")
+	}
+	ratingMs.Close()
+	// still open for the accounting
+	if err := accountingMs.SetRpAlias("cgrates.org:1001", "1002"); err != nil || accountingMs.aof == nil {
+		t.Fatal("Storage closed while in use: ", err)
+	}
+	accountingMs.Close()
+	if accountingMs.aof != nil {
+		t.Error("Storage not closed by the last user")
+	}
+	ms, err := internalMapStorage(dataDir, utils.MSGPACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.Close()
+	if ms == accountingMs {
+		t.Error("Closed storage handed back")
+	}
+	if alias, err := ms.GetRpAlias("cgrates.org:1001", true); err != nil || alias != "1002" {
+		t.Errorf("Unexpected alias restored: %s, %v", alias, err)
+	}
+}
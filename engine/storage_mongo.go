@@ -19,224 +19,1014 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>
 package engine
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"regexp"
 	"time"
 
+	"github.com/cgrates/cgrates/cache2go"
+	"github.com/cgrates/cgrates/utils"
 	"gopkg.in/mgo.v2"
-
 	"gopkg.in/mgo.v2/bson"
 )
 
+const (
+	colDst = "destinations"
+	colAct = "actions"
+	colApl = "actionplans"
+	colRpl = "ratingplans"
+	colRpf = "ratingprofiles"
+	colRpa = "rpaliases"
+	colAcc = "accounts"
+	colAca = "accaliases"
+	colShg = "sharedgroups"
+	colLcr = "lcrrules"
+	colDcs = "derivedchargers"
+	colCrs = "cdrstats"
+	colExr = "exchangerates"
+	colTax = "taxrules"
+	colNpn = "portednumbers"
+	colLcc = "callcostlogs"
+	colLat = "actiontriggerlogs"
+	colLtm = "actiontiminglogs"
+	colLer = "errorlogs"
+	colVer = "versions"
+)
+
+// Collections where the document is the object itself, indexed on its "id" field
+var mgoIdCollections = []string{colDst, colRpl, colRpf, colAcc, colShg, colCrs, colExr, colTax, colNpn}
+
+// Collections where the object is wrapped into a key/value document, indexed on "key"
+var mgoKeyCollections = []string{colAct, colApl, colRpa, colAca, colLcr, colDcs}
+
+// Maps the key prefixes used in cache towards the collection holding the objects and the field indexing them
+var mgoPrefixCollections = map[string][]string{
+	DESTINATION_PREFIX:     []string{colDst, "id"},
+	RATING_PLAN_PREFIX:     []string{colRpl, "id"},
+	RATING_PROFILE_PREFIX:  []string{colRpf, "id"},
+	ACCOUNT_PREFIX:         []string{colAcc, "id"},
+	SHARED_GROUP_PREFIX:    []string{colShg, "id"},
+	CDR_STATS_PREFIX:       []string{colCrs, "id"},
+	EXCHANGE_RATE_PREFIX:   []string{colExr, "id"},
+	TAX_RULES_PREFIX:       []string{colTax, "id"},
+	PORTED_NUMBER_PREFIX:   []string{colNpn, "id"},
+	ACTION_PREFIX:          []string{colAct, "key"},
+	ACTION_TIMING_PREFIX:   []string{colApl, "key"},
+	RP_ALIAS_PREFIX:        []string{colRpa, "key"},
+	ACC_ALIAS_PREFIX:       []string{colAca, "key"},
+	LCR_PREFIX:             []string{colLcr, "key"},
+	DERIVEDCHARGERS_PREFIX: []string{colDcs, "key"},
+}
+
+type StrKeyValue struct {
+	Key   string
+	Value string
+}
+
+type AcKeyValue struct {
+	Key   string
+	Value Actions
+}
+
+type AtKeyValue struct {
+	Key   string
+	Value ActionPlan
+}
+
+type LcrKeyValue struct {
+	Key   string
+	Value *LCR
+}
+
+type DcsKeyValue struct {
+	Key   string
+	Value utils.DerivedChargers
+}
+
 type MongoStorage struct {
 	session *mgo.Session
-	db      *mgo.Database
+	db      string
 }
 
-func NewMongoStorage(host, port, db, user, pass string) (Storage, error) {
-	dial := fmt.Sprintf(host)
-	if user != "" && pass != "" {
-		dial = fmt.Sprintf("%s:%s@%s", user, pass, dial)
-	}
+func NewMongoStorage(host, port, db, user, pass string) (*MongoStorage, error) {
+	address := host
 	if port != "" {
-		dial += ":" + port
+		address += ":" + port
 	}
-	session, err := mgo.Dial(dial)
+	if user != "" && pass != "" {
+		address = fmt.Sprintf("%s:%s@%s", user, pass, address)
+	}
+	session, err := mgo.Dial(fmt.Sprintf("mongodb://%s/%s", address, db))
 	if err != nil {
-		log.Printf(fmt.Sprintf("Could not connect to logger database: %v", err))
 		return nil, err
 	}
+	session.SetMode(mgo.Strong, true)
 	ndb := session.DB(db)
-	session.SetMode(mgo.Monotonic, true)
-	index := mgo.Index{Key: []string{"key"}, Background: true}
-	err = ndb.C("actions").EnsureIndex(index)
-	err = ndb.C("actiontimings").EnsureIndex(index)
-	index = mgo.Index{Key: []string{"id"}, Background: true}
-	err = ndb.C("ratingprofiles").EnsureIndex(index)
-	err = ndb.C("ratingplans").EnsureIndex(index)
-	err = ndb.C("destinations").EnsureIndex(index)
-	err = ndb.C("userbalances").EnsureIndex(index)
+	for _, col := range mgoIdCollections {
+		if err = ndb.C(col).EnsureIndex(mgo.Index{Key: []string{"id"}, Unique: true}); err != nil {
+			return nil, err
+		}
+	}
+	for _, col := range mgoKeyCollections {
+		if err = ndb.C(col).EnsureIndex(mgo.Index{Key: []string{"key"}, Unique: true}); err != nil {
+			return nil, err
+		}
+	}
+	// Indexes used when acting as StorDb
+	for col, keys := range map[string][]string{colCdr: []string{"cgrid", "runid"}, colLcc: []string{"cgrid", "runid"}} {
+		if err = ndb.C(col).EnsureIndex(mgo.Index{Key: keys}); err != nil {
+			return nil, err
+		}
+	}
+	if err = ndb.C(colCdr).EnsureIndexKey("orderid"); err != nil {
+		return nil, err
+	}
+	return &MongoStorage{db: db, session: session}, nil
+}
 
-	return &MongoStorage{db: ndb, session: session}, nil
+// Returns the collection on a copy of the master session, caller needs to close the session once done
+func (ms *MongoStorage) conn(col string) (*mgo.Session, *mgo.Collection) {
+	sessionCopy := ms.session.Copy()
+	return sessionCopy, sessionCopy.DB(ms.db).C(col)
+}
+
+// Converts mgo specific errors into the ones expected by the engine
+func mgoError(err error) error {
+	if err == mgo.ErrNotFound {
+		return errors.New(utils.ERR_NOT_FOUND)
+	}
+	return err
 }
 
 func (ms *MongoStorage) Close() {
 	ms.session.Close()
 }
 
+func (ms *MongoStorage) Flush(ignore string) (err error) {
+	session := ms.session.Copy()
+	defer session.Close()
+	return session.DB(ms.db).DropDatabase()
+}
+
+type VersionEntry struct {
+	Item    string
+	Version int64
+}
+
+func (ms *MongoStorage) GetVersion(item string) (int64, error) {
+	session, col := ms.conn(colVer)
+	defer session.Close()
+	var ver VersionEntry
+	if err := col.Find(bson.M{"item": item}).One(&ver); err != nil {
+		return 0, mgoError(err)
+	}
+	return ver.Version, nil
+}
+
+func (ms *MongoStorage) SetVersion(item string, version int64) (err error) {
+	session, col := ms.conn(colVer)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"item": item}, &VersionEntry{Item: item, Version: version})
+	return
+}
+
 func (ms *MongoStorage) GetKeysForPrefix(prefix string) ([]string, error) {
-	return nil, nil
+	if len(prefix) < len(DESTINATION_PREFIX) {
+		return nil, fmt.Errorf("unsupported prefix in GetKeysForPrefix: %s", prefix)
+	}
+	colFld, hasCol := mgoPrefixCollections[prefix[:len(DESTINATION_PREFIX)]]
+	if !hasCol {
+		return nil, fmt.Errorf("unsupported prefix in GetKeysForPrefix: %s", prefix)
+	}
+	keyField := colFld[1]
+	qry := bson.M{}
+	if subKey := prefix[len(DESTINATION_PREFIX):]; len(subKey) != 0 {
+		qry[keyField] = bson.M{"$regex": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(subKey)}}
+	}
+	session, col := ms.conn(colFld[0])
+	defer session.Close()
+	var result []bson.M
+	if err := col.Find(qry).Select(bson.M{keyField: 1}).All(&result); err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(result))
+	for i, doc := range result {
+		keys[i] = prefix[:len(DESTINATION_PREFIX)] + doc[keyField].(string)
+	}
+	return keys, nil
 }
 
-func (ms *MongoStorage) Flush(ignore string) (err error) {
-	err = ms.db.C("ratingprofiles").DropCollection()
-	if err != nil {
-		return
+func (ms *MongoStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []string) (err error) {
+	cache2go.BeginTransaction()
+	beginDestinationIndexing()
+	defer func() { endDestinationIndexing(err == nil) }()
+	if dKeys == nil || (float64(CountCachedDestPrefixes())*DESTINATIONS_LOAD_THRESHOLD < float64(len(dKeys))) {
+		// if need to load more than a half of exiting keys load them all
+		Logger.Info("Caching all destinations")
+		if dKeys, err = ms.GetKeysForPrefix(DESTINATION_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		resetDestinationIndex()
+	} else if len(dKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching destinations: %v", dKeys))
+		CleanStalePrefixes(dKeys)
 	}
-	err = ms.db.C("ratingplans").DropCollection()
-	if err != nil {
-		return
+	for _, key := range dKeys {
+		if len(key) <= len(DESTINATION_PREFIX) {
+			Logger.Warning(fmt.Sprintf("Got malformed destination id: %s", key))
+			continue
+		}
+		if _, err = ms.GetDestination(key[len(DESTINATION_PREFIX):]); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
 	}
-	err = ms.db.C("destinations").DropCollection()
-	if err != nil {
-		return
+	if len(dKeys) != 0 {
+		Logger.Info("Finished destinations caching.")
 	}
-	err = ms.db.C("actions").DropCollection()
-	if err != nil {
-		return
+	if rpKeys == nil {
+		Logger.Info("Caching all rating plans")
+		if rpKeys, err = ms.GetKeysForPrefix(RATING_PLAN_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(RATING_PLAN_PREFIX)
+	} else if len(rpKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching rating plans: %v", rpKeys))
+	}
+	for _, key := range rpKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetRatingPlan(key[len(RATING_PLAN_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(rpKeys) != 0 {
+		Logger.Info("Finished rating plans caching.")
 	}
-	err = ms.db.C("userbalances").DropCollection()
+	if rpfKeys == nil {
+		Logger.Info("Caching all rating profiles")
+		if rpfKeys, err = ms.GetKeysForPrefix(RATING_PROFILE_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(RATING_PROFILE_PREFIX)
+	} else if len(rpfKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching rating profile: %v", rpfKeys))
+	}
+	for _, key := range rpfKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetRatingProfile(key[len(RATING_PROFILE_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(rpfKeys) != 0 {
+		Logger.Info("Finished rating profile caching.")
+	}
+	if lcrKeys == nil {
+		Logger.Info("Caching LCR rules.")
+		if lcrKeys, err = ms.GetKeysForPrefix(LCR_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(LCR_PREFIX)
+	} else if len(lcrKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching LCR rules: %v", lcrKeys))
+	}
+	for _, key := range lcrKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetLCR(key[len(LCR_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(lcrKeys) != 0 {
+		Logger.Info("Finished LCR rules caching.")
+	}
+	if alsKeys == nil {
+		Logger.Info("Caching all rating subject aliases.")
+		if alsKeys, err = ms.GetKeysForPrefix(RP_ALIAS_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(RP_ALIAS_PREFIX)
+	} else if len(alsKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching rating subject aliases: %v", alsKeys))
+	}
+	for _, key := range alsKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetRpAlias(key[len(RP_ALIAS_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(alsKeys) != 0 {
+		Logger.Info("Finished rating profile aliases caching.")
+	}
+	// few of them, always reloaded in full
+	Logger.Info("Caching all exchange rates and tax rules.")
+	erKeys, err := ms.GetKeysForPrefix(EXCHANGE_RATE_PREFIX)
 	if err != nil {
-		return
+		cache2go.RollbackTransaction()
+		return err
+	}
+	cache2go.RemPrefixKey(EXCHANGE_RATE_PREFIX)
+	for _, key := range erKeys {
+		if _, err = ms.GetExchangeRate(key[len(EXCHANGE_RATE_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
 	}
-	err = ms.db.C("actiontimings").DropCollection()
+	trKeys, err := ms.GetKeysForPrefix(TAX_RULES_PREFIX)
 	if err != nil {
-		return
+		cache2go.RollbackTransaction()
+		return err
 	}
+	cache2go.RemPrefixKey(TAX_RULES_PREFIX)
+	for _, key := range trKeys {
+		if _, err = ms.GetTaxRules(key[len(TAX_RULES_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	Logger.Info("Finished exchange rates and tax rules caching.")
+	cache2go.CommitTransaction()
 	return nil
 }
 
-type AcKeyValue struct {
-	Key   string
-	Value Actions
+func (ms *MongoStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []string) (err error) {
+	cache2go.BeginTransaction()
+	if actKeys == nil {
+		cache2go.RemPrefixKey(ACTION_PREFIX)
+	}
+	if actKeys == nil {
+		Logger.Info("Caching all actions")
+		if actKeys, err = ms.GetKeysForPrefix(ACTION_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	} else if len(actKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching actions: %v", actKeys))
+	}
+	for _, key := range actKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetActions(key[len(ACTION_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(actKeys) != 0 {
+		Logger.Info("Finished actions caching.")
+	}
+	if shgKeys == nil {
+		cache2go.RemPrefixKey(SHARED_GROUP_PREFIX)
+	}
+	if shgKeys == nil {
+		Logger.Info("Caching all shared groups")
+		if shgKeys, err = ms.GetKeysForPrefix(SHARED_GROUP_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	} else if len(shgKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching shared groups: %v", shgKeys))
+	}
+	for _, key := range shgKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetSharedGroup(key[len(SHARED_GROUP_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(shgKeys) != 0 {
+		Logger.Info("Finished shared groups caching.")
+	}
+	if alsKeys == nil {
+		Logger.Info("Caching all account aliases.")
+		if alsKeys, err = ms.GetKeysForPrefix(ACC_ALIAS_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(ACC_ALIAS_PREFIX)
+	} else if len(alsKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching account aliases: %v", alsKeys))
+	}
+	for _, key := range alsKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetAccAlias(key[len(ACC_ALIAS_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(alsKeys) != 0 {
+		Logger.Info("Finished account aliases caching.")
+	}
+	// DerivedChargers caching
+	if dcsKeys == nil {
+		Logger.Info("Caching all derived chargers")
+		if dcsKeys, err = ms.GetKeysForPrefix(DERIVEDCHARGERS_PREFIX); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+		cache2go.RemPrefixKey(DERIVEDCHARGERS_PREFIX)
+	} else if len(dcsKeys) != 0 {
+		Logger.Info(fmt.Sprintf("Caching derived chargers: %v", dcsKeys))
+	}
+	for _, key := range dcsKeys {
+		cache2go.RemKey(key)
+		if _, err = ms.GetDerivedChargers(key[len(DERIVEDCHARGERS_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	if len(dcsKeys) != 0 {
+		Logger.Info("Finished derived chargers caching.")
+	}
+	cache2go.CommitTransaction()
+	return nil
 }
 
-type AtKeyValue struct {
-	Key   string
-	Value ActionPlan
+// Used to check if specific subject is stored using prefix key attached to entity
+func (ms *MongoStorage) HasData(category, subject string) (bool, error) {
+	switch category {
+	case DESTINATION_PREFIX, RATING_PLAN_PREFIX, RATING_PROFILE_PREFIX, ACTION_PREFIX, ACTION_TIMING_PREFIX, ACCOUNT_PREFIX:
+		colFld := mgoPrefixCollections[category]
+		session, col := ms.conn(colFld[0])
+		defer session.Close()
+		count, err := col.Find(bson.M{colFld[1]: subject}).Count()
+		return count > 0, err
+	}
+	return false, errors.New("Unsupported category in HasData")
 }
 
-type LogCostEntry struct {
-	Id       string `bson:"_id,omitempty"`
-	CallCost *CallCost
-	Source   string
+func (ms *MongoStorage) GetRatingPlan(key string, skipCache bool) (rp *RatingPlan, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(RATING_PLAN_PREFIX + key); err == nil {
+			return x.(*RatingPlan), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colRpl)
+	defer session.Close()
+	rp = new(RatingPlan)
+	if err = col.Find(bson.M{"id": key}).One(rp); err != nil {
+		return nil, mgoError(err)
+	}
+	cache2go.Cache(RATING_PLAN_PREFIX+key, rp)
+	return
 }
 
-type LogTimingEntry struct {
-	ActionTiming *ActionTiming
-	Actions      Actions
-	LogTime      time.Time
-	Source       string
+func (ms *MongoStorage) SetRatingPlan(rp *RatingPlan) (err error) {
+	session, col := ms.conn(colRpl)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": rp.Id}, rp)
+	if err == nil && historyScribe != nil {
+		response := 0
+		go historyScribe.Record(rp.GetHistoryRecord(), &response)
+	}
+	return
 }
 
-type LogTriggerEntry struct {
-	ubId          string
-	ActionTrigger *ActionTrigger
-	Actions       Actions
-	LogTime       time.Time
-	Source        string
+func (ms *MongoStorage) GetRatingProfile(key string, skipCache bool) (rpf *RatingProfile, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(RATING_PROFILE_PREFIX + key); err == nil {
+			return x.(*RatingProfile), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colRpf)
+	defer session.Close()
+	rpf = new(RatingProfile)
+	if err = col.Find(bson.M{"id": key}).One(rpf); err != nil {
+		return nil, mgoError(err)
+	}
+	cache2go.Cache(RATING_PROFILE_PREFIX+key, rpf)
+	return
 }
 
-type LogErrEntry struct {
-	Id     string `bson:"_id,omitempty"`
-	ErrStr string
-	Source string
+func (ms *MongoStorage) SetRatingProfile(rpf *RatingProfile) (err error) {
+	session, col := ms.conn(colRpf)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": rpf.Id}, rpf)
+	if err == nil && historyScribe != nil {
+		response := 0
+		go historyScribe.Record(rpf.GetHistoryRecord(), &response)
+	}
+	return
 }
 
-func (ms *MongoStorage) GetRatingPlan(key string) (rp *RatingPlan, err error) {
-	rp = new(RatingPlan)
-	err = ms.db.C("ratingplans").Find(bson.M{"id": key}).One(&rp)
+func (ms *MongoStorage) GetRpAlias(key string, skipCache bool) (alias string, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(RP_ALIAS_PREFIX + key); err == nil {
+			return x.(string), nil
+		} else {
+			return "", err
+		}
+	}
+	session, col := ms.conn(colRpa)
+	defer session.Close()
+	var kv StrKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return "", mgoError(err)
+	}
+	alias = kv.Value
+	cache2go.Cache(RP_ALIAS_PREFIX+key, alias)
 	return
 }
 
-func (ms *MongoStorage) SetRatingPlan(rp *RatingPlan) error {
-	if historyScribe != nil {
-		response := 0
-		historyScribe.Record(rp.GetHistoryRecord(), &response)
+func (ms *MongoStorage) SetRpAlias(key, alias string) (err error) {
+	session, col := ms.conn(colRpa)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"key": key}, &StrKeyValue{Key: key, Value: alias})
+	return
+}
+
+// Removes the aliases of a specific account, on a tenant
+func (ms *MongoStorage) RemoveRpAliases(tenantRtSubjects []*TenantRatingSubject) (err error) {
+	session, col := ms.conn(colRpa)
+	defer session.Close()
+	for _, tntRSubj := range tenantRtSubjects {
+		tenantPrfx := tntRSubj.Tenant + utils.CONCATENATED_KEY_SEP
+		var kvs []StrKeyValue
+		if err = col.Find(bson.M{"key": bson.M{"$regex": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(tenantPrfx)}}, "value": tntRSubj.Subject}).All(&kvs); err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			cache2go.RemKey(RP_ALIAS_PREFIX + kv.Key)
+			if err = col.Remove(bson.M{"key": kv.Key}); err != nil {
+				return err
+			}
+		}
+	}
+	return
+}
+
+func (ms *MongoStorage) GetRPAliases(tenant, subject string, skipCache bool) (aliases []string, err error) {
+	tenantPrfx := RP_ALIAS_PREFIX + tenant + utils.CONCATENATED_KEY_SEP
+	var alsKeys []string
+	if !skipCache {
+		alsKeys = cache2go.GetEntriesKeys(tenantPrfx)
+	}
+	if len(alsKeys) == 0 {
+		if alsKeys, err = ms.GetKeysForPrefix(tenantPrfx); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range alsKeys {
+		if alsSubj, err := ms.GetRpAlias(key[len(RP_ALIAS_PREFIX):], skipCache); err != nil {
+			return nil, err
+		} else if alsSubj == subject {
+			alsFromKey := key[len(tenantPrfx):] // take out the alias out of key+tenant
+			aliases = append(aliases, alsFromKey)
+		}
+	}
+	return aliases, nil
+}
+
+func (ms *MongoStorage) GetLCR(key string, skipCache bool) (lcr *LCR, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(LCR_PREFIX + key); err == nil {
+			return x.(*LCR), nil
+		} else {
+			return nil, err
+		}
 	}
-	return ms.db.C("ratingplans").Insert(rp)
+	session, col := ms.conn(colLcr)
+	defer session.Close()
+	var kv LcrKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return nil, mgoError(err)
+	}
+	lcr = kv.Value
+	cache2go.Cache(LCR_PREFIX+key, lcr)
+	return
 }
 
-func (ms *MongoStorage) GetRatingProfile(key string) (rp *RatingProfile, err error) {
-	rp = new(RatingProfile)
-	err = ms.db.C("ratingprofiles").Find(bson.M{"id": key}).One(&rp)
+func (ms *MongoStorage) SetLCR(lcr *LCR) (err error) {
+	session, col := ms.conn(colLcr)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"key": lcr.GetId()}, &LcrKeyValue{Key: lcr.GetId(), Value: lcr})
+	cache2go.Cache(LCR_PREFIX+lcr.GetId(), lcr)
 	return
 }
 
-func (ms *MongoStorage) SetRatingProfile(rp *RatingProfile) error {
-	if historyScribe != nil {
-		response := 0
-		historyScribe.Record(rp.GetHistoryRecord(), &response)
+func (ms *MongoStorage) GetAccAlias(key string, skipCache bool) (alias string, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(ACC_ALIAS_PREFIX + key); err == nil {
+			return x.(string), nil
+		} else {
+			return "", err
+		}
+	}
+	session, col := ms.conn(colAca)
+	defer session.Close()
+	var kv StrKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return "", mgoError(err)
 	}
-	return ms.db.C("ratingprofiles").Insert(rp)
+	alias = kv.Value
+	cache2go.Cache(ACC_ALIAS_PREFIX+key, alias)
+	return
 }
 
-func (ms *MongoStorage) GetDestination(key string) (result *Destination, err error) {
-	result = new(Destination)
-	err = ms.db.C("destinations").Find(bson.M{"id": key}).One(result)
-	if err != nil {
-		result = nil
+// Adds one alias for one account
+func (ms *MongoStorage) SetAccAlias(key, alias string) (err error) {
+	session, col := ms.conn(colAca)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"key": key}, &StrKeyValue{Key: key, Value: alias})
+	return
+}
+
+func (ms *MongoStorage) RemoveAccAliases(tenantAccounts []*TenantAccount) (err error) {
+	session, col := ms.conn(colAca)
+	defer session.Close()
+	for _, tntAcnt := range tenantAccounts {
+		tenantPrfx := tntAcnt.Tenant + utils.CONCATENATED_KEY_SEP
+		var kvs []StrKeyValue
+		if err = col.Find(bson.M{"key": bson.M{"$regex": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(tenantPrfx)}}, "value": tntAcnt.Account}).All(&kvs); err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			cache2go.RemKey(ACC_ALIAS_PREFIX + kv.Key)
+			if err = col.Remove(bson.M{"key": kv.Key}); err != nil {
+				return err
+			}
+		}
 	}
 	return
 }
 
-func (ms *MongoStorage) SetDestination(dest *Destination) error {
-	if historyScribe != nil {
+// Returns the aliases of one specific account on a tenant
+func (ms *MongoStorage) GetAccountAliases(tenant, account string, skipCache bool) (aliases []string, err error) {
+	tenantPrfx := ACC_ALIAS_PREFIX + tenant + utils.CONCATENATED_KEY_SEP
+	var alsKeys []string
+	if !skipCache {
+		alsKeys = cache2go.GetEntriesKeys(tenantPrfx)
+	}
+	if len(alsKeys) == 0 {
+		if alsKeys, err = ms.GetKeysForPrefix(tenantPrfx); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range alsKeys {
+		if alsAcnt, err := ms.GetAccAlias(key[len(ACC_ALIAS_PREFIX):], skipCache); err != nil {
+			return nil, err
+		} else if alsAcnt == account {
+			alsFromKey := key[len(tenantPrfx):] // take out the alias out of key+tenant
+			aliases = append(aliases, alsFromKey)
+		}
+	}
+	return aliases, nil
+}
+
+func (ms *MongoStorage) GetDestination(key string) (dest *Destination, err error) {
+	session, col := ms.conn(colDst)
+	defer session.Close()
+	dest = new(Destination)
+	if err = col.Find(bson.M{"id": key}).One(dest); err != nil {
+		return nil, mgoError(err)
+	}
+	// create optimized structure
+	indexDestination(dest)
+	return
+}
+
+func (ms *MongoStorage) SetDestination(dest *Destination) (err error) {
+	session, col := ms.conn(colDst)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": dest.Id}, dest)
+	if err == nil && historyScribe != nil {
 		response := 0
-		historyScribe.Record(dest.GetHistoryRecord(), &response)
+		go historyScribe.Record(dest.GetHistoryRecord(), &response)
+	}
+	return
+}
+
+func (ms *MongoStorage) GetActions(key string, skipCache bool) (as Actions, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(ACTION_PREFIX + key); err == nil {
+			return x.(Actions), nil
+		} else {
+			return nil, err
+		}
 	}
-	return ms.db.C("destinations").Insert(dest)
+	session, col := ms.conn(colAct)
+	defer session.Close()
+	var kv AcKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return nil, mgoError(err)
+	}
+	as = kv.Value
+	cache2go.Cache(ACTION_PREFIX+key, as)
+	return
 }
 
-func (ms *MongoStorage) GetActions(key string) (as Actions, err error) {
-	result := AcKeyValue{}
-	err = ms.db.C("actions").Find(bson.M{"key": key}).One(&result)
-	return result.Value, err
+func (ms *MongoStorage) SetActions(key string, as Actions) (err error) {
+	session, col := ms.conn(colAct)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"key": key}, &AcKeyValue{Key: key, Value: as})
+	return
 }
 
-func (ms *MongoStorage) SetActions(key string, as Actions) error {
-	return ms.db.C("actions").Insert(&AcKeyValue{Key: key, Value: as})
+func (ms *MongoStorage) GetSharedGroup(key string, skipCache bool) (sg *SharedGroup, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(SHARED_GROUP_PREFIX + key); err == nil {
+			return x.(*SharedGroup), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colShg)
+	defer session.Close()
+	sg = new(SharedGroup)
+	if err = col.Find(bson.M{"id": key}).One(sg); err != nil {
+		return nil, mgoError(err)
+	}
+	cache2go.Cache(SHARED_GROUP_PREFIX+key, sg)
+	return
 }
 
-func (ms *MongoStorage) GetAccount(key string) (result *Account, err error) {
-	result = new(Account)
-	err = ms.db.C("userbalances").Find(bson.M{"id": key}).One(result)
+func (ms *MongoStorage) SetSharedGroup(sg *SharedGroup) (err error) {
+	session, col := ms.conn(colShg)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"id": sg.Id}, sg)
 	return
 }
 
-func (ms *MongoStorage) SetAccount(ub *Account) error {
-	return ms.db.C("userbalances").Insert(ub)
+func (ms *MongoStorage) GetAccount(key string) (ub *Account, err error) {
+	session, col := ms.conn(colAcc)
+	defer session.Close()
+	ub = new(Account)
+	if err = col.Find(bson.M{"id": key}).One(ub); err != nil {
+		return nil, mgoError(err)
+	}
+	return
+}
+
+func (ms *MongoStorage) SetAccount(ub *Account) (err error) {
+	// never override existing account with an empty one
+	// UPDATE: if all balances expired and were cleaned it makes
+	// sense to write empty balance map
+	acc := ub // the ledger queued by the caller is written once the account is saved
+	if len(ub.BalanceMap) == 0 {
+		if ac, err := ms.GetAccount(ub.Id); err == nil && !ac.allBalancesExpired() {
+			ac.ActionTriggers = ub.ActionTriggers
+			ac.UnitCounters = ub.UnitCounters
+			ac.AllowNegative = ub.AllowNegative
+			ac.CreditLimit = ub.CreditLimit
+			ac.BillingCycle = ub.BillingCycle
+			ac.ActivationDate = ub.ActivationDate
+			ac.CancellationDate = ub.CancellationDate
+			ac.Disabled = ub.Disabled
+			ub = ac
+		}
+	}
+	session, col := ms.conn(colAcc)
+	defer session.Close()
+	if _, err = col.Upsert(bson.M{"id": ub.Id}, ub); err == nil {
+		acc.writeLedger()
+	}
+	return
 }
 
 func (ms *MongoStorage) GetActionTimings(key string) (ats ActionPlan, err error) {
-	result := AtKeyValue{}
-	err = ms.db.C("actiontimings").Find(bson.M{"key": key}).One(&result)
-	return result.Value, err
+	session, col := ms.conn(colApl)
+	defer session.Close()
+	var kv AtKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return nil, mgoError(err)
+	}
+	return kv.Value, nil
 }
 
-func (ms *MongoStorage) SetActionTimings(key string, ats ActionPlan) error {
-	return ms.db.C("actiontimings").Insert(&AtKeyValue{key, ats})
+func (ms *MongoStorage) SetActionTimings(key string, ats ActionPlan) (err error) {
+	session, col := ms.conn(colApl)
+	defer session.Close()
+	if len(ats) == 0 {
+		// delete the key
+		if err = col.Remove(bson.M{"key": key}); err == mgo.ErrNotFound {
+			err = nil
+		}
+		return err
+	}
+	_, err = col.Upsert(bson.M{"key": key}, &AtKeyValue{Key: key, Value: ats})
+	return
 }
 
 func (ms *MongoStorage) GetAllActionTimings() (ats map[string]ActionPlan, err error) {
-	result := AtKeyValue{}
-	iter := ms.db.C("actiontimings").Find(nil).Iter()
+	session, col := ms.conn(colApl)
+	defer session.Close()
+	var kv AtKeyValue
+	iter := col.Find(nil).Iter()
 	ats = make(map[string]ActionPlan)
-	for iter.Next(&result) {
-		ats[result.Key] = result.Value
+	for iter.Next(&kv) {
+		ats[kv.Key] = kv.Value
 	}
+	return ats, iter.Close()
+}
+
+func (ms *MongoStorage) GetDerivedChargers(key string, skipCache bool) (dcs utils.DerivedChargers, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(DERIVEDCHARGERS_PREFIX + key); err == nil {
+			return x.(utils.DerivedChargers), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colDcs)
+	defer session.Close()
+	var kv DcsKeyValue
+	if err = col.Find(bson.M{"key": key}).One(&kv); err != nil {
+		return nil, mgoError(err)
+	}
+	dcs = kv.Value
+	cache2go.Cache(DERIVEDCHARGERS_PREFIX+key, dcs)
 	return
 }
 
-func (ms *MongoStorage) LogCallCost(cgrid, source string, cc *CallCost) error {
-	return ms.db.C("cclog").Insert(&LogCostEntry{cgrid, cc, source})
+func (ms *MongoStorage) SetDerivedChargers(key string, dcs utils.DerivedChargers) (err error) {
+	session, col := ms.conn(colDcs)
+	defer session.Close()
+	if len(dcs) == 0 {
+		if err = col.Remove(bson.M{"key": key}); err == mgo.ErrNotFound {
+			err = nil
+		}
+		// FIXME: Does cache need cleanup too?
+		return err
+	}
+	_, err = col.Upsert(bson.M{"key": key}, &DcsKeyValue{Key: key, Value: dcs})
+	return err
 }
 
-func (ms *MongoStorage) GetCallCostLog(cgrid, source string) (cc *CallCost, err error) {
-	result := new(LogCostEntry)
-	err = ms.db.C("cclog").Find(bson.M{"_id": cgrid, "source": source}).One(result)
-	cc = result.CallCost
+func (ms *MongoStorage) SetCdrStats(cs *CdrStats) error {
+	session, col := ms.conn(colCrs)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"id": cs.Id}, cs)
+	return err
+}
+
+func (ms *MongoStorage) GetCdrStats(key string) (cs *CdrStats, err error) {
+	session, col := ms.conn(colCrs)
+	defer session.Close()
+	cs = &CdrStats{}
+	if err = col.Find(bson.M{"id": key}).One(cs); err != nil {
+		return nil, mgoError(err)
+	}
+	return
+}
+
+func (ms *MongoStorage) GetAllCdrStats() (css []*CdrStats, err error) {
+	session, col := ms.conn(colCrs)
+	defer session.Close()
+	err = col.Find(nil).All(&css)
+	return
+}
+
+func (ms *MongoStorage) GetExchangeRate(key string, skipCache bool) (er *ExchangeRate, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(EXCHANGE_RATE_PREFIX + key); err == nil {
+			return x.(*ExchangeRate), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colExr)
+	defer session.Close()
+	er = new(ExchangeRate)
+	if err = col.Find(bson.M{"id": key}).One(er); err != nil {
+		return nil, mgoError(err)
+	}
+	cache2go.Cache(EXCHANGE_RATE_PREFIX+key, er)
 	return
 }
 
+func (ms *MongoStorage) SetExchangeRate(er *ExchangeRate) error {
+	session, col := ms.conn(colExr)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"id": er.Id}, er)
+	return err
+}
+
+func (ms *MongoStorage) GetTaxRules(key string, skipCache bool) (trs *TaxRules, err error) {
+	if !skipCache {
+		if x, err := cache2go.GetCached(TAX_RULES_PREFIX + key); err == nil {
+			return x.(*TaxRules), nil
+		} else {
+			return nil, err
+		}
+	}
+	session, col := ms.conn(colTax)
+	defer session.Close()
+	trs = new(TaxRules)
+	if err = col.Find(bson.M{"id": key}).One(trs); err != nil {
+		return nil, mgoError(err)
+	}
+	cache2go.Cache(TAX_RULES_PREFIX+key, trs)
+	return
+}
+
+func (ms *MongoStorage) SetTaxRules(trs *TaxRules) error {
+	session, col := ms.conn(colTax)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"id": trs.Id}, trs)
+	return err
+}
+
+func (ms *MongoStorage) GetPortedNumber(key string) (pn *PortedNumber, err error) {
+	session, col := ms.conn(colNpn)
+	defer session.Close()
+	pn = new(PortedNumber)
+	if err = col.Find(bson.M{"id": key}).One(pn); err != nil {
+		return nil, mgoError(err)
+	}
+	return
+}
+
+func (ms *MongoStorage) SetPortedNumber(pn *PortedNumber) error {
+	session, col := ms.conn(colNpn)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"id": pn.Id}, pn)
+	return err
+}
+
+func (ms *MongoStorage) RemovePortedNumber(key string) error {
+	session, col := ms.conn(colNpn)
+	defer session.Close()
+	if err := col.Remove(bson.M{"id": key}); err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+type LogCostEntry struct {
+	Id       string `bson:"_id,omitempty"`
+	CgrId    string
+	CallCost *CallCost
+	Source   string
+	RunId    string
+}
+
+type LogTimingEntry struct {
+	ActionTiming *ActionTiming
+	Actions      Actions
+	LogTime      time.Time
+	Source       string
+}
+
+type LogTriggerEntry struct {
+	UbId          string
+	ActionTrigger *ActionTrigger
+	Actions       Actions
+	LogTime       time.Time
+	Source        string
+}
+
+type LogErrEntry struct {
+	Id     string `bson:"_id,omitempty"`
+	ErrStr string
+	Source string
+	RunId  string
+}
+
+func (ms *MongoStorage) LogCallCost(cgrid, source, runid string, cc *CallCost) error {
+	session, col := ms.conn(colLcc)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"_id": utils.ConcatenatedKey(source, runid, cgrid)}, &LogCostEntry{utils.ConcatenatedKey(source, runid, cgrid), cgrid, cc, source, runid})
+	return err
+}
+
+func (ms *MongoStorage) GetCallCostLog(cgrid, source, runid string) (cc *CallCost, err error) {
+	session, col := ms.conn(colLcc)
+	defer session.Close()
+	result := new(LogCostEntry)
+	qry := bson.M{"cgrid": cgrid, "runid": runid}
+	if source != "" { // Empty source matches costs out of any source
+		qry["source"] = source
+	}
+	if err = col.Find(qry).One(result); err != nil {
+		return nil, mgoError(err)
+	}
+	return result.CallCost, nil
+}
+
 func (ms *MongoStorage) LogActionTrigger(ubId, source string, at *ActionTrigger, as Actions) (err error) {
-	return ms.db.C("actlog").Insert(&LogTriggerEntry{ubId, at, as, time.Now(), source})
+	session, col := ms.conn(colLat)
+	defer session.Close()
+	return col.Insert(&LogTriggerEntry{ubId, at, as, time.Now(), source})
 }
 
 func (ms *MongoStorage) LogActionTiming(source string, at *ActionTiming, as Actions) (err error) {
-	return ms.db.C("actlog").Insert(&LogTimingEntry{at, as, time.Now(), source})
+	session, col := ms.conn(colLtm)
+	defer session.Close()
+	return col.Insert(&LogTimingEntry{at, as, time.Now(), source})
 }
 
-func (ms *MongoStorage) LogError(uuid, source, errstr string) (err error) {
-	return ms.db.C("errlog").Insert(&LogErrEntry{uuid, errstr, source})
+func (ms *MongoStorage) LogError(uuid, source, runid, errstr string) (err error) {
+	session, col := ms.conn(colLer)
+	defer session.Close()
+	_, err = col.Upsert(bson.M{"_id": utils.ConcatenatedKey(source, runid, uuid)}, &LogErrEntry{utils.ConcatenatedKey(source, runid, uuid), errstr, source, runid})
+	return err
 }
@@ -0,0 +1,167 @@
+/*
+Real-Time Charging System for Telecom Environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can Storagetribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITH*out ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+var mgoDb *MongoStorage
+
+func TestMongoConnect(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if mgoDb, err = NewMongoStorage("127.0.0.1", "27017", "cgrates_datadb_test", "", ""); err != nil {
+		t.Fatal("Could not connect to MongoDB", err.Error())
+	}
+}
+
+func TestMongoFlush(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if err := mgoDb.Flush(""); err != nil {
+		t.Error("Failed to Flush mongo database", err.Error())
+	}
+	mgoDb.CacheAccounting(nil, nil, nil, nil)
+}
+
+func TestMongoSetGetDerivedCharges(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	keyCharger1 := utils.ConcatenatedKey("*out", "cgrates.org", "call", "dan", "dan")
+	charger1 := utils.DerivedChargers{
+		&utils.DerivedCharger{RunId: "extra1", ReqTypeField: "^prepaid", DirectionField: "*default", TenantField: "*default", CategoryField: "*default",
+			AccountField: "rif", SubjectField: "rif", DestinationField: "*default", SetupTimeField: "*default", AnswerTimeField: "*default", UsageField: "*default"},
+		&utils.DerivedCharger{RunId: "extra2", ReqTypeField: "*default", DirectionField: "*default", TenantField: "*default", CategoryField: "*default",
+			AccountField: "ivo", SubjectField: "ivo", DestinationField: "*default", SetupTimeField: "*default", AnswerTimeField: "*default", UsageField: "*default"},
+	}
+	if err := mgoDb.SetDerivedChargers(keyCharger1, charger1); err != nil {
+		t.Error("Error on setting DerivedChargers", err.Error())
+	}
+	// Try retrieving from cache, should not be in yet
+	if _, err := mgoDb.GetDerivedChargers(keyCharger1, false); err == nil {
+		t.Error("DerivedCharger should not be in the cache")
+	}
+	// Retrieve from db
+	if rcvCharger, err := mgoDb.GetDerivedChargers(keyCharger1, true); err != nil {
+		t.Error("Error when retrieving DerivedCHarger", err.Error())
+	} else if !reflect.DeepEqual(rcvCharger, charger1) {
+		t.Errorf("Expecting %v, received: %v", charger1, rcvCharger)
+	}
+	// Retrieve from cache
+	if rcvCharger, err := mgoDb.GetDerivedChargers(keyCharger1, false); err != nil {
+		t.Error("Error when retrieving DerivedCHarger", err.Error())
+	} else if !reflect.DeepEqual(rcvCharger, charger1) {
+		t.Errorf("Expecting %v, received: %v", charger1, rcvCharger)
+	}
+}
+
+func TestMongoSetGetDestination(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	dst := &Destination{Id: "TEST_MONGO_DST", Prefixes: []string{"+4986517174963", "+4986517174960"}}
+	if err := mgoDb.SetDestination(dst); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := mgoDb.HasData(DESTINATION_PREFIX, dst.Id); err != nil {
+		t.Error(err)
+	} else if !has {
+		t.Error("Destination not found in HasData")
+	}
+	if rcv, err := mgoDb.GetDestination(dst.Id); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(dst, rcv) {
+		t.Errorf("Expecting %+v, received: %+v", dst, rcv)
+	}
+	if keys, err := mgoDb.GetKeysForPrefix(DESTINATION_PREFIX); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual([]string{DESTINATION_PREFIX + dst.Id}, keys) {
+		t.Errorf("Received keys: %v", keys)
+	}
+	if _, err := mgoDb.GetDestination("NON_EXISTENT"); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Unexpected error: ", err)
+	}
+}
+
+func TestMongoSetGetAccount(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	acnt := &Account{Id: utils.ConcatenatedKey("*out", "cgrates.org", "mongo"),
+		BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "MONGO_BAL", Value: 10, Weight: 10,
+			ExpirationDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}}}}
+	if err := mgoDb.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+	acnt.BalanceMap[utils.MONETARY+OUTBOUND][0].Value = 5
+	if err := mgoDb.SetAccount(acnt); err != nil { // Update should not duplicate
+		t.Fatal(err)
+	}
+	if rcv, err := mgoDb.GetAccount(acnt.Id); err != nil {
+		t.Error(err)
+	} else if rcv.BalanceMap[utils.MONETARY+OUTBOUND][0].Value != 5 ||
+		!rcv.BalanceMap[utils.MONETARY+OUTBOUND][0].ExpirationDate.Equal(acnt.BalanceMap[utils.MONETARY+OUTBOUND][0].ExpirationDate) {
+		t.Errorf("Received: %+v", rcv.BalanceMap[utils.MONETARY+OUTBOUND][0])
+	}
+}
+
+func TestMongoRpAliases(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if err := mgoDb.SetRpAlias(utils.RatingSubjectAliasKey("cgrates.org", "2001"), "1001"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgoDb.SetRpAlias(utils.RatingSubjectAliasKey("cgrates.org", "2002"), "1001"); err != nil {
+		t.Fatal(err)
+	}
+	if aliases, err := mgoDb.GetRPAliases("cgrates.org", "1001", true); err != nil {
+		t.Error(err)
+	} else if len(aliases) != 2 {
+		t.Error("Unexpected aliases: ", aliases)
+	}
+	if err := mgoDb.RemoveRpAliases([]*TenantRatingSubject{&TenantRatingSubject{Tenant: "cgrates.org", Subject: "1001"}}); err != nil {
+		t.Error(err)
+	}
+	if aliases, err := mgoDb.GetRPAliases("cgrates.org", "1001", true); err != nil {
+		t.Error(err)
+	} else if len(aliases) != 0 {
+		t.Error("Unexpected aliases: ", aliases)
+	}
+}
+
+func TestMongoCacheRating(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if err := mgoDb.CacheRating(nil, nil, nil, nil, nil); err != nil {
+		t.Error(err)
+	}
+	if err := mgoDb.CacheAccounting(nil, nil, nil, nil); err != nil {
+		t.Error(err)
+	}
+}
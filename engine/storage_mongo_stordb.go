@@ -0,0 +1,1463 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	colCdr   = "cdrs"
+	colCnt   = "counters"
+	colLdg   = utils.TBL_LEDGER
+	colInv   = utils.TBL_INVOICES
+	colTpLcr = utils.TBL_TP_LCRS
+)
+
+// Tariff plan tables are kept as collections with the same name, one document per row
+var mgoTpCollections = []string{utils.TBL_TP_TIMINGS, utils.TBL_TP_DESTINATIONS, utils.TBL_TP_RATES, utils.TBL_TP_DESTINATION_RATES, utils.TBL_TP_RATING_PLANS, utils.TBL_TP_RATE_PROFILES,
+	utils.TBL_TP_SHARED_GROUPS, utils.TBL_TP_CDR_STATS, utils.TBL_TP_LCRS, utils.TBL_TP_ACTIONS, utils.TBL_TP_ACTION_PLANS, utils.TBL_TP_ACTION_TRIGGERS, utils.TBL_TP_ACCOUNT_ACTIONS, utils.TBL_TP_DERIVED_CHARGERS, utils.TBL_TP_EXCHANGE_RATES, utils.TBL_TP_TAX_RULES, utils.TBL_TP_PORTED_NUMBERS, utils.TBL_TP_CALENDARS}
+
+// One document per CDR row as returned by GetStoredCdrs: the original CDR joined with one of its derived runs.
+// Raw CDRs without derived runs yet are kept with empty RunId, the document is replaced as soon as the first run is rated.
+type CdrEntry struct {
+	Id        string `bson:"_id,omitempty"`
+	CgrId     string
+	RunId     string
+	OrderId   int64
+	Raw       *StoredCdr `bson:",omitempty"`
+	Rated     *StoredCdr `bson:",omitempty"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LCR rules do not map one to one on the TpLcrRules model so we keep our own row format
+type TpLcrEntry struct {
+	Tpid           string
+	Tag            string
+	Direction      string
+	Tenant         string
+	Category       string
+	Account        string
+	Subject        string
+	DestinationTag string
+	RpCategory     string
+	Strategy       string
+	StrategyParams string
+	ActivationTime time.Time
+	Weight         float64
+	CreatedAt      time.Time
+}
+
+// Automatically increments the counter with the given name, used to generate CDR order ids
+func (ms *MongoStorage) nextSeq(name string) (int64, error) {
+	session, col := ms.conn(colCnt)
+	defer session.Close()
+	var cnt struct{ Seq int64 }
+	if _, err := col.Find(bson.M{"_id": name}).Apply(mgo.Change{Update: bson.M{"$inc": bson.M{"seq": 1}}, Upsert: true, ReturnNew: true}, &cnt); err != nil {
+		return 0, err
+	}
+	return cnt.Seq, nil
+}
+
+// Return a list with all TPids defined in the system, even if incomplete, isolated in some collection.
+func (ms *MongoStorage) GetTPIds() ([]string, error) {
+	session := ms.session.Copy()
+	defer session.Close()
+	var ids []string
+	for _, colName := range []string{utils.TBL_TP_TIMINGS, utils.TBL_TP_DESTINATIONS, utils.TBL_TP_RATES, utils.TBL_TP_DESTINATION_RATES, utils.TBL_TP_RATING_PLANS, utils.TBL_TP_RATE_PROFILES} {
+		var colIds []string
+		if err := session.DB(ms.db).C(colName).Find(nil).Distinct("tpid", &colIds); err != nil {
+			return nil, err
+		}
+		for _, id := range colIds {
+			if !utils.IsSliceMember(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Column names are received in their SQL form, bson keeps the lowercased field name without separators
+func mgoTpField(column string) string {
+	return strings.Replace(column, "_", "", -1)
+}
+
+func (ms *MongoStorage) GetTPTableIds(tpid, table string, distinct utils.TPDistinctIds, filters map[string]string, pagination *utils.Paginator) ([]string, error) {
+	session, col := ms.conn(table)
+	defer session.Close()
+	qry := bson.M{"tpid": tpid}
+	for key, value := range filters {
+		if key != "" && value != "" {
+			qry[mgoTpField(key)] = value
+		}
+	}
+	slct := bson.M{"_id": 0}
+	for _, d := range distinct {
+		slct[mgoTpField(d)] = 1
+	}
+	if pagination != nil && len(pagination.SearchTerm) != 0 {
+		var searchOr []bson.M
+		for _, d := range distinct {
+			searchOr = append(searchOr, bson.M{mgoTpField(d): bson.RegEx{Pattern: regexp.QuoteMeta(pagination.SearchTerm)}})
+		}
+		qry["$or"] = searchOr
+	}
+	var rows []bson.M
+	if err := col.Find(qry).Select(slct).All(&rows); err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, row := range rows {
+		vals := make([]string, len(distinct))
+		for idx, d := range distinct {
+			if val, hasIt := row[mgoTpField(d)]; hasIt {
+				vals[idx] = fmt.Sprintf("%v", val)
+			}
+		}
+		finalId := strings.Join(vals, utils.CONCATENATED_KEY_SEP)
+		if !utils.IsSliceMember(ids, finalId) {
+			ids = append(ids, finalId)
+		}
+	}
+	if pagination != nil {
+		if pagination.Offset != nil && pagination.Limit != nil { // Keep SQL compatibility by considering offset only when limit defined
+			if *pagination.Offset >= len(ids) {
+				ids = []string{}
+			} else {
+				ids = ids[*pagination.Offset:]
+			}
+		}
+		if pagination.Limit != nil && *pagination.Limit < len(ids) {
+			ids = ids[:*pagination.Limit]
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return ids, nil
+}
+
+func (ms *MongoStorage) RemTPData(table, tpid string, args ...string) error {
+	session := ms.session.Copy()
+	defer session.Close()
+	if len(table) == 0 { // Remove tpid out of all collections
+		for _, colName := range mgoTpCollections {
+			if _, err := session.DB(ms.db).C(colName).RemoveAll(bson.M{"tpid": tpid}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// Remove from a single collection
+	qry := bson.M{"tpid": tpid}
+	switch table {
+	default:
+		qry["tag"] = args[0]
+	case utils.TBL_TP_RATE_PROFILES:
+		qry["loadid"], qry["direction"], qry["tenant"], qry["category"], qry["subject"] = args[0], args[1], args[2], args[3], args[4]
+	case utils.TBL_TP_ACCOUNT_ACTIONS:
+		qry["loadid"], qry["direction"], qry["tenant"], qry["account"] = args[0], args[1], args[2], args[3]
+	case utils.TBL_TP_DERIVED_CHARGERS:
+		qry["loadid"], qry["direction"], qry["tenant"], qry["category"], qry["account"], qry["subject"] = args[0], args[1], args[2], args[3], args[4], args[5]
+	}
+	_, err := session.DB(ms.db).C(table).RemoveAll(qry)
+	return err
+}
+
+// Replaces the rows matching selector with the new ones
+func (ms *MongoStorage) setTpRows(table string, selector bson.M, rows []interface{}) error {
+	session, col := ms.conn(table)
+	defer session.Close()
+	if _, err := col.RemoveAll(selector); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return col.Insert(rows...)
+}
+
+// Returns the query for tpid and optionally the tag
+func mgoTpQuery(tpid, tag string) bson.M {
+	qry := bson.M{"tpid": tpid}
+	if len(tag) != 0 {
+		qry["tag"] = tag
+	}
+	return qry
+}
+
+func (ms *MongoStorage) SetTPTiming(tm *utils.ApierTPTiming) error {
+	session, col := ms.conn(utils.TBL_TP_TIMINGS)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"tpid": tm.TPid, "tag": tm.TimingId}, &TpTiming{
+		Tpid:      tm.TPid,
+		Tag:       tm.TimingId,
+		Years:     tm.Years,
+		Months:    tm.Months,
+		MonthDays: tm.MonthDays,
+		WeekDays:  tm.WeekDays,
+		Time:      tm.Time,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+func (ms *MongoStorage) GetTpTimings(tpid, tag string) (map[string]*utils.ApierTPTiming, error) {
+	session, col := ms.conn(utils.TBL_TP_TIMINGS)
+	defer session.Close()
+	var tpTimings []TpTiming
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpTimings); err != nil {
+		return nil, err
+	}
+	tms := make(map[string]*utils.ApierTPTiming)
+	for _, tpTm := range tpTimings {
+		tms[tpTm.Tag] = &utils.ApierTPTiming{TPid: tpTm.Tpid, TimingId: tpTm.Tag, Years: tpTm.Years, Months: tpTm.Months, MonthDays: tpTm.MonthDays, WeekDays: tpTm.WeekDays, Time: tpTm.Time}
+	}
+	return tms, nil
+}
+
+func (ms *MongoStorage) SetTPDestination(tpid string, dest *Destination) error {
+	if len(dest.Prefixes) == 0 {
+		return nil
+	}
+	var rows []interface{}
+	for _, prefix := range dest.Prefixes {
+		rows = append(rows, &TpDestination{Tpid: tpid, Tag: dest.Id, Prefix: prefix, CreatedAt: time.Now()})
+	}
+	return ms.setTpRows(utils.TBL_TP_DESTINATIONS, bson.M{"tpid": tpid, "tag": dest.Id}, rows)
+}
+
+func (ms *MongoStorage) GetTpDestinations(tpid, tag string) (map[string]*Destination, error) {
+	session, col := ms.conn(utils.TBL_TP_DESTINATIONS)
+	defer session.Close()
+	var tpDests []TpDestination
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpDests); err != nil {
+		return nil, err
+	}
+	dests := make(map[string]*Destination)
+	for _, tpDest := range tpDests {
+		dest, found := dests[tpDest.Tag]
+		if !found {
+			dest = &Destination{Id: tpDest.Tag}
+			dests[tpDest.Tag] = dest
+		}
+		dest.AddPrefix(tpDest.Prefix)
+	}
+	return dests, nil
+}
+
+func (ms *MongoStorage) SetTPRates(tpid string, rts map[string][]*utils.RateSlot) error {
+	for rtId, rSlots := range rts {
+		var rows []interface{}
+		for _, rs := range rSlots {
+			rows = append(rows, &TpRate{
+				Tpid:               tpid,
+				Tag:                rtId,
+				ConnectFee:         rs.ConnectFee,
+				Rate:               rs.Rate,
+				RateUnit:           rs.RateUnit,
+				RateIncrement:      rs.RateIncrement,
+				GroupIntervalStart: rs.GroupIntervalStart,
+				CreatedAt:          time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_RATES, bson.M{"tpid": tpid, "tag": rtId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpRates(tpid, tag string) (map[string]*utils.TPRate, error) {
+	session, col := ms.conn(utils.TBL_TP_RATES)
+	defer session.Close()
+	var tpRates []TpRate
+	if err := col.Find(mgoTpQuery(tpid, tag)).Sort("_id").All(&tpRates); err != nil {
+		return nil, err
+	}
+	rts := make(map[string]*utils.TPRate)
+	for _, tr := range tpRates {
+		rs, err := utils.NewRateSlot(tr.ConnectFee, tr.Rate, tr.RateUnit, tr.RateIncrement, tr.GroupIntervalStart)
+		if err != nil {
+			return nil, err
+		}
+		// same tag only to create rate groups
+		if er, exists := rts[tr.Tag]; exists {
+			if err := ValidNextGroup(er.RateSlots[len(er.RateSlots)-1], rs); err != nil {
+				return nil, err
+			}
+			er.RateSlots = append(er.RateSlots, rs)
+		} else {
+			rts[tr.Tag] = &utils.TPRate{TPid: tpid, RateId: tr.Tag, RateSlots: []*utils.RateSlot{rs}}
+		}
+	}
+	return rts, nil
+}
+
+func (ms *MongoStorage) SetTPDestinationRates(tpid string, drs map[string][]*utils.DestinationRate) error {
+	for drId, dRates := range drs {
+		var rows []interface{}
+		for _, dr := range dRates {
+			rows = append(rows, &TpDestinationRate{
+				Tpid:               tpid,
+				Tag:                drId,
+				DestinationsTag:    dr.DestinationId,
+				RatesTag:           dr.RateId,
+				RoundingMethod:     dr.RoundingMethod,
+				RoundingDecimals:   dr.RoundingDecimals,
+				MaxCost:            dr.MaxCost,
+				MaxCostStrategy:    dr.MaxCostStrategy,
+				TierPeriod:         dr.TierPeriod,
+				Currency:           dr.Currency,
+				MinDuration:        dr.MinDuration,
+				MinCost:            dr.MinCost,
+				FreeUnder:          dr.FreeUnder,
+				ConnectFeeOnAnswer: dr.ConnectFeeOnAnswer,
+				CreatedAt:          time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_DESTINATION_RATES, bson.M{"tpid": tpid, "tag": drId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Applies the limits out of paginator on the query
+func mgoPaginate(q *mgo.Query, pagination *utils.Paginator) *mgo.Query {
+	if pagination == nil {
+		return q
+	}
+	if pagination.Offset != nil {
+		q = q.Skip(*pagination.Offset)
+	}
+	if pagination.Limit != nil {
+		q = q.Limit(*pagination.Limit)
+	}
+	return q
+}
+
+func (ms *MongoStorage) GetTpDestinationRates(tpid, tag string, pagination *utils.Paginator) (map[string]*utils.TPDestinationRate, error) {
+	session, col := ms.conn(utils.TBL_TP_DESTINATION_RATES)
+	defer session.Close()
+	var tpDestinationRates []TpDestinationRate
+	if err := mgoPaginate(col.Find(mgoTpQuery(tpid, tag)), pagination).All(&tpDestinationRates); err != nil {
+		return nil, err
+	}
+	rts := make(map[string]*utils.TPDestinationRate)
+	for _, tpDr := range tpDestinationRates {
+		dr := &utils.DestinationRate{
+			DestinationId:      tpDr.DestinationsTag,
+			RateId:             tpDr.RatesTag,
+			RoundingMethod:     tpDr.RoundingMethod,
+			RoundingDecimals:   tpDr.RoundingDecimals,
+			MaxCost:            tpDr.MaxCost,
+			MaxCostStrategy:    tpDr.MaxCostStrategy,
+			TierPeriod:         tpDr.TierPeriod,
+			Currency:           tpDr.Currency,
+			MinDuration:        tpDr.MinDuration,
+			MinCost:            tpDr.MinCost,
+			FreeUnder:          tpDr.FreeUnder,
+			ConnectFeeOnAnswer: tpDr.ConnectFeeOnAnswer,
+		}
+		if existingDR, exists := rts[tpDr.Tag]; exists {
+			existingDR.DestinationRates = append(existingDR.DestinationRates, dr)
+		} else {
+			rts[tpDr.Tag] = &utils.TPDestinationRate{TPid: tpid, DestinationRateId: tpDr.Tag, DestinationRates: []*utils.DestinationRate{dr}}
+		}
+	}
+	return rts, nil
+}
+
+func (ms *MongoStorage) SetTPRatingPlans(tpid string, drts map[string][]*utils.TPRatingPlanBinding) error {
+	for rpId, rPlans := range drts {
+		var rows []interface{}
+		for _, rp := range rPlans {
+			rows = append(rows, &TpRatingPlan{
+				Tpid:         tpid,
+				Tag:          rpId,
+				DestratesTag: rp.DestinationRatesId,
+				TimingTag:    rp.TimingId,
+				Weight:       rp.Weight,
+				CreatedAt:    time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_RATING_PLANS, bson.M{"tpid": tpid, "tag": rpId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpRatingPlans(tpid, tag string, pagination *utils.Paginator) (map[string][]*utils.TPRatingPlanBinding, error) {
+	session, col := ms.conn(utils.TBL_TP_RATING_PLANS)
+	defer session.Close()
+	var tpRatingPlans []TpRatingPlan
+	if err := mgoPaginate(col.Find(mgoTpQuery(tpid, tag)), pagination).All(&tpRatingPlans); err != nil {
+		return nil, err
+	}
+	rpbns := make(map[string][]*utils.TPRatingPlanBinding)
+	for _, tpRp := range tpRatingPlans {
+		rpbns[tpRp.Tag] = append(rpbns[tpRp.Tag], &utils.TPRatingPlanBinding{
+			DestinationRatesId: tpRp.DestratesTag,
+			TimingId:           tpRp.TimingTag,
+			Weight:             tpRp.Weight,
+		})
+	}
+	return rpbns, nil
+}
+
+func (ms *MongoStorage) SetTPRatingProfiles(tpid string, rpfs map[string]*utils.TPRatingProfile) error {
+	for _, rpf := range rpfs {
+		var rows []interface{}
+		for _, ra := range rpf.RatingPlanActivations {
+			rows = append(rows, &TpRatingProfile{
+				Tpid:             rpf.TPid,
+				Loadid:           rpf.LoadId,
+				Tenant:           rpf.Tenant,
+				Category:         rpf.Category,
+				Subject:          rpf.Subject,
+				Direction:        rpf.Direction,
+				ActivationTime:   ra.ActivationTime,
+				RatingPlanTag:    ra.RatingPlanId,
+				FallbackSubjects: ra.FallbackSubjects,
+				CdrStatQueueIds:  ra.CdrStatQueueIds,
+				Timezone:         ra.Timezone,
+				CreatedAt:        time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_RATE_PROFILES, bson.M{"tpid": tpid, "loadid": rpf.LoadId, "direction": rpf.Direction, "tenant": rpf.Tenant,
+			"category": rpf.Category, "subject": rpf.Subject}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpRatingProfiles(qryRpf *utils.TPRatingProfile) (map[string]*utils.TPRatingProfile, error) {
+	session, col := ms.conn(utils.TBL_TP_RATE_PROFILES)
+	defer session.Close()
+	qry := bson.M{"tpid": qryRpf.TPid}
+	for fld, val := range map[string]string{"direction": qryRpf.Direction, "tenant": qryRpf.Tenant, "category": qryRpf.Category, "subject": qryRpf.Subject, "loadid": qryRpf.LoadId} {
+		if len(val) != 0 {
+			qry[fld] = val
+		}
+	}
+	var tpRpfs []TpRatingProfile
+	if err := col.Find(qry).All(&tpRpfs); err != nil {
+		return nil, err
+	}
+	rpfs := make(map[string]*utils.TPRatingProfile)
+	for _, tpRpf := range tpRpfs {
+		rp := &utils.TPRatingProfile{
+			TPid:      tpRpf.Tpid,
+			LoadId:    tpRpf.Loadid,
+			Direction: tpRpf.Direction,
+			Tenant:    tpRpf.Tenant,
+			Category:  tpRpf.Category,
+			Subject:   tpRpf.Subject,
+		}
+		ra := &utils.TPRatingActivation{
+			ActivationTime:   tpRpf.ActivationTime,
+			RatingPlanId:     tpRpf.RatingPlanTag,
+			FallbackSubjects: tpRpf.FallbackSubjects,
+			CdrStatQueueIds:  tpRpf.CdrStatQueueIds,
+			Timezone:         tpRpf.Timezone,
+		}
+		if existingRpf, exists := rpfs[rp.KeyId()]; !exists {
+			rp.RatingPlanActivations = []*utils.TPRatingActivation{ra}
+			rpfs[rp.KeyId()] = rp
+		} else { // Exists, update
+			existingRpf.RatingPlanActivations = append(existingRpf.RatingPlanActivations, ra)
+		}
+	}
+	return rpfs, nil
+}
+
+func (ms *MongoStorage) SetTPSharedGroups(tpid string, sgs map[string][]*utils.TPSharedGroup) error {
+	for sgId, sGroups := range sgs {
+		var rows []interface{}
+		for _, sg := range sGroups {
+			rows = append(rows, &TpSharedGroup{
+				Tpid:          tpid,
+				Tag:           sgId,
+				Account:       sg.Account,
+				Strategy:      sg.Strategy,
+				RatingSubject: sg.RatingSubject,
+				CreatedAt:     time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_SHARED_GROUPS, bson.M{"tpid": tpid, "tag": sgId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpSharedGroups(tpid, tag string) (map[string][]*utils.TPSharedGroup, error) {
+	session, col := ms.conn(utils.TBL_TP_SHARED_GROUPS)
+	defer session.Close()
+	var tpSgs []TpSharedGroup
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpSgs); err != nil {
+		return nil, err
+	}
+	sgs := make(map[string][]*utils.TPSharedGroup)
+	for _, tpSg := range tpSgs {
+		sgs[tpSg.Tag] = append(sgs[tpSg.Tag], &utils.TPSharedGroup{
+			Account:       tpSg.Account,
+			Strategy:      tpSg.Strategy,
+			RatingSubject: tpSg.RatingSubject,
+		})
+	}
+	return sgs, nil
+}
+
+func (ms *MongoStorage) SetTPExchangeRates(tpid string, ers map[string][]*utils.TPExchangeRate) error {
+	for erId, eRates := range ers {
+		var rows []interface{}
+		for _, er := range eRates {
+			rows = append(rows, &TpExchangeRate{
+				Tpid:           tpid,
+				Tag:            erId,
+				FromCurrency:   er.FromCurrency,
+				ToCurrency:     er.ToCurrency,
+				ActivationTime: er.ActivationTime,
+				Rate:           er.Rate,
+				CreatedAt:      time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_EXCHANGE_RATES, bson.M{"tpid": tpid, "tag": erId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpExchangeRates(tpid, tag string) (map[string][]*utils.TPExchangeRate, error) {
+	session, col := ms.conn(utils.TBL_TP_EXCHANGE_RATES)
+	defer session.Close()
+	var tpErs []TpExchangeRate
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpErs); err != nil {
+		return nil, err
+	}
+	ers := make(map[string][]*utils.TPExchangeRate)
+	for _, tpEr := range tpErs {
+		ers[tpEr.Tag] = append(ers[tpEr.Tag], &utils.TPExchangeRate{
+			FromCurrency:   tpEr.FromCurrency,
+			ToCurrency:     tpEr.ToCurrency,
+			ActivationTime: tpEr.ActivationTime,
+			Rate:           tpEr.Rate,
+		})
+	}
+	return ers, nil
+}
+
+func (ms *MongoStorage) SetTPTaxRules(tpid string, trs map[string][]*utils.TPTaxRule) error {
+	for trId, tRules := range trs {
+		var rows []interface{}
+		for _, tr := range tRules {
+			rows = append(rows, &TpTaxRule{
+				Tpid:           tpid,
+				Tag:            trId,
+				Tenant:         tr.Tenant,
+				Category:       tr.Category,
+				DestinationId:  tr.DestinationId,
+				ActivationTime: tr.ActivationTime,
+				Rate:           tr.Rate,
+				CreatedAt:      time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_TAX_RULES, bson.M{"tpid": tpid, "tag": trId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpTaxRules(tpid, tag string) (map[string][]*utils.TPTaxRule, error) {
+	session, col := ms.conn(utils.TBL_TP_TAX_RULES)
+	defer session.Close()
+	var tpTrs []TpTaxRule
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpTrs); err != nil {
+		return nil, err
+	}
+	trs := make(map[string][]*utils.TPTaxRule)
+	for _, tpTr := range tpTrs {
+		trs[tpTr.Tag] = append(trs[tpTr.Tag], &utils.TPTaxRule{
+			Tenant:         tpTr.Tenant,
+			Category:       tpTr.Category,
+			DestinationId:  tpTr.DestinationId,
+			ActivationTime: tpTr.ActivationTime,
+			Rate:           tpTr.Rate,
+		})
+	}
+	return trs, nil
+}
+
+func (ms *MongoStorage) SetTPPortedNumbers(tpid string, pns map[string][]*utils.TPPortedNumber) error {
+	for pnId, pNumbers := range pns {
+		var rows []interface{}
+		for _, pn := range pNumbers {
+			rows = append(rows, &TpPortedNumber{
+				Tpid:          tpid,
+				Tag:           pnId,
+				Number:        pn.Number,
+				RoutingPrefix: pn.RoutingPrefix,
+				CreatedAt:     time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_PORTED_NUMBERS, bson.M{"tpid": tpid, "tag": pnId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpPortedNumbers(tpid, tag string) (map[string][]*utils.TPPortedNumber, error) {
+	session, col := ms.conn(utils.TBL_TP_PORTED_NUMBERS)
+	defer session.Close()
+	var tpPns []TpPortedNumber
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpPns); err != nil {
+		return nil, err
+	}
+	pns := make(map[string][]*utils.TPPortedNumber)
+	for _, tpPn := range tpPns {
+		pns[tpPn.Tag] = append(pns[tpPn.Tag], &utils.TPPortedNumber{
+			Number:        tpPn.Number,
+			RoutingPrefix: tpPn.RoutingPrefix,
+		})
+	}
+	return pns, nil
+}
+
+func (ms *MongoStorage) SetTPCalendars(tpid string, cals map[string][]*utils.TPCalendarDay) error {
+	for calId, days := range cals {
+		var rows []interface{}
+		for _, day := range days {
+			rows = append(rows, &TpCalendar{
+				Tpid:      tpid,
+				Tag:       calId,
+				Date:      day.Date,
+				Yearly:    day.Yearly,
+				CreatedAt: time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_CALENDARS, bson.M{"tpid": tpid, "tag": calId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpCalendars(tpid, tag string) (map[string][]*utils.TPCalendarDay, error) {
+	session, col := ms.conn(utils.TBL_TP_CALENDARS)
+	defer session.Close()
+	var tpCals []TpCalendar
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpCals); err != nil {
+		return nil, err
+	}
+	cals := make(map[string][]*utils.TPCalendarDay)
+	for _, tpCal := range tpCals {
+		cals[tpCal.Tag] = append(cals[tpCal.Tag], &utils.TPCalendarDay{
+			Date:   tpCal.Date,
+			Yearly: tpCal.Yearly,
+		})
+	}
+	return cals, nil
+}
+
+func (ms *MongoStorage) SetTPCdrStats(tpid string, css map[string][]*utils.TPCdrStat) error {
+	for csId, cStats := range css {
+		var rows []interface{}
+		for _, cs := range cStats {
+			ql, _ := strconv.Atoi(cs.QueueLength)
+			rows = append(rows, &TpCdrStat{
+				Tpid:                tpid,
+				Tag:                 csId,
+				QueueLength:         ql,
+				TimeWindow:          cs.TimeWindow,
+				Metrics:             cs.Metrics,
+				SetupInterval:       cs.SetupInterval,
+				Tors:                cs.TORs,
+				CdrHosts:            cs.CdrHosts,
+				CdrSources:          cs.CdrSources,
+				ReqTypes:            cs.ReqTypes,
+				Directions:          cs.Directions,
+				Tenants:             cs.Tenants,
+				Categories:          cs.Categories,
+				Accounts:            cs.Accounts,
+				Subjects:            cs.Subjects,
+				DestinationPrefixes: cs.DestinationPrefixes,
+				UsageInterval:       cs.UsageInterval,
+				Suppliers:           cs.Suppliers,
+				DisconnectCauses:    cs.DisconnectCauses,
+				MediationRunids:     cs.MediationRunIds,
+				RatedAccounts:       cs.RatedAccounts,
+				RatedSubjects:       cs.RatedSubjects,
+				CostInterval:        cs.CostInterval,
+				ActionTriggers:      cs.ActionTriggers,
+				CreatedAt:           time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_CDR_STATS, bson.M{"tpid": tpid, "tag": csId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpCdrStats(tpid, tag string) (map[string][]*utils.TPCdrStat, error) {
+	session, col := ms.conn(utils.TBL_TP_CDR_STATS)
+	defer session.Close()
+	var tpCdrStats []TpCdrStat
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpCdrStats); err != nil {
+		return nil, err
+	}
+	css := make(map[string][]*utils.TPCdrStat)
+	for _, tpCs := range tpCdrStats {
+		css[tpCs.Tag] = append(css[tpCs.Tag], &utils.TPCdrStat{
+			QueueLength:         strconv.Itoa(tpCs.QueueLength),
+			TimeWindow:          tpCs.TimeWindow,
+			Metrics:             tpCs.Metrics,
+			SetupInterval:       tpCs.SetupInterval,
+			TORs:                tpCs.Tors,
+			CdrHosts:            tpCs.CdrHosts,
+			CdrSources:          tpCs.CdrSources,
+			ReqTypes:            tpCs.ReqTypes,
+			Directions:          tpCs.Directions,
+			Tenants:             tpCs.Tenants,
+			Categories:          tpCs.Categories,
+			Accounts:            tpCs.Accounts,
+			Subjects:            tpCs.Subjects,
+			DestinationPrefixes: tpCs.DestinationPrefixes,
+			UsageInterval:       tpCs.UsageInterval,
+			Suppliers:           tpCs.Suppliers,
+			DisconnectCauses:    tpCs.DisconnectCauses,
+			MediationRunIds:     tpCs.MediationRunids,
+			RatedAccounts:       tpCs.RatedAccounts,
+			RatedSubjects:       tpCs.RatedSubjects,
+			CostInterval:        tpCs.CostInterval,
+			ActionTriggers:      tpCs.ActionTriggers,
+		})
+	}
+	return css, nil
+}
+
+func (ms *MongoStorage) SetTPDerivedChargers(tpid string, sgs map[string][]*utils.TPDerivedCharger) error {
+	for dcId, dChargers := range sgs {
+		tmpDc := &TpDerivedCharger{}
+		if err := tmpDc.SetDerivedChargersId(dcId); err != nil {
+			return err
+		}
+		var rows []interface{}
+		for _, dc := range dChargers {
+			newDc := &TpDerivedCharger{
+				Tpid:                 tpid,
+				Runid:                dc.RunId,
+				RunFilters:           dc.RunFilters,
+				ReqTypeField:         dc.ReqTypeField,
+				DirectionField:       dc.DirectionField,
+				TenantField:          dc.TenantField,
+				CategoryField:        dc.CategoryField,
+				AccountField:         dc.AccountField,
+				SubjectField:         dc.SubjectField,
+				DestinationField:     dc.DestinationField,
+				SetupTimeField:       dc.SetupTimeField,
+				AnswerTimeField:      dc.AnswerTimeField,
+				UsageField:           dc.UsageField,
+				SupplierField:        dc.SupplierField,
+				DisconnectCauseField: dc.DisconnectCauseField,
+				CreatedAt:            time.Now(),
+			}
+			if err := newDc.SetDerivedChargersId(dcId); err != nil {
+				return err
+			}
+			rows = append(rows, newDc)
+		}
+		if err := ms.setTpRows(utils.TBL_TP_DERIVED_CHARGERS, bson.M{"tpid": tpid, "loadid": tmpDc.Loadid, "direction": tmpDc.Direction, "tenant": tmpDc.Tenant,
+			"category": tmpDc.Category, "account": tmpDc.Account, "subject": tmpDc.Subject}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpDerivedChargers(dc *utils.TPDerivedChargers) (map[string]*utils.TPDerivedChargers, error) {
+	session, col := ms.conn(utils.TBL_TP_DERIVED_CHARGERS)
+	defer session.Close()
+	qry := bson.M{"tpid": dc.TPid}
+	for fld, val := range map[string]string{"direction": dc.Direction, "tenant": dc.Tenant, "account": dc.Account, "category": dc.Category, "subject": dc.Subject, "loadid": dc.Loadid} {
+		if len(val) != 0 {
+			qry[fld] = val
+		}
+	}
+	var tpDerivedChargers []TpDerivedCharger
+	if err := col.Find(qry).All(&tpDerivedChargers); err != nil {
+		return nil, err
+	}
+	dcs := make(map[string]*utils.TPDerivedChargers)
+	for _, tpDcMdl := range tpDerivedChargers {
+		tpDc := &utils.TPDerivedChargers{TPid: tpDcMdl.Tpid, Loadid: tpDcMdl.Loadid, Direction: tpDcMdl.Direction, Tenant: tpDcMdl.Tenant, Category: tpDcMdl.Category,
+			Account: tpDcMdl.Account, Subject: tpDcMdl.Subject}
+		tag := tpDc.GetDerivedChargesId()
+		if _, hasIt := dcs[tag]; !hasIt {
+			dcs[tag] = tpDc
+		}
+		dcs[tag].DerivedChargers = append(dcs[tag].DerivedChargers, &utils.TPDerivedCharger{
+			RunId:                tpDcMdl.Runid,
+			RunFilters:           tpDcMdl.RunFilters,
+			ReqTypeField:         tpDcMdl.ReqTypeField,
+			DirectionField:       tpDcMdl.DirectionField,
+			TenantField:          tpDcMdl.TenantField,
+			CategoryField:        tpDcMdl.CategoryField,
+			AccountField:         tpDcMdl.AccountField,
+			SubjectField:         tpDcMdl.SubjectField,
+			DestinationField:     tpDcMdl.DestinationField,
+			SetupTimeField:       tpDcMdl.SetupTimeField,
+			AnswerTimeField:      tpDcMdl.AnswerTimeField,
+			UsageField:           tpDcMdl.UsageField,
+			SupplierField:        tpDcMdl.SupplierField,
+			DisconnectCauseField: tpDcMdl.DisconnectCauseField,
+		})
+	}
+	return dcs, nil
+}
+
+func (ms *MongoStorage) SetTPLCRs(tpid string, lcrs map[string]*LCR) error {
+	for _, lcr := range lcrs {
+		tag := utils.LCRKey(lcr.Direction, lcr.Tenant, lcr.Category, lcr.Account, lcr.Subject)
+		var rows []interface{}
+		for _, act := range lcr.Activations {
+			for _, entry := range act.Entries {
+				rows = append(rows, &TpLcrEntry{
+					Tpid:           tpid,
+					Tag:            tag,
+					Direction:      lcr.Direction,
+					Tenant:         lcr.Tenant,
+					Category:       lcr.Category,
+					Account:        lcr.Account,
+					Subject:        lcr.Subject,
+					DestinationTag: entry.DestinationId,
+					RpCategory:     entry.RPCategory,
+					Strategy:       entry.Strategy,
+					StrategyParams: entry.StrategyParams,
+					ActivationTime: act.ActivationTime,
+					Weight:         entry.Weight,
+					CreatedAt:      time.Now(),
+				})
+			}
+		}
+		if err := ms.setTpRows(colTpLcr, bson.M{"tpid": tpid, "tag": tag}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpLCRs(tpid, tag string) (map[string]*LCR, error) {
+	session, col := ms.conn(colTpLcr)
+	defer session.Close()
+	var tpLcrs []TpLcrEntry
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpLcrs); err != nil {
+		return nil, err
+	}
+	lcrs := make(map[string]*LCR)
+	for _, tpLcr := range tpLcrs {
+		lcr, found := lcrs[tpLcr.Tag]
+		if !found {
+			lcr = &LCR{Direction: tpLcr.Direction, Tenant: tpLcr.Tenant, Category: tpLcr.Category, Account: tpLcr.Account, Subject: tpLcr.Subject}
+			lcrs[tpLcr.Tag] = lcr
+		}
+		var act *LCRActivation
+		for _, existingAct := range lcr.Activations {
+			if existingAct.ActivationTime.Equal(tpLcr.ActivationTime) {
+				act = existingAct
+				break
+			}
+		}
+		if act == nil {
+			act = &LCRActivation{ActivationTime: tpLcr.ActivationTime}
+			lcr.Activations = append(lcr.Activations, act)
+		}
+		act.Entries = append(act.Entries, &LCREntry{
+			DestinationId:  tpLcr.DestinationTag,
+			RPCategory:     tpLcr.RpCategory,
+			Strategy:       tpLcr.Strategy,
+			StrategyParams: tpLcr.StrategyParams,
+			Weight:         tpLcr.Weight,
+		})
+	}
+	return lcrs, nil
+}
+
+func (ms *MongoStorage) SetTPActions(tpid string, acts map[string][]*utils.TPAction) error {
+	for acId, acs := range acts {
+		var rows []interface{}
+		for _, ac := range acs {
+			rows = append(rows, &TpAction{
+				Tpid:            tpid,
+				Tag:             acId,
+				Action:          ac.Identifier,
+				BalanceTag:      ac.BalanceId,
+				BalanceType:     ac.BalanceType,
+				Direction:       ac.Direction,
+				Units:           ac.Units,
+				ExpiryTime:      ac.ExpiryTime,
+				TimingTags:      ac.TimingTags,
+				DestinationTags: ac.DestinationIds,
+				RatingSubject:   ac.RatingSubject,
+				Category:        ac.Category,
+				SharedGroup:     ac.SharedGroup,
+				BalanceWeight:   ac.BalanceWeight,
+				ExtraParameters: ac.ExtraParameters,
+				Weight:          ac.Weight,
+				CreatedAt:       time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_ACTIONS, bson.M{"tpid": tpid, "tag": acId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpActions(tpid, tag string) (map[string][]*utils.TPAction, error) {
+	session, col := ms.conn(utils.TBL_TP_ACTIONS)
+	defer session.Close()
+	var tpActions []TpAction
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpActions); err != nil {
+		return nil, err
+	}
+	as := make(map[string][]*utils.TPAction)
+	for _, tpAc := range tpActions {
+		as[tpAc.Tag] = append(as[tpAc.Tag], &utils.TPAction{
+			Identifier:      tpAc.Action,
+			BalanceId:       tpAc.BalanceTag,
+			BalanceType:     tpAc.BalanceType,
+			Direction:       tpAc.Direction,
+			Units:           tpAc.Units,
+			ExpiryTime:      tpAc.ExpiryTime,
+			TimingTags:      tpAc.TimingTags,
+			DestinationIds:  tpAc.DestinationTags,
+			RatingSubject:   tpAc.RatingSubject,
+			Category:        tpAc.Category,
+			SharedGroup:     tpAc.SharedGroup,
+			BalanceWeight:   tpAc.BalanceWeight,
+			ExtraParameters: tpAc.ExtraParameters,
+			Weight:          tpAc.Weight,
+		})
+	}
+	return as, nil
+}
+
+// Sets actionTimings in mongo. Imput is expected in form map[actionTimingId][]rows, eg a full .csv file content
+func (ms *MongoStorage) SetTPActionTimings(tpid string, ats map[string][]*utils.TPActionTiming) error {
+	for apId, aPlans := range ats {
+		var rows []interface{}
+		for _, ap := range aPlans {
+			rows = append(rows, &TpActionPlan{
+				Tpid:       tpid,
+				Tag:        apId,
+				ActionsTag: ap.ActionsId,
+				TimingTag:  ap.TimingId,
+				Weight:     ap.Weight,
+				Timezone:   ap.Timezone,
+				CreatedAt:  time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_ACTION_PLANS, bson.M{"tpid": tpid, "tag": apId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTPActionTimings(tpid, tag string) (map[string][]*utils.TPActionTiming, error) {
+	session, col := ms.conn(utils.TBL_TP_ACTION_PLANS)
+	defer session.Close()
+	var tpActionPlans []TpActionPlan
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpActionPlans); err != nil {
+		return nil, err
+	}
+	ats := make(map[string][]*utils.TPActionTiming)
+	for _, tpAp := range tpActionPlans {
+		ats[tpAp.Tag] = append(ats[tpAp.Tag], &utils.TPActionTiming{ActionsId: tpAp.ActionsTag, TimingId: tpAp.TimingTag, Weight: tpAp.Weight, Timezone: tpAp.Timezone})
+	}
+	return ats, nil
+}
+
+func (ms *MongoStorage) SetTPActionTriggers(tpid string, ats map[string][]*utils.TPActionTrigger) error {
+	for atId, aTriggers := range ats {
+		var rows []interface{}
+		for _, at := range aTriggers {
+			id := at.Id
+			if id == "" {
+				id = utils.GenUUID()
+			}
+			rows = append(rows, &TpActionTrigger{
+				Tpid:                   tpid,
+				UniqueId:               id,
+				Tag:                    atId,
+				ThresholdType:          at.ThresholdType,
+				ThresholdValue:         at.ThresholdValue,
+				Recurrent:              at.Recurrent,
+				MinSleep:               at.MinSleep,
+				BalanceTag:             at.BalanceId,
+				BalanceType:            at.BalanceType,
+				BalanceDirection:       at.BalanceDirection,
+				BalanceDestinationTags: at.BalanceDestinationIds,
+				BalanceWeight:          at.BalanceWeight,
+				BalanceExpiryTime:      at.BalanceExpirationDate,
+				BalanceTimingTags:      at.BalanceTimingTags,
+				BalanceRatingSubject:   at.BalanceRatingSubject,
+				BalanceCategory:        at.BalanceCategory,
+				BalanceSharedGroup:     at.BalanceSharedGroup,
+				MinQueuedItems:         at.MinQueuedItems,
+				ActionsTag:             at.ActionsId,
+				Weight:                 at.Weight,
+				CreatedAt:              time.Now(),
+			})
+		}
+		if err := ms.setTpRows(utils.TBL_TP_ACTION_TRIGGERS, bson.M{"tpid": tpid, "tag": atId}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpActionTriggers(tpid, tag string) (map[string][]*utils.TPActionTrigger, error) {
+	session, col := ms.conn(utils.TBL_TP_ACTION_TRIGGERS)
+	defer session.Close()
+	var tpActionTriggers []TpActionTrigger
+	if err := col.Find(mgoTpQuery(tpid, tag)).All(&tpActionTriggers); err != nil {
+		return nil, err
+	}
+	ats := make(map[string][]*utils.TPActionTrigger)
+	for _, tpAt := range tpActionTriggers {
+		ats[tpAt.Tag] = append(ats[tpAt.Tag], &utils.TPActionTrigger{
+			Id:                    tpAt.UniqueId,
+			ThresholdType:         tpAt.ThresholdType,
+			ThresholdValue:        tpAt.ThresholdValue,
+			Recurrent:             tpAt.Recurrent,
+			MinSleep:              tpAt.MinSleep,
+			BalanceId:             tpAt.BalanceTag,
+			BalanceType:           tpAt.BalanceType,
+			BalanceDirection:      tpAt.BalanceDirection,
+			BalanceDestinationIds: tpAt.BalanceDestinationTags,
+			BalanceWeight:         tpAt.BalanceWeight,
+			BalanceExpirationDate: tpAt.BalanceExpiryTime,
+			BalanceTimingTags:     tpAt.BalanceTimingTags,
+			BalanceRatingSubject:  tpAt.BalanceRatingSubject,
+			BalanceCategory:       tpAt.BalanceCategory,
+			BalanceSharedGroup:    tpAt.BalanceSharedGroup,
+			Weight:                tpAt.Weight,
+			ActionsId:             tpAt.ActionsTag,
+			MinQueuedItems:        tpAt.MinQueuedItems,
+		})
+	}
+	return ats, nil
+}
+
+// Sets a group of account actions. Map key has the role of grouping within a tpid
+func (ms *MongoStorage) SetTPAccountActions(tpid string, aas map[string]*utils.TPAccountActions) error {
+	for _, aa := range aas {
+		if err := ms.setTpRows(utils.TBL_TP_ACCOUNT_ACTIONS, bson.M{"tpid": tpid, "loadid": aa.LoadId, "direction": aa.Direction, "tenant": aa.Tenant, "account": aa.Account},
+			[]interface{}{&TpAccountAction{
+				Tpid:              aa.TPid,
+				Loadid:            aa.LoadId,
+				Tenant:            aa.Tenant,
+				Account:           aa.Account,
+				Direction:         aa.Direction,
+				ActionPlanTag:     aa.ActionPlanId,
+				ActionTriggersTag: aa.ActionTriggersId,
+				CreatedAt:         time.Now(),
+			}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MongoStorage) GetTpAccountActions(aaFltr *utils.TPAccountActions) (map[string]*utils.TPAccountActions, error) {
+	session, col := ms.conn(utils.TBL_TP_ACCOUNT_ACTIONS)
+	defer session.Close()
+	qry := bson.M{"tpid": aaFltr.TPid}
+	for fld, val := range map[string]string{"direction": aaFltr.Direction, "tenant": aaFltr.Tenant, "account": aaFltr.Account, "loadid": aaFltr.LoadId} {
+		if len(val) != 0 {
+			qry[fld] = val
+		}
+	}
+	var tpAccActs []TpAccountAction
+	if err := col.Find(qry).All(&tpAccActs); err != nil {
+		return nil, err
+	}
+	aas := make(map[string]*utils.TPAccountActions)
+	for _, tpAa := range tpAccActs {
+		aacts := &utils.TPAccountActions{
+			TPid:             tpAa.Tpid,
+			LoadId:           tpAa.Loadid,
+			Tenant:           tpAa.Tenant,
+			Account:          tpAa.Account,
+			Direction:        tpAa.Direction,
+			ActionPlanId:     tpAa.ActionPlanTag,
+			ActionTriggersId: tpAa.ActionTriggersTag,
+		}
+		aas[aacts.KeyId()] = aacts
+	}
+	return aas, nil
+}
+
+// Stores the original CDR, derived ones are stored with SetRatedCdr
+func (ms *MongoStorage) SetCdr(cdr *StoredCdr) error {
+	orderId, err := ms.nextSeq(colCdr)
+	if err != nil {
+		return err
+	}
+	raw := *cdr
+	raw.CostDetails = nil // Costs are kept in the call cost logs
+	session, col := ms.conn(colCdr)
+	defer session.Close()
+	// Derived runs received before the original one will now show up
+	chng, err := col.UpdateAll(bson.M{"cgrid": cdr.CgrId, "runid": bson.M{"$ne": ""}}, bson.M{"$set": bson.M{"raw": &raw, "orderid": orderId}})
+	if err != nil {
+		return err
+	}
+	if chng.Matched != 0 { // Already joined with its runs
+		return nil
+	}
+	now := time.Now()
+	_, err = col.Upsert(bson.M{"_id": utils.ConcatenatedKey(cdr.CgrId, "")},
+		&CdrEntry{Id: utils.ConcatenatedKey(cdr.CgrId, ""), CgrId: cdr.CgrId, OrderId: orderId, Raw: &raw, CreatedAt: now, UpdatedAt: now})
+	return err
+}
+
+func (ms *MongoStorage) SetRatedCdr(storedCdr *StoredCdr) error {
+	session, col := ms.conn(colCdr)
+	defer session.Close()
+	rated := *storedCdr
+	rated.CostDetails = nil
+	now := time.Now()
+	setOnInsert := bson.M{"createdat": now}
+	orig := new(CdrEntry)
+	if err := col.Find(bson.M{"cgrid": storedCdr.CgrId, "raw": bson.M{"$exists": true}}).One(orig); err == nil {
+		setOnInsert["raw"], setOnInsert["orderid"] = orig.Raw, orig.OrderId
+	} else if err != mgo.ErrNotFound {
+		return err
+	}
+	if _, err := col.Upsert(bson.M{"_id": utils.ConcatenatedKey(storedCdr.CgrId, storedCdr.MediationRunId)},
+		bson.M{"$set": bson.M{"cgrid": storedCdr.CgrId, "runid": storedCdr.MediationRunId, "rated": &rated, "updatedat": now}, "$setOnInsert": setOnInsert}); err != nil {
+		return err
+	}
+	// Once rated, the original CDR is only returned joined with its runs
+	if err := col.RemoveId(utils.ConcatenatedKey(storedCdr.CgrId, "")); err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// Builds the cgrid/runid pairs having cost details matching the rated account and subject filters
+func (ms *MongoStorage) ratedCdrKeys(qryFltr *utils.CdrsFilter) ([]bson.M, error) {
+	qry := bson.M{}
+	acntQry := bson.M{}
+	if len(qryFltr.RatedAccounts) != 0 {
+		acntQry["$in"] = qryFltr.RatedAccounts
+	}
+	if len(qryFltr.NotRatedAccounts) != 0 {
+		acntQry["$nin"] = qryFltr.NotRatedAccounts
+	}
+	if len(acntQry) != 0 {
+		qry["callcost.account"] = acntQry
+	}
+	subjQry := bson.M{}
+	if len(qryFltr.RatedSubjects) != 0 {
+		subjQry["$in"] = qryFltr.RatedSubjects
+	}
+	if len(qryFltr.NotRatedSubjects) != 0 {
+		subjQry["$nin"] = qryFltr.NotRatedSubjects
+	}
+	if len(subjQry) != 0 {
+		qry["callcost.subject"] = subjQry
+	}
+	session, col := ms.conn(colLcc)
+	defer session.Close()
+	var entries []LogCostEntry
+	if err := col.Find(qry).Select(bson.M{"cgrid": 1, "runid": 1}).All(&entries); err != nil {
+		return nil, err
+	}
+	keys := make([]bson.M, len(entries))
+	for idx, entry := range entries {
+		keys[idx] = bson.M{"cgrid": entry.CgrId, "runid": entry.RunId}
+	}
+	return keys, nil
+}
+
+// Adds $in/$nin conditions for a field
+func mgoInFilter(conds []bson.M, fld string, in, notIn interface{}, hasIn, hasNotIn bool) []bson.M {
+	if hasIn {
+		conds = append(conds, bson.M{fld: bson.M{"$in": in}})
+	}
+	if hasNotIn {
+		conds = append(conds, bson.M{fld: bson.M{"$nin": notIn}})
+	}
+	return conds
+}
+
+func (ms *MongoStorage) GetStoredCdrs(qryFltr *utils.CdrsFilter) ([]*StoredCdr, int64, error) {
+	conds := []bson.M{
+		bson.M{"raw": bson.M{"$exists": true}},
+		bson.M{"$or": []bson.M{bson.M{"runid": ""}, bson.M{"rated": bson.M{"$exists": true}}}},
+	}
+	fldPrfx := "raw." // We use different fields to query account data in case of derived
+	if qryFltr.FilterOnDerived {
+		fldPrfx = "rated."
+	}
+	conds = mgoInFilter(conds, "cgrid", qryFltr.CgrIds, qryFltr.NotCgrIds, len(qryFltr.CgrIds) != 0, len(qryFltr.NotCgrIds) != 0)
+	conds = mgoInFilter(conds, "runid", qryFltr.RunIds, qryFltr.NotRunIds, len(qryFltr.RunIds) != 0, len(qryFltr.NotRunIds) != 0)
+	if len(qryFltr.NotRunIds) != 0 { // Not rated CDRs have no runid so they are out as in SQL NOT IN
+		conds = append(conds, bson.M{"rated": bson.M{"$exists": true}})
+	}
+	conds = mgoInFilter(conds, "raw.tor", qryFltr.Tors, qryFltr.NotTors, len(qryFltr.Tors) != 0, len(qryFltr.NotTors) != 0)
+	conds = mgoInFilter(conds, "raw.cdrhost", qryFltr.CdrHosts, qryFltr.NotCdrHosts, len(qryFltr.CdrHosts) != 0, len(qryFltr.NotCdrHosts) != 0)
+	conds = mgoInFilter(conds, "raw.cdrsource", qryFltr.CdrSources, qryFltr.NotCdrSources, len(qryFltr.CdrSources) != 0, len(qryFltr.NotCdrSources) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"reqtype", qryFltr.ReqTypes, qryFltr.NotReqTypes, len(qryFltr.ReqTypes) != 0, len(qryFltr.NotReqTypes) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"direction", qryFltr.Directions, qryFltr.NotDirections, len(qryFltr.Directions) != 0, len(qryFltr.NotDirections) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"tenant", qryFltr.Tenants, qryFltr.NotTenants, len(qryFltr.Tenants) != 0, len(qryFltr.NotTenants) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"category", qryFltr.Categories, qryFltr.NotCategories, len(qryFltr.Categories) != 0, len(qryFltr.NotCategories) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"account", qryFltr.Accounts, qryFltr.NotAccounts, len(qryFltr.Accounts) != 0, len(qryFltr.NotAccounts) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"subject", qryFltr.Subjects, qryFltr.NotSubjects, len(qryFltr.Subjects) != 0, len(qryFltr.NotSubjects) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"supplier", qryFltr.Suppliers, qryFltr.NotSuppliers, len(qryFltr.Suppliers) != 0, len(qryFltr.NotSuppliers) != 0)
+	conds = mgoInFilter(conds, fldPrfx+"disconnectcause", qryFltr.DisconnectCauses, qryFltr.NotDisconnectCauses, len(qryFltr.DisconnectCauses) != 0, len(qryFltr.NotDisconnectCauses) != 0)
+	conds = mgoInFilter(conds, "rated.cost", qryFltr.Costs, qryFltr.NotCosts, len(qryFltr.Costs) != 0, len(qryFltr.NotCosts) != 0)
+	if len(qryFltr.DestPrefixes) != 0 {
+		var prfxs []bson.M
+		for _, destPrefix := range qryFltr.DestPrefixes {
+			prfxs = append(prfxs, bson.M{fldPrfx + "destination": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(destPrefix)}})
+		}
+		conds = append(conds, bson.M{"$or": prfxs})
+	}
+	if len(qryFltr.NotDestPrefixes) != 0 {
+		var prfxs []bson.M
+		for _, destPrefix := range qryFltr.NotDestPrefixes {
+			prfxs = append(prfxs, bson.M{fldPrfx + "destination": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(destPrefix)}})
+		}
+		conds = append(conds, bson.M{"$nor": prfxs})
+	}
+	if len(qryFltr.ExtraFields) != 0 { // Extra fields searches, any of them matching
+		var extraFlds []bson.M
+		for field, value := range qryFltr.ExtraFields {
+			extraFlds = append(extraFlds, bson.M{"raw.extrafields." + field: value})
+		}
+		conds = append(conds, bson.M{"$or": extraFlds})
+	}
+	if len(qryFltr.NotExtraFields) != 0 {
+		var extraFlds []bson.M
+		for field, value := range qryFltr.NotExtraFields {
+			extraFlds = append(extraFlds, bson.M{"raw.extrafields." + field: value})
+		}
+		conds = append(conds, bson.M{"$nor": extraFlds})
+	}
+	if len(qryFltr.RatedAccounts) != 0 || len(qryFltr.NotRatedAccounts) != 0 || len(qryFltr.RatedSubjects) != 0 || len(qryFltr.NotRatedSubjects) != 0 {
+		keys, err := ms.ratedCdrKeys(qryFltr)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(keys) == 0 { // Nothing rated matches
+			return nil, 0, nil
+		}
+		conds = append(conds, bson.M{"$or": keys})
+	}
+	if qryFltr.OrderIdStart != 0 { // Keep backwards compatible by testing 0 value
+		conds = append(conds, bson.M{"orderid": bson.M{"$gte": qryFltr.OrderIdStart}})
+	}
+	if qryFltr.OrderIdEnd != 0 {
+		conds = append(conds, bson.M{"orderid": bson.M{"$lt": qryFltr.OrderIdEnd}})
+	}
+	if qryFltr.SetupTimeStart != nil {
+		conds = append(conds, bson.M{fldPrfx + "setuptime": bson.M{"$gte": *qryFltr.SetupTimeStart}})
+	}
+	if qryFltr.SetupTimeEnd != nil {
+		conds = append(conds, bson.M{fldPrfx + "setuptime": bson.M{"$lt": *qryFltr.SetupTimeEnd}})
+	}
+	if qryFltr.AnswerTimeStart != nil && !qryFltr.AnswerTimeStart.IsZero() { // With IsZero we keep backwards compatible with ApierV1
+		conds = append(conds, bson.M{fldPrfx + "answertime": bson.M{"$gte": *qryFltr.AnswerTimeStart}})
+	}
+	if qryFltr.AnswerTimeEnd != nil && !qryFltr.AnswerTimeEnd.IsZero() {
+		conds = append(conds, bson.M{fldPrfx + "answertime": bson.M{"$lt": *qryFltr.AnswerTimeEnd}})
+	}
+	if qryFltr.CreatedAtStart != nil && !qryFltr.CreatedAtStart.IsZero() {
+		conds = append(conds, bson.M{"createdat": bson.M{"$gte": *qryFltr.CreatedAtStart}})
+	}
+	if qryFltr.CreatedAtEnd != nil && !qryFltr.CreatedAtEnd.IsZero() {
+		conds = append(conds, bson.M{"createdat": bson.M{"$lt": *qryFltr.CreatedAtEnd}})
+	}
+	if qryFltr.UpdatedAtStart != nil && !qryFltr.UpdatedAtStart.IsZero() {
+		conds = append(conds, bson.M{"updatedat": bson.M{"$gte": *qryFltr.UpdatedAtStart}})
+	}
+	if qryFltr.UpdatedAtEnd != nil && !qryFltr.UpdatedAtEnd.IsZero() {
+		conds = append(conds, bson.M{"updatedat": bson.M{"$lt": *qryFltr.UpdatedAtEnd}})
+	}
+	if qryFltr.UsageStart != nil { // Usage is stored as duration in nanoseconds
+		conds = append(conds, bson.M{fldPrfx + "usage": bson.M{"$gte": int64(*qryFltr.UsageStart * float64(time.Second))}})
+	}
+	if qryFltr.UsageEnd != nil {
+		conds = append(conds, bson.M{fldPrfx + "usage": bson.M{"$lt": int64(*qryFltr.UsageEnd * float64(time.Second))}})
+	}
+	if qryFltr.CostStart != nil {
+		if qryFltr.CostEnd == nil {
+			conds = append(conds, bson.M{"rated.cost": bson.M{"$gte": *qryFltr.CostStart}})
+		} else if *qryFltr.CostStart == 0.0 && *qryFltr.CostEnd == -1.0 { // Special case when we want to skip errors
+			conds = append(conds, bson.M{"$or": []bson.M{bson.M{"rated": bson.M{"$exists": false}}, bson.M{"rated.cost": bson.M{"$gte": 0.0}}}})
+		} else {
+			conds = append(conds, bson.M{"rated.cost": bson.M{"$gte": *qryFltr.CostStart, "$lt": *qryFltr.CostEnd}})
+		}
+	} else if qryFltr.CostEnd != nil {
+		if *qryFltr.CostEnd == -1.0 { // Non-rated CDRs
+			conds = append(conds, bson.M{"rated": bson.M{"$exists": false}})
+		} else { // Above limited CDRs, since costStart is empty, make sure we query also not rated ones
+			conds = append(conds, bson.M{"$or": []bson.M{bson.M{"rated": bson.M{"$exists": false}}, bson.M{"rated.cost": bson.M{"$lt": *qryFltr.CostEnd}}}})
+		}
+	}
+	session, col := ms.conn(colCdr)
+	defer session.Close()
+	q := mgoPaginate(col.Find(bson.M{"$and": conds}).Sort("orderid", "runid"), &qryFltr.Paginator)
+	if qryFltr.Count {
+		cnt, err := q.Count()
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, int64(cnt), nil
+	}
+	var entries []CdrEntry
+	if err := q.All(&entries); err != nil {
+		return nil, 0, err
+	}
+	var cdrs []*StoredCdr
+	for _, entry := range entries {
+		storCdr := *entry.Raw
+		storCdr.OrderId = entry.OrderId
+		storCdr.MediationRunId = entry.RunId
+		storCdr.Cost = -1 // There was no cost provided, will fakely insert 0 if we do not handle it and reflect on re-rating
+		if entry.Rated != nil {
+			if qryFltr.FilterOnDerived {
+				storCdr.ReqType, storCdr.Direction, storCdr.Tenant, storCdr.Category = entry.Rated.ReqType, entry.Rated.Direction, entry.Rated.Tenant, entry.Rated.Category
+				storCdr.Account, storCdr.Subject, storCdr.Destination = entry.Rated.Account, entry.Rated.Subject, entry.Rated.Destination
+				storCdr.SetupTime, storCdr.AnswerTime, storCdr.Usage = entry.Rated.SetupTime, entry.Rated.AnswerTime, entry.Rated.Usage
+				storCdr.Supplier, storCdr.DisconnectCause = entry.Rated.Supplier, entry.Rated.DisconnectCause
+			}
+			storCdr.Cost = entry.Rated.Cost
+			if cc, err := ms.GetCallCostLog(entry.CgrId, "", entry.RunId); err == nil && cc != nil {
+				storCdr.CostDetails = cc
+				storCdr.RatedAccount, storCdr.RatedSubject = cc.Account, cc.Subject
+			}
+		}
+		cdrs = append(cdrs, &storCdr)
+	}
+	return cdrs, 0, nil
+}
+
+// Remove CDR data out of all CDR collections based on their cgrid
+func (ms *MongoStorage) RemStoredCdrs(cgrIds []string) error {
+	if len(cgrIds) == 0 {
+		return nil
+	}
+	session := ms.session.Copy()
+	defer session.Close()
+	for _, colName := range []string{colCdr, colLcc} {
+		if _, err := session.DB(ms.db).C(colName).RemoveAll(bson.M{"cgrid": bson.M{"$in": cgrIds}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Removal is already permanent in mongo
+func (ms *MongoStorage) PurgeStoredCdrs(cgrIds []string) error {
+	return ms.RemStoredCdrs(cgrIds)
+}
+
+func (ms *MongoStorage) SetLedgerEntry(entry *LedgerEntry) error {
+	session, col := ms.conn(colLdg)
+	defer session.Close()
+	return col.Insert(entry)
+}
+
+// Stores the invoice, the ones without number get the next one in sequence
+func (ms *MongoStorage) SetInvoice(inv *Invoice) error {
+	if inv.Number == 0 {
+		number, err := ms.nextSeq(colInv)
+		if err != nil {
+			return err
+		}
+		inv.Number = number
+	}
+	session, col := ms.conn(colInv)
+	defer session.Close()
+	_, err := col.Upsert(bson.M{"number": inv.Number}, inv)
+	return err
+}
+
+// Returns the invoices of an account ordered by number, empty account for all of them, number for a specific one
+func (ms *MongoStorage) GetInvoices(accountId string, number int64) ([]*Invoice, error) {
+	qry := bson.M{}
+	if accountId != "" {
+		qry["accountid"] = accountId
+	}
+	if number != 0 {
+		qry["number"] = number
+	}
+	session, col := ms.conn(colInv)
+	defer session.Close()
+	var invs []*Invoice
+	if err := col.Find(qry).Sort("number").All(&invs); err != nil {
+		return nil, err
+	}
+	return invs, nil
+}
+
+// Returns the ledger of an account in the order it was written, zero times leave the interval open
+func (ms *MongoStorage) GetLedgerEntries(accountId string, timeStart, timeEnd time.Time) ([]*LedgerEntry, error) {
+	qry := bson.M{"accountid": accountId}
+	tmQry := bson.M{}
+	if !timeStart.IsZero() {
+		tmQry["$gte"] = timeStart
+	}
+	if !timeEnd.IsZero() {
+		tmQry["$lt"] = timeEnd
+	}
+	if len(tmQry) != 0 {
+		qry["timestamp"] = tmQry
+	}
+	session, col := ms.conn(colLdg)
+	defer session.Close()
+	var entries []*LedgerEntry
+	if err := col.Find(qry).Sort("timestamp", "_id").All(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,291 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+var mgoStorDb *MongoStorage
+
+func TestMongoStorDbConnect(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if mgoStorDb, err = NewMongoStorage("127.0.0.1", "27017", "cgrates_stordb_test", "", ""); err != nil {
+		t.Fatal("Could not connect to MongoDB", err.Error())
+	}
+	if err := mgoStorDb.Flush(""); err != nil {
+		t.Error("Failed to Flush mongo database", err.Error())
+	}
+}
+
+func TestMongoStorDbSetGetTPTiming(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	tm := &utils.ApierTPTiming{TPid: TEST_SQL, TimingId: "ALWAYS", Time: "00:00:00"}
+	if err := mgoStorDb.SetTPTiming(tm); err != nil {
+		t.Error(err.Error())
+	}
+	if tmgs, err := mgoStorDb.GetTpTimings(TEST_SQL, tm.TimingId); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(tm, tmgs[tm.TimingId]) {
+		t.Errorf("Expecting: %+v, received: %+v", tm, tmgs[tm.TimingId])
+	}
+	// Update
+	tm.Time = "00:00:01"
+	if err := mgoStorDb.SetTPTiming(tm); err != nil {
+		t.Error(err.Error())
+	}
+	if tmgs, err := mgoStorDb.GetTpTimings(TEST_SQL, tm.TimingId); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(tm, tmgs[tm.TimingId]) {
+		t.Errorf("Expecting: %+v, received: %+v", tm, tmgs[tm.TimingId])
+	}
+}
+
+func TestMongoStorDbSetGetTPDestination(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	dst := &Destination{Id: TEST_SQL, Prefixes: []string{"+49", "+49151", "+49176"}}
+	if err := mgoStorDb.SetTPDestination(TEST_SQL, dst); err != nil {
+		t.Error(err.Error())
+	}
+	if dsts, err := mgoStorDb.GetTpDestinations(TEST_SQL, TEST_SQL); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(dst, dsts[TEST_SQL]) {
+		t.Errorf("Expecting: %+v, received: %+v", dst, dsts[TEST_SQL])
+	}
+}
+
+func TestMongoStorDbSetGetTPRatingProfiles(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	ras := []*utils.TPRatingActivation{&utils.TPRatingActivation{ActivationTime: "2012-01-01T00:00:00Z", RatingPlanId: TEST_SQL}}
+	rp := &utils.TPRatingProfile{TPid: TEST_SQL, LoadId: TEST_SQL, Tenant: "cgrates.org", Category: "call", Direction: "*out", Subject: "*any", RatingPlanActivations: ras}
+	if err := mgoStorDb.SetTPRatingProfiles(TEST_SQL, map[string]*utils.TPRatingProfile{rp.KeyId(): rp}); err != nil {
+		t.Error(err.Error())
+	}
+	if rps, err := mgoStorDb.GetTpRatingProfiles(rp); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(rp, rps[rp.KeyId()]) {
+		t.Errorf("Expecting: %v, received: %v", rp, rps[rp.KeyId()])
+	}
+	if ids, err := mgoStorDb.GetTPTableIds(TEST_SQL, utils.TBL_TP_RATE_PROFILES, utils.TPDistinctIds{"loadid", "direction", "tenant", "category", "subject"}, nil, nil); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual([]string{rp.KeyId()}, ids) {
+		t.Errorf("Received: %v", ids)
+	}
+}
+
+func TestMongoStorDbGetTPIds(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if tpIds, err := mgoStorDb.GetTPIds(); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual([]string{TEST_SQL}, tpIds) {
+		t.Errorf("Received: %v", tpIds)
+	}
+}
+
+func TestMongoStorDbRemoveTPData(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if err := mgoStorDb.RemTPData(utils.TBL_TP_TIMINGS, TEST_SQL, "ALWAYS"); err != nil {
+		t.Error(err.Error())
+	}
+	if tmgs, err := mgoStorDb.GetTpTimings(TEST_SQL, "ALWAYS"); err != nil {
+		t.Error(err.Error())
+	} else if len(tmgs) != 0 {
+		t.Errorf("Timings should be empty, got instead: %+v", tmgs)
+	}
+	if err := mgoStorDb.RemTPData("", TEST_SQL); err != nil {
+		t.Error(err.Error())
+	}
+	if tpIds, err := mgoStorDb.GetTPIds(); err != nil {
+		t.Error(err.Error())
+	} else if len(tpIds) != 0 {
+		t.Errorf("Received: %v", tpIds)
+	}
+}
+
+func TestMongoStorDbSetCdr(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	for idx, cdr := range []*StoredCdr{
+		&StoredCdr{TOR: utils.VOICE, AccId: "bbb1", CdrHost: "192.168.1.1", CdrSource: TEST_SQL, ReqType: utils.META_RATED, Direction: "*out", Tenant: "cgrates.org",
+			Category: "call", Account: "1001", Subject: "1001", Destination: "1002", SetupTime: time.Date(2013, 12, 7, 8, 42, 24, 0, time.UTC),
+			AnswerTime: time.Date(2013, 12, 7, 8, 42, 26, 0, time.UTC), Usage: time.Duration(10) * time.Second, ExtraFields: map[string]string{"field_extr1": "val_extr1"}},
+		&StoredCdr{TOR: utils.VOICE, AccId: "bbb2", CdrHost: "192.168.1.2", CdrSource: TEST_SQL, ReqType: utils.META_PREPAID, Direction: "*out", Tenant: "itsyscom.com",
+			Category: "call", Account: "1002", Subject: "1002", Destination: "+4986517174963", SetupTime: time.Date(2013, 12, 7, 8, 42, 25, 0, time.UTC),
+			AnswerTime: time.Date(2013, 12, 7, 8, 42, 26, 0, time.UTC), Usage: time.Duration(20) * time.Second, ExtraFields: map[string]string{"field_extr1": "val_extr2"}},
+	} {
+		cdr.CgrId = utils.Sha1(cdr.AccId, cdr.SetupTime.String())
+		if err := mgoStorDb.SetCdr(cdr); err != nil {
+			t.Error(err.Error())
+		}
+		if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{CgrIds: []string{cdr.CgrId}}); err != nil {
+			t.Error(err.Error())
+		} else if len(cdrs) != 1 {
+			t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+		} else if cdrs[0].OrderId != int64(idx+1) || cdrs[0].Cost != -1 || cdrs[0].MediationRunId != "" {
+			t.Errorf("Unexpected CDR returned: %+v", cdrs[0])
+		}
+	}
+}
+
+func TestMongoStorDbSetRatedCdr(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{Accounts: []string{"1001"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Fatalf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	for _, runId := range []string{utils.DEFAULT_RUNID, "run2"} {
+		ratedCdr := *cdrs[0]
+		ratedCdr.MediationRunId = runId
+		ratedCdr.Account = "1003"
+		ratedCdr.Cost = 1.01
+		if err := mgoStorDb.SetRatedCdr(&ratedCdr); err != nil {
+			t.Error(err.Error())
+		}
+	}
+	cc := &CallCost{Direction: "*out", Category: "call", Tenant: "cgrates.org", Subject: "1001", Account: "1003", Destination: "1002", Cost: 1.01,
+		Timespans: TimeSpans{&TimeSpan{TimeStart: cdrs[0].AnswerTime, TimeEnd: cdrs[0].AnswerTime.Add(cdrs[0].Usage)}}}
+	if err := mgoStorDb.LogCallCost(cdrs[0].CgrId, TEST_SQL, utils.DEFAULT_RUNID, cc); err != nil {
+		t.Error(err.Error())
+	}
+	if rcvCc, err := mgoStorDb.GetCallCostLog(cdrs[0].CgrId, "", utils.DEFAULT_RUNID); err != nil {
+		t.Error(err.Error())
+	} else if rcvCc.Cost != cc.Cost {
+		t.Errorf("Expecting call cost: %v, received: %v", cc, rcvCc)
+	}
+}
+
+func TestMongoStorDbGetStoredCdrs(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	// All CDRs, the rated one joined with its runs
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 3 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	if _, count, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{Count: true}); err != nil {
+		t.Error(err.Error())
+	} else if count != 3 {
+		t.Errorf("Unexpected count of CDRs returned: %d", count)
+	}
+	// Filter on runids
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{RunIds: []string{utils.DEFAULT_RUNID}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	} else if cdrs[0].RatedAccount != "1003" || cdrs[0].CostDetails == nil {
+		t.Errorf("Unexpected CDR returned: %+v", cdrs[0])
+	}
+	// Original and derived accounts
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{Accounts: []string{"1001"}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 2 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{Accounts: []string{"1003"}, FilterOnDerived: true}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 2 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Rated accounts out of cost details
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{RatedAccounts: []string{"1003"}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Destination prefixes
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{DestPrefixes: []string{"+49"}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Extra fields
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{ExtraFields: map[string]string{"field_extr1": "val_extr2"}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Not rated
+	costEnd := -1.0
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{CostEnd: &costEnd}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Usage
+	usageStart := 15.0
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{UsageStart: &usageStart}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 1 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+	// Paginator
+	limit := 2
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{Paginator: utils.Paginator{Limit: &limit}}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 2 {
+		t.Errorf("Unexpected number of CDRs returned: %+v", cdrs)
+	}
+}
+
+func TestMongoStorDbRemStoredCdrs(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var cgrIds []string
+	for _, cdr := range cdrs {
+		if !utils.IsSliceMember(cgrIds, cdr.CgrId) {
+			cgrIds = append(cgrIds, cdr.CgrId)
+		}
+	}
+	if err := mgoStorDb.RemStoredCdrs(cgrIds); err != nil {
+		t.Error(err.Error())
+	}
+	if cdrs, _, err := mgoStorDb.GetStoredCdrs(&utils.CdrsFilter{}); err != nil {
+		t.Error(err.Error())
+	} else if len(cdrs) != 0 {
+		t.Errorf("Unexpected CDRs returned: %+v", cdrs)
+	}
+}
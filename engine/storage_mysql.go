@@ -82,7 +82,7 @@ func (self *MySQLStorage) LogCallCost(cgrid, source, runid string, cc *CallCost)
 		Logger.Err(fmt.Sprintf("Error marshalling timespans to json: %v", err))
 		return err
 	}
-	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,tor,direction,tenant,category,account,subject,destination,cost,timespans,cost_source,created_at) VALUES ('%s','%s','%s','%s','%s','%s','%s','%s','%s',%f,'%s','%s','%s') ON DUPLICATE KEY UPDATE tor=values(tor),direction=values(direction),tenant=values(tenant),category=values(category),account=values(account),subject=values(subject),destination=values(destination),cost=values(cost),timespans=values(timespans),cost_source=values(cost_source),updated_at='%s'",
+	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,tor,direction,tenant,category,account,subject,destination,cost,currency,debited_cost,debited_currency,tax,gross_cost,timespans,cost_source,created_at) VALUES ('%s','%s','%s','%s','%s','%s','%s','%s','%s',%f,'%s',%f,'%s',%f,%f,'%s','%s','%s') ON DUPLICATE KEY UPDATE tor=values(tor),direction=values(direction),tenant=values(tenant),category=values(category),account=values(account),subject=values(subject),destination=values(destination),cost=values(cost),currency=values(currency),debited_cost=values(debited_cost),debited_currency=values(debited_currency),tax=values(tax),gross_cost=values(gross_cost),timespans=values(timespans),cost_source=values(cost_source),updated_at='%s'",
 		utils.TBL_COST_DETAILS,
 		cgrid,
 		runid,
@@ -94,6 +94,11 @@ func (self *MySQLStorage) LogCallCost(cgrid, source, runid string, cc *CallCost)
 		cc.Subject,
 		cc.Destination,
 		cc.Cost,
+		cc.Currency,
+		cc.DebitedCost,
+		cc.DebitedCurrency,
+		cc.Tax,
+		cc.GrossCost,
 		tss,
 		source,
 		time.Now().Format(time.RFC3339),
@@ -106,7 +111,7 @@ func (self *MySQLStorage) LogCallCost(cgrid, source, runid string, cc *CallCost)
 }
 
 func (self *MySQLStorage) SetRatedCdr(storedCdr *StoredCdr) (err error) {
-	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,reqtype,direction,tenant,category,account,subject,destination,setup_time,answer_time,`usage`,supplier,disconnect_cause,cost,extra_info,created_at) VALUES ('%s','%s','%s','%s','%s','%s','%s','%s','%s','%s','%s',%v,'%s','%s',%f,'%s','%s') ON DUPLICATE KEY UPDATE reqtype=values(reqtype),direction=values(direction),tenant=values(tenant),category=values(category),account=values(account),subject=values(subject),destination=values(destination),setup_time=values(setup_time),answer_time=values(answer_time),`usage`=values(`usage`),cost=values(cost),supplier=values(supplier),disconnect_cause=values(disconnect_cause),extra_info=values(extra_info), updated_at='%s'",
+	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,reqtype,direction,tenant,category,account,subject,destination,setup_time,answer_time,`usage`,supplier,disconnect_cause,cost,currency,debited_cost,debited_currency,tax,gross_cost,resolved_destination,extra_info,created_at) VALUES ('%s','%s','%s','%s','%s','%s','%s','%s','%s','%s','%s',%v,'%s','%s',%f,'%s',%f,'%s',%f,%f,'%s','%s','%s') ON DUPLICATE KEY UPDATE reqtype=values(reqtype),direction=values(direction),tenant=values(tenant),category=values(category),account=values(account),subject=values(subject),destination=values(destination),setup_time=values(setup_time),answer_time=values(answer_time),`usage`=values(`usage`),cost=values(cost),currency=values(currency),debited_cost=values(debited_cost),debited_currency=values(debited_currency),tax=values(tax),gross_cost=values(gross_cost),resolved_destination=values(resolved_destination),supplier=values(supplier),disconnect_cause=values(disconnect_cause),extra_info=values(extra_info), updated_at='%s'",
 		utils.TBL_RATED_CDRS,
 		storedCdr.CgrId,
 		storedCdr.MediationRunId,
@@ -123,6 +128,12 @@ func (self *MySQLStorage) SetRatedCdr(storedCdr *StoredCdr) (err error) {
 		storedCdr.Supplier,
 		storedCdr.DisconnectCause,
 		storedCdr.Cost,
+		storedCdr.Currency,
+		storedCdr.DebitedCost,
+		storedCdr.DebitedCurrency,
+		storedCdr.Tax,
+		storedCdr.GrossCost,
+		storedCdr.ResolvedDestination,
 		storedCdr.ExtraInfo,
 		time.Now().Format(time.RFC3339),
 		time.Now().Format(time.RFC3339)))
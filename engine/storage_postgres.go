@@ -94,26 +94,31 @@ func (self *PostgresStorage) LogCallCost(cgrid, source, runid string, cc *CallCo
 	}
 	tx := self.db.Begin()
 	cd := &TblCostDetail{
-		Cgrid:       cgrid,
-		Runid:       runid,
-		Tor:         cc.TOR,
-		Direction:   cc.Direction,
-		Tenant:      cc.Tenant,
-		Category:    cc.Category,
-		Account:     cc.Account,
-		Subject:     cc.Subject,
-		Destination: cc.Destination,
-		Cost:        cc.Cost,
-		Timespans:   string(tss),
-		CostSource:  source,
-		CreatedAt:   time.Now(),
+		Cgrid:           cgrid,
+		Runid:           runid,
+		Tor:             cc.TOR,
+		Direction:       cc.Direction,
+		Tenant:          cc.Tenant,
+		Category:        cc.Category,
+		Account:         cc.Account,
+		Subject:         cc.Subject,
+		Destination:     cc.Destination,
+		Cost:            cc.Cost,
+		Currency:        cc.Currency,
+		DebitedCost:     cc.DebitedCost,
+		DebitedCurrency: cc.DebitedCurrency,
+		Tax:             cc.Tax,
+		GrossCost:       cc.GrossCost,
+		Timespans:       string(tss),
+		CostSource:      source,
+		CreatedAt:       time.Now(),
 	}
 
 	if tx.Save(cd).Error != nil { // Check further since error does not properly reflect duplicates here (sql: no rows in result set)
 		tx.Rollback()
 		tx = self.db.Begin()
 		updated := tx.Model(TblCostDetail{}).Where(&TblCostDetail{Cgrid: cgrid, Runid: runid}).Updates(&TblCostDetail{Tor: cc.TOR, Direction: cc.Direction, Tenant: cc.Tenant, Category: cc.Category,
-			Account: cc.Account, Subject: cc.Subject, Destination: cc.Destination, Cost: cc.Cost, Timespans: string(tss), CostSource: source, UpdatedAt: time.Now()})
+			Account: cc.Account, Subject: cc.Subject, Destination: cc.Destination, Cost: cc.Cost, Currency: cc.Currency, DebitedCost: cc.DebitedCost, DebitedCurrency: cc.DebitedCurrency, Tax: cc.Tax, GrossCost: cc.GrossCost, Timespans: string(tss), CostSource: source, UpdatedAt: time.Now()})
 		if updated.Error != nil {
 			tx.Rollback()
 			return updated.Error
@@ -126,30 +131,37 @@ func (self *PostgresStorage) LogCallCost(cgrid, source, runid string, cc *CallCo
 func (self *PostgresStorage) SetRatedCdr(cdr *StoredCdr) (err error) {
 	tx := self.db.Begin()
 	saved := tx.Save(&TblRatedCdr{
-		Cgrid:           cdr.CgrId,
-		Runid:           cdr.MediationRunId,
-		Reqtype:         cdr.ReqType,
-		Direction:       cdr.Direction,
-		Tenant:          cdr.Tenant,
-		Category:        cdr.Category,
-		Account:         cdr.Account,
-		Subject:         cdr.Subject,
-		Destination:     cdr.Destination,
-		SetupTime:       cdr.SetupTime,
-		AnswerTime:      cdr.AnswerTime,
-		Usage:           cdr.Usage.Seconds(),
-		Supplier:        cdr.Supplier,
-		DisconnectCause: cdr.DisconnectCause,
-		Cost:            cdr.Cost,
-		ExtraInfo:       cdr.ExtraInfo,
-		CreatedAt:       time.Now(),
+		Cgrid:               cdr.CgrId,
+		Runid:               cdr.MediationRunId,
+		Reqtype:             cdr.ReqType,
+		Direction:           cdr.Direction,
+		Tenant:              cdr.Tenant,
+		Category:            cdr.Category,
+		Account:             cdr.Account,
+		Subject:             cdr.Subject,
+		Destination:         cdr.Destination,
+		SetupTime:           cdr.SetupTime,
+		AnswerTime:          cdr.AnswerTime,
+		Usage:               cdr.Usage.Seconds(),
+		Supplier:            cdr.Supplier,
+		DisconnectCause:     cdr.DisconnectCause,
+		Cost:                cdr.Cost,
+		Currency:            cdr.Currency,
+		DebitedCost:         cdr.DebitedCost,
+		DebitedCurrency:     cdr.DebitedCurrency,
+		Tax:                 cdr.Tax,
+		GrossCost:           cdr.GrossCost,
+		ResolvedDestination: cdr.ResolvedDestination,
+		ExtraInfo:           cdr.ExtraInfo,
+		CreatedAt:           time.Now(),
 	})
 	if saved.Error != nil {
 		tx.Rollback()
 		tx = self.db.Begin()
 		updated := tx.Model(TblRatedCdr{}).Where(&TblRatedCdr{Cgrid: cdr.CgrId, Runid: cdr.MediationRunId}).Updates(&TblRatedCdr{Reqtype: cdr.ReqType,
 			Direction: cdr.Direction, Tenant: cdr.Tenant, Category: cdr.Category, Account: cdr.Account, Subject: cdr.Subject, Destination: cdr.Destination,
-			SetupTime: cdr.SetupTime, AnswerTime: cdr.AnswerTime, Usage: cdr.Usage.Seconds(), Supplier: cdr.Supplier, DisconnectCause: cdr.DisconnectCause, Cost: cdr.Cost, ExtraInfo: cdr.ExtraInfo,
+			SetupTime: cdr.SetupTime, AnswerTime: cdr.AnswerTime, Usage: cdr.Usage.Seconds(), Supplier: cdr.Supplier, DisconnectCause: cdr.DisconnectCause, Cost: cdr.Cost,
+			Currency: cdr.Currency, DebitedCost: cdr.DebitedCost, DebitedCurrency: cdr.DebitedCurrency, Tax: cdr.Tax, GrossCost: cdr.GrossCost, ResolvedDestination: cdr.ResolvedDestination, ExtraInfo: cdr.ExtraInfo,
 			UpdatedAt: time.Now()})
 		if updated.Error != nil {
 			tx.Rollback()
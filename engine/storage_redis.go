@@ -29,21 +29,25 @@ import (
 	"github.com/hoisie/redis"
 
 	"io/ioutil"
+	"strconv"
+	"sync"
 	"time"
 )
 
 type RedisStorage struct {
-	dbNb int
-	db   *redis.Client
-	ms   Marshaler
+	dbNb       int
+	db         *redis.Client
+	dbMux      sync.RWMutex // db is replaced on reconnects
+	pass       string
+	sentinels  []string // when present the master address is discovered over sentinels
+	masterName string
+	ms         Marshaler
 }
 
 func NewRedisStorage(address string, db int, pass, mrshlerStr string) (*RedisStorage, error) {
-	ndb := &redis.Client{Addr: address, Db: db}
-	if pass != "" {
-		if err := ndb.Auth(pass); err != nil {
-			return nil, err
-		}
+	ndb, err := newRedisClient(address, db, pass)
+	if err != nil {
+		return nil, err
 	}
 
 	var mrshler Marshaler
@@ -54,7 +58,7 @@ func NewRedisStorage(address string, db int, pass, mrshlerStr string) (*RedisSto
 	} else {
 		return nil, fmt.Errorf("Unsupported marshaler: %v", mrshlerStr)
 	}
-	return &RedisStorage{db: ndb, dbNb: db, ms: mrshler}, nil
+	return &RedisStorage{db: ndb, dbNb: db, pass: pass, ms: mrshler}, nil
 }
 
 func (rs *RedisStorage) Close() {
@@ -63,24 +67,46 @@ func (rs *RedisStorage) Close() {
 }
 
 func (rs *RedisStorage) Flush(ignore string) (err error) {
-	err = rs.db.Flush(false)
+	err = rs.do(func(db *redis.Client) error {
+		return db.Flush(false)
+	})
+	return
+}
+
+func (rs *RedisStorage) GetVersion(item string) (version int64, err error) {
+	key := VERSION_PREFIX + item
+	if exists, err := rs.exists(key); err != nil {
+		return 0, err
+	} else if !exists {
+		return 0, errors.New(utils.ERR_NOT_FOUND)
+	}
+	var values []byte
+	if values, err = rs.get(key); err == nil {
+		version, err = strconv.ParseInt(string(values), 10, 64)
+	}
 	return
 }
 
+func (rs *RedisStorage) SetVersion(item string, version int64) error {
+	return rs.set(VERSION_PREFIX+item, []byte(strconv.FormatInt(version, 10)))
+}
+
 func (rs *RedisStorage) GetKeysForPrefix(prefix string) ([]string, error) {
-	return rs.db.Keys(prefix + "*")
+	return rs.keys(prefix + "*")
 }
 
 func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []string) (err error) {
 	cache2go.BeginTransaction()
-	if dKeys == nil || (float64(cache2go.CountEntries(DESTINATION_PREFIX))*DESTINATIONS_LOAD_THRESHOLD < float64(len(dKeys))) {
+	beginDestinationIndexing()
+	defer func() { endDestinationIndexing(err == nil) }()
+	if dKeys == nil || (float64(CountCachedDestPrefixes())*DESTINATIONS_LOAD_THRESHOLD < float64(len(dKeys))) {
 		// if need to load more than a half of exiting keys load them all
 		Logger.Info("Caching all destinations")
-		if dKeys, err = rs.db.Keys(DESTINATION_PREFIX + "*"); err != nil {
+		if dKeys, err = rs.keys(DESTINATION_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
-		cache2go.RemPrefixKey(DESTINATION_PREFIX)
+		resetDestinationIndex()
 	} else if len(dKeys) != 0 {
 		Logger.Info(fmt.Sprintf("Caching destinations: %v", dKeys))
 		CleanStalePrefixes(dKeys)
@@ -100,7 +126,7 @@ func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []s
 	}
 	if rpKeys == nil {
 		Logger.Info("Caching all rating plans")
-		if rpKeys, err = rs.db.Keys(RATING_PLAN_PREFIX + "*"); err != nil {
+		if rpKeys, err = rs.keys(RATING_PLAN_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -120,7 +146,7 @@ func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []s
 	}
 	if rpfKeys == nil {
 		Logger.Info("Caching all rating profiles")
-		if rpfKeys, err = rs.db.Keys(RATING_PROFILE_PREFIX + "*"); err != nil {
+		if rpfKeys, err = rs.keys(RATING_PROFILE_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -140,7 +166,7 @@ func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []s
 	}
 	if lcrKeys == nil {
 		Logger.Info("Caching LCR rules.")
-		if lcrKeys, err = rs.db.Keys(LCR_PREFIX + "*"); err != nil {
+		if lcrKeys, err = rs.keys(LCR_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -160,7 +186,7 @@ func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []s
 	}
 	if alsKeys == nil {
 		Logger.Info("Caching all rating subject aliases.")
-		if alsKeys, err = rs.db.Keys(RP_ALIAS_PREFIX + "*"); err != nil {
+		if alsKeys, err = rs.keys(RP_ALIAS_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -178,6 +204,33 @@ func (rs *RedisStorage) CacheRating(dKeys, rpKeys, rpfKeys, alsKeys, lcrKeys []s
 	if len(alsKeys) != 0 {
 		Logger.Info("Finished rating profile aliases caching.")
 	}
+	// few of them, always reloaded in full
+	Logger.Info("Caching all exchange rates and tax rules.")
+	erKeys, err := rs.keys(EXCHANGE_RATE_PREFIX + "*")
+	if err != nil {
+		cache2go.RollbackTransaction()
+		return err
+	}
+	cache2go.RemPrefixKey(EXCHANGE_RATE_PREFIX)
+	for _, key := range erKeys {
+		if _, err = rs.GetExchangeRate(key[len(EXCHANGE_RATE_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	trKeys, err := rs.keys(TAX_RULES_PREFIX + "*")
+	if err != nil {
+		cache2go.RollbackTransaction()
+		return err
+	}
+	cache2go.RemPrefixKey(TAX_RULES_PREFIX)
+	for _, key := range trKeys {
+		if _, err = rs.GetTaxRules(key[len(TAX_RULES_PREFIX):], true); err != nil {
+			cache2go.RollbackTransaction()
+			return err
+		}
+	}
+	Logger.Info("Finished exchange rates and tax rules caching.")
 	cache2go.CommitTransaction()
 	return nil
 }
@@ -189,7 +242,7 @@ func (rs *RedisStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []str
 	}
 	if actKeys == nil {
 		Logger.Info("Caching all actions")
-		if actKeys, err = rs.db.Keys(ACTION_PREFIX + "*"); err != nil {
+		if actKeys, err = rs.keys(ACTION_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -211,7 +264,7 @@ func (rs *RedisStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []str
 	}
 	if shgKeys == nil {
 		Logger.Info("Caching all shared groups")
-		if shgKeys, err = rs.db.Keys(SHARED_GROUP_PREFIX + "*"); err != nil {
+		if shgKeys, err = rs.keys(SHARED_GROUP_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -230,7 +283,7 @@ func (rs *RedisStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []str
 	}
 	if alsKeys == nil {
 		Logger.Info("Caching all account aliases.")
-		if alsKeys, err = rs.db.Keys(ACC_ALIAS_PREFIX + "*"); err != nil {
+		if alsKeys, err = rs.keys(ACC_ALIAS_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -251,7 +304,7 @@ func (rs *RedisStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []str
 	// DerivedChargers caching
 	if dcsKeys == nil {
 		Logger.Info("Caching all derived chargers")
-		if dcsKeys, err = rs.db.Keys(DERIVEDCHARGERS_PREFIX + "*"); err != nil {
+		if dcsKeys, err = rs.keys(DERIVEDCHARGERS_PREFIX + "*"); err != nil {
 			cache2go.RollbackTransaction()
 			return err
 		}
@@ -277,7 +330,7 @@ func (rs *RedisStorage) CacheAccounting(actKeys, shgKeys, alsKeys, dcsKeys []str
 func (rs *RedisStorage) HasData(category, subject string) (bool, error) {
 	switch category {
 	case DESTINATION_PREFIX, RATING_PLAN_PREFIX, RATING_PROFILE_PREFIX, ACTION_PREFIX, ACTION_TIMING_PREFIX, ACCOUNT_PREFIX:
-		return rs.db.Exists(category + subject)
+		return rs.exists(category + subject)
 	}
 	return false, errors.New("Unsupported category in HasData")
 }
@@ -292,7 +345,7 @@ func (rs *RedisStorage) GetRatingPlan(key string, skipCache bool) (rp *RatingPla
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		b := bytes.NewBuffer(values)
 		r, err := zlib.NewReader(b)
 		if err != nil {
@@ -316,7 +369,7 @@ func (rs *RedisStorage) SetRatingPlan(rp *RatingPlan) (err error) {
 	w := zlib.NewWriter(&b)
 	w.Write(result)
 	w.Close()
-	err = rs.db.Set(RATING_PLAN_PREFIX+rp.Id, b.Bytes())
+	err = rs.set(RATING_PLAN_PREFIX+rp.Id, b.Bytes())
 	if err == nil && historyScribe != nil {
 		response := 0
 		go historyScribe.Record(rp.GetHistoryRecord(), &response)
@@ -336,7 +389,7 @@ func (rs *RedisStorage) GetRatingProfile(key string, skipCache bool) (rpf *Ratin
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		rpf = new(RatingProfile)
 		err = rs.ms.Unmarshal(values, rpf)
 		cache2go.Cache(key, rpf)
@@ -346,7 +399,7 @@ func (rs *RedisStorage) GetRatingProfile(key string, skipCache bool) (rpf *Ratin
 
 func (rs *RedisStorage) SetRatingProfile(rpf *RatingProfile) (err error) {
 	result, err := rs.ms.Marshal(rpf)
-	err = rs.db.Set(RATING_PROFILE_PREFIX+rpf.Id, result)
+	err = rs.set(RATING_PROFILE_PREFIX+rpf.Id, result)
 	if err == nil && historyScribe != nil {
 		response := 0
 		go historyScribe.Record(rpf.GetHistoryRecord(), &response)
@@ -365,7 +418,7 @@ func (rs *RedisStorage) GetRpAlias(key string, skipCache bool) (alias string, er
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		alias = string(values)
 		cache2go.Cache(key, alias)
 	}
@@ -373,13 +426,13 @@ func (rs *RedisStorage) GetRpAlias(key string, skipCache bool) (alias string, er
 }
 
 func (rs *RedisStorage) SetRpAlias(key, alias string) (err error) {
-	err = rs.db.Set(RP_ALIAS_PREFIX+key, []byte(alias))
+	err = rs.set(RP_ALIAS_PREFIX+key, []byte(alias))
 	return
 }
 
 // Removes the aliases of a specific account, on a tenant
 func (rs *RedisStorage) RemoveRpAliases(tenantRtSubjects []*TenantRatingSubject) (err error) {
-	alsKeys, err := rs.db.Keys(RP_ALIAS_PREFIX + "*")
+	alsKeys, err := rs.keys(RP_ALIAS_PREFIX + "*")
 	if err != nil {
 		return err
 	}
@@ -397,7 +450,7 @@ func (rs *RedisStorage) RemoveRpAliases(tenantRtSubjects []*TenantRatingSubject)
 				continue
 			}
 			cache2go.RemKey(key)
-			if _, err = rs.db.Del(key); err != nil {
+			if _, err = rs.del(key); err != nil {
 				return err
 			}
 			break
@@ -413,7 +466,7 @@ func (rs *RedisStorage) GetRPAliases(tenant, subject string, skipCache bool) (al
 		alsKeys = cache2go.GetEntriesKeys(tenantPrfx)
 	}
 	if len(alsKeys) == 0 {
-		if alsKeys, err = rs.db.Keys(tenantPrfx + "*"); err != nil {
+		if alsKeys, err = rs.keys(tenantPrfx + "*"); err != nil {
 			return nil, err
 		}
 	}
@@ -438,7 +491,7 @@ func (rs *RedisStorage) GetLCR(key string, skipCache bool) (lcr *LCR, err error)
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		err = rs.ms.Unmarshal(values, &lcr)
 		cache2go.Cache(key, lcr)
 	}
@@ -447,7 +500,7 @@ func (rs *RedisStorage) GetLCR(key string, skipCache bool) (lcr *LCR, err error)
 
 func (rs *RedisStorage) SetLCR(lcr *LCR) (err error) {
 	result, err := rs.ms.Marshal(lcr)
-	err = rs.db.Set(LCR_PREFIX+lcr.GetId(), result)
+	err = rs.set(LCR_PREFIX+lcr.GetId(), result)
 	cache2go.Cache(LCR_PREFIX+lcr.GetId(), lcr)
 	return
 }
@@ -462,7 +515,7 @@ func (rs *RedisStorage) GetAccAlias(key string, skipCache bool) (alias string, e
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		alias = string(values)
 		cache2go.Cache(key, alias)
 	}
@@ -471,13 +524,13 @@ func (rs *RedisStorage) GetAccAlias(key string, skipCache bool) (alias string, e
 
 // Adds one alias for one account
 func (rs *RedisStorage) SetAccAlias(key, alias string) (err error) {
-	err = rs.db.Set(ACC_ALIAS_PREFIX+key, []byte(alias))
+	err = rs.set(ACC_ALIAS_PREFIX+key, []byte(alias))
 	//cache2go.Cache(ALIAS_PREFIX+key, alias)
 	return
 }
 
 func (rs *RedisStorage) RemoveAccAliases(tenantAccounts []*TenantAccount) (err error) {
-	alsKeys, err := rs.db.Keys(ACC_ALIAS_PREFIX + "*")
+	alsKeys, err := rs.keys(ACC_ALIAS_PREFIX + "*")
 	if err != nil {
 		return err
 	}
@@ -495,7 +548,7 @@ func (rs *RedisStorage) RemoveAccAliases(tenantAccounts []*TenantAccount) (err e
 				continue
 			}
 			cache2go.RemKey(key)
-			if _, err = rs.db.Del(key); err != nil {
+			if _, err = rs.del(key); err != nil {
 				return err
 			}
 		}
@@ -511,7 +564,7 @@ func (rs *RedisStorage) GetAccountAliases(tenant, account string, skipCache bool
 		alsKeys = cache2go.GetEntriesKeys(tenantPrfx)
 	}
 	if len(alsKeys) == 0 {
-		if alsKeys, err = rs.db.Keys(tenantPrfx + "*"); err != nil {
+		if alsKeys, err = rs.keys(tenantPrfx + "*"); err != nil {
 			return nil, err
 		}
 	}
@@ -529,7 +582,7 @@ func (rs *RedisStorage) GetAccountAliases(tenant, account string, skipCache bool
 func (rs *RedisStorage) GetDestination(key string) (dest *Destination, err error) {
 	key = DESTINATION_PREFIX + key
 	var values []byte
-	if values, err = rs.db.Get(key); len(values) > 0 && err == nil {
+	if values, err = rs.get(key); len(values) > 0 && err == nil {
 		b := bytes.NewBuffer(values)
 		r, err := zlib.NewReader(b)
 		if err != nil {
@@ -543,9 +596,7 @@ func (rs *RedisStorage) GetDestination(key string) (dest *Destination, err error
 		dest = new(Destination)
 		err = rs.ms.Unmarshal(out, dest)
 		// create optimized structure
-		for _, p := range dest.Prefixes {
-			cache2go.CachePush(DESTINATION_PREFIX+p, dest.Id)
-		}
+		indexDestination(dest)
 	} else {
 		return nil, errors.New("not found")
 	}
@@ -561,7 +612,7 @@ func (rs *RedisStorage) SetDestination(dest *Destination) (err error) {
 	w := zlib.NewWriter(&b)
 	w.Write(result)
 	w.Close()
-	err = rs.db.Set(DESTINATION_PREFIX+dest.Id, b.Bytes())
+	err = rs.set(DESTINATION_PREFIX+dest.Id, b.Bytes())
 	if err == nil && historyScribe != nil {
 		response := 0
 		go historyScribe.Record(dest.GetHistoryRecord(), &response)
@@ -580,7 +631,7 @@ func (rs *RedisStorage) GetActions(key string, skipCache bool) (as Actions, err
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		err = rs.ms.Unmarshal(values, &as)
 		cache2go.Cache(key, as)
 	}
@@ -589,7 +640,7 @@ func (rs *RedisStorage) GetActions(key string, skipCache bool) (as Actions, err
 
 func (rs *RedisStorage) SetActions(key string, as Actions) (err error) {
 	result, err := rs.ms.Marshal(&as)
-	err = rs.db.Set(ACTION_PREFIX+key, result)
+	err = rs.set(ACTION_PREFIX+key, result)
 	// cache2go.Cache(ACTION_PREFIX+key, as)
 	return
 }
@@ -604,7 +655,7 @@ func (rs *RedisStorage) GetSharedGroup(key string, skipCache bool) (sg *SharedGr
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		err = rs.ms.Unmarshal(values, &sg)
 		cache2go.Cache(key, sg)
 	}
@@ -613,14 +664,14 @@ func (rs *RedisStorage) GetSharedGroup(key string, skipCache bool) (sg *SharedGr
 
 func (rs *RedisStorage) SetSharedGroup(sg *SharedGroup) (err error) {
 	result, err := rs.ms.Marshal(sg)
-	err = rs.db.Set(SHARED_GROUP_PREFIX+sg.Id, result)
+	err = rs.set(SHARED_GROUP_PREFIX+sg.Id, result)
 	//cache2go.Cache(SHARED_GROUP_PREFIX+sg.Id, sg)
 	return
 }
 
 func (rs *RedisStorage) GetAccount(key string) (ub *Account, err error) {
 	var values []byte
-	if values, err = rs.db.Get(ACCOUNT_PREFIX + key); err == nil {
+	if values, err = rs.get(ACCOUNT_PREFIX + key); err == nil {
 		ub = &Account{Id: key}
 		err = rs.ms.Unmarshal(values, ub)
 	}
@@ -632,23 +683,30 @@ func (rs *RedisStorage) SetAccount(ub *Account) (err error) {
 	// never override existing account with an empty one
 	// UPDATE: if all balances expired and were cleaned it makes
 	// sense to write empty balance map
+	acc := ub // the ledger queued by the caller is written once the account is saved
 	if len(ub.BalanceMap) == 0 {
 		if ac, err := rs.GetAccount(ub.Id); err == nil && !ac.allBalancesExpired() {
 			ac.ActionTriggers = ub.ActionTriggers
 			ac.UnitCounters = ub.UnitCounters
 			ac.AllowNegative = ub.AllowNegative
+			ac.CreditLimit = ub.CreditLimit
+			ac.BillingCycle = ub.BillingCycle
+			ac.ActivationDate = ub.ActivationDate
+			ac.CancellationDate = ub.CancellationDate
 			ac.Disabled = ub.Disabled
 			ub = ac
 		}
 	}
 	result, err := rs.ms.Marshal(ub)
-	err = rs.db.Set(ACCOUNT_PREFIX+ub.Id, result)
+	if err = rs.set(ACCOUNT_PREFIX+ub.Id, result); err == nil {
+		acc.writeLedger()
+	}
 	return
 }
 
 func (rs *RedisStorage) GetActionTimings(key string) (ats ActionPlan, err error) {
 	var values []byte
-	if values, err = rs.db.Get(ACTION_TIMING_PREFIX + key); err == nil {
+	if values, err = rs.get(ACTION_TIMING_PREFIX + key); err == nil {
 		err = rs.ms.Unmarshal(values, &ats)
 	}
 	return
@@ -657,22 +715,22 @@ func (rs *RedisStorage) GetActionTimings(key string) (ats ActionPlan, err error)
 func (rs *RedisStorage) SetActionTimings(key string, ats ActionPlan) (err error) {
 	if len(ats) == 0 {
 		// delete the key
-		_, err = rs.db.Del(ACTION_TIMING_PREFIX + key)
+		_, err = rs.del(ACTION_TIMING_PREFIX + key)
 		return err
 	}
 	result, err := rs.ms.Marshal(&ats)
-	err = rs.db.Set(ACTION_TIMING_PREFIX+key, result)
+	err = rs.set(ACTION_TIMING_PREFIX+key, result)
 	return
 }
 
 func (rs *RedisStorage) GetAllActionTimings() (ats map[string]ActionPlan, err error) {
-	keys, err := rs.db.Keys(ACTION_TIMING_PREFIX + "*")
+	keys, err := rs.keys(ACTION_TIMING_PREFIX + "*")
 	if err != nil {
 		return nil, err
 	}
 	ats = make(map[string]ActionPlan, len(keys))
 	for _, key := range keys {
-		values, err := rs.db.Get(key)
+		values, err := rs.get(key)
 		if err != nil {
 			continue
 		}
@@ -694,7 +752,7 @@ func (rs *RedisStorage) GetDerivedChargers(key string, skipCache bool) (dcs util
 		}
 	}
 	var values []byte
-	if values, err = rs.db.Get(key); err == nil {
+	if values, err = rs.get(key); err == nil {
 		err = rs.ms.Unmarshal(values, &dcs)
 		cache2go.Cache(key, dcs)
 	}
@@ -703,36 +761,36 @@ func (rs *RedisStorage) GetDerivedChargers(key string, skipCache bool) (dcs util
 
 func (rs *RedisStorage) SetDerivedChargers(key string, dcs utils.DerivedChargers) (err error) {
 	if len(dcs) == 0 {
-		_, err = rs.db.Del(DERIVEDCHARGERS_PREFIX + key)
+		_, err = rs.del(DERIVEDCHARGERS_PREFIX + key)
 		// FIXME: Does cache need cleanup too?
 		return err
 	}
 	marshaled, err := rs.ms.Marshal(dcs)
-	err = rs.db.Set(DERIVEDCHARGERS_PREFIX+key, marshaled)
+	err = rs.set(DERIVEDCHARGERS_PREFIX+key, marshaled)
 	return err
 }
 
 func (rs *RedisStorage) SetCdrStats(cs *CdrStats) error {
 	marshaled, err := rs.ms.Marshal(cs)
-	err = rs.db.Set(CDR_STATS_PREFIX+cs.Id, marshaled)
+	err = rs.set(CDR_STATS_PREFIX+cs.Id, marshaled)
 	return err
 }
 
 func (rs *RedisStorage) GetCdrStats(key string) (cs *CdrStats, err error) {
 	var values []byte
-	if values, err = rs.db.Get(CDR_STATS_PREFIX + key); err == nil {
+	if values, err = rs.get(CDR_STATS_PREFIX + key); err == nil {
 		err = rs.ms.Unmarshal(values, &cs)
 	}
 	return
 }
 
 func (rs *RedisStorage) GetAllCdrStats() (css []*CdrStats, err error) {
-	keys, err := rs.db.Keys(CDR_STATS_PREFIX + "*")
+	keys, err := rs.keys(CDR_STATS_PREFIX + "*")
 	if err != nil {
 		return nil, err
 	}
 	for _, key := range keys {
-		value, err := rs.db.Get(key)
+		value, err := rs.get(key)
 		if err != nil {
 			continue
 		}
@@ -743,19 +801,91 @@ func (rs *RedisStorage) GetAllCdrStats() (css []*CdrStats, err error) {
 	return
 }
 
+func (rs *RedisStorage) GetExchangeRate(key string, skipCache bool) (er *ExchangeRate, err error) {
+	key = EXCHANGE_RATE_PREFIX + key
+	if !skipCache {
+		if x, err := cache2go.GetCached(key); err == nil {
+			return x.(*ExchangeRate), nil
+		} else {
+			return nil, err
+		}
+	}
+	var values []byte
+	if values, err = rs.get(key); err == nil {
+		er = new(ExchangeRate)
+		err = rs.ms.Unmarshal(values, er)
+		cache2go.Cache(key, er)
+	}
+	return
+}
+
+func (rs *RedisStorage) SetExchangeRate(er *ExchangeRate) error {
+	marshaled, err := rs.ms.Marshal(er)
+	if err != nil {
+		return err
+	}
+	return rs.set(EXCHANGE_RATE_PREFIX+er.Id, marshaled)
+}
+
+func (rs *RedisStorage) GetTaxRules(key string, skipCache bool) (trs *TaxRules, err error) {
+	key = TAX_RULES_PREFIX + key
+	if !skipCache {
+		if x, err := cache2go.GetCached(key); err == nil {
+			return x.(*TaxRules), nil
+		} else {
+			return nil, err
+		}
+	}
+	var values []byte
+	if values, err = rs.get(key); err == nil {
+		trs = new(TaxRules)
+		err = rs.ms.Unmarshal(values, trs)
+		cache2go.Cache(key, trs)
+	}
+	return
+}
+
+func (rs *RedisStorage) SetTaxRules(trs *TaxRules) error {
+	marshaled, err := rs.ms.Marshal(trs)
+	if err != nil {
+		return err
+	}
+	return rs.set(TAX_RULES_PREFIX+trs.Id, marshaled)
+}
+
+func (rs *RedisStorage) GetPortedNumber(key string) (pn *PortedNumber, err error) {
+	var values []byte
+	if values, err = rs.get(PORTED_NUMBER_PREFIX + key); len(values) > 0 && err == nil {
+		pn = &PortedNumber{Id: key, RoutingPrefix: string(values)}
+	} else {
+		return nil, errors.New(utils.ERR_NOT_FOUND)
+	}
+	return
+}
+
+// Keeps only the routing prefix, there can be millions of ported numbers
+func (rs *RedisStorage) SetPortedNumber(pn *PortedNumber) error {
+	return rs.set(PORTED_NUMBER_PREFIX+pn.Id, []byte(pn.RoutingPrefix))
+}
+
+func (rs *RedisStorage) RemovePortedNumber(key string) (err error) {
+	_, err = rs.del(PORTED_NUMBER_PREFIX + key)
+	return
+}
+
 func (rs *RedisStorage) LogCallCost(cgrid, source, runid string, cc *CallCost) (err error) {
 	var result []byte
 	result, err = rs.ms.Marshal(cc)
 	if err != nil {
 		return
 	}
-	err = rs.db.Set(LOG_CALL_COST_PREFIX+source+runid+"_"+cgrid, result)
+	err = rs.set(LOG_CALL_COST_PREFIX+source+runid+"_"+cgrid, result)
 	return
 }
 
 func (rs *RedisStorage) GetCallCostLog(cgrid, source, runid string) (cc *CallCost, err error) {
 	var values []byte
-	if values, err = rs.db.Get(LOG_CALL_COST_PREFIX + source + runid + "_" + cgrid); err == nil {
+	if values, err = rs.get(LOG_CALL_COST_PREFIX + source + runid + "_" + cgrid); err == nil {
 		err = rs.ms.Unmarshal(values, cc)
 	}
 	return
@@ -770,7 +900,7 @@ func (rs *RedisStorage) LogActionTrigger(ubId, source string, at *ActionTrigger,
 	if err != nil {
 		return
 	}
-	rs.db.Set(LOG_ACTION_TRIGGER_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%v*%v*%v", ubId, string(mat), string(mas))))
+	rs.set(LOG_ACTION_TRIGGER_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%v*%v*%v", ubId, string(mat), string(mas))))
 	return
 }
 
@@ -783,11 +913,11 @@ func (rs *RedisStorage) LogActionTiming(source string, at *ActionTiming, as Acti
 	if err != nil {
 		return
 	}
-	err = rs.db.Set(LOG_ACTION_TIMMING_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%v*%v", string(mat), string(mas))))
+	err = rs.set(LOG_ACTION_TIMMING_PREFIX+source+"_"+time.Now().Format(time.RFC3339Nano), []byte(fmt.Sprintf("%v*%v", string(mat), string(mas))))
 	return
 }
 
 func (rs *RedisStorage) LogError(uuid, source, runid, errstr string) (err error) {
-	err = rs.db.Set(LOG_ERR+source+runid+"_"+uuid, []byte(errstr))
+	err = rs.set(LOG_ERR+source+runid+"_"+uuid, []byte(errstr))
 	return
 }
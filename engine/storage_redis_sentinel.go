@@ -0,0 +1,246 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoisie/redis"
+)
+
+const (
+	REDIS_SENTINEL_TIMEOUT  = 2 * time.Second
+	REDIS_FAILOVER_RETRIES  = 5
+	REDIS_FAILOVER_INTERVAL = time.Second
+)
+
+// Connects to the master currently elected by the sentinels, the master is looked up again every time the connection fails
+func NewRedisSentinelStorage(sentinels []string, masterName string, db int, pass, mrshlerStr string) (*RedisStorage, error) {
+	address, err := redisSentinelMaster(sentinels, masterName)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := NewRedisStorage(address, db, pass, mrshlerStr)
+	if err != nil {
+		return nil, err
+	}
+	rs.sentinels = sentinels
+	rs.masterName = masterName
+	return rs, nil
+}
+
+func newRedisClient(address string, db int, pass string) (*redis.Client, error) {
+	ndb := &redis.Client{Addr: address, Db: db}
+	if pass != "" {
+		if err := ndb.Auth(pass); err != nil {
+			return nil, err
+		}
+	}
+	return ndb, nil
+}
+
+// Asks the sentinels in order, the first one knowing the master wins
+func redisSentinelMaster(sentinels []string, masterName string) (string, error) {
+	for _, sentinel := range sentinels {
+		address, err := querySentinelMaster(sentinel, masterName)
+		if err != nil {
+			Logger.Warning(fmt.Sprintf("<RedisStorage> Sentinel %s could not resolve master %s: %v", sentinel, masterName, err))
+			continue
+		}
+		return address, nil
+	}
+	return "", fmt.Errorf("no sentinel could resolve redis master: %s", masterName)
+}
+
+// Queries one sentinel with SENTINEL get-master-addr-by-name, talking the redis protocol directly since the client library does not support it
+func querySentinelMaster(sentinel, masterName string) (string, error) {
+	conn, err := net.DialTimeout("tcp", sentinel, REDIS_SENTINEL_TIMEOUT)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(REDIS_SENTINEL_TIMEOUT))
+	var cmd bytes.Buffer
+	args := []string{"SENTINEL", "get-master-addr-by-name", masterName}
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write(cmd.Bytes()); err != nil {
+		return "", err
+	}
+	r := bufio.NewReader(conn)
+	reply, err := readRedisLine(r)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(reply, "-"):
+		return "", errors.New(reply[1:])
+	case reply == "*-1":
+		return "", errors.New("unknown master")
+	case reply != "*2":
+		return "", fmt.Errorf("unexpected reply: %s", reply)
+	}
+	hostPort := make([]string, 2)
+	for i := range hostPort {
+		if hostPort[i], err = readRedisBulk(r); err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(hostPort[0], hostPort[1]), nil
+}
+
+func readRedisLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return "", errors.New("empty reply")
+	}
+	return line, nil
+}
+
+func readRedisBulk(r *bufio.Reader) (string, error) {
+	header, err := readRedisLine(r)
+	if err != nil {
+		return "", err
+	}
+	if header[0] != '$' {
+		return "", fmt.Errorf("unexpected reply: %s", header)
+	}
+	size, err := strconv.Atoi(header[1:])
+	if err != nil || size < 0 {
+		return "", fmt.Errorf("unexpected reply: %s", header)
+	}
+	buf := make([]byte, size+2) // data followed by \r\n
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf[:size]), nil
+}
+
+// Errors after which the command can succeed on a new connection, possibly towards a newly elected master
+func isRedisConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, isNetErr := err.(net.Error); isNetErr || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	errStr := err.Error()
+	// READONLY comes from a former master demoted to slave, LOADING from a restarted server still reading its dataset
+	return strings.HasPrefix(errStr, "READONLY") || strings.HasPrefix(errStr, "LOADING") || strings.Contains(errStr, "connection refused")
+}
+
+func (rs *RedisStorage) client() *redis.Client {
+	rs.dbMux.RLock()
+	defer rs.dbMux.RUnlock()
+	return rs.db
+}
+
+// Replaces the connection pool, looking up the master again if we are using sentinels
+func (rs *RedisStorage) reconnect(failed *redis.Client) error {
+	rs.dbMux.Lock()
+	defer rs.dbMux.Unlock()
+	if rs.db != failed { // somebody else reconnected in the meantime
+		return nil
+	}
+	address := rs.db.Addr
+	if len(rs.sentinels) != 0 {
+		var err error
+		if address, err = redisSentinelMaster(rs.sentinels, rs.masterName); err != nil {
+			return err
+		}
+		if address != rs.db.Addr {
+			Logger.Info(fmt.Sprintf("<RedisStorage> Redis master %s moved from %s to %s", rs.masterName, rs.db.Addr, address))
+		}
+	}
+	ndb, err := newRedisClient(address, rs.dbNb, rs.pass)
+	if err != nil {
+		return err
+	}
+	rs.db = ndb
+	return nil
+}
+
+// Executes the command, retrying it on a fresh connection as long as the failure is connection related.
+// All the commands issued by RedisStorage (GET, SET, DEL, KEYS, EXISTS, FLUSHDB) are idempotent so repeating them is safe.
+func (rs *RedisStorage) do(cmd func(*redis.Client) error) (err error) {
+	db := rs.client()
+	for i := 0; ; i++ {
+		if err = cmd(db); !isRedisConnError(err) || i == REDIS_FAILOVER_RETRIES {
+			return
+		}
+		Logger.Warning(fmt.Sprintf("<RedisStorage> Connection error: %v, retrying", err))
+		time.Sleep(REDIS_FAILOVER_INTERVAL)
+		if rErr := rs.reconnect(db); rErr != nil {
+			Logger.Warning(fmt.Sprintf("<RedisStorage> Cannot reconnect: %v", rErr))
+		}
+		db = rs.client()
+	}
+}
+
+func (rs *RedisStorage) get(key string) (values []byte, err error) {
+	err = rs.do(func(db *redis.Client) (err error) {
+		values, err = db.Get(key)
+		return
+	})
+	return
+}
+
+func (rs *RedisStorage) set(key string, values []byte) error {
+	return rs.do(func(db *redis.Client) error {
+		return db.Set(key, values)
+	})
+}
+
+func (rs *RedisStorage) del(key string) (deleted bool, err error) {
+	err = rs.do(func(db *redis.Client) (err error) {
+		deleted, err = db.Del(key)
+		return
+	})
+	return
+}
+
+func (rs *RedisStorage) keys(pattern string) (keys []string, err error) {
+	err = rs.do(func(db *redis.Client) (err error) {
+		keys, err = db.Keys(pattern)
+		return
+	})
+	return
+}
+
+func (rs *RedisStorage) exists(key string) (exists bool, err error) {
+	err = rs.do(func(db *redis.Client) (err error) {
+		exists, err = db.Exists(key)
+		return
+	})
+	return
+}
@@ -0,0 +1,97 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// Answers every connection with the given raw reply, recording the command received
+func fakeSentinel(t *testing.T, reply string, cmds chan<- string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			var cmd []string
+			for i := 0; i < 7; i++ { // *3 followed by 3 bulk strings
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				cmd = append(cmd, strings.TrimSpace(line))
+			}
+			cmds <- strings.Join(cmd, " ")
+			conn.Write([]byte(reply))
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+func TestRedisSentinelMaster(t *testing.T) {
+	cmds := make(chan string, 2)
+	unknown := fakeSentinel(t, "*-1\r\n", cmds)
+	defer unknown.Close()
+	good := fakeSentinel(t, "*2\r\n$9\r\n10.0.0.12\r\n$4\r\n6379\r\n", cmds)
+	defer good.Close()
+	// Dead sentinel first, then one which does not know the master
+	dead, _ := net.Listen("tcp", "127.0.0.1:0")
+	deadAddr := dead.Addr().String()
+	dead.Close()
+	if master, err := redisSentinelMaster([]string{deadAddr, unknown.Addr().String(), good.Addr().String()}, "cgrates"); err != nil {
+		t.Error(err)
+	} else if master != "10.0.0.12:6379" {
+		t.Error("Unexpected master: ", master)
+	}
+	eCmd := "*3 $8 SENTINEL $23 get-master-addr-by-name $7 cgrates"
+	for i := 0; i < 2; i++ {
+		if cmd := <-cmds; cmd != eCmd {
+			t.Errorf("Expecting: %s, received: %s", eCmd, cmd)
+		}
+	}
+	if _, err := redisSentinelMaster([]string{unknown.Addr().String()}, "cgrates"); err == nil {
+		t.Error("Expecting error for unknown master")
+	}
+}
+
+func TestIsRedisConnError(t *testing.T) {
+	for _, err := range []error{io.EOF, io.ErrUnexpectedEOF, &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+		errors.New("READONLY You can't write against a read only slave."), errors.New("LOADING Redis is loading the dataset in memory")} {
+		if !isRedisConnError(err) {
+			t.Error("Expecting connection error: ", err)
+		}
+	}
+	for _, err := range []error{nil, errors.New("Key `ubl_1001` does not exist")} {
+		if isRedisConnError(err) {
+			t.Error("Not expecting connection error: ", err)
+		}
+	}
+}
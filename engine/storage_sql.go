@@ -53,6 +53,32 @@ func (self *SQLStorage) GetKeysForPrefix(prefix string) ([]string, error) {
 	return nil, nil
 }
 
+func (self *SQLStorage) GetVersion(item string) (int64, error) {
+	if !self.db.HasTable(TblVersion{}) { // created before we started versioning
+		return 0, errors.New(utils.ERR_NOT_FOUND)
+	}
+	var ver TblVersion
+	if err := self.db.Where(&TblVersion{Item: item}).First(&ver).Error; err == gorm.RecordNotFound {
+		return 0, errors.New(utils.ERR_NOT_FOUND)
+	} else if err != nil {
+		return 0, err
+	}
+	return ver.Version, nil
+}
+
+func (self *SQLStorage) SetVersion(item string, version int64) error {
+	if err := self.db.AutoMigrate(&TblVersion{}).Error; err != nil {
+		return err
+	}
+	var ver TblVersion
+	if err := self.db.Where(&TblVersion{Item: item}).First(&ver).Error; err != nil && err != gorm.RecordNotFound {
+		return err
+	}
+	ver.Item = item
+	ver.Version = version
+	return self.db.Save(&ver).Error
+}
+
 func (self *SQLStorage) CreateTablesFromScript(scriptPath string) error {
 	fileContent, err := ioutil.ReadFile(scriptPath)
 	if err != nil {
@@ -168,7 +194,7 @@ func (self *SQLStorage) RemTPData(table, tpid string, args ...string) error {
 	tx := self.db.Begin()
 	if len(table) == 0 { // Remove tpid out of all tables
 		for _, tblName := range []string{utils.TBL_TP_TIMINGS, utils.TBL_TP_DESTINATIONS, utils.TBL_TP_RATES, utils.TBL_TP_DESTINATION_RATES, utils.TBL_TP_RATING_PLANS, utils.TBL_TP_RATE_PROFILES,
-			utils.TBL_TP_SHARED_GROUPS, utils.TBL_TP_CDR_STATS, utils.TBL_TP_LCRS, utils.TBL_TP_ACTIONS, utils.TBL_TP_ACTION_PLANS, utils.TBL_TP_ACTION_TRIGGERS, utils.TBL_TP_ACCOUNT_ACTIONS, utils.TBL_TP_DERIVED_CHARGERS} {
+			utils.TBL_TP_SHARED_GROUPS, utils.TBL_TP_CDR_STATS, utils.TBL_TP_LCRS, utils.TBL_TP_ACTIONS, utils.TBL_TP_ACTION_PLANS, utils.TBL_TP_ACTION_TRIGGERS, utils.TBL_TP_ACCOUNT_ACTIONS, utils.TBL_TP_DERIVED_CHARGERS, utils.TBL_TP_EXCHANGE_RATES, utils.TBL_TP_TAX_RULES, utils.TBL_TP_PORTED_NUMBERS, utils.TBL_TP_CALENDARS} {
 			if err := tx.Table(tblName).Where("tpid = ?", tpid).Delete(nil).Error; err != nil {
 				tx.Rollback()
 				return err
@@ -267,13 +293,19 @@ func (self *SQLStorage) SetTPDestinationRates(tpid string, drs map[string][]*uti
 		}
 		for _, dr := range dRates {
 			saved := tx.Save(&TpDestinationRate{
-				Tpid:             tpid,
-				Tag:              drId,
-				DestinationsTag:  dr.DestinationId,
-				RatesTag:         dr.RateId,
-				RoundingMethod:   dr.RoundingMethod,
-				RoundingDecimals: dr.RoundingDecimals,
-				CreatedAt:        time.Now(),
+				Tpid:               tpid,
+				Tag:                drId,
+				DestinationsTag:    dr.DestinationId,
+				RatesTag:           dr.RateId,
+				RoundingMethod:     dr.RoundingMethod,
+				RoundingDecimals:   dr.RoundingDecimals,
+				TierPeriod:         dr.TierPeriod,
+				Currency:           dr.Currency,
+				MinDuration:        dr.MinDuration,
+				MinCost:            dr.MinCost,
+				FreeUnder:          dr.FreeUnder,
+				ConnectFeeOnAnswer: dr.ConnectFeeOnAnswer,
+				CreatedAt:          time.Now(),
 			})
 			if saved.Error != nil {
 				tx.Rollback()
@@ -336,6 +368,7 @@ func (self *SQLStorage) SetTPRatingProfiles(tpid string, rpfs map[string]*utils.
 				RatingPlanTag:    ra.RatingPlanId,
 				FallbackSubjects: ra.FallbackSubjects,
 				CdrStatQueueIds:  ra.CdrStatQueueIds,
+				Timezone:         ra.Timezone,
 				CreatedAt:        time.Now(),
 			})
 			if saved.Error != nil {
@@ -377,6 +410,123 @@ func (self *SQLStorage) SetTPSharedGroups(tpid string, sgs map[string][]*utils.T
 	return nil
 }
 
+func (self *SQLStorage) SetTPExchangeRates(tpid string, ers map[string][]*utils.TPExchangeRate) error {
+	if len(ers) == 0 {
+		return nil //Nothing to set
+	}
+	tx := self.db.Begin()
+	for erId, eRates := range ers {
+		if err := tx.Where(&TpExchangeRate{Tpid: tpid, Tag: erId}).Delete(TpExchangeRate{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, er := range eRates {
+			saved := tx.Save(&TpExchangeRate{
+				Tpid:           tpid,
+				Tag:            erId,
+				FromCurrency:   er.FromCurrency,
+				ToCurrency:     er.ToCurrency,
+				ActivationTime: er.ActivationTime,
+				Rate:           er.Rate,
+				CreatedAt:      time.Now(),
+			})
+			if saved.Error != nil {
+				tx.Rollback()
+				return saved.Error
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+func (self *SQLStorage) SetTPTaxRules(tpid string, trs map[string][]*utils.TPTaxRule) error {
+	if len(trs) == 0 {
+		return nil //Nothing to set
+	}
+	tx := self.db.Begin()
+	for trId, tRules := range trs {
+		if err := tx.Where(&TpTaxRule{Tpid: tpid, Tag: trId}).Delete(TpTaxRule{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, tr := range tRules {
+			saved := tx.Save(&TpTaxRule{
+				Tpid:           tpid,
+				Tag:            trId,
+				Tenant:         tr.Tenant,
+				Category:       tr.Category,
+				DestinationId:  tr.DestinationId,
+				ActivationTime: tr.ActivationTime,
+				Rate:           tr.Rate,
+				CreatedAt:      time.Now(),
+			})
+			if saved.Error != nil {
+				tx.Rollback()
+				return saved.Error
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+func (self *SQLStorage) SetTPPortedNumbers(tpid string, pns map[string][]*utils.TPPortedNumber) error {
+	if len(pns) == 0 {
+		return nil //Nothing to set
+	}
+	tx := self.db.Begin()
+	for pnId, pNumbers := range pns {
+		if err := tx.Where(&TpPortedNumber{Tpid: tpid, Tag: pnId}).Delete(TpPortedNumber{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, pn := range pNumbers {
+			saved := tx.Save(&TpPortedNumber{
+				Tpid:          tpid,
+				Tag:           pnId,
+				Number:        pn.Number,
+				RoutingPrefix: pn.RoutingPrefix,
+				CreatedAt:     time.Now(),
+			})
+			if saved.Error != nil {
+				tx.Rollback()
+				return saved.Error
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
+func (self *SQLStorage) SetTPCalendars(tpid string, cals map[string][]*utils.TPCalendarDay) error {
+	if len(cals) == 0 {
+		return nil //Nothing to set
+	}
+	tx := self.db.Begin()
+	for calId, days := range cals {
+		if err := tx.Where(&TpCalendar{Tpid: tpid, Tag: calId}).Delete(TpCalendar{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for _, day := range days {
+			saved := tx.Save(&TpCalendar{
+				Tpid:      tpid,
+				Tag:       calId,
+				Date:      day.Date,
+				Yearly:    day.Yearly,
+				CreatedAt: time.Now(),
+			})
+			if saved.Error != nil {
+				tx.Rollback()
+				return saved.Error
+			}
+		}
+	}
+	tx.Commit()
+	return nil
+}
+
 func (self *SQLStorage) SetTPCdrStats(tpid string, css map[string][]*utils.TPCdrStat) error {
 	if len(css) == 0 {
 		return nil //Nothing to set
@@ -582,6 +732,7 @@ func (self *SQLStorage) SetTPActionTimings(tpid string, ats map[string][]*utils.
 				ActionsTag: ap.ActionsId,
 				TimingTag:  ap.TimingId,
 				Weight:     ap.Weight,
+				Timezone:   ap.Timezone,
 				CreatedAt:  time.Now(),
 			})
 			if saved.Error != nil {
@@ -601,7 +752,7 @@ func (self *SQLStorage) GetTPActionTimings(tpid, tag string) (map[string][]*util
 		return nil, err
 	}
 	for _, tpAp := range tpActionPlans {
-		ats[tpAp.Tag] = append(ats[tpAp.Tag], &utils.TPActionTiming{ActionsId: tpAp.ActionsTag, TimingId: tpAp.TimingTag, Weight: tpAp.Weight})
+		ats[tpAp.Tag] = append(ats[tpAp.Tag], &utils.TPActionTiming{ActionsId: tpAp.ActionsTag, TimingId: tpAp.TimingTag, Weight: tpAp.Weight, Timezone: tpAp.Timezone})
 	}
 	return ats, nil
 }
@@ -706,6 +857,11 @@ func (self *SQLStorage) GetCallCostLog(cgrid, source, runid string) (*CallCost,
 	cc.Subject = tpCostDetail.Subject
 	cc.Destination = tpCostDetail.Destination
 	cc.Cost = tpCostDetail.Cost
+	cc.Currency = tpCostDetail.Currency
+	cc.DebitedCost = tpCostDetail.DebitedCost
+	cc.DebitedCurrency = tpCostDetail.DebitedCurrency
+	cc.Tax = tpCostDetail.Tax
+	cc.GrossCost = tpCostDetail.GrossCost
 	if err := json.Unmarshal([]byte(tpCostDetail.Timespans), &cc.Timespans); err != nil {
 		return nil, err
 	}
@@ -767,17 +923,17 @@ func (self *SQLStorage) GetStoredCdrs(qryFltr *utils.CdrsFilter) ([]*StoredCdr,
 	// Select string
 	var selectStr string
 	if qryFltr.FilterOnDerived { // We use different tables to query account data in case of derived
-		selectStr = fmt.Sprintf("%s.cgrid,%s.id,%s.tor,%s.accid,%s.cdrhost,%s.cdrsource,%s.reqtype,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.setup_time,%s.answer_time,%s.usage,%s.supplier,%s.disconnect_cause,%s.extra_fields,%s.runid,%s.cost,%s.tor,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.cost,%s.timespans",
+		selectStr = fmt.Sprintf("%s.cgrid,%s.id,%s.tor,%s.accid,%s.cdrhost,%s.cdrsource,%s.reqtype,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.setup_time,%s.answer_time,%s.usage,%s.supplier,%s.disconnect_cause,%s.extra_fields,%s.runid,%s.cost,%s.tor,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.cost,%s.timespans,%s.currency,%s.debited_cost,%s.debited_currency,%s.tax,%s.gross_cost,%s.resolved_destination",
 			utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS,
 			utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS,
 			utils.TBL_CDRS_EXTRA, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS,
-			utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS)
+			utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS)
 	} else {
-		selectStr = fmt.Sprintf("%s.cgrid,%s.id,%s.tor,%s.accid,%s.cdrhost,%s.cdrsource,%s.reqtype,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.setup_time,%s.answer_time,%s.usage,%s.supplier,%s.disconnect_cause,%s.extra_fields,%s.runid,%s.cost,%s.tor,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.cost,%s.timespans",
+		selectStr = fmt.Sprintf("%s.cgrid,%s.id,%s.tor,%s.accid,%s.cdrhost,%s.cdrsource,%s.reqtype,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.setup_time,%s.answer_time,%s.usage,%s.supplier,%s.disconnect_cause,%s.extra_fields,%s.runid,%s.cost,%s.tor,%s.direction,%s.tenant,%s.category,%s.account,%s.subject,%s.destination,%s.cost,%s.timespans,%s.currency,%s.debited_cost,%s.debited_currency,%s.tax,%s.gross_cost,%s.resolved_destination",
 			utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY,
 			utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_PRIMARY,
 			utils.TBL_CDRS_EXTRA, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS,
-			utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS)
+			utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_COST_DETAILS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS, utils.TBL_RATED_CDRS)
 
 	}
 	// Join string
@@ -1120,16 +1276,17 @@ func (self *SQLStorage) GetStoredCdrs(qryFltr *utils.CdrsFilter) ([]*StoredCdr,
 	}
 	for rows.Next() {
 		var cgrid, tor, accid, cdrhost, cdrsrc, reqtype, direction, tenant, category, account, subject, destination, runid, ccTor,
-			ccDirection, ccTenant, ccCategory, ccAccount, ccSubject, ccDestination, ccSupplier, ccDisconnectCause sql.NullString
+			ccDirection, ccTenant, ccCategory, ccAccount, ccSubject, ccDestination, ccSupplier, ccDisconnectCause, currency, debitedCurrency, resolvedDestination sql.NullString
 		var extraFields, ccTimespansBytes []byte
 		var setupTime, answerTime mysql.NullTime
 		var orderid int64
-		var usage, cost, ccCost sql.NullFloat64
+		var usage, cost, ccCost, debitedCost, tax, grossCost sql.NullFloat64
 		var extraFieldsMp map[string]string
 		var ccTimespans TimeSpans
 		if err := rows.Scan(&cgrid, &orderid, &tor, &accid, &cdrhost, &cdrsrc, &reqtype, &direction, &tenant, &category, &account, &subject, &destination,
 			&setupTime, &answerTime, &usage, &ccSupplier, &ccDisconnectCause,
-			&extraFields, &runid, &cost, &ccTor, &ccDirection, &ccTenant, &ccCategory, &ccAccount, &ccSubject, &ccDestination, &ccCost, &ccTimespansBytes); err != nil {
+			&extraFields, &runid, &cost, &ccTor, &ccDirection, &ccTenant, &ccCategory, &ccAccount, &ccSubject, &ccDestination, &ccCost, &ccTimespansBytes,
+			&currency, &debitedCost, &debitedCurrency, &tax, &grossCost, &resolvedDestination); err != nil {
 			return nil, 0, err
 		}
 		if len(extraFields) != 0 {
@@ -1149,6 +1306,8 @@ func (self *SQLStorage) GetStoredCdrs(qryFltr *utils.CdrsFilter) ([]*StoredCdr,
 			Category: category.String, Account: account.String, Subject: subject.String, Destination: destination.String,
 			SetupTime: setupTime.Time, AnswerTime: answerTime.Time, Usage: usageDur, Supplier: ccSupplier.String, DisconnectCause: ccDisconnectCause.String,
 			ExtraFields: extraFieldsMp, MediationRunId: runid.String, RatedAccount: ccAccount.String, RatedSubject: ccSubject.String, Cost: cost.Float64,
+			Currency: currency.String, DebitedCost: debitedCost.Float64, DebitedCurrency: debitedCurrency.String,
+			Tax: tax.Float64, GrossCost: grossCost.Float64, ResolvedDestination: resolvedDestination.String,
 		}
 		if ccTimespans != nil {
 			storCdr.CostDetails = &CallCost{Direction: ccDirection.String, Category: ccCategory.String, Tenant: ccTenant.String, Subject: ccSubject.String, Account: ccAccount.String, Destination: ccDestination.String, TOR: ccTor.String,
@@ -1186,6 +1345,127 @@ func (self *SQLStorage) RemStoredCdrs(cgrIds []string) error {
 	return nil
 }
 
+// Unlike RemStoredCdrs, removes the rows for good together with their cost details
+func (self *SQLStorage) PurgeStoredCdrs(cgrIds []string) error {
+	if len(cgrIds) == 0 {
+		return nil
+	}
+	tx := self.db.Begin()
+	for _, tblName := range []string{utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_EXTRA, utils.TBL_COST_DETAILS, utils.TBL_RATED_CDRS} {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE cgrid IN (?)", tblName), cgrIds).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (self *SQLStorage) SetLedgerEntry(entry *LedgerEntry) error {
+	return self.db.Save(&TblLedger{
+		AccountId:   entry.AccountId,
+		BalanceType: entry.BalanceType,
+		BalanceUuid: entry.BalanceUuid,
+		BalanceId:   entry.BalanceId,
+		Delta:       entry.Delta,
+		Value:       entry.Value,
+		Cgrid:       entry.CgrId,
+		ActionId:    entry.ActionId,
+		ActionType:  entry.ActionType,
+		CreatedAt:   entry.Timestamp,
+	}).Error
+}
+
+// Returns the ledger of an account in the order it was written, zero times leave the interval open
+func (self *SQLStorage) GetLedgerEntries(accountId string, timeStart, timeEnd time.Time) ([]*LedgerEntry, error) {
+	q := self.db.Where("account_id = ?", accountId)
+	if !timeStart.IsZero() {
+		q = q.Where("created_at >= ?", timeStart)
+	}
+	if !timeEnd.IsZero() {
+		q = q.Where("created_at < ?", timeEnd)
+	}
+	var tblEntries []TblLedger
+	if err := q.Order("id").Find(&tblEntries).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]*LedgerEntry, len(tblEntries))
+	for i, tblEntry := range tblEntries {
+		entries[i] = &LedgerEntry{
+			AccountId:   tblEntry.AccountId,
+			BalanceType: tblEntry.BalanceType,
+			BalanceUuid: tblEntry.BalanceUuid,
+			BalanceId:   tblEntry.BalanceId,
+			Delta:       tblEntry.Delta,
+			Value:       tblEntry.Value,
+			CgrId:       tblEntry.Cgrid,
+			ActionId:    tblEntry.ActionId,
+			ActionType:  tblEntry.ActionType,
+			Timestamp:   tblEntry.CreatedAt,
+		}
+	}
+	return entries, nil
+}
+
+// Stores the invoice, the ones without number get the next one in sequence
+func (self *SQLStorage) SetInvoice(inv *Invoice) error {
+	content, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	tblInv := &TblInvoice{
+		Id:          inv.Number,
+		AccountId:   inv.AccountId,
+		PeriodStart: inv.PeriodStart,
+		PeriodEnd:   inv.PeriodEnd,
+		Net:         inv.Net,
+		Tax:         inv.Tax,
+		Gross:       inv.Gross,
+		Content:     string(content),
+	}
+	tx := self.db.Begin()
+	if inv.Number == 0 {
+		if err := tx.Save(tblInv).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		inv.Number = tblInv.Id
+		// the content has to carry the number given by the database
+		if content, err = json.Marshal(inv); err != nil {
+			tx.Rollback()
+			return err
+		}
+		tblInv.Content = string(content)
+	}
+	if err := tx.Save(tblInv).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Returns the invoices of an account ordered by number, empty account for all of them, number for a specific one
+func (self *SQLStorage) GetInvoices(accountId string, number int64) ([]*Invoice, error) {
+	q := self.db.Select("content")
+	if accountId != "" {
+		q = q.Where("account_id = ?", accountId)
+	}
+	if number != 0 {
+		q = q.Where("id = ?", number)
+	}
+	var tblInvs []TblInvoice
+	if err := q.Order("id").Find(&tblInvs).Error; err != nil {
+		return nil, err
+	}
+	invs := make([]*Invoice, len(tblInvs))
+	for i, tblInv := range tblInvs {
+		invs[i] = new(Invoice)
+		if err := json.Unmarshal([]byte(tblInv.Content), invs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return invs, nil
+}
+
 func (self *SQLStorage) GetTpDestinations(tpid, tag string) (map[string]*Destination, error) {
 	dests := make(map[string]*Destination)
 	var tpDests []TpDestination
@@ -1270,12 +1550,18 @@ func (self *SQLStorage) GetTpDestinationRates(tpid, tag string, pagination *util
 			DestinationRateId: tpDr.Tag,
 			DestinationRates: []*utils.DestinationRate{
 				&utils.DestinationRate{
-					DestinationId:    tpDr.DestinationsTag,
-					RateId:           tpDr.RatesTag,
-					RoundingMethod:   tpDr.RoundingMethod,
-					RoundingDecimals: tpDr.RoundingDecimals,
-					MaxCost:          tpDr.MaxCost,
-					MaxCostStrategy:  tpDr.MaxCostStrategy,
+					DestinationId:      tpDr.DestinationsTag,
+					RateId:             tpDr.RatesTag,
+					RoundingMethod:     tpDr.RoundingMethod,
+					RoundingDecimals:   tpDr.RoundingDecimals,
+					MaxCost:            tpDr.MaxCost,
+					MaxCostStrategy:    tpDr.MaxCostStrategy,
+					TierPeriod:         tpDr.TierPeriod,
+					Currency:           tpDr.Currency,
+					MinDuration:        tpDr.MinDuration,
+					MinCost:            tpDr.MinCost,
+					FreeUnder:          tpDr.FreeUnder,
+					ConnectFeeOnAnswer: tpDr.ConnectFeeOnAnswer,
 				},
 			},
 		}
@@ -1380,6 +1666,7 @@ func (self *SQLStorage) GetTpRatingProfiles(qryRpf *utils.TPRatingProfile) (map[
 			RatingPlanId:     tpRpf.RatingPlanTag,
 			FallbackSubjects: tpRpf.FallbackSubjects,
 			CdrStatQueueIds:  tpRpf.CdrStatQueueIds,
+			Timezone:         tpRpf.Timezone,
 		}
 		if existingRpf, exists := rpfs[rp.KeyId()]; !exists {
 			rp.RatingPlanActivations = []*utils.TPRatingActivation{ra}
@@ -1414,6 +1701,87 @@ func (self *SQLStorage) GetTpSharedGroups(tpid, tag string) (map[string][]*utils
 	return sgs, nil
 }
 
+func (self *SQLStorage) GetTpExchangeRates(tpid, tag string) (map[string][]*utils.TPExchangeRate, error) {
+	ers := make(map[string][]*utils.TPExchangeRate)
+	var tpExchangeRates []TpExchangeRate
+	q := self.db.Where("tpid = ?", tpid)
+	if len(tag) != 0 {
+		q = q.Where("tag = ?", tag)
+	}
+	if err := q.Find(&tpExchangeRates).Error; err != nil {
+		return nil, err
+	}
+	for _, tpEr := range tpExchangeRates {
+		ers[tpEr.Tag] = append(ers[tpEr.Tag], &utils.TPExchangeRate{
+			FromCurrency:   tpEr.FromCurrency,
+			ToCurrency:     tpEr.ToCurrency,
+			ActivationTime: tpEr.ActivationTime,
+			Rate:           tpEr.Rate,
+		})
+	}
+	return ers, nil
+}
+
+func (self *SQLStorage) GetTpTaxRules(tpid, tag string) (map[string][]*utils.TPTaxRule, error) {
+	trs := make(map[string][]*utils.TPTaxRule)
+	var tpTaxRules []TpTaxRule
+	q := self.db.Where("tpid = ?", tpid)
+	if len(tag) != 0 {
+		q = q.Where("tag = ?", tag)
+	}
+	if err := q.Find(&tpTaxRules).Error; err != nil {
+		return nil, err
+	}
+	for _, tpTr := range tpTaxRules {
+		trs[tpTr.Tag] = append(trs[tpTr.Tag], &utils.TPTaxRule{
+			Tenant:         tpTr.Tenant,
+			Category:       tpTr.Category,
+			DestinationId:  tpTr.DestinationId,
+			ActivationTime: tpTr.ActivationTime,
+			Rate:           tpTr.Rate,
+		})
+	}
+	return trs, nil
+}
+
+func (self *SQLStorage) GetTpPortedNumbers(tpid, tag string) (map[string][]*utils.TPPortedNumber, error) {
+	pns := make(map[string][]*utils.TPPortedNumber)
+	var tpPortedNumbers []TpPortedNumber
+	q := self.db.Where("tpid = ?", tpid)
+	if len(tag) != 0 {
+		q = q.Where("tag = ?", tag)
+	}
+	if err := q.Find(&tpPortedNumbers).Error; err != nil {
+		return nil, err
+	}
+	for _, tpPn := range tpPortedNumbers {
+		pns[tpPn.Tag] = append(pns[tpPn.Tag], &utils.TPPortedNumber{
+			Number:        tpPn.Number,
+			RoutingPrefix: tpPn.RoutingPrefix,
+		})
+	}
+	return pns, nil
+}
+
+func (self *SQLStorage) GetTpCalendars(tpid, tag string) (map[string][]*utils.TPCalendarDay, error) {
+	cals := make(map[string][]*utils.TPCalendarDay)
+	var tpCalendars []TpCalendar
+	q := self.db.Where("tpid = ?", tpid)
+	if len(tag) != 0 {
+		q = q.Where("tag = ?", tag)
+	}
+	if err := q.Find(&tpCalendars).Error; err != nil {
+		return nil, err
+	}
+	for _, tpCal := range tpCalendars {
+		cals[tpCal.Tag] = append(cals[tpCal.Tag], &utils.TPCalendarDay{
+			Date:   tpCal.Date,
+			Yearly: tpCal.Yearly,
+		})
+	}
+	return cals, nil
+}
+
 func (self *SQLStorage) GetTpCdrStats(tpid, tag string) (map[string][]*utils.TPCdrStat, error) {
 	css := make(map[string][]*utils.TPCdrStat)
 
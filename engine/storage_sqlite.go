@@ -0,0 +1,169 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+	"github.com/cgrates/gorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type SQLiteStorage struct {
+	*SQLStorage
+}
+
+// The database name is the path towards the SQLite file, created if not already there
+func NewSQLiteStorage(name string, maxConn, maxIdleConn int) (Storage, error) {
+	db, err := gorm.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", name))
+	if err != nil {
+		return nil, err
+	}
+	if err = db.DB().Ping(); err != nil {
+		return nil, err
+	}
+	// SQLite serializes the writes, more connections would only end up in locked database errors
+	db.DB().SetMaxIdleConns(1)
+	db.DB().SetMaxOpenConns(1)
+	//db.LogMode(true)
+
+	return &SQLiteStorage{&SQLStorage{Db: db.DB(), db: db}}, nil
+}
+
+func (self *SQLiteStorage) Flush(scriptsPath string) (err error) {
+	for _, scriptName := range []string{CREATE_CDRS_TABLES_SQL, CREATE_TARIFFPLAN_TABLES_SQL} {
+		if err := self.CreateTablesFromScript(path.Join(scriptsPath, scriptName)); err != nil {
+			return err
+		}
+	}
+	for _, tbl := range []string{utils.TBL_CDRS_PRIMARY, utils.TBL_CDRS_EXTRA} {
+		if _, err := self.Db.Exec(fmt.Sprintf("SELECT 1 FROM %s", tbl)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SQLite does not accept parenthesis around the members of a compound select
+func (self *SQLiteStorage) GetTPIds() ([]string, error) {
+	rows, err := self.Db.Query(
+		fmt.Sprintf("SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s UNION SELECT tpid FROM %s",
+			utils.TBL_TP_TIMINGS,
+			utils.TBL_TP_DESTINATIONS,
+			utils.TBL_TP_RATES,
+			utils.TBL_TP_DESTINATION_RATES,
+			utils.TBL_TP_RATING_PLANS,
+			utils.TBL_TP_RATE_PROFILES))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (self *SQLiteStorage) SetTPTiming(tm *utils.ApierTPTiming) error {
+	if tm == nil {
+		return nil //Nothing to set
+	}
+	if _, err := self.Db.Exec(fmt.Sprintf("INSERT INTO %s (tpid, tag, years, months, month_days, week_days, time, created_at) VALUES(?,?,?,?,?,?,?,?) ON CONFLICT(tpid, tag) DO UPDATE SET years=excluded.years, months=excluded.months, month_days=excluded.month_days, week_days=excluded.week_days, time=excluded.time", utils.TBL_TP_TIMINGS),
+		tm.TPid, tm.TimingId, tm.Years, tm.Months, tm.MonthDays, tm.WeekDays, tm.Time, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (self *SQLiteStorage) LogCallCost(cgrid, source, runid string, cc *CallCost) (err error) {
+	if cc == nil {
+		return nil
+	}
+	tss, err := json.Marshal(cc.Timespans)
+	if err != nil {
+		Logger.Err(fmt.Sprintf("Error marshalling timespans to json: %v", err))
+		return err
+	}
+	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,tor,direction,tenant,category,account,subject,destination,cost,currency,debited_cost,debited_currency,tax,gross_cost,timespans,cost_source,created_at) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?) ON CONFLICT(cgrid, runid) DO UPDATE SET tor=excluded.tor,direction=excluded.direction,tenant=excluded.tenant,category=excluded.category,account=excluded.account,subject=excluded.subject,destination=excluded.destination,cost=excluded.cost,currency=excluded.currency,debited_cost=excluded.debited_cost,debited_currency=excluded.debited_currency,tax=excluded.tax,gross_cost=excluded.gross_cost,timespans=excluded.timespans,cost_source=excluded.cost_source,updated_at=excluded.created_at",
+		utils.TBL_COST_DETAILS),
+		cgrid,
+		runid,
+		cc.TOR,
+		cc.Direction,
+		cc.Tenant,
+		cc.Category,
+		cc.Account,
+		cc.Subject,
+		cc.Destination,
+		cc.Cost,
+		cc.Currency,
+		cc.DebitedCost,
+		cc.DebitedCurrency,
+		cc.Tax,
+		cc.GrossCost,
+		string(tss),
+		source,
+		time.Now())
+	if err != nil {
+		Logger.Err(fmt.Sprintf("failed to execute insert statement: %v", err))
+		return err
+	}
+	return nil
+}
+
+func (self *SQLiteStorage) SetRatedCdr(storedCdr *StoredCdr) (err error) {
+	_, err = self.Db.Exec(fmt.Sprintf("INSERT INTO %s (cgrid,runid,reqtype,direction,tenant,category,account,subject,destination,setup_time,answer_time,usage,supplier,disconnect_cause,cost,currency,debited_cost,debited_currency,tax,gross_cost,resolved_destination,extra_info,created_at) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?) ON CONFLICT(cgrid, runid) DO UPDATE SET reqtype=excluded.reqtype,direction=excluded.direction,tenant=excluded.tenant,category=excluded.category,account=excluded.account,subject=excluded.subject,destination=excluded.destination,setup_time=excluded.setup_time,answer_time=excluded.answer_time,usage=excluded.usage,cost=excluded.cost,currency=excluded.currency,debited_cost=excluded.debited_cost,debited_currency=excluded.debited_currency,tax=excluded.tax,gross_cost=excluded.gross_cost,resolved_destination=excluded.resolved_destination,supplier=excluded.supplier,disconnect_cause=excluded.disconnect_cause,extra_info=excluded.extra_info,updated_at=excluded.created_at",
+		utils.TBL_RATED_CDRS),
+		storedCdr.CgrId,
+		storedCdr.MediationRunId,
+		storedCdr.ReqType,
+		storedCdr.Direction,
+		storedCdr.Tenant,
+		storedCdr.Category,
+		storedCdr.Account,
+		storedCdr.Subject,
+		storedCdr.Destination,
+		storedCdr.SetupTime,
+		storedCdr.AnswerTime,
+		storedCdr.Usage.Seconds(),
+		storedCdr.Supplier,
+		storedCdr.DisconnectCause,
+		storedCdr.Cost,
+		storedCdr.Currency,
+		storedCdr.DebitedCost,
+		storedCdr.DebitedCurrency,
+		storedCdr.Tax,
+		storedCdr.GrossCost,
+		storedCdr.ResolvedDestination,
+		storedCdr.ExtraInfo,
+		time.Now())
+	if err != nil {
+		Logger.Err(fmt.Sprintf("failed to execute cdr insert statement: %s", err.Error()))
+	}
+	return
+}
@@ -0,0 +1,206 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+var sqliteDb *SQLiteStorage
+var sqliteDir string
+
+func TestSQLiteCreateTables(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if sqliteDir, err = ioutil.TempDir("", "cgr_sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := NewSQLiteStorage(path.Join(sqliteDir, "cgrates.db"), 0, 0); err != nil {
+		t.Fatal("Error on opening database connection: ", err)
+	} else {
+		sqliteDb = d.(*SQLiteStorage)
+	}
+	if err := sqliteDb.Flush(path.Join(*dataDir, "storage", utils.SQLITE)); err != nil {
+		t.Fatal("Error on sqliteDb creation: ", err.Error())
+	}
+}
+
+func TestSQLiteSetGetTPTiming(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	tm := &utils.ApierTPTiming{TPid: TEST_SQL, TimingId: "ALWAYS", Time: "00:00:00"}
+	if err := sqliteDb.SetTPTiming(tm); err != nil {
+		t.Error(err.Error())
+	}
+	if tmgs, err := sqliteDb.GetTpTimings(TEST_SQL, tm.TimingId); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(tm, tmgs[tm.TimingId]) {
+		t.Errorf("Expecting: %+v, received: %+v", tm, tmgs[tm.TimingId])
+	}
+	// Update
+	tm.Time = "00:00:01"
+	if err := sqliteDb.SetTPTiming(tm); err != nil {
+		t.Error(err.Error())
+	}
+	if tmgs, err := sqliteDb.GetTpTimings(TEST_SQL, tm.TimingId); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(tm, tmgs[tm.TimingId]) {
+		t.Errorf("Expecting: %+v, received: %+v", tm, tmgs[tm.TimingId])
+	}
+}
+
+func TestSQLiteSetGetTPDestination(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	dst := &Destination{Id: TEST_SQL, Prefixes: []string{"+49", "+49151", "+49176"}}
+	if err := sqliteDb.SetTPDestination(TEST_SQL, dst); err != nil {
+		t.Error(err.Error())
+	}
+	if dsts, err := sqliteDb.GetTpDestinations(TEST_SQL, TEST_SQL); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(dst, dsts[TEST_SQL]) {
+		t.Errorf("Expecting: %+v, received: %+v", dst, dsts[TEST_SQL])
+	}
+}
+
+func TestSQLiteGetTPIds(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	eTPIds := []string{TEST_SQL}
+	if tpIds, err := sqliteDb.GetTPIds(); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(eTPIds, tpIds) {
+		t.Errorf("Expecting: %+v, received: %+v", eTPIds, tpIds)
+	}
+}
+
+func TestSQLiteSetCdr(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	strCdr1 := &StoredCdr{TOR: utils.VOICE, AccId: "bbb1", CdrHost: "192.168.1.1", CdrSource: "UNKNOWN", ReqType: utils.META_RATED,
+		Direction: "*out", Tenant: "cgrates.org", Category: "call", Account: "1001", Subject: "1001", Destination: "1002",
+		SetupTime: time.Date(2013, 12, 7, 8, 42, 24, 0, time.UTC), AnswerTime: time.Date(2013, 12, 7, 8, 42, 26, 0, time.UTC),
+		Usage: time.Duration(10) * time.Second, Supplier: "SUPPL1",
+		ExtraFields:    map[string]string{"field_extr1": "val_extr1", "fieldextr2": "valextr2"},
+		MediationRunId: utils.DEFAULT_RUNID, Cost: 1.201, Currency: "EUR", DebitedCost: 1.02, DebitedCurrency: "GBP", Tax: 0.2282, GrossCost: 1.4292,
+		ResolvedDestination: "491002"}
+	strCdr1.CgrId = utils.Sha1(strCdr1.AccId, strCdr1.SetupTime.String())
+	if err := sqliteDb.SetCdr(strCdr1); err != nil {
+		t.Error(err.Error())
+	}
+	// Rated twice to check the update
+	for i := 0; i < 2; i++ {
+		if err := sqliteDb.SetRatedCdr(strCdr1); err != nil {
+			t.Error(err.Error())
+		}
+	}
+	cc := &CallCost{Direction: "*out", Category: "call", Tenant: "cgrates.org", Subject: "91001", Account: "8001", Destination: "1002", TOR: utils.VOICE,
+		Cost: 1.201, Currency: "EUR", DebitedCost: 1.02, DebitedCurrency: "GBP", Tax: 0.2282, GrossCost: 1.4292,
+		Timespans: []*TimeSpan{&TimeSpan{TimeStart: time.Date(2013, 9, 10, 13, 40, 0, 0, time.UTC), TimeEnd: time.Date(2013, 9, 10, 13, 41, 0, 0, time.UTC)}}}
+	for i := 0; i < 2; i++ {
+		if err := sqliteDb.LogCallCost(strCdr1.CgrId, TEST_SQL, utils.DEFAULT_RUNID, cc); err != nil {
+			t.Error(err.Error())
+		}
+	}
+	if ccRcv, err := sqliteDb.GetCallCostLog(strCdr1.CgrId, TEST_SQL, utils.DEFAULT_RUNID); err != nil {
+		t.Error(err.Error())
+	} else if !reflect.DeepEqual(cc, ccRcv) {
+		t.Errorf("Expecting call cost: %v, received: %v", cc, ccRcv)
+	}
+}
+
+func TestSQLiteGetStoredCdrs(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	if storedCdrs, _, err := sqliteDb.GetStoredCdrs(new(utils.CdrsFilter)); err != nil {
+		t.Error(err.Error())
+	} else if len(storedCdrs) != 1 {
+		t.Error("Unexpected number of StoredCdrs returned: ", storedCdrs)
+	} else if storedCdrs[0].Cost != 1.201 || storedCdrs[0].RatedAccount != "8001" || storedCdrs[0].Usage != time.Duration(10)*time.Second {
+		t.Errorf("Unexpected StoredCdr returned: %+v", storedCdrs[0])
+	} else if storedCdrs[0].Currency != "EUR" || storedCdrs[0].DebitedCost != 1.02 || storedCdrs[0].DebitedCurrency != "GBP" ||
+		storedCdrs[0].Tax != 0.2282 || storedCdrs[0].GrossCost != 1.4292 || storedCdrs[0].ResolvedDestination != "491002" {
+		t.Errorf("Unexpected rating fields returned: %+v", storedCdrs[0])
+	}
+	if storedCdrs, _, err := sqliteDb.GetStoredCdrs(&utils.CdrsFilter{DestPrefixes: []string{"+49"}}); err != nil {
+		t.Error(err.Error())
+	} else if len(storedCdrs) != 0 {
+		t.Error("Unexpected number of StoredCdrs returned: ", storedCdrs)
+	}
+}
+
+func TestSQLiteSetGetInvoices(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	inv := &Invoice{AccountId: "*out:cgrates.org:1001", PeriodStart: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC),
+		Lines: []*InvoiceLine{&InvoiceLine{Type: INVOICE_USAGE, Category: "call", DestinationId: "GERMANY", Quantity: 2, Net: 1, Tax: 0.19, Gross: 1.19}}, Net: 1, Tax: 0.19, Gross: 1.19}
+	if err := sqliteDb.SetInvoice(inv); err != nil {
+		t.Fatal(err)
+	} else if inv.Number != 1 {
+		t.Error("Wrong invoice number: ", inv.Number)
+	}
+	other := &Invoice{AccountId: "*out:cgrates.org:1002"}
+	if err := sqliteDb.SetInvoice(other); err != nil || other.Number != 2 {
+		t.Errorf("Wrong invoice number: %d, %v", other.Number, err)
+	}
+	// regeneration keeps the number
+	inv.Net = 2
+	if err := sqliteDb.SetInvoice(inv); err != nil || inv.Number != 1 {
+		t.Errorf("Wrong invoice number: %d, %v", inv.Number, err)
+	}
+	if invs, err := sqliteDb.GetInvoices("*out:cgrates.org:1001", 0); err != nil {
+		t.Error(err)
+	} else if len(invs) != 1 || invs[0].Number != 1 || invs[0].Net != 2 || len(invs[0].Lines) != 1 || invs[0].Lines[0].DestinationId != "GERMANY" {
+		t.Errorf("Wrong invoices: %+v", invs)
+	}
+	if invs, err := sqliteDb.GetInvoices("", 2); err != nil || len(invs) != 1 || invs[0].AccountId != "*out:cgrates.org:1002" {
+		t.Errorf("Wrong invoices: %+v, %v", invs, err)
+	}
+}
+
+func TestSQLiteRemStoredCdrs(t *testing.T) {
+	if !*testLocal {
+		return
+	}
+	cgrIdB1 := utils.Sha1("bbb1", time.Date(2013, 12, 7, 8, 42, 24, 0, time.UTC).String())
+	if err := sqliteDb.RemStoredCdrs([]string{cgrIdB1}); err != nil {
+		t.Error(err.Error())
+	}
+	if storedCdrs, _, err := sqliteDb.GetStoredCdrs(new(utils.CdrsFilter)); err != nil {
+		t.Error(err.Error())
+	} else if len(storedCdrs) != 0 {
+		t.Error("Unexpected number of StoredCdrs returned: ", storedCdrs)
+	}
+	sqliteDb.Close()
+	os.RemoveAll(sqliteDir)
+}
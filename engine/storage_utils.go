@@ -21,13 +21,14 @@ package engine
 import (
 	"errors"
 	"strconv"
+	"sync"
 
 	"github.com/cgrates/cgrates/utils"
 )
 
 // Various helpers to deal with database
 
-func ConfigureRatingStorage(db_type, host, port, name, user, pass, marshaler string) (db RatingStorage, err error) {
+func ConfigureRatingStorage(db_type, host, port, name, user, pass, marshaler string, sentinels []string, masterName string) (db RatingStorage, err error) {
 	var d Storage
 	switch db_type {
 	case utils.REDIS:
@@ -37,10 +38,18 @@ func ConfigureRatingStorage(db_type, host, port, name, user, pass, marshaler str
 			Logger.Crit("Redis db name must be an integer!")
 			return nil, err
 		}
+		if len(sentinels) != 0 {
+			d, err = NewRedisSentinelStorage(sentinels, masterName, db_nb, pass, marshaler)
+			break
+		}
 		if port != "" {
 			host += ":" + port
 		}
 		d, err = NewRedisStorage(host, db_nb, pass, marshaler)
+	case utils.MONGO:
+		d, err = NewMongoStorage(host, port, name, user, pass)
+	case utils.META_INTERNAL:
+		d, err = internalMapStorage(name, marshaler)
 	default:
 		err = errors.New("unknown db")
 	}
@@ -50,7 +59,7 @@ func ConfigureRatingStorage(db_type, host, port, name, user, pass, marshaler str
 	return d.(RatingStorage), nil
 }
 
-func ConfigureAccountingStorage(db_type, host, port, name, user, pass, marshaler string) (db AccountingStorage, err error) {
+func ConfigureAccountingStorage(db_type, host, port, name, user, pass, marshaler string, sentinels []string, masterName string) (db AccountingStorage, err error) {
 	var d Storage
 	switch db_type {
 	case utils.REDIS:
@@ -60,15 +69,18 @@ func ConfigureAccountingStorage(db_type, host, port, name, user, pass, marshaler
 			Logger.Crit("Redis db name must be an integer!")
 			return nil, err
 		}
+		if len(sentinels) != 0 {
+			d, err = NewRedisSentinelStorage(sentinels, masterName, db_nb, pass, marshaler)
+			break
+		}
 		if port != "" {
 			host += ":" + port
 		}
 		d, err = NewRedisStorage(host, db_nb, pass, marshaler)
-	/*
-		case utils.MONGO:
-			d, err = NewMongoStorage(host, port, name, user, pass)
-			db = d.(AccountingStorage)
-	*/
+	case utils.MONGO:
+		d, err = NewMongoStorage(host, port, name, user, pass)
+	case utils.META_INTERNAL:
+		d, err = internalMapStorage(name, marshaler)
 	default:
 		err = errors.New("unknown db")
 	}
@@ -93,13 +105,15 @@ func ConfigureLogStorage(db_type, host, port, name, user, pass, marshaler string
 				host += ":" + port
 			}
 			d, err = NewRedisStorage(host, db_nb, pass, marshaler)
-		case utils.MONGO:
-			d, err = NewMongoStorage(host, port, name, user, pass)
 	*/
 	case utils.POSTGRES:
 		d, err = NewPostgresStorage(host, port, name, user, pass, maxConn, maxIdleConn)
 	case utils.MYSQL:
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn)
+	case utils.MONGO:
+		d, err = NewMongoStorage(host, port, name, user, pass)
 	default:
 		err = errors.New("unknown db")
 	}
@@ -116,6 +130,10 @@ func ConfigureLoadStorage(db_type, host, port, name, user, pass, marshaler strin
 		d, err = NewPostgresStorage(host, port, name, user, pass, maxConn, maxIdleConn)
 	case utils.MYSQL:
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn)
+	case utils.MONGO:
+		d, err = NewMongoStorage(host, port, name, user, pass)
 	default:
 		err = errors.New("unknown db")
 	}
@@ -132,6 +150,10 @@ func ConfigureCdrStorage(db_type, host, port, name, user, pass string, maxConn,
 		d, err = NewPostgresStorage(host, port, name, user, pass, maxConn, maxIdleConn)
 	case utils.MYSQL:
 		d, err = NewMySQLStorage(host, port, name, user, pass, maxConn, maxIdleConn)
+	case utils.SQLITE:
+		d, err = NewSQLiteStorage(name, maxConn, maxIdleConn)
+	case utils.MONGO:
+		d, err = NewMongoStorage(host, port, name, user, pass)
 	default:
 		err = errors.New("unknown db")
 	}
@@ -140,3 +162,44 @@ func ConfigureCdrStorage(db_type, host, port, name, user, pass string, maxConn,
 	}
 	return d.(CdrStorage), nil
 }
+
+// Persistent map storage shared by the databases pointing to the same folder
+type internalStorage struct {
+	ms    *MapStorage
+	users int
+}
+
+var internalStorages = make(map[string]*internalStorage)
+var internalStoragesMux sync.Mutex
+
+// The database name is the folder holding the data, rating and accounting sharing it will share the storage too
+func internalMapStorage(dataDir, marshaler string) (*MapStorage, error) {
+	internalStoragesMux.Lock()
+	defer internalStoragesMux.Unlock()
+	if is, hasIt := internalStorages[dataDir]; hasIt {
+		is.users++
+		return is.ms, nil
+	}
+	ms, err := NewMapStoragePersistent(dataDir, MAP_SNAPSHOT_INTERVAL, marshaler)
+	if err != nil {
+		return nil, err
+	}
+	internalStorages[dataDir] = &internalStorage{ms: ms, users: 1}
+	return ms, nil
+}
+
+// Drops one user of a shared storage, returns true if nobody else uses it and it can be closed
+func releaseInternalMapStorage(ms *MapStorage) bool {
+	internalStoragesMux.Lock()
+	defer internalStoragesMux.Unlock()
+	is, hasIt := internalStorages[ms.dataDir]
+	if !hasIt || is.ms != ms {
+		return true
+	}
+	is.users--
+	if is.users > 0 {
+		return false
+	}
+	delete(internalStorages, ms.dataDir)
+	return true
+}
@@ -36,7 +36,9 @@ func NewStoredCdrFromExternalCdr(extCdr *ExternalCdr) (*StoredCdr, error) {
 	storedCdr := &StoredCdr{CgrId: extCdr.CgrId, OrderId: extCdr.OrderId, TOR: extCdr.TOR, AccId: extCdr.AccId, CdrHost: extCdr.CdrHost, CdrSource: extCdr.CdrSource,
 		ReqType: extCdr.ReqType, Direction: extCdr.Direction, Tenant: extCdr.Tenant, Category: extCdr.Category, Account: extCdr.Account, Subject: extCdr.Subject,
 		Destination: extCdr.Destination, Supplier: extCdr.Supplier, DisconnectCause: extCdr.DisconnectCause, ExtraFields: extCdr.ExtraFields,
-		MediationRunId: extCdr.MediationRunId, RatedAccount: extCdr.RatedAccount, RatedSubject: extCdr.RatedSubject, Cost: extCdr.Cost, Rated: extCdr.Rated}
+		MediationRunId: extCdr.MediationRunId, RatedAccount: extCdr.RatedAccount, RatedSubject: extCdr.RatedSubject, Cost: extCdr.Cost,
+		Currency: extCdr.Currency, DebitedCost: extCdr.DebitedCost, DebitedCurrency: extCdr.DebitedCurrency,
+		Tax: extCdr.Tax, GrossCost: extCdr.GrossCost, ResolvedDestination: extCdr.ResolvedDestination, Rated: extCdr.Rated}
 	if storedCdr.SetupTime, err = utils.ParseTimeDetectLayout(extCdr.SetupTime); err != nil {
 		return nil, err
 	}
@@ -56,32 +58,38 @@ func NewStoredCdrFromExternalCdr(extCdr *ExternalCdr) (*StoredCdr, error) {
 
 // Kinda standard of internal CDR, complies to CDR interface also
 type StoredCdr struct {
-	CgrId           string
-	OrderId         int64             // Stor order id used as export order id
-	TOR             string            // type of record, meta-field, should map to one of the TORs hardcoded inside the server <*voice|*data|*sms>
-	AccId           string            // represents the unique accounting id given by the telecom switch generating the CDR
-	CdrHost         string            // represents the IP address of the host generating the CDR (automatically populated by the server)
-	CdrSource       string            // formally identifies the source of the CDR (free form field)
-	ReqType         string            // matching the supported request types by the **CGRateS**, accepted values are hardcoded in the server <prepaid|postpaid|pseudoprepaid|rated>.
-	Direction       string            // matching the supported direction identifiers of the CGRateS <*out>
-	Tenant          string            // tenant whom this record belongs
-	Category        string            // free-form filter for this record, matching the category defined in rating profiles.
-	Account         string            // account id (accounting subsystem) the record should be attached to
-	Subject         string            // rating subject (rating subsystem) this record should be attached to
-	Destination     string            // destination to be charged
-	SetupTime       time.Time         // set-up time of the event. Supported formats: datetime RFC3339 compatible, SQL datetime (eg: MySQL), unix timestamp.
-	AnswerTime      time.Time         // answer time of the event. Supported formats: datetime RFC3339 compatible, SQL datetime (eg: MySQL), unix timestamp.
-	Usage           time.Duration     // event usage information (eg: in case of tor=*voice this will represent the total duration of a call)
-	Supplier        string            // Supplier information when available
-	DisconnectCause string            // Disconnect cause of the event
-	ExtraFields     map[string]string // Extra fields to be stored in CDR
-	MediationRunId  string
-	RatedAccount    string // Populated out of rating data
-	RatedSubject    string
-	Cost            float64
-	ExtraInfo       string    // Container for extra information related to this CDR, eg: populated with error reason in case of error on calculation
-	CostDetails     *CallCost // Attach the cost details to CDR when possible
-	Rated           bool      // Mark the CDR as rated so we do not process it during mediation
+	CgrId               string
+	OrderId             int64             // Stor order id used as export order id
+	TOR                 string            // type of record, meta-field, should map to one of the TORs hardcoded inside the server <*voice|*data|*sms>
+	AccId               string            // represents the unique accounting id given by the telecom switch generating the CDR
+	CdrHost             string            // represents the IP address of the host generating the CDR (automatically populated by the server)
+	CdrSource           string            // formally identifies the source of the CDR (free form field)
+	ReqType             string            // matching the supported request types by the **CGRateS**, accepted values are hardcoded in the server <prepaid|postpaid|pseudoprepaid|rated>.
+	Direction           string            // matching the supported direction identifiers of the CGRateS <*out>
+	Tenant              string            // tenant whom this record belongs
+	Category            string            // free-form filter for this record, matching the category defined in rating profiles.
+	Account             string            // account id (accounting subsystem) the record should be attached to
+	Subject             string            // rating subject (rating subsystem) this record should be attached to
+	Destination         string            // destination to be charged
+	SetupTime           time.Time         // set-up time of the event. Supported formats: datetime RFC3339 compatible, SQL datetime (eg: MySQL), unix timestamp.
+	AnswerTime          time.Time         // answer time of the event. Supported formats: datetime RFC3339 compatible, SQL datetime (eg: MySQL), unix timestamp.
+	Usage               time.Duration     // event usage information (eg: in case of tor=*voice this will represent the total duration of a call)
+	Supplier            string            // Supplier information when available
+	DisconnectCause     string            // Disconnect cause of the event
+	ExtraFields         map[string]string // Extra fields to be stored in CDR
+	MediationRunId      string
+	RatedAccount        string // Populated out of rating data
+	RatedSubject        string
+	Cost                float64
+	Currency            string  // currency of the rates the cost was calculated with
+	DebitedCost         float64 // cost converted into the currency of the account it was debited from
+	DebitedCurrency     string
+	Tax                 float64   // taxes on top of the cost
+	GrossCost           float64   // cost including the taxes
+	ResolvedDestination string    // destination the CDR was rated on, prefixed with the routing prefix if the number was ported
+	ExtraInfo           string    // Container for extra information related to this CDR, eg: populated with error reason in case of error on calculation
+	CostDetails         *CallCost // Attach the cost details to CDR when possible
+	Rated               bool      // Mark the CDR as rated so we do not process it during mediation
 }
 
 func (storedCdr *StoredCdr) CostDetailsJson() string {
@@ -100,15 +108,30 @@ func (storedCdr *StoredCdr) UsageMultiply(multiplyFactor float64, roundDecimals
 // Used to multiply cost on export
 func (storedCdr *StoredCdr) CostMultiply(multiplyFactor float64, roundDecimals int) {
 	storedCdr.Cost = utils.Round(storedCdr.Cost*multiplyFactor, roundDecimals, utils.ROUNDING_MIDDLE)
+	storedCdr.Tax = utils.Round(storedCdr.Tax*multiplyFactor, roundDecimals, utils.ROUNDING_MIDDLE)
+	storedCdr.GrossCost = utils.Round(storedCdr.GrossCost*multiplyFactor, roundDecimals, utils.ROUNDING_MIDDLE)
 }
 
 // Format cost as string on export
 func (storedCdr *StoredCdr) FormatCost(shiftDecimals, roundDecimals int) string {
-	cost := storedCdr.Cost
+	return formatAmount(storedCdr.Cost, shiftDecimals, roundDecimals)
+}
+
+// Format tax as string on export, same as the cost
+func (storedCdr *StoredCdr) FormatTax(shiftDecimals, roundDecimals int) string {
+	return formatAmount(storedCdr.Tax, shiftDecimals, roundDecimals)
+}
+
+// Format gross cost as string on export, same as the cost
+func (storedCdr *StoredCdr) FormatGrossCost(shiftDecimals, roundDecimals int) string {
+	return formatAmount(storedCdr.GrossCost, shiftDecimals, roundDecimals)
+}
+
+func formatAmount(amount float64, shiftDecimals, roundDecimals int) string {
 	if shiftDecimals != 0 {
-		cost = cost * math.Pow10(shiftDecimals)
+		amount = amount * math.Pow10(shiftDecimals)
 	}
-	return strconv.FormatFloat(cost, 'f', roundDecimals, 64)
+	return strconv.FormatFloat(amount, 'f', roundDecimals, 64)
 }
 
 // Formats usage on export
@@ -169,6 +192,18 @@ func (storedCdr *StoredCdr) FieldAsString(rsrFld *utils.RSRField) string {
 		return rsrFld.ParseValue(storedCdr.RatedSubject)
 	case utils.COST:
 		return rsrFld.ParseValue(strconv.FormatFloat(storedCdr.Cost, 'f', -1, 64)) // Recommended to use FormatCost
+	case utils.CURRENCY:
+		return rsrFld.ParseValue(storedCdr.Currency)
+	case utils.DEBITED_COST:
+		return rsrFld.ParseValue(strconv.FormatFloat(storedCdr.DebitedCost, 'f', -1, 64))
+	case utils.DEBITED_CURRENCY:
+		return rsrFld.ParseValue(storedCdr.DebitedCurrency)
+	case utils.TAX:
+		return rsrFld.ParseValue(strconv.FormatFloat(storedCdr.Tax, 'f', -1, 64))
+	case utils.GROSS_COST:
+		return rsrFld.ParseValue(strconv.FormatFloat(storedCdr.GrossCost, 'f', -1, 64))
+	case utils.RESOLVED_DESTINATION:
+		return rsrFld.ParseValue(storedCdr.ResolvedDestination)
 	case utils.COST_DETAILS:
 		return rsrFld.ParseValue(storedCdr.CostDetailsJson())
 	default:
@@ -370,29 +405,35 @@ func (storedCdr *StoredCdr) ForkCdr(runId string, reqTypeFld, directionFld, tena
 
 func (storedCdr *StoredCdr) AsExternalCdr() *ExternalCdr {
 	return &ExternalCdr{CgrId: storedCdr.CgrId,
-		OrderId:         storedCdr.OrderId,
-		TOR:             storedCdr.TOR,
-		AccId:           storedCdr.AccId,
-		CdrHost:         storedCdr.CdrHost,
-		CdrSource:       storedCdr.CdrSource,
-		ReqType:         storedCdr.ReqType,
-		Direction:       storedCdr.Direction,
-		Tenant:          storedCdr.Tenant,
-		Category:        storedCdr.Category,
-		Account:         storedCdr.Account,
-		Subject:         storedCdr.Subject,
-		Destination:     storedCdr.Destination,
-		SetupTime:       storedCdr.SetupTime.Format(time.RFC3339),
-		AnswerTime:      storedCdr.AnswerTime.Format(time.RFC3339),
-		Usage:           storedCdr.FormatUsage(utils.SECONDS),
-		Supplier:        storedCdr.Supplier,
-		DisconnectCause: storedCdr.DisconnectCause,
-		ExtraFields:     storedCdr.ExtraFields,
-		MediationRunId:  storedCdr.MediationRunId,
-		RatedAccount:    storedCdr.RatedAccount,
-		RatedSubject:    storedCdr.RatedSubject,
-		Cost:            storedCdr.Cost,
-		CostDetails:     storedCdr.CostDetailsJson(),
+		OrderId:             storedCdr.OrderId,
+		TOR:                 storedCdr.TOR,
+		AccId:               storedCdr.AccId,
+		CdrHost:             storedCdr.CdrHost,
+		CdrSource:           storedCdr.CdrSource,
+		ReqType:             storedCdr.ReqType,
+		Direction:           storedCdr.Direction,
+		Tenant:              storedCdr.Tenant,
+		Category:            storedCdr.Category,
+		Account:             storedCdr.Account,
+		Subject:             storedCdr.Subject,
+		Destination:         storedCdr.Destination,
+		SetupTime:           storedCdr.SetupTime.Format(time.RFC3339),
+		AnswerTime:          storedCdr.AnswerTime.Format(time.RFC3339),
+		Usage:               storedCdr.FormatUsage(utils.SECONDS),
+		Supplier:            storedCdr.Supplier,
+		DisconnectCause:     storedCdr.DisconnectCause,
+		ExtraFields:         storedCdr.ExtraFields,
+		MediationRunId:      storedCdr.MediationRunId,
+		RatedAccount:        storedCdr.RatedAccount,
+		RatedSubject:        storedCdr.RatedSubject,
+		Cost:                storedCdr.Cost,
+		Currency:            storedCdr.Currency,
+		DebitedCost:         storedCdr.DebitedCost,
+		DebitedCurrency:     storedCdr.DebitedCurrency,
+		Tax:                 storedCdr.Tax,
+		GrossCost:           storedCdr.GrossCost,
+		ResolvedDestination: storedCdr.ResolvedDestination,
+		CostDetails:         storedCdr.CostDetailsJson(),
 	}
 }
 
@@ -560,31 +601,37 @@ func (storedCdr *StoredCdr) String() string {
 }
 
 type ExternalCdr struct {
-	CgrId           string
-	OrderId         int64
-	TOR             string
-	AccId           string
-	CdrHost         string
-	CdrSource       string
-	ReqType         string
-	Direction       string
-	Tenant          string
-	Category        string
-	Account         string
-	Subject         string
-	Destination     string
-	SetupTime       string
-	AnswerTime      string
-	Usage           string
-	Supplier        string
-	DisconnectCause string
-	ExtraFields     map[string]string
-	MediationRunId  string
-	RatedAccount    string
-	RatedSubject    string
-	Cost            float64
-	CostDetails     string
-	Rated           bool // Mark the CDR as rated so we do not process it during mediation
+	CgrId               string
+	OrderId             int64
+	TOR                 string
+	AccId               string
+	CdrHost             string
+	CdrSource           string
+	ReqType             string
+	Direction           string
+	Tenant              string
+	Category            string
+	Account             string
+	Subject             string
+	Destination         string
+	SetupTime           string
+	AnswerTime          string
+	Usage               string
+	Supplier            string
+	DisconnectCause     string
+	ExtraFields         map[string]string
+	MediationRunId      string
+	RatedAccount        string
+	RatedSubject        string
+	Cost                float64
+	Currency            string
+	DebitedCost         float64
+	DebitedCurrency     string
+	Tax                 float64
+	GrossCost           float64
+	ResolvedDestination string
+	CostDetails         string
+	Rated               bool // Mark the CDR as rated so we do not process it during mediation
 }
 
 // Used when authorizing requests from outside, eg ApierV1.GetMaxSessionTime
@@ -0,0 +1,132 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Tax applied on top of the net cost, eg: VAT or telecom excise
+type TaxRule struct {
+	Id             string // tax tag, a newer activation of the same tax replaces the older one
+	Category       string // *any for all categories
+	DestinationId  string // *any for all destinations
+	ActivationTime time.Time
+	Rate           float64 // part of the net cost, eg: 0.19 for 19%
+}
+
+// Tax rules of one tenant, the ones of *any apply on all tenants
+type TaxRules struct {
+	Id    string // tenant
+	Rules []*TaxRule
+}
+
+func (tr *TaxRule) matchesCategory(category string) bool {
+	return tr.Category == "" || tr.Category == utils.ANY || tr.Category == category
+}
+
+func (tr *TaxRule) matchesDestination(destination string, matchDestinations func(string) []*PrefixMatch) bool {
+	if tr.DestinationId == "" || tr.DestinationId == utils.ANY {
+		return true
+	}
+	for _, match := range matchDestinations(destination) {
+		for _, dId := range match.Ids {
+			if dId == tr.DestinationId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Adds the rule replacing an existing one of the same tax, category, destination and activation time
+func (trs *TaxRules) AddRule(rules ...*TaxRule) {
+	for _, rule := range rules {
+		found := false
+		for idx, existing := range trs.Rules {
+			if existing.Id == rule.Id && existing.Category == rule.Category && existing.DestinationId == rule.DestinationId &&
+				existing.ActivationTime.Equal(rule.ActivationTime) {
+				trs.Rules[idx] = rule
+				found = true
+				break
+			}
+		}
+		if !found {
+			trs.Rules = append(trs.Rules, rule)
+		}
+	}
+}
+
+// Returns the rules active at the specified time, one per tax: the latest activated out of the matching ones
+func (trs *TaxRules) GetActiveRules(category, destination string, t time.Time) map[string]*TaxRule {
+	return trs.getActiveRules(category, destination, t, MatchDestinations)
+}
+
+func (trs *TaxRules) getActiveRules(category, destination string, t time.Time, matchDestinations func(string) []*PrefixMatch) map[string]*TaxRule {
+	active := make(map[string]*TaxRule)
+	for _, rule := range trs.Rules {
+		if rule.ActivationTime.After(t) || !rule.matchesCategory(category) || !rule.matchesDestination(destination, matchDestinations) {
+			continue
+		}
+		if existing, exists := active[rule.Id]; !exists || rule.ActivationTime.After(existing.ActivationTime) {
+			active[rule.Id] = rule
+		}
+	}
+	return active
+}
+
+// Returns the sum of the taxes applying on the costs of a tenant at the specified time.
+// The taxes of the tenant replace the ones with the same tag defined for all tenants.
+func GetTaxRate(tenant, category, destination string, t time.Time) (rate float64) {
+	return getTaxRate(dataStorage, MatchDestinations, tenant, category, destination, t)
+}
+
+func getTaxRate(ratingDb RatingStorage, matchDestinations func(string) []*PrefixMatch, tenant, category, destination string, t time.Time) (rate float64) {
+	active := make(map[string]*TaxRule)
+	for _, tnt := range []string{utils.ANY, tenant} {
+		trs, err := ratingDb.GetTaxRules(tnt, false)
+		if err != nil || trs == nil {
+			continue
+		}
+		for taxId, rule := range trs.getActiveRules(category, destination, t, matchDestinations) {
+			active[taxId] = rule
+		}
+	}
+	for _, rule := range active {
+		rate += rule.Rate
+	}
+	return
+}
+
+// Splits the net cost into tax and gross cost
+func (cc *CallCost) applyTax(taxRate float64, roundingDecimals int, roundingMethod string) {
+	cc.Tax = utils.Round(cc.Cost*taxRate, roundingDecimals, roundingMethod)
+	cc.GrossCost = utils.Round(cc.Cost+cc.Tax, roundingDecimals, roundingMethod)
+}
+
+// Tax rate of the call, looked up once at the time the call started
+func (cd *CallDescriptor) getTaxRate() float64 {
+	if !cd.taxLoaded {
+		cd.taxRate = getTaxRate(cd.getRatingStorage(), cd.matchDestinations, cd.Tenant, cd.Category, cd.Destination, cd.TimeStart)
+		cd.taxLoaded = true
+	}
+	return cd.taxRate
+}
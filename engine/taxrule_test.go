@@ -0,0 +1,111 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestTaxRulesAddRule(t *testing.T) {
+	trs := &TaxRules{Id: "cgrates.org"}
+	trs.AddRule(&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.19},
+		&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC), Rate: 0.2})
+	trs.AddRule(&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.21})
+	if len(trs.Rules) != 2 {
+		t.Fatal("Rule with the same activation not replaced: ", trs.Rules)
+	}
+	if trs.Rules[0].Rate != 0.21 {
+		t.Error("Wrong replaced rule: ", trs.Rules[0])
+	}
+}
+
+func TestTaxRulesGetActiveRules(t *testing.T) {
+	trs := &TaxRules{Id: "cgrates.org", Rules: []*TaxRule{
+		&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.19},
+		&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC), Rate: 0.2},
+		&TaxRule{Id: "EXCISE", Category: "call", DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.05},
+	}}
+	if active := trs.GetActiveRules("call", "1002", time.Date(2014, 12, 1, 0, 0, 0, 0, time.UTC)); len(active) != 0 {
+		t.Error("Rules active before activation: ", active)
+	}
+	if active := trs.GetActiveRules("call", "1002", time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)); len(active) != 2 || active["VAT"].Rate != 0.19 {
+		t.Error("Wrong active rules: ", active)
+	}
+	if active := trs.GetActiveRules("sms", "1002", time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)); len(active) != 1 || active["VAT"].Rate != 0.2 {
+		t.Error("Wrong active rules: ", active)
+	}
+}
+
+func TestGetTaxRate(t *testing.T) {
+	// rules loaded out of the csv test data
+	if rate := GetTaxRate("tax.org", "call", "+49715", time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)); utils.Round(rate, 4, utils.ROUNDING_MIDDLE) != 0.24 {
+		t.Error("Wrong tax rate: ", rate)
+	}
+	if rate := GetTaxRate("tax.org", "call", "+49715", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); utils.Round(rate, 4, utils.ROUNDING_MIDDLE) != 0.25 {
+		t.Error("Wrong tax rate: ", rate)
+	}
+	if rate := GetTaxRate("tax.org", "call", "0723045", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 0.2 {
+		t.Error("Excise applied outside its destination: ", rate)
+	}
+	if rate := GetTaxRate("tax.org", "sms", "+49715", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 0.2 {
+		t.Error("Excise applied outside its category: ", rate)
+	}
+	if rate := GetTaxRate("cgrates.org", "call", "+49715", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 0 {
+		t.Error("Tax applied on other tenant: ", rate)
+	}
+}
+
+func TestGetTaxRateCached(t *testing.T) {
+	trs := &TaxRules{Id: "cache.org", Rules: []*TaxRule{
+		&TaxRule{Id: "VAT", Category: utils.ANY, DestinationId: utils.ANY, ActivationTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Rate: 0.1}}}
+	if err := dataStorage.SetTaxRules(trs); err != nil {
+		t.Fatal(err)
+	}
+	if rate := GetTaxRate("cache.org", "call", "+49715", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 0 {
+		t.Error("Tax rules used before caching: ", rate)
+	}
+	if err := dataStorage.CacheRating([]string{}, []string{}, []string{}, []string{}, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if rate := GetTaxRate("cache.org", "call", "+49715", time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)); rate != 0.1 {
+		t.Error("Wrong tax rate after caching: ", rate)
+	}
+}
+
+func TestBalanceInfoGetMoneyAmountTaxed(t *testing.T) {
+	bi := &BalanceInfo{TaxRate: 0.2}
+	if amount := bi.GetMoneyAmount(10); amount != 12 {
+		t.Error("Wrong taxed amount: ", amount)
+	}
+	bi.ExchangeRate = 0.5
+	if amount := bi.GetMoneyAmount(10); amount != 6 {
+		t.Error("Wrong taxed and converted amount: ", amount)
+	}
+}
+
+func TestCallCostApplyTax(t *testing.T) {
+	cc := &CallCost{Cost: 1.5}
+	cc.applyTax(0.19, 4, utils.ROUNDING_MIDDLE)
+	if cc.Tax != 0.285 || cc.GrossCost != 1.785 {
+		t.Errorf("Wrong tax breakdown: %+v", cc)
+	}
+}
@@ -67,13 +67,17 @@ func (mi *UnitInfo) Equal(other *UnitInfo) bool {
 type BalanceInfo struct {
 	UnitBalanceUuid  string
 	MoneyBalanceUuid string
-	AccountId        string // used when debited from shared balance
+	AccountId        string  // used when debited from shared balance
+	ExchangeRate     float64 // converts the cost into the currency of the money balance, 0 when no conversion was needed
+	TaxRate          float64 // taxes debited on top of the cost
 }
 
 func (bi *BalanceInfo) Equal(other *BalanceInfo) bool {
 	return bi.UnitBalanceUuid == other.UnitBalanceUuid &&
 		bi.MoneyBalanceUuid == other.MoneyBalanceUuid &&
-		bi.AccountId == other.AccountId
+		bi.AccountId == other.AccountId &&
+		bi.ExchangeRate == other.ExchangeRate &&
+		bi.TaxRate == other.TaxRate
 }
 
 type TimeSpans []*TimeSpan
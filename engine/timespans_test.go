@@ -1499,35 +1499,35 @@ func TestTSCompressDecompress(t *testing.T) {
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 1111 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
@@ -1551,35 +1551,35 @@ func TestTSMultipleCompressDecompress(t *testing.T) {
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 1111 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
 				&Increment{
 					Duration:            time.Minute,
 					Cost:                10.4,
-					BalanceInfo:         &BalanceInfo{"1", "2", "3"},
+					BalanceInfo:         &BalanceInfo{"1", "2", "3", 0, 0},
 					BalanceRateInterval: &RateInterval{Rating: &RIRate{Rates: RateGroups{&Rate{GroupIntervalStart: 0, Value: 100, RateIncrement: 10 * time.Second, RateUnit: time.Second}}}},
 					UnitInfo:            &UnitInfo{"1", 2.3, utils.VOICE},
 				},
@@ -35,7 +35,8 @@ import (
 var (
 	TPExportFormats = []string{utils.CSV}
 	exportedFiles   = []string{utils.TIMINGS_CSV, utils.DESTINATIONS_CSV, utils.RATES_CSV, utils.DESTINATION_RATES_CSV, utils.RATING_PLANS_CSV, utils.RATING_PROFILES_CSV,
-		utils.SHARED_GROUPS_CSV, utils.ACTIONS_CSV, utils.ACTION_PLANS_CSV, utils.ACTION_TRIGGERS_CSV, utils.ACCOUNT_ACTIONS_CSV, utils.DERIVED_CHARGERS_CSV, utils.CDR_STATS_CSV}
+		utils.SHARED_GROUPS_CSV, utils.ACTIONS_CSV, utils.ACTION_PLANS_CSV, utils.ACTION_TRIGGERS_CSV, utils.ACCOUNT_ACTIONS_CSV, utils.DERIVED_CHARGERS_CSV, utils.CDR_STATS_CSV,
+		utils.EXCHANGE_RATES_CSV, utils.TAX_RULES_CSV, utils.PORTED_NUMBERS_CSV, utils.CALENDARS_CSV}
 )
 
 func NewTPExporter(storDb LoadStorage, tpID, expPath, fileFormat, sep string, compress bool) (*TPExporter, error) {
@@ -102,6 +103,10 @@ func (self *TPExporter) Run() error {
 		self.exportAccountActions,
 		self.exportDerivedChargers,
 		self.exportCdrStats,
+		self.exportExchangeRates,
+		self.exportTaxRules,
+		self.exportPortedNumbers,
+		self.exportCalendars,
 	} {
 		if err := fHandler(); err != nil {
 			self.removeFiles()
@@ -423,6 +428,82 @@ func (self *TPExporter) ExportStats() *utils.ExportedTPStats {
 	return &utils.ExportedTPStats{ExportPath: self.exportPath, ExportedFiles: self.exportedFiles, Compressed: self.compress}
 }
 
+func (self *TPExporter) exportExchangeRates() error {
+	fileName := exportedFiles[13]
+	storData, err := self.storDb.GetTpExchangeRates(self.tpID, "")
+	if err != nil {
+		return nil
+	}
+	exportedData := make([]utils.ExportedData, len(storData))
+	idx := 0
+	for erId, ers := range storData {
+		exportedData[idx] = &utils.TPExchangeRates{TPid: self.tpID, ExchangeRatesId: erId, ExchangeRates: ers}
+		idx += 1
+	}
+	if err := self.writeOut(fileName, exportedData); err != nil {
+		return err
+	}
+	self.exportedFiles = append(self.exportedFiles, fileName)
+	return nil
+}
+
+func (self *TPExporter) exportTaxRules() error {
+	fileName := exportedFiles[14]
+	storData, err := self.storDb.GetTpTaxRules(self.tpID, "")
+	if err != nil {
+		return nil
+	}
+	exportedData := make([]utils.ExportedData, len(storData))
+	idx := 0
+	for trId, trs := range storData {
+		exportedData[idx] = &utils.TPTaxRules{TPid: self.tpID, TaxRulesId: trId, TaxRules: trs}
+		idx += 1
+	}
+	if err := self.writeOut(fileName, exportedData); err != nil {
+		return err
+	}
+	self.exportedFiles = append(self.exportedFiles, fileName)
+	return nil
+}
+
+func (self *TPExporter) exportPortedNumbers() error {
+	fileName := exportedFiles[15]
+	storData, err := self.storDb.GetTpPortedNumbers(self.tpID, "")
+	if err != nil {
+		return nil
+	}
+	exportedData := make([]utils.ExportedData, len(storData))
+	idx := 0
+	for pnId, pns := range storData {
+		exportedData[idx] = &utils.TPPortedNumbers{TPid: self.tpID, PortedNumbersId: pnId, PortedNumbers: pns}
+		idx += 1
+	}
+	if err := self.writeOut(fileName, exportedData); err != nil {
+		return err
+	}
+	self.exportedFiles = append(self.exportedFiles, fileName)
+	return nil
+}
+
+func (self *TPExporter) exportCalendars() error {
+	fileName := exportedFiles[16]
+	storData, err := self.storDb.GetTpCalendars(self.tpID, "")
+	if err != nil {
+		return nil
+	}
+	exportedData := make([]utils.ExportedData, len(storData))
+	idx := 0
+	for calId, days := range storData {
+		exportedData[idx] = &utils.TPCalendar{TPid: self.tpID, CalendarId: calId, Days: days}
+		idx += 1
+	}
+	if err := self.writeOut(fileName, exportedData); err != nil {
+		return err
+	}
+	self.exportedFiles = append(self.exportedFiles, fileName)
+	return nil
+}
+
 func (self *TPExporter) GetCacheBuffer() *bytes.Buffer {
 	return self.cacheBuff
 }
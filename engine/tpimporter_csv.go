@@ -54,6 +54,10 @@ var fileHandlers = map[string]func(*TPCSVImporter, string) error{
 	utils.ACCOUNT_ACTIONS_CSV:   (*TPCSVImporter).importAccountActions,
 	utils.DERIVED_CHARGERS_CSV:  (*TPCSVImporter).importDerivedChargers,
 	utils.CDR_STATS_CSV:         (*TPCSVImporter).importCdrStats,
+	utils.EXCHANGE_RATES_CSV:    (*TPCSVImporter).importExchangeRates,
+	utils.TAX_RULES_CSV:         (*TPCSVImporter).importTaxRules,
+	utils.PORTED_NUMBERS_CSV:    (*TPCSVImporter).importPortedNumbers,
+	utils.CALENDARS_CSV:         (*TPCSVImporter).importCalendars,
 }
 
 func (self *TPCSVImporter) Run() error {
@@ -208,16 +212,28 @@ func (self *TPCSVImporter) importDestinationRates(fn string) error {
 			log.Printf("Error parsing max cost from: %v", record[5])
 			return err
 		}
+		minCost, err := strconv.ParseFloat(ValueOrDefault(record[10], "0"), 64)
+		if err != nil {
+			log.Printf("Error parsing min cost from: %v", record[10])
+			return err
+		}
+		connectFeeOnAnswer, _ := strconv.ParseBool(record[12])
 		if _, hasIt := drs[record[0]]; !hasIt {
 			drs[record[0]] = make([]*utils.DestinationRate, 0)
 		}
 		drs[record[0]] = append(drs[record[0]], &utils.DestinationRate{
-			DestinationId:    record[1],
-			RateId:           record[2],
-			RoundingMethod:   record[3],
-			RoundingDecimals: roundingDecimals,
-			MaxCost:          maxCost,
-			MaxCostStrategy:  record[6],
+			DestinationId:      record[1],
+			RateId:             record[2],
+			RoundingMethod:     record[3],
+			RoundingDecimals:   roundingDecimals,
+			MaxCost:            maxCost,
+			MaxCostStrategy:    record[6],
+			TierPeriod:         record[7],
+			Currency:           record[8],
+			MinDuration:        record[9],
+			MinCost:            minCost,
+			FreeUnder:          record[11],
+			ConnectFeeOnAnswer: connectFeeOnAnswer,
 		})
 	}
 
@@ -317,7 +333,8 @@ func (self *TPCSVImporter) importRatingProfiles(fn string) error {
 			Direction: direction,
 			Subject:   subject,
 			RatingPlanActivations: []*utils.TPRatingActivation{
-				&utils.TPRatingActivation{ActivationTime: record[4], RatingPlanId: ratingPlanTag, FallbackSubjects: fallbacksubject}},
+				&utils.TPRatingActivation{ActivationTime: record[4], RatingPlanId: ratingPlanTag, FallbackSubjects: fallbacksubject,
+					CdrStatQueueIds: record[7], Timezone: record[8]}},
 		}
 		if rp, hasIt := rpfs[newRp.KeyId()]; hasIt {
 			rp.RatingPlanActivations = append(rp.RatingPlanActivations, newRp.RatingPlanActivations...)
@@ -471,6 +488,7 @@ func (self *TPCSVImporter) importActionTimings(fn string) error {
 			ActionsId: actionsTag,
 			TimingId:  timingTag,
 			Weight:    weight,
+			Timezone:  record[4],
 		})
 	}
 	if err := self.StorDb.SetTPActionTimings(self.TPid, aplns); err != nil {
@@ -730,3 +748,142 @@ func (self *TPCSVImporter) importCdrStats(fn string) error {
 	}
 	return nil
 }
+
+func (self *TPCSVImporter) importExchangeRates(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	fParser, err := NewTPCSVFileParser(self.DirPath, fn)
+	if err != nil {
+		return err
+	}
+	ers := make(map[string][]*utils.TPExchangeRate)
+	lineNr := 0
+	for {
+		lineNr++
+		record, err := fParser.ParseNextLine()
+		if err == io.EOF { // Reached end of file
+			break
+		} else if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		rate, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		ers[record[0]] = append(ers[record[0]], &utils.TPExchangeRate{FromCurrency: record[1], ToCurrency: record[2], ActivationTime: record[3], Rate: rate})
+	}
+	if err := self.StorDb.SetTPExchangeRates(self.TPid, ers); err != nil {
+		if self.Verbose {
+			log.Printf("Ignoring line %d, storDb operational error: <%s> ", lineNr, err.Error())
+		}
+	}
+	return nil
+}
+
+func (self *TPCSVImporter) importTaxRules(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	fParser, err := NewTPCSVFileParser(self.DirPath, fn)
+	if err != nil {
+		return err
+	}
+	trs := make(map[string][]*utils.TPTaxRule)
+	lineNr := 0
+	for {
+		lineNr++
+		record, err := fParser.ParseNextLine()
+		if err == io.EOF { // Reached end of file
+			break
+		} else if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		rate, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		trs[record[0]] = append(trs[record[0]], &utils.TPTaxRule{Tenant: record[1], Category: record[2], DestinationId: record[3], ActivationTime: record[4], Rate: rate})
+	}
+	if err := self.StorDb.SetTPTaxRules(self.TPid, trs); err != nil {
+		if self.Verbose {
+			log.Printf("Ignoring line %d, storDb operational error: <%s> ", lineNr, err.Error())
+		}
+	}
+	return nil
+}
+
+func (self *TPCSVImporter) importPortedNumbers(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	fParser, err := NewTPCSVFileParser(self.DirPath, fn)
+	if err != nil {
+		return err
+	}
+	pns := make(map[string][]*utils.TPPortedNumber)
+	lineNr := 0
+	for {
+		lineNr++
+		record, err := fParser.ParseNextLine()
+		if err == io.EOF { // Reached end of file
+			break
+		} else if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		pns[record[0]] = append(pns[record[0]], &utils.TPPortedNumber{Number: record[1], RoutingPrefix: record[2]})
+	}
+	if err := self.StorDb.SetTPPortedNumbers(self.TPid, pns); err != nil {
+		if self.Verbose {
+			log.Printf("Ignoring line %d, storDb operational error: <%s> ", lineNr, err.Error())
+		}
+	}
+	return nil
+}
+
+func (self *TPCSVImporter) importCalendars(fn string) error {
+	if self.Verbose {
+		log.Printf("Processing file: <%s> ", fn)
+	}
+	fParser, err := NewTPCSVFileParser(self.DirPath, fn)
+	if err != nil {
+		return err
+	}
+	cals := make(map[string][]*utils.TPCalendarDay)
+	lineNr := 0
+	for {
+		lineNr++
+		record, err := fParser.ParseNextLine()
+		if err == io.EOF { // Reached end of file
+			break
+		} else if err != nil {
+			if self.Verbose {
+				log.Printf("Ignoring line %d, warning: <%s> ", lineNr, err.Error())
+			}
+			continue
+		}
+		yearly, _ := strconv.ParseBool(record[2])
+		cals[record[0]] = append(cals[record[0]], &utils.TPCalendarDay{Date: record[1], Yearly: yearly})
+	}
+	if err := self.StorDb.SetTPCalendars(self.TPid, cals); err != nil {
+		if self.Verbose {
+			log.Printf("Ignoring line %d, storDb operational error: <%s> ", lineNr, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Attempts to lock the receiving account of a *transfer_balance action, and the pause between them
+const (
+	TRANSFER_LOCK_TRIES = 5
+	TRANSFER_LOCK_WAIT  = 20 * time.Millisecond
+)
+
+// Moves the value of the action balance out of the balances of one account matching the action filters
+// into a balance with the same filters of the other account, then saves both accounts.
+// Both accounts have to be locked by the caller. On error none of them is changed.
+func TransferBalance(from, to *Account, a *Action) error {
+	if from == nil || to == nil || a == nil || a.Balance == nil {
+		return errors.New("nil account or action")
+	}
+	if from.Id == to.Id {
+		return fmt.Errorf("cannot transfer within the same account %s", from.Id)
+	}
+	if to.Disabled {
+		return fmt.Errorf("account %s is disabled", to.Id)
+	}
+	amount := a.Balance.Value
+	if amount <= 0 {
+		return fmt.Errorf("invalid transfer value: %v", amount)
+	}
+	id := a.BalanceType + a.Direction
+	// the source balances paying for the transfer, the most important ones first
+	from.CleanExpiredBalances()
+	from.BalanceMap[id].Sort()
+	var sources BalanceChain
+	available := 0.0
+	for _, b := range from.BalanceMap[id] {
+		if b.IsExpired() || b.Value <= 0 || b.SharedGroup != "" || !b.MatchFilter(a.Balance) {
+			continue
+		}
+		sources = append(sources, b)
+		available += b.Value
+	}
+	if available < amount {
+		return fmt.Errorf("not enough %s in account %s: %v available for transfering %v", a.BalanceType, from.Id, available, amount)
+	}
+	fromChain, toChain := from.BalanceMap[id].Clone(), to.BalanceMap[id].Clone()
+	fromQueued, toQueued := len(from.ledger), len(to.ledger)
+	rollback := func() {
+		from.BalanceMap[id], to.BalanceMap[id] = fromChain, toChain
+		from.ledger, to.ledger = from.ledger[:fromQueued], to.ledger[:toQueued]
+	}
+	fromLedger, toLedger := newLedgerSnapshot(from), newLedgerSnapshot(to)
+	left := amount
+	for _, b := range sources {
+		debit := math.Min(b.Value, left)
+		b.SubstractAmount(debit)
+		if left = utils.Round(left-debit, globalRoundingDecimals, utils.ROUNDING_MIDDLE); left <= 0 {
+			break
+		}
+	}
+	to.creditBalance(id, a.Balance, amount)
+	fromLedger.write("", a)
+	toLedger.write("", a)
+	if err := accountingStorage.SetAccount(to); err != nil {
+		rollback()
+		return err
+	}
+	if err := accountingStorage.SetAccount(from); err != nil {
+		rollback()
+		if errTo := accountingStorage.SetAccount(to); errTo != nil {
+			Logger.Crit(fmt.Sprintf("<TransferBalance> Could not roll back the credit of account %s: %v", to.Id, errTo))
+		}
+		return err
+	}
+	from.executeActionTriggers(nil)
+	to.executeActionTriggers(nil)
+	return nil
+}
+
+// Adds the value to the first balance matching the filter, creating a balance out of the filter if none matches
+func (acc *Account) creditBalance(id string, filter *Balance, value float64) {
+	if acc.BalanceMap == nil {
+		acc.BalanceMap = make(map[string]BalanceChain, 1)
+	}
+	for _, b := range acc.BalanceMap[id] {
+		if !b.IsExpired() && b.SharedGroup == "" && b.MatchFilter(filter) {
+			b.SubstractAmount(-value)
+			return
+		}
+	}
+	b := filter.Clone()
+	b.Uuid = utils.GenUUID()
+	b.Value = value
+	b.SharedGroup = "" // the transfers stay inside the account
+	b.dirty = true
+	acc.BalanceMap[id] = append(acc.BalanceMap[id], b)
+}
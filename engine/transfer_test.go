@@ -0,0 +1,144 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestTransferBalance(t *testing.T) {
+	from := &Account{Id: "*out:cgrates.org:reseller", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "r1", Value: 10, Weight: 10}, &Balance{Uuid: "r2", Value: 3, Weight: 20}},
+		utils.VOICE + OUTBOUND:    BalanceChain{&Balance{Uuid: "r3", Value: 100, DestinationIds: "NAT"}, &Balance{Uuid: "r4", Value: 100, DestinationIds: "RET"}}}}
+	to := &Account{Id: "*out:cgrates.org:customer", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "c1", Value: 1}}}}
+	a := &Action{Id: "TRANSFER", ActionType: TRANSFER_BALANCE, BalanceType: utils.MONETARY, Direction: OUTBOUND, Balance: &Balance{Value: 5}}
+	if err := TransferBalance(from, to, a); err != nil {
+		t.Fatal(err)
+	}
+	// the heavier balance pays first
+	if from.BalanceMap[utils.MONETARY+OUTBOUND].GetBalance("r2").Value != 0 || from.BalanceMap[utils.MONETARY+OUTBOUND].GetBalance("r1").Value != 8 ||
+		to.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 6 || len(to.BalanceMap[utils.MONETARY+OUTBOUND]) != 1 {
+		t.Errorf("Wrong transfer: %+v, %+v", from.BalanceMap[utils.MONETARY+OUTBOUND], to.BalanceMap[utils.MONETARY+OUTBOUND])
+	}
+	if acc, err := accountingStorage.GetAccount("*out:cgrates.org:customer"); err != nil || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 6 {
+		t.Errorf("Receiving account not saved: %+v, %v", acc, err)
+	}
+	if acc, err := accountingStorage.GetAccount("*out:cgrates.org:reseller"); err != nil || acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 8 {
+		t.Errorf("Giving account not saved: %+v, %v", acc, err)
+	}
+	// minutes for a destination go into a new balance of the same destination
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	a = &Action{Id: "TRANSFER", ActionType: TRANSFER_BALANCE, BalanceType: utils.VOICE, Direction: OUTBOUND, Balance: &Balance{Value: 60, DestinationIds: "NAT", ExpirationDate: expiry}}
+	if err := TransferBalance(from, to, a); err == nil {
+		t.Error("Transfered out of balances not matching the expiration")
+	}
+	a.Balance.ExpirationDate = time.Time{}
+	if err := TransferBalance(from, to, a); err != nil {
+		t.Fatal(err)
+	}
+	if from.BalanceMap[utils.VOICE+OUTBOUND].GetBalance("r3").Value != 40 || from.BalanceMap[utils.VOICE+OUTBOUND].GetBalance("r4").Value != 100 {
+		t.Errorf("Wrong balances debited: %+v", from.BalanceMap[utils.VOICE+OUTBOUND])
+	}
+	if bc := to.BalanceMap[utils.VOICE+OUTBOUND]; len(bc) != 1 || bc[0].Value != 60 || bc[0].DestinationIds != "NAT" || bc[0].Uuid == "" {
+		t.Errorf("Wrong balance credited: %+v", bc)
+	}
+}
+
+func TestTransferBalanceFailed(t *testing.T) {
+	from := &Account{Id: "*out:cgrates.org:reseller", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "r1", Value: 10}}}}
+	to := &Account{Id: "*out:cgrates.org:customer", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Uuid: "c1", Value: 1}}}}
+	a := &Action{ActionType: TRANSFER_BALANCE, BalanceType: utils.MONETARY, Direction: OUTBOUND, Balance: &Balance{Value: 11}}
+	if err := TransferBalance(from, to, a); err == nil {
+		t.Error("Transfered more than available")
+	}
+	if from.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 10 || to.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 1 {
+		t.Error("Balances changed by a failed transfer")
+	}
+	a.Balance.Value = -1
+	if err := TransferBalance(from, to, a); err == nil {
+		t.Error("Transfered negative value")
+	}
+	a.Balance.Value = 1
+	if err := TransferBalance(from, from, a); err == nil {
+		t.Error("Transfered within the same account")
+	}
+	to.Disabled = true
+	if err := TransferBalance(from, to, a); err == nil {
+		t.Error("Transfered to a disabled account")
+	}
+}
+
+func TestTransferBalanceActionTiming(t *testing.T) {
+	accountingStorage.SetAccount(&Account{Id: "*out:cgrates.org:parent", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 20}}}})
+	accountingStorage.SetAccount(&Account{Id: "*out:cgrates.org:child", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 0}}}})
+	at := &ActionTiming{AccountIds: []string{"*out:cgrates.org:parent"}}
+	at.SetActions(Actions{&Action{ActionType: TRANSFER_BALANCE, BalanceType: utils.MONETARY, Direction: OUTBOUND, ExtraParameters: "*out:cgrates.org:child",
+		Balance: &Balance{Value: 7}}})
+	if err := at.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:cgrates.org:parent"); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 13 {
+		t.Error("Wrong giving balance: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:cgrates.org:child"); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 7 {
+		t.Error("Wrong receiving balance: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+}
+
+func TestTransferBalanceActionReceiverLock(t *testing.T) {
+	accountingStorage.SetAccount(&Account{Id: "*out:cgrates.org:child", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 0}}}})
+	parent := &Account{Id: "*out:cgrates.org:parent", BalanceMap: map[string]BalanceChain{
+		utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 20}}}}
+	a := &Action{ActionType: TRANSFER_BALANCE, BalanceType: utils.MONETARY, Direction: OUTBOUND, ExtraParameters: "*out:cgrates.org:child",
+		Balance: &Balance{Value: 5}}
+	// as from a trigger, only the giving account is locked
+	if _, err := AccLock.Guard(func() (interface{}, error) {
+		return 0, transferBalanceAction(parent, nil, a, nil)
+	}, parent.Id); err != nil {
+		t.Fatal(err)
+	}
+	if acc, _ := accountingStorage.GetAccount("*out:cgrates.org:child"); acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 5 {
+		t.Error("Wrong receiving balance: ", acc.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+	// receiver busy with another operation
+	locked, release := make(chan bool), make(chan bool)
+	go AccLock.Guard(func() (interface{}, error) {
+		locked <- true
+		<-release
+		return 0, nil
+	}, "*out:cgrates.org:child")
+	<-locked
+	err := transferBalanceAction(parent, nil, a, nil)
+	close(release)
+	if err == nil {
+		t.Error("Transfered into a locked account")
+	}
+	if parent.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue() != 15 {
+		t.Error("Giving account changed: ", parent.BalanceMap[utils.MONETARY+OUTBOUND].GetTotalValue())
+	}
+}
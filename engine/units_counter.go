@@ -20,9 +20,6 @@ package engine
 
 import (
 	"strings"
-
-	"github.com/cgrates/cgrates/cache2go"
-	"github.com/cgrates/cgrates/utils"
 )
 
 // Amount of a trafic of a certain type
@@ -76,10 +73,9 @@ func (uc *UnitsCounter) addUnits(amount float64, prefix string) {
 			if !mb.HasDestination() {
 				continue
 			}
-			for _, p := range utils.SplitPrefix(prefix, MIN_PREFIX_MATCH) {
-				if x, err := cache2go.GetCached(DESTINATION_PREFIX + p); err == nil {
-					destIds := x.(map[interface{}]struct{})
-					if _, found := destIds[mb.DestinationIds]; found {
+			for _, match := range MatchDestinations(prefix) {
+				for _, dId := range match.Ids {
+					if dId == mb.DestinationIds {
 						mb.Value += amount
 						counted = true
 						break
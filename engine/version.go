@@ -0,0 +1,110 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Items carrying their own schema version, one per database
+const (
+	VER_RATING_DB     = "rating_db"
+	VER_ACCOUNTING_DB = "accounting_db"
+	VER_STOR_DB       = "stor_db"
+)
+
+// Schema versions understood by this code. Increase them on any change of the stored structures
+// and add the step transforming the previous version into the migrations list.
+var CurrentVersions = map[string]int64{
+	VER_RATING_DB:     8,
+	VER_ACCOUNTING_DB: 9,
+	VER_STOR_DB:       11,
+}
+
+// Makes sure the data in storage has the schema version we expect.
+// An empty database has nothing to migrate so it is stamped with the current version.
+func CheckVersion(storage Storage, item string) error {
+	current, hasIt := CurrentVersions[item]
+	if !hasIt {
+		return fmt.Errorf("unknown version item: %s", item)
+	}
+	version, err := storage.GetVersion(item)
+	if err != nil && err.Error() != utils.ERR_NOT_FOUND {
+		return err
+	}
+	if err != nil { // not versioned yet
+		if empty, err := isEmptyDb(storage, item); err != nil {
+			return err
+		} else if empty {
+			return storage.SetVersion(item, current)
+		}
+		version = 0
+	}
+	if version != current {
+		return fmt.Errorf("%s has version %d while version %d is required, please run cgr-migrator", item, version, current)
+	}
+	return nil
+}
+
+// Stamps the current versions on a freshly flushed database so it will not be taken for an unversioned one.
+// Rating and accounting can share the same database, hence stamping both.
+func SetCurrentVersions(storage Storage, items ...string) error {
+	for _, item := range items {
+		if err := storage.SetVersion(item, CurrentVersions[item]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isEmptyDb(storage Storage, item string) (bool, error) {
+	var prefixes []string
+	switch item {
+	case VER_RATING_DB:
+		prefixes = []string{DESTINATION_PREFIX, RATING_PLAN_PREFIX, RATING_PROFILE_PREFIX}
+	case VER_ACCOUNTING_DB:
+		prefixes = []string{ACCOUNT_PREFIX, ACTION_PREFIX, ACTION_TIMING_PREFIX}
+	case VER_STOR_DB:
+		if cdrStorage, canCast := storage.(CdrStorage); canCast {
+			if _, cnt, err := cdrStorage.GetStoredCdrs(&utils.CdrsFilter{Count: true}); err != nil {
+				return false, err
+			} else if cnt != 0 {
+				return false, nil
+			}
+		}
+		if loadStorage, canCast := storage.(LoadStorage); canCast {
+			if tpIds, err := loadStorage.GetTPIds(); err != nil {
+				return false, err
+			} else if len(tpIds) != 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	for _, prefix := range prefixes {
+		if keys, err := storage.GetKeysForPrefix(prefix); err != nil {
+			return false, err
+		} else if len(keys) != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
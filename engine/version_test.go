@@ -0,0 +1,111 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestVersionCheckEmptyDb(t *testing.T) {
+	ms, _ := NewMapStorage()
+	if err := CheckVersion(ms, VER_ACCOUNTING_DB); err != nil {
+		t.Error(err)
+	}
+	if version, err := ms.GetVersion(VER_ACCOUNTING_DB); err != nil {
+		t.Error(err)
+	} else if version != CurrentVersions[VER_ACCOUNTING_DB] {
+		t.Error("Empty database not stamped: ", version)
+	}
+	ms.SetVersion(VER_ACCOUNTING_DB, CurrentVersions[VER_ACCOUNTING_DB]+1)
+	if err := CheckVersion(ms, VER_ACCOUNTING_DB); err == nil {
+		t.Error("Expecting version mismatch")
+	}
+}
+
+func TestVersionMigrateAccounting(t *testing.T) {
+	ms, _ := NewMapStorage()
+	acnt := &Account{Id: "*out:cgrates.org:1001", BalanceMap: map[string]BalanceChain{utils.MONETARY + OUTBOUND: BalanceChain{&Balance{Value: 10}}}}
+	ms.SetAccount(acnt)
+	ms.SetActionTimings("MORE_MINUTES", ActionPlan{&ActionTiming{Id: "MORE_MINUTES", ActionsId: "MINI"}})
+	if err := CheckVersion(ms, VER_ACCOUNTING_DB); err == nil {
+		t.Error("Unversioned data accepted")
+	}
+	// Dry run should not touch anything
+	if err := NewMigrator(nil, ms, nil, true).Migrate(VER_ACCOUNTING_DB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.GetVersion(VER_ACCOUNTING_DB); err == nil || err.Error() != utils.ERR_NOT_FOUND {
+		t.Error("Version written on dry run: ", err)
+	}
+	if rcv, _ := ms.GetAccount(acnt.Id); rcv.BalanceMap[utils.MONETARY+OUTBOUND][0].Uuid != "" {
+		t.Error("Account changed on dry run: ", rcv.BalanceMap[utils.MONETARY+OUTBOUND][0])
+	}
+	if err := NewMigrator(nil, ms, nil, false).Migrate(VER_ACCOUNTING_DB); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckVersion(ms, VER_ACCOUNTING_DB); err != nil {
+		t.Error(err)
+	}
+	if rcv, _ := ms.GetAccount(acnt.Id); rcv.BalanceMap[utils.MONETARY+OUTBOUND][0].Uuid == "" {
+		t.Error("Balance not migrated: ", rcv.BalanceMap[utils.MONETARY+OUTBOUND][0])
+	}
+	if apl, _ := ms.GetActionTimings("MORE_MINUTES"); apl[0].Uuid == "" {
+		t.Error("Action timing not migrated: ", apl[0])
+	}
+	if err := NewMigrator(nil, nil, nil, false).Migrate(VER_ACCOUNTING_DB); err == nil {
+		t.Error("Expecting error for missing database")
+	}
+}
+
+func TestVersionMigrateClosedBillingCycles(t *testing.T) {
+	ms, _ := NewMapStorage()
+	last := &ClosedBillingCycle{Start: time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2015, 5, 1, 0, 0, 0, 0, time.UTC)}
+	ms.SetAccount(&Account{Id: "*out:cgrates.org:postpaid", BillingCycle: &BillingCycle{Period: utils.META_MONTHLY, AnchorDay: 1,
+		Closed: []*ClosedBillingCycle{&ClosedBillingCycle{Start: time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), End: last.Start}, last}}})
+	ms.SetAccount(&Account{Id: "*out:cgrates.org:prepaid"})
+	ms.SetVersion(VER_ACCOUNTING_DB, 8)
+	if err := NewMigrator(nil, ms, nil, false).Migrate(VER_ACCOUNTING_DB); err != nil {
+		t.Fatal(err)
+	}
+	if rcv, _ := ms.GetAccount("*out:cgrates.org:postpaid"); len(rcv.BillingCycle.Closed) != 1 || !rcv.BillingCycle.Closed[0].Start.Equal(last.Start) {
+		t.Errorf("Closed cycles not trimmed: %+v", rcv.BillingCycle.Closed)
+	}
+}
+
+func TestVersionMigrationSteps(t *testing.T) {
+	for item, current := range CurrentVersions {
+		for version := int64(0); version < current; version++ {
+			if _, hasIt := migrations[item][version]; !hasIt {
+				t.Errorf("No migration for %s from version %d", item, version)
+			}
+		}
+	}
+	// the document based StorDbs have no schema to change
+	ms, _ := NewMapStorage()
+	ms.SetVersion(VER_STOR_DB, 1)
+	if err := NewMigrator(nil, nil, ms, false).Migrate(VER_STOR_DB); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := ms.GetVersion(VER_STOR_DB); err != nil || version != CurrentVersions[VER_STOR_DB] {
+		t.Errorf("StorDb not migrated: %d, %v", version, err)
+	}
+}
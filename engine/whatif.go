@@ -0,0 +1,173 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cgrates/cgrates/utils"
+)
+
+// Rating data of a tariff plan loaded aside the live one, the shared cache and destination index stay untouched
+type ratingSandbox struct {
+	ratingDb *MapStorage
+	dstIndex *PrefixTrie
+}
+
+// Loads the rating part of the tariff plan with a loader writing into the isolated ratingDb
+func newRatingSandbox(loader TPLoader, ratingDb *MapStorage) (*ratingSandbox, error) {
+	for _, load := range []func() error{loader.LoadDestinations, loader.LoadCalendars, loader.LoadTimings, loader.LoadRates,
+		loader.LoadDestinationRates, loader.LoadRatingPlans, loader.LoadRatingProfiles, loader.LoadTaxRules,
+		loader.LoadPortedNumbers} {
+		if err := load(); err != nil {
+			return nil, err
+		}
+	}
+	if err := loader.WriteToDatabase(false, false); err != nil {
+		return nil, err
+	}
+	sb := &ratingSandbox{ratingDb: ratingDb, dstIndex: NewPrefixTrie()}
+	dstIds, _ := loader.GetLoadedIds(DESTINATION_PREFIX)
+	for _, dstId := range dstIds {
+		dst, err := ratingDb.GetDestination(dstId)
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range dst.Prefixes {
+			sb.dstIndex.Add(prefix, dst.Id)
+		}
+	}
+	return sb, nil
+}
+
+// Rates the stored CDR against the sandbox, balances are not debited
+func (sb *ratingSandbox) getCost(cdr *StoredCdr) (*CallCost, error) {
+	cd := &CallDescriptor{
+		TOR:           cdr.TOR,
+		Direction:     cdr.Direction,
+		Tenant:        cdr.Tenant,
+		Category:      cdr.Category,
+		Subject:       cdr.Subject,
+		Account:       cdr.Account,
+		Destination:   cdr.Destination,
+		TimeStart:     cdr.AnswerTime,
+		TimeEnd:       cdr.AnswerTime.Add(cdr.Usage),
+		DurationIndex: cdr.Usage,
+		CgrId:         cdr.CgrId,
+		sandbox:       sb,
+	}
+	return cd.GetCost()
+}
+
+// Storage holding the rating data of the call, the sandbox one during simulations
+func (cd *CallDescriptor) getRatingStorage() RatingStorage {
+	if cd.sandbox != nil {
+		return cd.sandbox.ratingDb
+	}
+	return dataStorage
+}
+
+func (cd *CallDescriptor) matchDestinations(number string) []*PrefixMatch {
+	if cd.sandbox != nil {
+		return cd.sandbox.dstIndex.Match(number, MIN_PREFIX_MATCH)
+	}
+	return MatchDestinations(number)
+}
+
+// Cost difference of the candidate tariff plan for the CDRs of one account towards one destination
+type WhatIfDelta struct {
+	Tenant        string
+	Account       string
+	DestinationId string // destination matched by the candidate plan
+	Cdrs          int
+	Usage         time.Duration
+	CurrentCost   float64
+	CandidateCost float64
+	Delta         float64 // CandidateCost - CurrentCost
+}
+
+type WhatIfReport struct {
+	TPid          string
+	RatedCdrs     int      // CDRs rated against the candidate plan and part of the deltas
+	UnratedCdrs   int      // CDRs without a current cost to compare with
+	FailedCgrIds  []string // CDRs the candidate plan could not rate
+	CurrentCost   float64
+	CandidateCost float64
+	Delta         float64
+	Deltas        []*WhatIfDelta // ordered by tenant, account and destination
+}
+
+/*
+Rates the stored CDRs matching the filter against the tariff plan tpid out of storDb and compares with their current costs.
+Balances, the live rating data with its cache and the stored CDRs are left untouched.
+*/
+func WhatIfRating(storDb LoadStorage, cdrDb CdrStorage, tpid string, cdrsFltr *utils.CdrsFilter) (*WhatIfReport, error) {
+	ratingDb, _ := NewIsolatedMapStorage()
+	accountDb, _ := NewIsolatedMapStorage()
+	sb, err := newRatingSandbox(NewDbReader(storDb, ratingDb, accountDb, tpid), ratingDb)
+	if err != nil {
+		return nil, err
+	}
+	cdrs, _, err := cdrDb.GetStoredCdrs(cdrsFltr)
+	if err != nil {
+		return nil, err
+	}
+	report := &WhatIfReport{TPid: tpid}
+	deltas := make(map[string]*WhatIfDelta)
+	for _, cdr := range cdrs {
+		if cdr.Cost < 0 {
+			report.UnratedCdrs++
+			continue
+		}
+		cc, err := sb.getCost(cdr)
+		if err != nil {
+			report.FailedCgrIds = append(report.FailedCgrIds, cdr.CgrId)
+			continue
+		}
+		dstId := utils.ANY
+		if len(cc.Timespans) != 0 && cc.Timespans[0].MatchedDestId != "" {
+			dstId = cc.Timespans[0].MatchedDestId
+		}
+		key := utils.ConcatenatedKey(cdr.Tenant, cdr.Account, dstId)
+		delta, exists := deltas[key]
+		if !exists {
+			delta = &WhatIfDelta{Tenant: cdr.Tenant, Account: cdr.Account, DestinationId: dstId}
+			deltas[key] = delta
+		}
+		delta.Cdrs++
+		delta.Usage += cdr.Usage
+		delta.CurrentCost += cdr.Cost
+		delta.CandidateCost += cc.Cost
+		delta.Delta = delta.CandidateCost - delta.CurrentCost
+		report.RatedCdrs++
+		report.CurrentCost += cdr.Cost
+		report.CandidateCost += cc.Cost
+	}
+	report.Delta = report.CandidateCost - report.CurrentCost
+	keys := make([]string, 0, len(deltas))
+	for key := range deltas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		report.Deltas = append(report.Deltas, deltas[key])
+	}
+	return report, nil
+}
@@ -0,0 +1,74 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/cache2go"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestWhatIfSandboxIsolation(t *testing.T) {
+	ratingDb, _ := NewIsolatedMapStorage()
+	accountDb, _ := NewIsolatedMapStorage()
+	csvr := NewStringCSVReader(ratingDb, accountDb, ',',
+		`WI_MOBILE,+4915`,
+		`ALWAYS,*any,*any,*any,*any,00:00:00`,
+		`RT_WI_2CENT,0,2,1s,1s,0s`,
+		`DR_WI,WI_MOBILE,RT_WI_2CENT,*up,4,0,,,,,,,`,
+		`RP_WI,DR_WI,ALWAYS,10`,
+		`*out,whatif.org,call,*any,2012-01-01T00:00:00Z,RP_WI,,,`,
+		"", "", "", "", "", "", "", "", "", "", "", "")
+	sb, err := newRatingSandbox(csvr, ratingDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdr := &StoredCdr{CgrId: "whatif1", TOR: utils.VOICE, Direction: utils.OUT, Tenant: "whatif.org", Category: "call",
+		Account: "1001", Subject: "1001", Destination: "+4915123456", AnswerTime: time.Date(2015, 3, 1, 10, 0, 0, 0, time.UTC), Usage: time.Minute}
+	cc, err := sb.getCost(cdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.Cost != 120 || cc.Timespans[0].MatchedDestId != "WI_MOBILE" {
+		t.Errorf("Wrong sandbox cost: %+v", cc)
+	}
+	// nothing of the candidate plan reaches the live data
+	for _, match := range MatchDestinations(cdr.Destination) {
+		for _, dId := range match.Ids {
+			if dId == "WI_MOBILE" {
+				t.Error("Sandbox destination indexed live")
+			}
+		}
+	}
+	if _, err := cache2go.GetCached(RATING_PLAN_PREFIX + "RP_WI"); err == nil {
+		t.Error("Sandbox rating plan cached live")
+	}
+	if _, err := cache2go.GetCached(RATING_PROFILE_PREFIX + "*out:whatif.org:call:*any"); err == nil {
+		t.Error("Sandbox rating profile cached live")
+	}
+	if _, err := dataStorage.GetRatingPlan("RP_WI", true); err == nil {
+		t.Error("Sandbox rating plan stored live")
+	}
+	if _, err := (&CallDescriptor{Direction: utils.OUT, Tenant: "whatif.org", Category: "call", Subject: "1001", Account: "1001",
+		Destination: cdr.Destination, TimeStart: cdr.AnswerTime, TimeEnd: cdr.AnswerTime.Add(cdr.Usage)}).GetCost(); err == nil {
+		t.Error("Candidate plan used for live rating")
+	}
+}
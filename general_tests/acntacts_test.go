@@ -47,13 +47,13 @@ func TestAcntActsLoadCsv(t *testing.T) {
 	actions := `TOPUP10_AC,*topup_reset,,,*voice,*out,,*any,,,*unlimited,,10,10,10
 DISABLE_ACNT,*disable_account,,,,,,,,,,,,,10
 ENABLE_ACNT,*enable_account,,,,,,,,,,,,,10`
-	actionPlans := `TOPUP10_AT,TOPUP10_AC,ASAP,10`
+	actionPlans := `TOPUP10_AT,TOPUP10_AC,ASAP,10,`
 	actionTriggers := ``
 	accountActions := `cgrates.org,1,*out,TOPUP10_AT,`
 	derivedCharges := ``
 	cdrStats := ``
 	csvr := engine.NewStringCSVReader(ratingDbAcntActs, acntDbAcntActs, ',', destinations, timings, rates, destinationRates, ratingPlans, ratingProfiles,
-		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats)
+		sharedGroups, lcrs, actions, actionPlans, actionTriggers, accountActions, derivedCharges, cdrStats, "", "", "", "")
 	if err := csvr.LoadAll(); err != nil {
 		t.Fatal(err)
 	}
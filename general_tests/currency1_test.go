@@ -0,0 +1,115 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can Storagetribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITH*out ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package general_tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSetStorageCurrency1(t *testing.T) {
+	ratingDb, _ = engine.NewMapStorageJson()
+	engine.SetRatingStorage(ratingDb)
+	acntDb, _ = engine.NewMapStorageJson()
+	engine.SetAccountingStorage(acntDb)
+}
+
+func TestLoadCsvTpCurrency1(t *testing.T) {
+	timings := `ALWAYS,*any,*any,*any,*any,00:00:00`
+	rates := `RT_USD,0.05,0.1,60s,60s,0s`
+	destinationRates := `DR_USD,*any,RT_USD,*up,4,0,,,USD,,,,`
+	ratingPlans := `RP_USD,DR_USD,ALWAYS,10`
+	ratingProfiles := `*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_USD,,,`
+	exchangeRates := `EXR_EUR_USD,EUR,USD,2015-01-01T00:00:00Z,1.25
+EXR_EUR_USD,EUR,USD,2015-06-01T00:00:00Z,1`
+	csvr := engine.NewStringCSVReader(ratingDb, acntDb, ',', "", timings, rates, destinationRates, ratingPlans, ratingProfiles,
+		"", "", "", "", "", "", "", "", exchangeRates, "", "", "")
+	if err := csvr.LoadTimings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadDestinationRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingPlans(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadExchangeRates(); err != nil {
+		t.Fatal(err)
+	}
+	csvr.WriteToDatabase(false, false)
+	ratingDb.CacheRating(nil, nil, nil, nil, nil)
+	for acntId, currency := range map[string]string{"*out:cgrates.org:1001": "EUR", "*out:cgrates.org:1002": "USD", "*out:cgrates.org:1003": "GBP"} {
+		acnt := &engine.Account{Id: acntId,
+			BalanceMap: map[string]engine.BalanceChain{utils.MONETARY + engine.OUTBOUND: engine.BalanceChain{&engine.Balance{Uuid: utils.GenUUID(), Value: 10, Currency: currency}}}}
+		if err := acntDb.SetAccount(acnt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDebitCurrency1(t *testing.T) {
+	timeStart := time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC)
+	if cc, err := voiceCallDescriptor("1001", "1003", timeStart, 2*time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.25 || cc.Currency != "USD" || cc.DebitedCost != 0.2 || cc.DebitedCurrency != "EUR" {
+		t.Errorf("Wrong converted cost: %+v", cc)
+	}
+	// the new exchange rate is active
+	if cc, err := voiceCallDescriptor("1001", "1003", time.Date(2015, 7, 1, 10, 0, 0, 0, time.UTC), time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.15 || cc.DebitedCost != 0.15 || cc.DebitedCurrency != "EUR" {
+		t.Errorf("Wrong converted cost: %+v", cc)
+	}
+	if cc, err := voiceCallDescriptor("1002", "1003", timeStart, 2*time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.25 || cc.DebitedCost != 0.25 || cc.DebitedCurrency != "USD" {
+		t.Errorf("Wrong cost in the currency of the rates: %+v", cc)
+	}
+	if acnt, err := acntDb.GetAccount("*out:cgrates.org:1001"); err != nil {
+		t.Fatal(err)
+	} else if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 9.65 {
+		t.Error("Wrong EUR balance: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+	if acnt, err := acntDb.GetAccount("*out:cgrates.org:1002"); err != nil {
+		t.Fatal(err)
+	} else if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 9.75 {
+		t.Error("Wrong USD balance: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+}
+
+func TestDebitCurrency1NoExchangeRate(t *testing.T) {
+	// no USD to GBP rate, the cost is not taken as the same amount of GBP
+	if _, err := voiceCallDescriptor("1003", "1003", time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC), 2*time.Minute).Debit(); err == nil {
+		t.Error("Debited without exchange rate")
+	}
+	if acnt, err := acntDb.GetAccount("*out:cgrates.org:1003"); err != nil {
+		t.Fatal(err)
+	} else if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 10 {
+		t.Error("Wrong GBP balance: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package general_tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSetStorageShortCalls1(t *testing.T) {
+	ratingDb, _ = engine.NewMapStorageJson()
+	engine.SetRatingStorage(ratingDb)
+	acntDb, _ = engine.NewMapStorageJson()
+	engine.SetAccountingStorage(acntDb)
+}
+
+func TestLoadCsvTpShortCalls1(t *testing.T) {
+	destinations := `DST_1002,1002
+DST_1003,1003`
+	timings := `ALWAYS,*any,*any,*any,*any,00:00:00`
+	rates := `RT_1CENT,0.1,0.01,1s,1s,0s`
+	destinationRates := `DR_SHORT,DST_1002,RT_1CENT,*up,4,0,,,,30s,0.5,,
+DR_SHORT,DST_1003,RT_1CENT,*up,4,0,,,,,,5s,true`
+	ratingPlans := `RP_SHORT,DR_SHORT,ALWAYS,10`
+	ratingProfiles := `*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_SHORT,,,`
+	csvr := engine.NewStringCSVReader(ratingDb, acntDb, ',', destinations, timings, rates, destinationRates, ratingPlans, ratingProfiles,
+		"", "", "", "", "", "", "", "", "", "", "", "")
+	if err := csvr.LoadDestinations(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadTimings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadDestinationRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingPlans(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	csvr.WriteToDatabase(false, false)
+	ratingDb.CacheRating(nil, nil, nil, nil, nil)
+	acnt := &engine.Account{Id: "*out:cgrates.org:1001",
+		BalanceMap: map[string]engine.BalanceChain{utils.MONETARY + engine.OUTBOUND: engine.BalanceChain{&engine.Balance{Uuid: utils.GenUUID(), Value: 10}}}}
+	if err := acntDb.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetCostShortCalls1(t *testing.T) {
+	timeStart := time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC)
+	// 10s billed as the minimum 30s, 0.1 connect fee + 0.3 raised to the minimum cost
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 10*time.Second).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.5 || cc.GetDuration() != 30*time.Second {
+		t.Errorf("Wrong minimum charge: %v for %v", cc.Cost, cc.GetDuration())
+	}
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 50*time.Second).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.6 || cc.GetDuration() != 50*time.Second {
+		t.Errorf("Wrong cost: %v for %v", cc.Cost, cc.GetDuration())
+	}
+	if cc, err := voiceCallDescriptor("1001", "1003", timeStart, 3*time.Second).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0 {
+		t.Error("Short call charged: ", cc.Cost)
+	}
+	if cc, err := voiceCallDescriptor("1001", "1003", timeStart, 10*time.Second).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.2 {
+		t.Error("Wrong cost: ", cc.Cost)
+	}
+}
+
+func TestDebitShortCalls1(t *testing.T) {
+	timeStart := time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC)
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 10*time.Second).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.5 {
+		t.Error("Wrong minimum charge: ", cc.Cost)
+	} else if cc.MinCostIncrement == nil || cc.MinCostIncrement.Cost != 0.1 {
+		t.Errorf("Wrong minimum cost top up: %+v", cc.MinCostIncrement)
+	}
+	if cc, err := voiceCallDescriptor("1001", "1003", timeStart, 3*time.Second).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0 {
+		t.Error("Short call charged: ", cc.Cost)
+	}
+	if acnt, err := acntDb.GetAccount("*out:cgrates.org:1001"); err != nil {
+		t.Fatal(err)
+	} else if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 9.5 {
+		t.Error("Wrong balance: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package general_tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSetStorageTax1(t *testing.T) {
+	ratingDb, _ = engine.NewMapStorageJson()
+	engine.SetRatingStorage(ratingDb)
+	acntDb, _ = engine.NewMapStorageJson()
+	engine.SetAccountingStorage(acntDb)
+}
+
+func TestLoadCsvTpTax1(t *testing.T) {
+	timings := `ALWAYS,*any,*any,*any,*any,00:00:00`
+	rates := `RT_1CENT,0,0.6,60s,60s,0s`
+	destinationRates := `DR_1CENT,*any,RT_1CENT,*up,4,0,,,,,,,`
+	ratingPlans := `RP_1CENT,DR_1CENT,ALWAYS,10`
+	ratingProfiles := `*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_1CENT,,,`
+	taxRules := `VAT,*any,*any,*any,2015-01-01T00:00:00Z,0.19
+VAT,cgrates.org,*any,*any,2015-01-01T00:00:00Z,0.2`
+	csvr := engine.NewStringCSVReader(ratingDb, acntDb, ',', "", timings, rates, destinationRates, ratingPlans, ratingProfiles,
+		"", "", "", "", "", "", "", "", "", taxRules, "", "")
+	if err := csvr.LoadTimings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadDestinationRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingPlans(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadTaxRules(); err != nil {
+		t.Fatal(err)
+	}
+	csvr.WriteToDatabase(false, false)
+	ratingDb.CacheRating(nil, nil, nil, nil, nil)
+	acnt := &engine.Account{Id: "*out:cgrates.org:1001",
+		BalanceMap: map[string]engine.BalanceChain{utils.MONETARY + engine.OUTBOUND: engine.BalanceChain{&engine.Balance{Uuid: utils.GenUUID(), Value: 10}}}}
+	if err := acntDb.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetCostTax1(t *testing.T) {
+	if cc, err := voiceCallDescriptor("1001", "1002", time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC), 2*time.Minute).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 1.2 || cc.Tax != 0.24 || cc.GrossCost != 1.44 {
+		t.Errorf("Wrong tax breakdown: %+v", cc)
+	}
+	// no tax active yet
+	if cc, err := voiceCallDescriptor("1001", "1002", time.Date(2014, 3, 2, 10, 0, 0, 0, time.UTC), 2*time.Minute).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 1.2 || cc.Tax != 0 || cc.GrossCost != 1.2 {
+		t.Errorf("Wrong untaxed cost: %+v", cc)
+	}
+}
+
+func TestDebitTax1(t *testing.T) {
+	cd := voiceCallDescriptor("1001", "1002", time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC), 2*time.Minute)
+	if cc, err := cd.Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 1.2 || cc.Tax != 0.24 || cc.GrossCost != 1.44 || cc.DebitedCost != 1.44 {
+		t.Errorf("Wrong tax breakdown: %+v", cc)
+	}
+	if acnt, err := acntDb.GetAccount("*out:cgrates.org:1001"); err != nil {
+		t.Fatal(err)
+	} else if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 8.56 {
+		t.Error("Gross cost not debited: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+}
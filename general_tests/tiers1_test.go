@@ -0,0 +1,126 @@
+/*
+Real-time Charging System for Telecom & ISP environments
+Copyright (C) 2012-2015 ITsysCOM GmbH
+
+This program is free software: you can Storagetribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITH*out ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>
+*/
+
+package general_tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
+)
+
+func TestSetStorageTiers1(t *testing.T) {
+	ratingDb, _ = engine.NewMapStorageJson()
+	engine.SetRatingStorage(ratingDb)
+	acntDb, _ = engine.NewMapStorageJson()
+	engine.SetAccountingStorage(acntDb)
+}
+
+func TestLoadCsvTpTiers1(t *testing.T) {
+	timings := `ALWAYS,*any,*any,*any,*any,00:00:00`
+	rates := `RT_TIERED,0,0.1,60s,60s,0s
+RT_TIERED,0,0.05,60s,60s,10m`
+	destinationRates := `DR_TIERED,*any,RT_TIERED,*up,4,0,,*monthly,,,,,`
+	ratingPlans := `RP_TIERED,DR_TIERED,ALWAYS,10`
+	ratingProfiles := `*out,cgrates.org,call,*any,2012-01-01T00:00:00Z,RP_TIERED,,,`
+	csvr := engine.NewStringCSVReader(ratingDb, acntDb, ',', "", timings, rates, destinationRates, ratingPlans, ratingProfiles,
+		"", "", "", "", "", "", "", "", "", "", "", "")
+	if err := csvr.LoadTimings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadDestinationRates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingPlans(); err != nil {
+		t.Fatal(err)
+	}
+	if err := csvr.LoadRatingProfiles(); err != nil {
+		t.Fatal(err)
+	}
+	csvr.WriteToDatabase(false, false)
+	ratingDb.CacheRating(nil, nil, nil, nil, nil)
+	acnt := &engine.Account{Id: "*out:cgrates.org:1001",
+		BalanceMap: map[string]engine.BalanceChain{utils.MONETARY + engine.OUTBOUND: engine.BalanceChain{&engine.Balance{Uuid: utils.GenUUID(), Value: 10}}}}
+	if err := acntDb.SetAccount(acnt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// voiceCallDescriptor builds an outbound voice call from account to destination, starting at timeStart
+func voiceCallDescriptor(account, destination string, timeStart time.Time, usage time.Duration) *engine.CallDescriptor {
+	return &engine.CallDescriptor{
+		Direction:     utils.OUT,
+		Category:      "call",
+		Tenant:        "cgrates.org",
+		Subject:       account,
+		Account:       account,
+		Destination:   destination,
+		TimeStart:     timeStart,
+		TimeEnd:       timeStart.Add(usage),
+		DurationIndex: usage,
+		TOR:           utils.VOICE,
+	}
+}
+
+func TestDebitTiers1(t *testing.T) {
+	timeStart := time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC)
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 8*time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.8 {
+		t.Error("Wrong cost for the first tier: ", cc.Cost)
+	}
+	// crosses the 10 minutes of the month
+	timeStart = timeStart.Add(time.Hour)
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 4*time.Minute).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.3 {
+		t.Error("Wrong cost crossing the tiers: ", cc.Cost)
+	}
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart, 4*time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.3 {
+		t.Error("Wrong debit crossing the tiers: ", cc.Cost)
+	}
+	if cc, err := voiceCallDescriptor("1001", "1002", timeStart.Add(time.Hour), 2*time.Minute).GetCost(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.1 {
+		t.Error("Wrong cost for the second tier: ", cc.Cost)
+	}
+	// new month starts again with the first tier
+	if cc, err := voiceCallDescriptor("1001", "1002", time.Date(2015, 4, 1, 10, 0, 0, 0, time.UTC), 2*time.Minute).Debit(); err != nil {
+		t.Fatal(err)
+	} else if cc.Cost != 0.2 {
+		t.Error("Wrong cost for a new month: ", cc.Cost)
+	}
+	acnt, err := acntDb.GetAccount("*out:cgrates.org:1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pu := acnt.PeriodUsages[utils.ConcatenatedKey(utils.OUT, utils.VOICE, utils.META_MONTHLY)]; pu == nil ||
+		!pu.PeriodStart.Equal(time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)) || pu.Usage != 2*time.Minute {
+		t.Errorf("Wrong period usage: %+v", pu)
+	}
+	if acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue() != 8.7 {
+		t.Error("Wrong balance: ", acnt.BalanceMap[utils.MONETARY+engine.OUTBOUND].GetTotalValue())
+	}
+}
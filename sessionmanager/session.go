@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/cgrates/cgrates/engine"
+	"github.com/cgrates/cgrates/utils"
 )
 
 // Session type holding the call information fields, a session delegate for specific
@@ -66,11 +67,33 @@ func NewSession(ev engine.Event, connId string, sm SessionManager) *Session {
 	return s
 }
 
+// Reserves the balances for the usage of the next debit, keeping them away from the other calls on the account.
+// Unless committed or released meanwhile, the reservation expires after a couple of debit intervals.
+func (s *Session) reserve(cd engine.CallDescriptor, debitPeriod time.Duration) (*engine.Reservation, error) {
+	cd.TimeEnd = cd.TimeStart.Add(debitPeriod)
+	reservation := new(engine.Reservation)
+	if err := s.sessionManager.Rater().ReserveBalance(engine.ReservationRequest{CallDescriptor: cd, TTL: 2 * debitPeriod}, reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
 // the debit loop method (to be stoped by sending somenthing on stopDebit channel)
+// each debit commits the reservation made in advance for it, then reserves the following one
 func (s *Session) debitLoop(runIdx int) {
 	nextCd := *s.sessionRuns[runIdx].CallDescriptor
 	index := 0.0
 	debitPeriod := s.sessionManager.DebitInterval()
+	reservation, err := s.reserve(nextCd, debitPeriod)
+	if err != nil {
+		engine.Logger.Err(fmt.Sprintf("Could not reserve the balances for session %s: %v", nextCd.CgrId, err))
+		if err.Error() == utils.ERR_INSUFFICIENT_CREDIT {
+			s.sessionManager.DisconnectSession(s.eventStart, s.connId, INSUFFICIENT_FUNDS)
+		} else {
+			s.sessionManager.DisconnectSession(s.eventStart, s.connId, SYSTEM_ERROR)
+		}
+		return
+	}
 	for {
 		select {
 		case <-s.stopDebit:
@@ -80,11 +103,11 @@ func (s *Session) debitLoop(runIdx int) {
 		if index > 0 { // first time use the session start time
 			nextCd.TimeStart = nextCd.TimeEnd
 		}
-		nextCd.TimeEnd = nextCd.TimeStart.Add(debitPeriod)
+		nextCd.TimeEnd = nextCd.TimeStart.Add(reservation.Usage) // the reserved usage, less than the debit period when short of credit
 		nextCd.LoopIndex = index
-		nextCd.DurationIndex += debitPeriod // first presumed duration
+		nextCd.DurationIndex += reservation.Usage // first presumed duration
 		cc := new(engine.CallCost)
-		if err := s.sessionManager.Rater().MaxDebit(nextCd, cc); err != nil {
+		if err := s.sessionManager.Rater().CommitReservation(nextCd, cc); err != nil {
 			engine.Logger.Err(fmt.Sprintf("Could not complete debit opperation: %v", err))
 			s.sessionManager.DisconnectSession(s.eventStart, s.connId, SYSTEM_ERROR)
 			return
@@ -99,10 +122,18 @@ func (s *Session) debitLoop(runIdx int) {
 		s.sessionRuns[runIdx].CallCosts = append(s.sessionRuns[runIdx].CallCosts, cc)
 		nextCd.TimeEnd = cc.GetEndTime() // set debited timeEnd
 		// update call duration with real debited duration
-		nextCd.DurationIndex -= debitPeriod
+		nextCd.DurationIndex -= reservation.Usage
 		nextCd.DurationIndex += nextCd.GetDuration()
 		nextCd.MaxCostSoFar += cc.Cost
+		followingCd := nextCd
+		followingCd.TimeStart = nextCd.TimeEnd
+		reservation, err = s.reserve(followingCd, debitPeriod)
 		time.Sleep(cc.GetDuration())
+		if err != nil { // nothing left for continuing once the debited usage is consumed
+			engine.Logger.Err(fmt.Sprintf("Could not reserve the balances for session %s: %v", nextCd.CgrId, err))
+			s.sessionManager.DisconnectSession(s.eventStart, s.connId, INSUFFICIENT_FUNDS)
+			return
+		}
 		index++
 	}
 }
@@ -110,6 +141,13 @@ func (s *Session) debitLoop(runIdx int) {
 // Stops the debit loop
 func (s *Session) Close(ev engine.Event) error {
 	close(s.stopDebit) // Close the channel so all the sessionRuns listening will be notified
+	// give back the balances reserved for the debits not done anymore
+	for _, sr := range s.SessionRuns() {
+		var reply string
+		if err := s.sessionManager.Rater().ReleaseReservation(*sr.CallDescriptor, &reply); err != nil && err.Error() != utils.ERR_NOT_FOUND {
+			engine.Logger.Err(fmt.Sprintf("Could not release the reservation of session %s: %v", sr.CallDescriptor.CgrId, err))
+		}
+	}
 	if _, err := ev.GetEndTime(); err != nil {
 		engine.Logger.Err("Error parsing event stop time.")
 		for idx := range s.sessionRuns {
@@ -121,6 +159,7 @@ func (s *Session) Close(ev engine.Event) error {
 		if len(sr.CallCosts) == 0 {
 			continue // why would we have 0 callcosts
 		}
+		firstCC := sr.CallCosts[0]
 		lastCC := sr.CallCosts[len(sr.CallCosts)-1]
 		lastCC.Timespans.Decompress()
 		// put credit back
@@ -134,11 +173,30 @@ func (s *Session) Close(ev engine.Event) error {
 			engine.Logger.Crit(fmt.Sprintf("Error parsing call duration from event %s", err.Error()))
 			return err
 		}
-		hangupTime := startTime.Add(duration)
-		end := lastCC.Timespans[len(lastCC.Timespans)-1].TimeEnd
-		refundDuration := end.Sub(hangupTime)
+		var charged float64
+		for _, cc := range sr.CallCosts {
+			charged += cc.Cost
+		}
 		var refundIncrements engine.Increments
-		for i := len(lastCC.Timespans) - 1; i >= 0; i-- {
+		free := firstCC.IsFreeUsage(duration)
+		if free {
+			// short calls are not charged, give back everything debited in the session
+			for _, cc := range sr.CallCosts {
+				cc.Timespans.Decompress()
+				for _, ts := range cc.Timespans {
+					refundIncrements = append(refundIncrements, ts.Increments...)
+				}
+				cc.Timespans = nil
+				cc.Cost = 0
+			}
+		}
+		// the usage is billed at least for the minimum duration of the rate
+		hangupTime := startTime.Add(firstCC.GetBillableUsage(duration))
+		refundDuration := time.Duration(0)
+		if len(lastCC.Timespans) > 0 {
+			refundDuration = lastCC.Timespans[len(lastCC.Timespans)-1].TimeEnd.Sub(hangupTime)
+		}
+		for i := len(lastCC.Timespans) - 1; i >= 0 && refundDuration > 0; i-- {
 			ts := lastCC.Timespans[i]
 			tsDuration := ts.GetDuration()
 			if refundDuration <= tsDuration {
@@ -164,6 +222,13 @@ func (s *Session) Close(ev engine.Event) error {
 		}
 		// show only what was actualy refunded (stopped in timespan)
 		// engine.Logger.Info(fmt.Sprintf("Refund duration: %v", initialRefundDuration-refundDuration))
+		usageCost := charged - refundIncrements.GetTotalCost()
+		for _, fee := range []*engine.Increment{firstCC.ConnectFeeIncrement, firstCC.MinCostIncrement} {
+			if fee != nil {
+				usageCost -= fee.Cost
+			}
+		}
+		refundIncrements = append(refundIncrements, firstCC.GetFeeRefunds(duration, usageCost)...)
 		if len(refundIncrements) > 0 {
 			cd := &engine.CallDescriptor{
 				Direction:   lastCC.Direction,
@@ -173,6 +238,7 @@ func (s *Session) Close(ev engine.Event) error {
 				Account:     lastCC.Account,
 				Destination: lastCC.Destination,
 				Increments:  refundIncrements,
+				CgrId:       s.eventStart.GetCgrId(),
 			}
 			var response float64
 			err := s.sessionManager.Rater().RefundIncrements(*cd, &response)
@@ -180,8 +246,9 @@ func (s *Session) Close(ev engine.Event) error {
 				return err
 			}
 		}
-		cost := refundIncrements.GetTotalCost()
-		lastCC.Cost -= cost
+		if !free {
+			lastCC.Cost -= refundIncrements.GetTotalCost()
+		}
 		lastCC.Timespans.Compress()
 	}
 	go s.SaveOperations()
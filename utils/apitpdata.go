@@ -153,6 +153,25 @@ type DestinationRate struct {
 	RoundingDecimals int
 	MaxCost          float64
 	MaxCostStrategy  string
+	TierPeriod         string  // *daily, *monthly or *yearly when the rates are tiered on the usage cumulated over that period
+	Currency           string  // currency of the rates, empty for the default one
+	MinDuration        string  // minimum billable duration, shorter calls are charged as lasting this long
+	MinCost            float64 // minimum cost of a call
+	FreeUnder          string  // calls shorter than this are not charged at all
+	ConnectFeeOnAnswer bool    // charge the connect fee only on answered calls
+}
+
+// Minimum billable duration and the duration under which calls are free, zero when not set
+func (self *DestinationRate) GetShortCallDurations() (minDuration, freeUnder time.Duration, err error) {
+	if self.MinDuration != "" {
+		if minDuration, err = ParseDurationWithSecs(self.MinDuration); err != nil {
+			return
+		}
+	}
+	if self.FreeUnder != "" {
+		freeUnder, err = ParseDurationWithSecs(self.FreeUnder)
+	}
+	return
 }
 
 type ApierTPTiming struct {
@@ -173,13 +192,16 @@ func (self *ApierTPTiming) AsExportSlice() [][]string {
 }
 
 type TPTiming struct {
-	Id        string
-	Years     Years
-	Months    Months
-	MonthDays MonthDays
-	WeekDays  WeekDays
-	StartTime string
-	EndTime   string
+	Id              string
+	Years           Years
+	Months          Months
+	MonthDays       MonthDays
+	WeekDays        WeekDays
+	StartTime       string
+	EndTime         string
+	Calendar        string       // holiday calendar referenced in the week days as *holidays:<calendar>
+	ExcludeCalendar bool         // referenced as !*holidays:<calendar>, the holidays disable the timing
+	Holidays        CalendarDays // days of the calendar, filled in by the loader
 }
 
 type TPRatingPlan struct {
@@ -232,11 +254,11 @@ type TPRatingProfile struct {
 	RatingPlanActivations []*TPRatingActivation // Activate rate profiles at specific time
 }
 
-//TPid,LoadId,Direction,Tenant,Category,Subject,ActivationTime,RatingPlanId,RatesFallbackSubject
+//TPid,LoadId,Direction,Tenant,Category,Subject,ActivationTime,RatingPlanId,RatesFallbackSubject,CdrStatQueueIds,Timezone
 func (self *TPRatingProfile) AsExportSlice() [][]string {
 	retSlice := make([][]string, len(self.RatingPlanActivations))
 	for idx, rpln := range self.RatingPlanActivations {
-		retSlice[idx] = []string{self.Direction, self.Tenant, self.Category, self.Subject, rpln.ActivationTime, rpln.RatingPlanId, rpln.FallbackSubjects}
+		retSlice[idx] = []string{self.Direction, self.Tenant, self.Category, self.Subject, rpln.ActivationTime, rpln.RatingPlanId, rpln.FallbackSubjects, rpln.CdrStatQueueIds, rpln.Timezone}
 	}
 	return retSlice
 }
@@ -268,6 +290,7 @@ type TPRatingActivation struct {
 	RatingPlanId     string // Id of RatingPlan profile
 	FallbackSubjects string // So we follow the api
 	CdrStatQueueIds  string
+	Timezone         string // IANA time zone the timings of the rating plan are evaluated in, server local one if empty
 }
 
 // Helper to return the subject fallback keys we need in dataDb
@@ -355,6 +378,91 @@ type TPSharedGroup struct {
 	RatingSubject string
 }
 
+type TPExchangeRates struct {
+	TPid            string
+	ExchangeRatesId string
+	ExchangeRates   []*TPExchangeRate
+}
+
+// Id,FromCurrency,ToCurrency,ActivationTime,Rate
+func (self *TPExchangeRates) AsExportSlice() [][]string {
+	retSlice := make([][]string, len(self.ExchangeRates))
+	for idx, er := range self.ExchangeRates {
+		retSlice[idx] = []string{self.ExchangeRatesId, er.FromCurrency, er.ToCurrency, er.ActivationTime, strconv.FormatFloat(er.Rate, 'f', -1, 64)}
+	}
+	return retSlice
+}
+
+type TPExchangeRate struct {
+	FromCurrency   string
+	ToCurrency     string
+	ActivationTime string
+	Rate           float64 // units of ToCurrency for one unit of FromCurrency
+}
+
+type TPTaxRules struct {
+	TPid       string
+	TaxRulesId string
+	TaxRules   []*TPTaxRule
+}
+
+// Id,Tenant,Category,DestinationId,ActivationTime,Rate
+func (self *TPTaxRules) AsExportSlice() [][]string {
+	retSlice := make([][]string, len(self.TaxRules))
+	for idx, tr := range self.TaxRules {
+		retSlice[idx] = []string{self.TaxRulesId, tr.Tenant, tr.Category, tr.DestinationId, tr.ActivationTime, strconv.FormatFloat(tr.Rate, 'f', -1, 64)}
+	}
+	return retSlice
+}
+
+type TPTaxRule struct {
+	Tenant         string // *any for all tenants
+	Category       string // *any for all categories
+	DestinationId  string // *any for all destinations
+	ActivationTime string
+	Rate           float64 // part of the net cost, eg: 0.19 for 19%
+}
+
+type TPPortedNumbers struct {
+	TPid            string
+	PortedNumbersId string
+	PortedNumbers   []*TPPortedNumber
+}
+
+// Id,Number,RoutingPrefix
+func (self *TPPortedNumbers) AsExportSlice() [][]string {
+	retSlice := make([][]string, len(self.PortedNumbers))
+	for idx, pn := range self.PortedNumbers {
+		retSlice[idx] = []string{self.PortedNumbersId, pn.Number, pn.RoutingPrefix}
+	}
+	return retSlice
+}
+
+type TPPortedNumber struct {
+	Number        string // full number, as received in the destination of the calls
+	RoutingPrefix string // prefix of the operator the number was ported to, empty to remove the porting
+}
+
+type TPCalendar struct {
+	TPid       string
+	CalendarId string
+	Days       []*TPCalendarDay
+}
+
+// Id,Date,Yearly
+func (self *TPCalendar) AsExportSlice() [][]string {
+	retSlice := make([][]string, len(self.Days))
+	for idx, day := range self.Days {
+		retSlice[idx] = []string{self.CalendarId, day.Date, strconv.FormatBool(day.Yearly)}
+	}
+	return retSlice
+}
+
+type TPCalendarDay struct {
+	Date   string // YYYY-MM-DD
+	Yearly bool   // recurring every year on the month and day of the date
+}
+
 type TPLcrRules struct {
 	TPid       string
 	LcrRulesId string
@@ -507,11 +615,11 @@ type TPActionPlan struct {
 	ActionPlan []*TPActionTiming // Set of ActionTiming bindings this profile will group
 }
 
-//TPid,Tag,ActionsTag,TimingTag,Weight
+//TPid,Tag,ActionsTag,TimingTag,Weight,Timezone
 func (self *TPActionPlan) AsExportSlice() [][]string {
 	retSlice := make([][]string, len(self.ActionPlan))
 	for idx, ap := range self.ActionPlan {
-		retSlice[idx] = []string{self.Id, ap.ActionsId, ap.TimingId, strconv.FormatFloat(ap.Weight, 'f', -1, 64)}
+		retSlice[idx] = []string{self.Id, ap.ActionsId, ap.TimingId, strconv.FormatFloat(ap.Weight, 'f', -1, 64), ap.Timezone}
 	}
 	return retSlice
 }
@@ -520,6 +628,7 @@ type TPActionTiming struct {
 	ActionsId string  // Actions id
 	TimingId  string  // Timing profile id
 	Weight    float64 // Binding's weight
+	Timezone  string  // IANA time zone the timing is scheduled in, server local one if empty
 }
 
 type TPActionTriggers struct {
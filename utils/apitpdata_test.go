@@ -150,12 +150,13 @@ func TestTPRatingProfileAsExportSlice(t *testing.T) {
 			&TPRatingActivation{
 				ActivationTime:   "2014-01-15T00:00:00Z",
 				RatingPlanId:     "TEST_RPLAN2",
-				FallbackSubjects: "subj1;subj2"},
+				FallbackSubjects: "subj1;subj2",
+				Timezone:         "Europe/Berlin"},
 		},
 	}
 	expectedSlc := [][]string{
-		[]string{OUT, "cgrates.org", "call", "*any", "2014-01-14T00:00:00Z", "TEST_RPLAN1", "subj1;subj2"},
-		[]string{OUT, "cgrates.org", "call", "*any", "2014-01-15T00:00:00Z", "TEST_RPLAN2", "subj1;subj2"},
+		[]string{OUT, "cgrates.org", "call", "*any", "2014-01-14T00:00:00Z", "TEST_RPLAN1", "subj1;subj2", "", ""},
+		[]string{OUT, "cgrates.org", "call", "*any", "2014-01-15T00:00:00Z", "TEST_RPLAN2", "subj1;subj2", "", "Europe/Berlin"},
 	}
 	if slc := tpRpf.AsExportSlice(); !reflect.DeepEqual(expectedSlc, slc) {
 		t.Errorf("Expecting: %+v, received: %+v", expectedSlc, slc)
@@ -401,12 +402,13 @@ func TestTPActionTriggersAsExportSlice(t *testing.T) {
 			&TPActionTiming{
 				ActionsId: "TOPUP_RST_5",
 				TimingId:  "ASAP",
-				Weight:    20.0},
+				Weight:    20.0,
+				Timezone:  "America/New_York"},
 		},
 	}
 	expectedSlc := [][]string{
-		[]string{"PACKAGE_10", "TOPUP_RST_10", "ASAP", "10"},
-		[]string{"PACKAGE_10", "TOPUP_RST_5", "ASAP", "20"},
+		[]string{"PACKAGE_10", "TOPUP_RST_10", "ASAP", "10", ""},
+		[]string{"PACKAGE_10", "TOPUP_RST_5", "ASAP", "20", "America/New_York"},
 	}
 	if slc := ap.AsExportSlice(); !reflect.DeepEqual(expectedSlc, slc) {
 		t.Errorf("Expecting: %+v, received: %+v", expectedSlc, slc)
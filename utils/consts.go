@@ -4,6 +4,7 @@ const (
 	VERSION                      = "0.9.1rc6"
 	POSTGRES                     = "postgres"
 	MYSQL                        = "mysql"
+	SQLITE                       = "sqlite"
 	MONGO                        = "mongo"
 	REDIS                        = "redis"
 	LOCALHOST                    = "127.0.0.1"
@@ -28,6 +29,7 @@ const (
 	ERR_BROKEN_REFERENCE         = "BROKEN_REFERENCE"
 	ERR_PARSER_ERROR             = "PARSER_ERROR"
 	ERR_INVALID_PATH             = "INVALID_PATH"
+	ERR_INSUFFICIENT_CREDIT      = "INSUFFICIENT_CREDIT"
 	TBL_TP_TIMINGS               = "tp_timings"
 	TBL_TP_DESTINATIONS          = "tp_destinations"
 	TBL_TP_RATES                 = "tp_rates"
@@ -42,10 +44,17 @@ const (
 	TBL_TP_ACTION_TRIGGERS       = "tp_action_triggers"
 	TBL_TP_ACCOUNT_ACTIONS       = "tp_account_actions"
 	TBL_TP_DERIVED_CHARGERS      = "tp_derived_chargers"
+	TBL_TP_EXCHANGE_RATES        = "tp_exchange_rates"
+	TBL_TP_TAX_RULES             = "tp_tax_rules"
+	TBL_TP_PORTED_NUMBERS        = "tp_ported_numbers"
+	TBL_TP_CALENDARS             = "tp_calendars"
 	TBL_CDRS_PRIMARY             = "cdrs_primary"
 	TBL_CDRS_EXTRA               = "cdrs_extra"
 	TBL_COST_DETAILS             = "cost_details"
 	TBL_RATED_CDRS               = "rated_cdrs"
+	TBL_VERSIONS                 = "versions"
+	TBL_LEDGER                   = "ledger"
+	TBL_INVOICES                 = "invoices"
 	TIMINGS_CSV                  = "Timings.csv"
 	DESTINATIONS_CSV             = "Destinations.csv"
 	RATES_CSV                    = "Rates.csv"
@@ -60,20 +69,28 @@ const (
 	ACCOUNT_ACTIONS_CSV          = "AccountActions.csv"
 	DERIVED_CHARGERS_CSV         = "DerivedChargers.csv"
 	CDR_STATS_CSV                = "CdrStats.csv"
+	EXCHANGE_RATES_CSV           = "ExchangeRates.csv"
+	TAX_RULES_CSV                = "TaxRules.csv"
+	PORTED_NUMBERS_CSV           = "PortedNumbers.csv"
+	CALENDARS_CSV                = "Calendars.csv"
 	TIMINGS_NRCOLS               = 6
 	DESTINATIONS_NRCOLS          = 2
 	RATES_NRCOLS                 = 6
-	DESTINATION_RATES_NRCOLS     = 7
+	DESTINATION_RATES_NRCOLS     = 13
 	DESTRATE_TIMINGS_NRCOLS      = 4
-	RATE_PROFILES_NRCOLS         = 8
+	RATE_PROFILES_NRCOLS         = 9
 	SHARED_GROUPS_NRCOLS         = 4
 	LCRS_NRCOLS                  = 11
 	ACTIONS_NRCOLS               = 15
-	ACTION_PLANS_NRCOLS          = 4
+	ACTION_PLANS_NRCOLS          = 5
 	ACTION_TRIGGERS_NRCOLS       = 19
 	ACCOUNT_ACTIONS_NRCOLS       = 5
 	DERIVED_CHARGERS_NRCOLS      = 19
 	CDR_STATS_NRCOLS             = 23
+	EXCHANGE_RATES_NRCOLS        = 5
+	TAX_RULES_NRCOLS             = 6
+	PORTED_NUMBERS_NRCOLS        = 3
+	CALENDARS_NRCOLS             = 3
 	ROUNDING_UP                  = "*up"
 	ROUNDING_MIDDLE              = "*middle"
 	ROUNDING_DOWN                = "*down"
@@ -112,6 +129,13 @@ const (
 	RATED_SUBJECT                = "rated_subject"
 	COST                         = "cost"
 	COST_DETAILS                 = "cost_details"
+	CURRENCY                     = "currency"
+	DEBITED_COST                 = "debited_cost"
+	DEBITED_CURRENCY             = "debited_currency"
+	TAX                          = "tax"
+	GROSS_COST                   = "gross_cost"
+	RESOLVED_DESTINATION         = "resolved_destination"
+	META_HOLIDAYS                = "*holidays:"
 	DEFAULT_RUNID                = "*default"
 	META_DEFAULT                 = "*default"
 	STATIC_VALUE_PREFIX          = "^"
@@ -119,6 +143,13 @@ const (
 	DRYRUN                       = "dry_run"
 	COMBIMED                     = "combimed"
 	INTERNAL                     = "internal"
+	META_INTERNAL                = "*internal"
+	META_DELETE                  = "*delete"
+	META_ARCHIVE                 = "*archive"
+	META_DAILY                   = "*daily"
+	META_WEEKLY                  = "*weekly"
+	META_MONTHLY                 = "*monthly"
+	META_YEARLY                  = "*yearly"
 	ZERO_RATING_SUBJECT_PREFIX   = "*zero"
 	OK                           = "OK"
 	CDRE_FIXED_WIDTH             = "fwv"
@@ -23,9 +23,37 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	locations    = make(map[string]*time.Location)
+	locationsMux sync.RWMutex
+)
+
+// Returns the IANA time zone with the given name, loaded once and cached afterwards.
+// Empty name stands for the local time zone of the server.
+func GetLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	locationsMux.RLock()
+	loc, cached := locations[name]
+	locationsMux.RUnlock()
+	if cached {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationsMux.Lock()
+	locations[name] = loc
+	locationsMux.Unlock()
+	return loc, nil
+}
+
 // Defines years days series
 type Years []int
 
@@ -270,3 +298,38 @@ func (wd WeekDays) Serialize(sep string) string {
 	}
 	return wdStr
 }
+
+// Day of a holiday calendar, Year 0 for the ones recurring yearly
+type CalendarDay struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// Defines the days of a holiday calendar
+type CalendarDays []CalendarDay
+
+// Return true if the day of the specified time is inside the calendar
+func (cds CalendarDays) Contains(t time.Time) bool {
+	year, month, day := t.Date()
+	for _, cd := range cds {
+		if cd.Month == month && cd.Day == day && (cd.Year == 0 || cd.Year == year) {
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the YYYY-MM-DD date, its year is ignored for the days recurring yearly
+func (cds *CalendarDays) Add(date string, yearly bool) error {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return err
+	}
+	cd := CalendarDay{Month: t.Month(), Day: t.Day()}
+	if !yearly {
+		cd.Year = t.Year()
+	}
+	*cds = append(*cds, cd)
+	return nil
+}
@@ -147,3 +147,22 @@ func TestWeekDaysSerialize(t *testing.T) {
 		t.Errorf("Expected: %s, got: %s", expectString3, wdsString3)
 	}
 }
+
+func TestCalendarDaysContains(t *testing.T) {
+	cds := &CalendarDays{}
+	if err := cds.Add("2015-12-25", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := cds.Add("2015-04-06", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := cds.Add("2015-13-01", false); err == nil {
+		t.Error("Invalid date accepted")
+	}
+	if !cds.Contains(time.Date(2017, 12, 25, 10, 0, 0, 0, time.UTC)) {
+		t.Error("Yearly day not recurring")
+	}
+	if !cds.Contains(time.Date(2015, 4, 6, 23, 59, 59, 0, time.UTC)) || cds.Contains(time.Date(2016, 4, 6, 10, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong one time day")
+	}
+}